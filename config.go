@@ -1,9 +1,14 @@
 package quic
 
 import (
+	"cmp"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/quic-go/quic-go/internal/congestion"
 	"github.com/quic-go/quic-go/internal/protocol"
 	"github.com/quic-go/quic-go/quicvarint"
 )
@@ -18,6 +23,13 @@ func (c *Config) handshakeTimeout() time.Duration {
 	return 2 * c.HandshakeIdleTimeout
 }
 
+func (c *Config) throughputSampleInterval() time.Duration {
+	if c.ThroughputSampleInterval > 0 {
+		return c.ThroughputSampleInterval
+	}
+	return time.Second
+}
+
 func (c *Config) maxRetryTokenAge() time.Duration {
 	return c.handshakeTimeout()
 }
@@ -45,15 +57,214 @@ func validateConfig(config *Config) error {
 	if config.InitialPacketSize > protocol.MaxPacketBufferSize {
 		config.InitialPacketSize = protocol.MaxPacketBufferSize
 	}
+	if config.InitialRTT < 0 {
+		config.InitialRTT = 0
+	}
 	// check that all QUIC versions are actually supported
 	for _, v := range config.Versions {
 		if !protocol.IsValidVersion(v) {
 			return fmt.Errorf("invalid QUIC version: %s", v)
 		}
 	}
+	return validateCongestionControlConfig(config)
+}
+
+// maxBandwidth is the largest valid CongestionControlConfig.MaxBandwidth.
+// Larger values are most likely caused by a unit conversion error.
+const maxBandwidth = 1000 * 1000 * 1000 * 1000 * BitsPerSecond // 1 Tbps
+
+// ErrUnknownCongestionControl is returned when dialing or listening
+// if the congestion control algorithm is not one of the supported algorithms.
+var ErrUnknownCongestionControl = errors.New("unsupported congestion control algorithm")
+
+// congestionControlAlgorithms are the valid values of CongestionControlConfig.Algorithm, in addition to the empty string.
+var congestionControlAlgorithms = []string{"reno", "cubic", "hysteria", "westwood", "hybrid", "vegas", "prague", "fixed"}
+
+// validateCongestionControlConfig validates the congestion control configuration,
+// including the values set using the deprecated fields of the Config.
+func validateCongestionControlConfig(config *Config) error {
+	cc := &config.Congestion
+	algorithm := cmp.Or(cc.Algorithm, config.CongestionControl)
+	if algorithm != "" && !slices.Contains(congestionControlAlgorithms, algorithm) {
+		return fmt.Errorf("%w: %q (valid algorithms: %s)", ErrUnknownCongestionControl, algorithm, strings.Join(congestionControlAlgorithms, ", "))
+	}
+	if cc.ShadowAlgorithm != "" && !slices.Contains(congestionControlAlgorithms, cc.ShadowAlgorithm) {
+		return fmt.Errorf("%w: %q (valid algorithms: %s)", ErrUnknownCongestionControl, cc.ShadowAlgorithm, strings.Join(congestionControlAlgorithms, ", "))
+	}
+	if (algorithm == "fixed" || cc.ShadowAlgorithm == "fixed") && cc.FixedWindowPackets <= 0 {
+		return errors.New("the fixed congestion controller requires FixedWindowPackets to be set")
+	}
+	if cc.MaxBandwidthMbps < 0 {
+		cc.MaxBandwidthMbps = 0
+	}
+	if cc.MaxBandwidth > maxBandwidth {
+		return fmt.Errorf("invalid max bandwidth: %d bps", cc.MaxBandwidth)
+	}
+	if cc.InitialCongestionWindowPackets < 0 {
+		cc.InitialCongestionWindowPackets = 0
+	}
+	if cc.HysteriaInitialBandwidth != 0 {
+		target := cc.MaxBandwidth
+		if target == 0 {
+			target = congestion.BandwidthFromMbps(cmp.Or(cc.MaxBandwidthMbps, max(config.MaxBandwidthMbps, 0), 10))
+		}
+		if cc.HysteriaInitialBandwidth < congestion.MinHysteriaInitialBandwidth || cc.HysteriaInitialBandwidth > target {
+			return fmt.Errorf("invalid Hysteria initial bandwidth: %d bps", cc.HysteriaInitialBandwidth)
+		}
+	}
+	switch cc.MinRatePolicy {
+	case MinRatePolicyBDP, MinRatePolicyPackets:
+	default:
+		return fmt.Errorf("invalid min rate policy: %d", cc.MinRatePolicy)
+	}
+	switch cc.LossTolerancePolicy {
+	case LossTolerancePolicyLossRate, LossTolerancePolicyEpisode:
+	default:
+		return fmt.Errorf("invalid loss tolerance policy: %d", cc.LossTolerancePolicy)
+	}
+	switch cc.OptimizeFor {
+	case OptimizeForBalanced, OptimizeForThroughput, OptimizeForLatency:
+	default:
+		return fmt.Errorf("invalid optimization goal: %d", cc.OptimizeFor)
+	}
+	if cc.MinRatePackets < 0 {
+		cc.MinRatePackets = 0
+	}
+	if cc.HysteriaJitterFilterWindow < 0 {
+		cc.HysteriaJitterFilterWindow = 0
+	}
+	if (cc.CubicBeta != 0 || cc.CubicBetaLastMax != 0 || cc.CubicCongestionWindowScale != 0 || cc.DisableCubicTCPFriendliness) && !usesCubic(algorithm) && !usesCubic(cc.ShadowAlgorithm) {
+		return errors.New("the CUBIC parameters require the cubic or hybrid congestion control algorithm")
+	}
+	if cc.CubicBeta != 0 && (cc.CubicBeta <= 0 || cc.CubicBeta >= 1) {
+		return fmt.Errorf("invalid CUBIC beta: %f", cc.CubicBeta)
+	}
+	if cc.CubicBetaLastMax != 0 && (cc.CubicBetaLastMax <= 0 || cc.CubicBetaLastMax >= 1) {
+		return fmt.Errorf("invalid CUBIC betaLastMax: %f", cc.CubicBetaLastMax)
+	}
+	if cc.CubicCongestionWindowScale < 0 {
+		return fmt.Errorf("invalid CUBIC congestion window scale: %d", cc.CubicCongestionWindowScale)
+	}
+	if cc.RenoBeta != 0 && (cc.RenoBeta <= 0 || cc.RenoBeta >= 1) {
+		return fmt.Errorf("invalid Reno beta: %f", cc.RenoBeta)
+	}
+	if cc.NumEmulatedConnections < 0 {
+		return fmt.Errorf("invalid number of emulated connections: %d", cc.NumEmulatedConnections)
+	}
+	if cc.Resume.CongestionWindow < 0 || cc.Resume.SlowStartThreshold < 0 {
+		return fmt.Errorf("invalid congestion snapshot: congestion window %d, slow start threshold %d", cc.Resume.CongestionWindow, cc.Resume.SlowStartThreshold)
+	}
+	if cc.HysteriaRTOBackoff != 0 && (cc.HysteriaRTOBackoff < 0 || cc.HysteriaRTOBackoff > 1) {
+		return fmt.Errorf("invalid Hysteria RTO backoff: %f", cc.HysteriaRTOBackoff)
+	}
+	if cc.HysteriaStableRTTs < 0 {
+		return fmt.Errorf("invalid number of Hysteria stable RTTs: %d", cc.HysteriaStableRTTs)
+	}
+	if cc.HysteriaPacingAlpha != 0 && (cc.HysteriaPacingAlpha < 0 || cc.HysteriaPacingAlpha > 1) {
+		return fmt.Errorf("invalid Hysteria pacing alpha: %f", cc.HysteriaPacingAlpha)
+	}
+	for _, f := range []float64{cc.HysteriaGrowthFactor, cc.HysteriaHighRTTGrowthFactor, cc.HysteriaVeryHighRTTGrowthFactor} {
+		if f != 0 && !(f > 1) {
+			return fmt.Errorf("invalid Hysteria growth factor: %f", f)
+		}
+	}
+	if cc.HysteriaDrainGain != 0 && (cc.HysteriaDrainGain < 0 || cc.HysteriaDrainGain > 1) {
+		return fmt.Errorf("invalid Hysteria drain gain: %f", cc.HysteriaDrainGain)
+	}
+	if cc.HysteriaMaxRateCut != 0 && !(cc.HysteriaMaxRateCut > 0 && cc.HysteriaMaxRateCut < 1) {
+		return fmt.Errorf("invalid Hysteria max rate cut: %f", cc.HysteriaMaxRateCut)
+	}
+	if cc.MaxDatagramSizeCeiling != 0 && cc.MaxDatagramSizeCeiling < protocol.MinInitialPacketSize {
+		return fmt.Errorf("invalid max datagram size ceiling: %d", cc.MaxDatagramSizeCeiling)
+	}
+	for _, g := range []float64{cc.SlowStartPacingGain, cc.CongestionAvoidancePacingGain} {
+		if g != 0 && !(g >= 1) {
+			return fmt.Errorf("invalid pacing gain: %f", g)
+		}
+	}
+	if cc.MinSlowStartDuration < 0 {
+		return fmt.Errorf("invalid min slow start duration: %s", cc.MinSlowStartDuration)
+	}
+	if cc.MinSlowStartBytes < 0 {
+		return fmt.Errorf("invalid min slow start bytes: %d", cc.MinSlowStartBytes)
+	}
+	if cc.IdleRestartThreshold < 0 {
+		return fmt.Errorf("invalid idle restart threshold: %s", cc.IdleRestartThreshold)
+	}
+	if cc.HysteriaMaxQueuingDelay < 0 {
+		return fmt.Errorf("invalid Hysteria max queuing delay: %s", cc.HysteriaMaxQueuingDelay)
+	}
+	if cc.HysteriaLossCooldown < 0 {
+		return fmt.Errorf("invalid Hysteria loss cooldown: %s", cc.HysteriaLossCooldown)
+	}
+	if cc.HybridSlowStartMinSamples < 0 {
+		return fmt.Errorf("invalid hybrid slow start min samples: %d", cc.HybridSlowStartMinSamples)
+	}
+	if cc.HybridSlowStartMinDelayThreshold < 0 || cc.HybridSlowStartMaxDelayThreshold < 0 {
+		return errors.New("hybrid slow start delay thresholds must not be negative")
+	}
+	if cc.HybridSlowStartMinDelayThreshold > 0 && cc.HybridSlowStartMaxDelayThreshold > 0 &&
+		cc.HybridSlowStartMinDelayThreshold > cc.HybridSlowStartMaxDelayThreshold {
+		return errors.New("hybrid slow start min delay threshold must not exceed the max delay threshold")
+	}
+	if cc.MaxPacingBurst < 0 {
+		return fmt.Errorf("invalid max pacing burst: %d", cc.MaxPacingBurst)
+	}
+	for i, t := range cc.HysteriaLossThresholds {
+		if t.Threshold < 0 || t.Threshold > 1 {
+			return fmt.Errorf("invalid Hysteria loss threshold: %f", t.Threshold)
+		}
+		if i > 0 && t.RTTBelow <= cc.HysteriaLossThresholds[i-1].RTTBelow {
+			return errors.New("Hysteria loss thresholds must be ordered by ascending RTT")
+		}
+	}
 	return nil
 }
 
+// usesCubic says if the congestion control algorithm uses CUBIC, and therefore the CUBIC parameters.
+// The reno congestion controller uses the same implementation, but not the cubic function.
+func usesCubic(algorithm string) bool {
+	return algorithm == "cubic" || algorithm == "hybrid"
+}
+
+// populateCongestionControlConfig populates the congestion control configuration with default values.
+// Values set using the deprecated fields of the Config are used if the corresponding field isn't set.
+func populateCongestionControlConfig(config *Config) CongestionControlConfig {
+	cc := config.Congestion
+	cc.Algorithm = cmp.Or(cc.Algorithm, config.CongestionControl, "reno")
+	cc.MaxBandwidthMbps = cmp.Or(cc.MaxBandwidthMbps, config.MaxBandwidthMbps)
+	if (cc.Algorithm == "hysteria" || cc.Algorithm == "hybrid") && cc.MaxBandwidthMbps <= 0 && cc.MaxBandwidth == 0 {
+		cc.MaxBandwidthMbps = 10 // 默认给 10Mbps 兜底
+	}
+	if cc.MaxBandwidth == 0 {
+		cc.MaxBandwidth = congestion.BandwidthFromMbps(cc.MaxBandwidthMbps)
+	}
+	cc.HysteriaBrutal = cc.HysteriaBrutal || config.HysteriaBrutal
+	if cc.OnCWNDChange == nil {
+		cc.OnCWNDChange = config.OnCWNDChange
+	}
+	cc.InitialCongestionWindowPackets = cmp.Or(cc.InitialCongestionWindowPackets, config.InitialCongestionWindowPackets)
+	applyOptimizationGoal(&cc)
+	return cc
+}
+
+// applyOptimizationGoal applies the presets of the OptimizationGoal to the fields that are not set.
+// The presets are documented with the OptimizationGoal constants.
+func applyOptimizationGoal(cc *CongestionControlConfig) {
+	switch cc.OptimizeFor {
+	case OptimizeForThroughput:
+		cc.HysteriaMaxQueuingDelay = cmp.Or(cc.HysteriaMaxQueuingDelay, 50*time.Millisecond)
+		cc.HysteriaGrowthFactor = cmp.Or(cc.HysteriaGrowthFactor, 1.25)
+		cc.HysteriaHighRTTGrowthFactor = cmp.Or(cc.HysteriaHighRTTGrowthFactor, 1.5)
+	case OptimizeForLatency:
+		cc.MaxPacingBurst = cmp.Or(cc.MaxPacingBurst, 6000)
+		cc.HysteriaMaxQueuingDelay = cmp.Or(cc.HysteriaMaxQueuingDelay, 5*time.Millisecond)
+		cc.HysteriaDrainGain = cmp.Or(cc.HysteriaDrainGain, 0.5)
+		cc.LossTolerancePolicy = cmp.Or(cc.LossTolerancePolicy, LossTolerancePolicyEpisode)
+		cc.IdleRestartThreshold = cmp.Or(cc.IdleRestartThreshold, time.Second)
+	}
+}
+
 // populateConfig populates fields in the quic.Config with their default values, if none are set
 // it may be called with nil
 func populateConfig(config *Config) *Config {
@@ -105,19 +316,6 @@ func populateConfig(config *Config) *Config {
 		initialPacketSize = protocol.InitialPacketSize
 	}
 
-	// --- 处理拥塞控制默认值 ---
-	cc := config.CongestionControl
-	if cc != "" && cc != "cubic" && cc != "hysteria" {
-		fmt.Errorf("不支持的拥塞控制算法: %s (仅支持 cubic 或 hysteria)", cc)
-	}
-	if cc == "" {
-		cc = "cubic"
-	}
-	maxBW := config.MaxBandwidthMbps
-	if cc == "hysteria" && maxBW <= 0 {
-		maxBW = 10 // 默认给 10Mbps 兜底
-	}
-
 	return &Config{
 		GetConfigForClient:               config.GetConfigForClient,
 		Versions:                         versions,
@@ -134,11 +332,22 @@ func populateConfig(config *Config) *Config {
 		TokenStore:                       config.TokenStore,
 		EnableDatagrams:                  config.EnableDatagrams,
 		InitialPacketSize:                initialPacketSize,
+		InitialRTT:                       config.InitialRTT,
+		OnRTTSample:                      config.OnRTTSample,
 		DisablePathMTUDiscovery:          config.DisablePathMTUDiscovery,
 		EnableStreamResetPartialDelivery: config.EnableStreamResetPartialDelivery,
 		Allow0RTT:                        config.Allow0RTT,
-		CongestionControl:                cc,
-		MaxBandwidthMbps:                 maxBW,
+		Congestion:                       populateCongestionControlConfig(config),
+		LostPacketHistorySize:            max(config.LostPacketHistorySize, 0),
+		CongestionTraceSize:              max(config.CongestionTraceSize, 0),
+		OnThroughputSample:               config.OnThroughputSample,
+		ThroughputSampleInterval:         config.ThroughputSampleInterval,
+		Logger:                           config.Logger,
+		CongestionControl:                config.CongestionControl,
+		MaxBandwidthMbps:                 config.MaxBandwidthMbps,
+		HysteriaBrutal:                   config.HysteriaBrutal,
+		OnCWNDChange:                     config.OnCWNDChange,
+		InitialCongestionWindowPackets:   config.InitialCongestionWindowPackets,
 		Tracer:                           config.Tracer,
 	}
 }
@@ -2,6 +2,7 @@ package quic
 
 import (
 	"context"
+	"log/slog"
 	"reflect"
 	"testing"
 	"time"
@@ -65,6 +66,12 @@ func TestConfigValidation(t *testing.T) {
 		require.NoError(t, validateConfig(conf))
 		require.Equal(t, uint16(protocol.MaxPacketBufferSize), conf.InitialPacketSize)
 	})
+
+	t.Run("initial RTT", func(t *testing.T) {
+		conf := &Config{InitialRTT: -time.Second}
+		require.NoError(t, validateConfig(conf))
+		require.Zero(t, conf.InitialRTT)
+	})
 }
 
 func TestConfigHandshakeIdleTimeout(t *testing.T) {
@@ -86,7 +93,7 @@ func configWithNonZeroNonFunctionFields(t *testing.T) *Config {
 		}
 
 		switch fn := typ.Field(i).Name; fn {
-		case "GetConfigForClient", "RequireAddressValidation", "GetLogWriter", "AllowConnectionWindowIncrease", "Tracer":
+		case "GetConfigForClient", "RequireAddressValidation", "GetLogWriter", "AllowConnectionWindowIncrease", "Tracer", "OnCWNDChange", "OnRTTSample", "OnThroughputSample":
 			// Can't compare functions.
 		case "Versions":
 			f.Set(reflect.ValueOf([]Version{1, 2, 3}))
@@ -122,12 +129,81 @@ func configWithNonZeroNonFunctionFields(t *testing.T) *Config {
 			f.Set(reflect.ValueOf(true))
 		case "InitialPacketSize":
 			f.Set(reflect.ValueOf(uint16(1350)))
+		case "InitialRTT":
+			f.Set(reflect.ValueOf(10 * time.Millisecond))
 		case "DisablePathMTUDiscovery":
 			f.Set(reflect.ValueOf(true))
 		case "Allow0RTT":
 			f.Set(reflect.ValueOf(true))
 		case "EnableStreamResetPartialDelivery":
 			f.Set(reflect.ValueOf(true))
+		case "Congestion":
+			f.Set(reflect.ValueOf(CongestionControlConfig{
+				Algorithm:                        "westwood",
+				ShadowAlgorithm:                  "vegas",
+				MaxBandwidthMbps:                 100,
+				MaxBandwidth:                     1_500_000 * BitsPerSecond,
+				HysteriaBrutal:                   true,
+				HysteriaRTTOnly:                  true,
+				HysteriaInitialBandwidth:         5 * 1024 * 1024 * BitsPerSecond,
+				HysteriaAutoBandwidth:            true,
+				HysteriaLossThresholds:           []HysteriaLossThreshold{{RTTBelow: time.Second, Threshold: 0.5}},
+				HysteriaJitterFilterWindow:       4,
+				HysteriaRTOBackoff:               0.25,
+				HysteriaStableRTTs:               5,
+				HysteriaPacingAlpha:              0.25,
+				HysteriaGrowthFactor:             1.2,
+				HysteriaHighRTTGrowthFactor:      1.5,
+				HysteriaVeryHighRTTGrowthFactor:  2,
+				HysteriaDrainGain:                0.5,
+				HysteriaMaxRateCut:               0.4,
+				HysteriaMaxQueuingDelay:          20 * time.Millisecond,
+				HysteriaLossCooldown:             500 * time.Millisecond,
+				InitialCongestionWindowPackets:   20,
+				MinRatePolicy:                    MinRatePolicyPackets,
+				MinRatePackets:                   16,
+				LossTolerancePolicy:              LossTolerancePolicyEpisode,
+				HoldWindowOnToleratedLoss:        true,
+				MinSlowStartDuration:             time.Second,
+				MinSlowStartBytes:                100000,
+				CubicBeta:                        0.8,
+				CubicBetaLastMax:                 0.9,
+				CubicCongestionWindowScale:       820,
+				DisableCubicTCPFriendliness:      true,
+				RenoBeta:                         0.5,
+				NumEmulatedConnections:           2,
+				RenoByteCounting:                 true,
+				HybridSlowStartMinSamples:        4,
+				HybridSlowStartMinDelayThreshold: 10 * time.Millisecond,
+				HybridSlowStartMaxDelayThreshold: 50 * time.Millisecond,
+				SlowStartPacingGain:              2.5,
+				CongestionAvoidancePacingGain:    1.1,
+				MaxPacingRate:                    50_000_000 * BitsPerSecond,
+				MaxPacingBurst:                   30_000,
+				DisablePacing:                    true,
+				IdleRestartThreshold:             time.Second,
+				Resume:                           CongestionSnapshot{CongestionWindow: 100000, SlowStartThreshold: 50000},
+				FixedWindowPackets:               64,
+				MaxDatagramSizeCeiling:           1400,
+				OptimizeFor:                      OptimizeForLatency,
+				EnableChaosInjection:             true,
+			}))
+		case "LostPacketHistorySize":
+			f.Set(reflect.ValueOf(100))
+		case "CongestionTraceSize":
+			f.Set(reflect.ValueOf(1000))
+		case "ThroughputSampleInterval":
+			f.Set(reflect.ValueOf(500 * time.Millisecond))
+		case "Logger":
+			f.Set(reflect.ValueOf(slog.New(slog.DiscardHandler)))
+		case "CongestionControl":
+			f.Set(reflect.ValueOf("hysteria"))
+		case "MaxBandwidthMbps":
+			f.Set(reflect.ValueOf(42))
+		case "HysteriaBrutal":
+			f.Set(reflect.ValueOf(true))
+		case "InitialCongestionWindowPackets":
+			f.Set(reflect.ValueOf(10))
 		default:
 			t.Fatalf("all fields must be accounted for, but saw unknown field %q", fn)
 		}
@@ -186,6 +262,415 @@ func TestConfigDefaultValues(t *testing.T) {
 	require.EqualValues(t, protocol.DefaultMaxIncomingUniStreams, c.MaxIncomingUniStreams)
 	require.False(t, c.DisablePathMTUDiscovery)
 	require.Nil(t, c.GetConfigForClient)
+	require.Equal(t, "reno", c.Congestion.Algorithm)
+	require.Zero(t, c.Congestion.MaxBandwidthMbps)
+	require.Zero(t, c.Congestion.InitialCongestionWindowPackets)
+}
+
+func TestConfigCongestionControl(t *testing.T) {
+	t.Run("unknown algorithm", func(t *testing.T) {
+		err := validateConfig(&Config{Congestion: CongestionControlConfig{Algorithm: "foobar"}})
+		require.ErrorIs(t, err, ErrUnknownCongestionControl)
+		require.EqualError(t, err,
+			`unsupported congestion control algorithm: "foobar" (valid algorithms: reno, cubic, hysteria, westwood, hybrid, vegas, prague, fixed)`,
+		)
+		require.ErrorIs(t, validateConfig(&Config{CongestionControl: "foobar"}), ErrUnknownCongestionControl)
+		// algorithm names are case-sensitive
+		require.ErrorIs(t, validateConfig(&Config{Congestion: CongestionControlConfig{Algorithm: "Hysteria"}}), ErrUnknownCongestionControl)
+		require.NoError(t, validateConfig(&Config{Congestion: CongestionControlConfig{Algorithm: ""}}))
+	})
+
+	t.Run("shadow algorithm", func(t *testing.T) {
+		require.NoError(t, validateConfig(&Config{Congestion: CongestionControlConfig{ShadowAlgorithm: "hysteria"}}))
+		require.ErrorIs(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{ShadowAlgorithm: "foobar"}}),
+			ErrUnknownCongestionControl,
+		)
+		require.EqualError(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{ShadowAlgorithm: "fixed"}}),
+			"the fixed congestion controller requires FixedWindowPackets to be set",
+		)
+	})
+
+	t.Run("Prague", func(t *testing.T) {
+		require.NoError(t, validateConfig(&Config{Congestion: CongestionControlConfig{Algorithm: "prague"}}))
+	})
+
+	t.Run("fixed window", func(t *testing.T) {
+		require.NoError(t, validateConfig(&Config{Congestion: CongestionControlConfig{Algorithm: "fixed", FixedWindowPackets: 100}}))
+		require.EqualError(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{Algorithm: "fixed"}}),
+			"the fixed congestion controller requires FixedWindowPackets to be set",
+		)
+		require.EqualError(t,
+			validateConfig(&Config{CongestionControl: "fixed"}),
+			"the fixed congestion controller requires FixedWindowPackets to be set",
+		)
+	})
+
+	t.Run("Hysteria loss thresholds", func(t *testing.T) {
+		require.NoError(t, validateConfig(&Config{Congestion: CongestionControlConfig{
+			HysteriaLossThresholds: []HysteriaLossThreshold{
+				{RTTBelow: 10 * time.Millisecond, Threshold: 0},
+				{RTTBelow: 20 * time.Millisecond, Threshold: 1},
+			},
+		}}))
+		require.EqualError(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{
+				HysteriaLossThresholds: []HysteriaLossThreshold{{RTTBelow: 10 * time.Millisecond, Threshold: 1.1}},
+			}}),
+			"invalid Hysteria loss threshold: 1.100000",
+		)
+		require.EqualError(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{
+				HysteriaLossThresholds: []HysteriaLossThreshold{
+					{RTTBelow: 20 * time.Millisecond, Threshold: 0.1},
+					{RTTBelow: 10 * time.Millisecond, Threshold: 0.2},
+				},
+			}}),
+			"Hysteria loss thresholds must be ordered by ascending RTT",
+		)
+	})
+
+	t.Run("Hysteria RTO backoff", func(t *testing.T) {
+		require.NoError(t, validateConfig(&Config{Congestion: CongestionControlConfig{HysteriaRTOBackoff: 1}}))
+		require.EqualError(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{HysteriaRTOBackoff: 1.5}}),
+			"invalid Hysteria RTO backoff: 1.500000",
+		)
+	})
+
+	t.Run("Hysteria stable RTTs", func(t *testing.T) {
+		require.NoError(t, validateConfig(&Config{Congestion: CongestionControlConfig{HysteriaStableRTTs: 1}}))
+		require.EqualError(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{HysteriaStableRTTs: -1}}),
+			"invalid number of Hysteria stable RTTs: -1",
+		)
+	})
+
+	t.Run("Hysteria pacing alpha", func(t *testing.T) {
+		require.NoError(t, validateConfig(&Config{Congestion: CongestionControlConfig{HysteriaPacingAlpha: 1}}))
+		require.EqualError(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{HysteriaPacingAlpha: 1.5}}),
+			"invalid Hysteria pacing alpha: 1.500000",
+		)
+	})
+
+	t.Run("Hysteria growth factors", func(t *testing.T) {
+		require.NoError(t, validateConfig(&Config{Congestion: CongestionControlConfig{
+			HysteriaGrowthFactor:            1.05,
+			HysteriaHighRTTGrowthFactor:     1.5,
+			HysteriaVeryHighRTTGrowthFactor: 2,
+		}}))
+		require.EqualError(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{HysteriaGrowthFactor: 1}}),
+			"invalid Hysteria growth factor: 1.000000",
+		)
+		require.EqualError(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{HysteriaVeryHighRTTGrowthFactor: 0.5}}),
+			"invalid Hysteria growth factor: 0.500000",
+		)
+	})
+
+	t.Run("Hysteria drain gain", func(t *testing.T) {
+		require.NoError(t, validateConfig(&Config{Congestion: CongestionControlConfig{HysteriaDrainGain: 1}}))
+		require.EqualError(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{HysteriaDrainGain: 1.5}}),
+			"invalid Hysteria drain gain: 1.500000",
+		)
+	})
+
+	t.Run("Hysteria max rate cut", func(t *testing.T) {
+		require.NoError(t, validateConfig(&Config{Congestion: CongestionControlConfig{HysteriaMaxRateCut: 0.75}}))
+		require.EqualError(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{HysteriaMaxRateCut: 1}}),
+			"invalid Hysteria max rate cut: 1.000000",
+		)
+	})
+
+	t.Run("max datagram size ceiling", func(t *testing.T) {
+		require.NoError(t, validateConfig(&Config{Congestion: CongestionControlConfig{MaxDatagramSizeCeiling: 1400}}))
+		require.EqualError(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{MaxDatagramSizeCeiling: 1000}}),
+			"invalid max datagram size ceiling: 1000",
+		)
+	})
+
+	t.Run("Hysteria initial bandwidth", func(t *testing.T) {
+		require.NoError(t, validateConfig(&Config{Congestion: CongestionControlConfig{
+			Algorithm:                "hysteria",
+			MaxBandwidthMbps:         100,
+			HysteriaInitialBandwidth: 100 * 1024 * 1024 * BitsPerSecond,
+		}}))
+		// the target rate defaults to 10 Mbps
+		require.NoError(t, validateConfig(&Config{Congestion: CongestionControlConfig{HysteriaInitialBandwidth: 10 * 1024 * 1024 * BitsPerSecond}}))
+		require.EqualError(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{HysteriaInitialBandwidth: 11 * 1024 * 1024 * BitsPerSecond}}),
+			"invalid Hysteria initial bandwidth: 11534336 bps",
+		)
+		require.EqualError(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{
+				MaxBandwidth:             100 * 1024 * 1024 * BitsPerSecond,
+				HysteriaInitialBandwidth: 512 * 1024 * BitsPerSecond,
+			}}),
+			"invalid Hysteria initial bandwidth: 524288 bps",
+		)
+	})
+
+	t.Run("pacing gains", func(t *testing.T) {
+		require.NoError(t, validateConfig(&Config{Congestion: CongestionControlConfig{SlowStartPacingGain: 1, CongestionAvoidancePacingGain: 1.5}}))
+		require.EqualError(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{SlowStartPacingGain: 0.5}}),
+			"invalid pacing gain: 0.500000",
+		)
+		require.EqualError(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{CongestionAvoidancePacingGain: -1}}),
+			"invalid pacing gain: -1.000000",
+		)
+	})
+
+	t.Run("idle restart threshold", func(t *testing.T) {
+		require.NoError(t, validateConfig(&Config{Congestion: CongestionControlConfig{IdleRestartThreshold: time.Second}}))
+		require.EqualError(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{IdleRestartThreshold: -time.Second}}),
+			"invalid idle restart threshold: -1s",
+		)
+	})
+
+	t.Run("min slow start", func(t *testing.T) {
+		require.NoError(t, validateConfig(&Config{Congestion: CongestionControlConfig{MinSlowStartDuration: time.Second, MinSlowStartBytes: 1 << 20}}))
+		require.EqualError(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{MinSlowStartDuration: -time.Second}}),
+			"invalid min slow start duration: -1s",
+		)
+		require.EqualError(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{MinSlowStartBytes: -1}}),
+			"invalid min slow start bytes: -1",
+		)
+	})
+
+	t.Run("Hysteria max queuing delay", func(t *testing.T) {
+		require.NoError(t, validateConfig(&Config{Congestion: CongestionControlConfig{HysteriaMaxQueuingDelay: 10 * time.Millisecond}}))
+		require.EqualError(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{HysteriaMaxQueuingDelay: -time.Millisecond}}),
+			"invalid Hysteria max queuing delay: -1ms",
+		)
+	})
+
+	t.Run("Hysteria loss cooldown", func(t *testing.T) {
+		require.NoError(t, validateConfig(&Config{Congestion: CongestionControlConfig{HysteriaLossCooldown: 500 * time.Millisecond}}))
+		require.EqualError(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{HysteriaLossCooldown: -time.Millisecond}}),
+			"invalid Hysteria loss cooldown: -1ms",
+		)
+	})
+
+	t.Run("hybrid slow start", func(t *testing.T) {
+		require.NoError(t, validateConfig(&Config{Congestion: CongestionControlConfig{
+			HybridSlowStartMinSamples:        4,
+			HybridSlowStartMinDelayThreshold: 20 * time.Millisecond,
+		}}))
+		require.EqualError(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{HybridSlowStartMinSamples: -1}}),
+			"invalid hybrid slow start min samples: -1",
+		)
+		require.EqualError(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{HybridSlowStartMaxDelayThreshold: -time.Millisecond}}),
+			"hybrid slow start delay thresholds must not be negative",
+		)
+		require.EqualError(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{
+				HybridSlowStartMinDelayThreshold: 20 * time.Millisecond,
+				HybridSlowStartMaxDelayThreshold: 10 * time.Millisecond,
+			}}),
+			"hybrid slow start min delay threshold must not exceed the max delay threshold",
+		)
+	})
+
+	t.Run("max pacing burst", func(t *testing.T) {
+		require.NoError(t, validateConfig(&Config{Congestion: CongestionControlConfig{MaxPacingBurst: 1}}))
+		require.EqualError(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{MaxPacingBurst: -1}}),
+			"invalid max pacing burst: -1",
+		)
+	})
+
+	t.Run("min rate policy", func(t *testing.T) {
+		require.NoError(t, validateConfig(&Config{Congestion: CongestionControlConfig{MinRatePolicy: MinRatePolicyPackets}}))
+		require.EqualError(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{MinRatePolicy: 42}}),
+			"invalid min rate policy: 42",
+		)
+	})
+
+	t.Run("loss tolerance policy", func(t *testing.T) {
+		require.NoError(t, validateConfig(&Config{Congestion: CongestionControlConfig{LossTolerancePolicy: LossTolerancePolicyEpisode}}))
+		require.EqualError(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{LossTolerancePolicy: 42}}),
+			"invalid loss tolerance policy: 42",
+		)
+	})
+
+	t.Run("CUBIC parameters", func(t *testing.T) {
+		require.NoError(t, validateConfig(&Config{Congestion: CongestionControlConfig{Algorithm: "cubic", CubicBeta: 0.5, CubicBetaLastMax: 0.75}}))
+		require.NoError(t, validateConfig(&Config{Congestion: CongestionControlConfig{Algorithm: "hybrid", CubicBeta: 0.5}}))
+		require.NoError(t, validateConfig(&Config{Congestion: CongestionControlConfig{ShadowAlgorithm: "cubic", CubicBeta: 0.5}}))
+		require.EqualError(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{Algorithm: "cubic", CubicBeta: 1}}),
+			"invalid CUBIC beta: 1.000000",
+		)
+		require.EqualError(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{Algorithm: "cubic", CubicBetaLastMax: -0.5}}),
+			"invalid CUBIC betaLastMax: -0.500000",
+		)
+		// the reno congestion controller doesn't use the CUBIC parameters
+		for _, algorithm := range []string{"", "reno", "westwood"} {
+			require.EqualError(t,
+				validateConfig(&Config{Congestion: CongestionControlConfig{Algorithm: algorithm, CubicBeta: 0.5}}),
+				"the CUBIC parameters require the cubic or hybrid congestion control algorithm",
+			)
+			require.EqualError(t,
+				validateConfig(&Config{Congestion: CongestionControlConfig{Algorithm: algorithm, DisableCubicTCPFriendliness: true}}),
+				"the CUBIC parameters require the cubic or hybrid congestion control algorithm",
+			)
+			require.EqualError(t,
+				validateConfig(&Config{Congestion: CongestionControlConfig{Algorithm: algorithm, CubicCongestionWindowScale: 820}}),
+				"the CUBIC parameters require the cubic or hybrid congestion control algorithm",
+			)
+		}
+		require.NoError(t, validateConfig(&Config{Congestion: CongestionControlConfig{Algorithm: "cubic", DisableCubicTCPFriendliness: true}}))
+		require.NoError(t, validateConfig(&Config{Congestion: CongestionControlConfig{Algorithm: "cubic", CubicCongestionWindowScale: 820}}))
+		require.EqualError(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{Algorithm: "cubic", CubicCongestionWindowScale: -1}}),
+			"invalid CUBIC congestion window scale: -1",
+		)
+	})
+
+	t.Run("Reno beta", func(t *testing.T) {
+		require.NoError(t, validateConfig(&Config{Congestion: CongestionControlConfig{RenoBeta: 0.5}}))
+		require.EqualError(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{RenoBeta: 1}}),
+			"invalid Reno beta: 1.000000",
+		)
+	})
+
+	t.Run("number of emulated connections", func(t *testing.T) {
+		require.NoError(t, validateConfig(&Config{Congestion: CongestionControlConfig{NumEmulatedConnections: 4}}))
+		require.EqualError(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{NumEmulatedConnections: -1}}),
+			"invalid number of emulated connections: -1",
+		)
+	})
+
+	t.Run("resume", func(t *testing.T) {
+		require.NoError(t, validateConfig(&Config{Congestion: CongestionControlConfig{
+			Resume: CongestionSnapshot{CongestionWindow: 100000, SlowStartThreshold: 50000},
+		}}))
+		require.EqualError(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{Resume: CongestionSnapshot{CongestionWindow: -1}}}),
+			"invalid congestion snapshot: congestion window -1, slow start threshold 0",
+		)
+		require.EqualError(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{Resume: CongestionSnapshot{SlowStartThreshold: -1}}}),
+			"invalid congestion snapshot: congestion window 0, slow start threshold -1",
+		)
+	})
+
+	t.Run("Hysteria default bandwidth", func(t *testing.T) {
+		c := populateConfig(&Config{Congestion: CongestionControlConfig{Algorithm: "hysteria"}})
+		require.Equal(t, 10, c.Congestion.MaxBandwidthMbps)
+		require.Equal(t, 10*1024*1024*BitsPerSecond, c.Congestion.MaxBandwidth)
+		c = populateConfig(&Config{Congestion: CongestionControlConfig{Algorithm: "hybrid"}})
+		require.Equal(t, 10, c.Congestion.MaxBandwidthMbps)
+	})
+
+	t.Run("max bandwidth", func(t *testing.T) {
+		// MaxBandwidth takes precedence over MaxBandwidthMbps
+		c := populateConfig(&Config{Congestion: CongestionControlConfig{
+			Algorithm:        "hysteria",
+			MaxBandwidth:     500_000 * BitsPerSecond,
+			MaxBandwidthMbps: 42,
+		}})
+		require.Equal(t, 500_000*BitsPerSecond, c.Congestion.MaxBandwidth)
+		c = populateConfig(&Config{Congestion: CongestionControlConfig{Algorithm: "hysteria", MaxBandwidthMbps: 42}})
+		require.Equal(t, 42*1024*1024*BitsPerSecond, c.Congestion.MaxBandwidth)
+		// if only MaxBandwidth is set, MaxBandwidthMbps doesn't default to 10 Mbps
+		c = populateConfig(&Config{Congestion: CongestionControlConfig{Algorithm: "hysteria", MaxBandwidth: 2_500_000_000 * BitsPerSecond}})
+		require.Zero(t, c.Congestion.MaxBandwidthMbps)
+
+		require.NoError(t, validateConfig(&Config{Congestion: CongestionControlConfig{MaxBandwidth: 2_500_000_000 * BitsPerSecond}}))
+		require.EqualError(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{MaxBandwidth: 2_000_000_000_000 * BitsPerSecond}}),
+			"invalid max bandwidth: 2000000000000 bps",
+		)
+	})
+
+	t.Run("deprecated fields", func(t *testing.T) {
+		var called bool
+		c := populateConfig(&Config{
+			CongestionControl:              "hysteria",
+			MaxBandwidthMbps:               42,
+			HysteriaBrutal:                 true,
+			OnCWNDChange:                   func(_, _ ByteCount) { called = true },
+			InitialCongestionWindowPackets: 10,
+		})
+		require.Equal(t, "hysteria", c.Congestion.Algorithm)
+		require.Equal(t, 42, c.Congestion.MaxBandwidthMbps)
+		require.True(t, c.Congestion.HysteriaBrutal)
+		require.Equal(t, 10, c.Congestion.InitialCongestionWindowPackets)
+		require.NotNil(t, c.Congestion.OnCWNDChange)
+		c.Congestion.OnCWNDChange(1, 2)
+		require.True(t, called)
+	})
+
+	t.Run("Congestion takes precedence over the deprecated fields", func(t *testing.T) {
+		c := populateConfig(&Config{
+			Congestion:        CongestionControlConfig{Algorithm: "westwood", InitialCongestionWindowPackets: 20},
+			CongestionControl: "hysteria",
+
+			InitialCongestionWindowPackets: 10,
+		})
+		require.Equal(t, "westwood", c.Congestion.Algorithm)
+		require.Equal(t, 20, c.Congestion.InitialCongestionWindowPackets)
+	})
+
+	t.Run("optimization goal", func(t *testing.T) {
+		require.NoError(t, validateConfig(&Config{Congestion: CongestionControlConfig{OptimizeFor: OptimizeForLatency}}))
+		require.EqualError(t,
+			validateConfig(&Config{Congestion: CongestionControlConfig{OptimizeFor: 42}}),
+			"invalid optimization goal: 42",
+		)
+
+		c := populateConfig(&Config{})
+		require.Equal(t, OptimizeForBalanced, c.Congestion.OptimizeFor)
+		require.Zero(t, c.Congestion.MaxPacingBurst)
+		require.Zero(t, c.Congestion.HysteriaMaxQueuingDelay)
+
+		c = populateConfig(&Config{Congestion: CongestionControlConfig{OptimizeFor: OptimizeForThroughput}})
+		require.Equal(t, 50*time.Millisecond, c.Congestion.HysteriaMaxQueuingDelay)
+		require.Equal(t, 1.25, c.Congestion.HysteriaGrowthFactor)
+		require.Equal(t, 1.5, c.Congestion.HysteriaHighRTTGrowthFactor)
+		require.Zero(t, c.Congestion.MaxPacingBurst)
+		require.Equal(t, LossTolerancePolicyLossRate, c.Congestion.LossTolerancePolicy)
+
+		c = populateConfig(&Config{Congestion: CongestionControlConfig{OptimizeFor: OptimizeForLatency}})
+		require.Equal(t, ByteCount(6000), c.Congestion.MaxPacingBurst)
+		require.Equal(t, 5*time.Millisecond, c.Congestion.HysteriaMaxQueuingDelay)
+		require.Equal(t, 0.5, c.Congestion.HysteriaDrainGain)
+		require.Equal(t, LossTolerancePolicyEpisode, c.Congestion.LossTolerancePolicy)
+		require.Equal(t, time.Second, c.Congestion.IdleRestartThreshold)
+		require.Zero(t, c.Congestion.HysteriaGrowthFactor)
+
+		// parameters that are set explicitly take precedence
+		c = populateConfig(&Config{Congestion: CongestionControlConfig{
+			OptimizeFor:             OptimizeForLatency,
+			MaxPacingBurst:          20_000,
+			HysteriaMaxQueuingDelay: 10 * time.Millisecond,
+		}})
+		require.Equal(t, ByteCount(20_000), c.Congestion.MaxPacingBurst)
+		require.Equal(t, 10*time.Millisecond, c.Congestion.HysteriaMaxQueuingDelay)
+		require.Equal(t, 0.5, c.Congestion.HysteriaDrainGain)
+	})
 }
 
 func TestConfigZeroLimits(t *testing.T) {
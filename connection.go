@@ -151,6 +151,18 @@ type Conn struct {
 
 	rttStats  *utils.RTTStats
 	connStats utils.ConnectionStats
+	// congestionTrace records the decisions of the congestion controller, see Config.CongestionTraceSize
+	congestionTrace *congestion.Trace
+
+	// bandwidth probes requested by the application, started by the run loop
+	bandwidthProbesMx  sync.Mutex
+	bandwidthProbes    []func(Bandwidth)
+	hasBandwidthProbes atomic.Bool
+	// a chaos injection requested by the application, applied by the run loop
+	chaosInjection atomic.Pointer[ChaosInjection]
+	// the time the next throughput sample is taken, and the previous sample, see Config.OnThroughputSample
+	nextThroughputSample monotime.Time
+	lastThroughputSample throughputCounters
 
 	cryptoStreamManager   *cryptoStreamManager
 	sentPacketHandler     ackhandler.SentPacketHandler
@@ -312,8 +324,6 @@ var newConnection = func(
 	s.preSetup()
 	s.rttStats.SetInitialRTT(rtt)
 
-	// --- 修复开始：根据配置选择拥塞算法 ---
-	// 1. 标准创建 Handler (此时内部会自动创建默认的 Cubic)
 	s.sentPacketHandler = ackhandler.NewSentPacketHandler(
 		0,
 		protocol.ByteCount(s.config.InitialPacketSize),
@@ -323,31 +333,10 @@ var newConnection = func(
 		s.conn.capabilities().ECN,
 		s.receivedPacketHandler.IgnorePacketsBelow,
 		s.perspective,
+		s.newCongestionController,
 		s.qlogger,
 		s.logger,
 	)
-	//println("CongestionControl:", s.config.CongestionControl)
-	if s.config.CongestionControl == "hysteria" {
-		//println("DEBUG: Using Hysteria congestion control!")
-		targetMbps := s.config.MaxBandwidthMbps
-		//println("targetMbps: ", targetMbps)
-		if targetMbps <= 0 {
-			targetMbps = 3
-		}
-
-		hysAlgo := congestion.NewHysteriaSender(
-			s.rttStats,
-			protocol.ByteCount(s.config.InitialPacketSize),
-			targetMbps,
-		)
-
-		if setter, ok := s.sentPacketHandler.(interface {
-			SetCongestionControl(congestion.SendAlgorithmWithDebugInfos)
-		}); ok {
-			setter.SetCongestionControl(hysAlgo)
-			//println("DEBUG: Successfully injected Hysteria!")
-		}
-	}
 	s.currentMTUEstimate.Store(uint32(estimateMaxPayloadSize(protocol.ByteCount(s.config.InitialPacketSize))))
 	statelessResetToken := statelessResetter.GetStatelessResetToken(srcConnID)
 	params := &wire.TransportParameters{
@@ -465,7 +454,6 @@ var newClientConnection = func(
 	)
 	s.ctx, s.ctxCancel = context.WithCancelCause(ctx)
 	s.preSetup()
-	// --- Hysteria 注入开始 ---
 	s.sentPacketHandler = ackhandler.NewSentPacketHandler(
 		initialPacketNumber,
 		protocol.ByteCount(s.config.InitialPacketSize),
@@ -475,43 +463,11 @@ var newClientConnection = func(
 		s.conn.capabilities().ECN,
 		s.receivedPacketHandler.IgnorePacketsBelow,
 		s.perspective,
+		s.newCongestionController,
 		s.qlogger,
 		s.logger,
 	)
 
-	// 检查配置是否开启了 Hysteria 模式
-	//println("CongestionControl:", s.config.CongestionControl)
-	if s.config.CongestionControl == "hysteria" {
-		//println("切换到Hysteria")
-
-		// 使用配置中的带宽值
-
-		targetMbps := s.config.MaxBandwidthMbps
-		//println("targetMbps: ", targetMbps)
-		if targetMbps <= 0 {
-			targetMbps = 1 // 保底默认值
-		}
-
-		hysAlgo := congestion.NewHysteriaSender(
-			s.rttStats,
-			protocol.ByteCount(s.config.InitialPacketSize),
-			targetMbps,
-		)
-
-		// 定义探测接口
-		type hSetter interface {
-			SetCongestionControl(congestion.SendAlgorithmWithDebugInfos)
-		}
-
-		if setter, ok := s.sentPacketHandler.(hSetter); ok {
-			setter.SetCongestionControl(hysAlgo)
-			//fmt.Printf("DEBUG: Successfully injected Hysteria with %d Mbps!\n", targetMbps)
-		} else {
-			fmt.Printf("DEBUG: FAILED. Actual type of sentPacketHandler is: %T\n", s.sentPacketHandler)
-		}
-	}
-	// 注入 Hysteria
-
 	s.currentMTUEstimate.Store(uint32(estimateMaxPayloadSize(protocol.ByteCount(s.config.InitialPacketSize))))
 	oneRTTStream := newCryptoStream()
 	params := &wire.TransportParameters{
@@ -582,6 +538,18 @@ func (c *Conn) preSetup() {
 		false, // ACK_FREQUENCY is not supported yet
 	)
 	c.rttStats = utils.NewRTTStats()
+	c.rttStats.ConfigureInitialRTT(c.config.InitialRTT)
+	if onRTTSample := c.config.OnRTTSample; onRTTSample != nil {
+		c.rttStats.SetSampleObserver(func(sample, ackDelay time.Duration) {
+			onRTTSample(sample, ackDelay, time.Now())
+		})
+	}
+	if c.config.LostPacketHistorySize > 0 {
+		c.connStats.LostPackets = utils.NewLostPacketLog(c.config.LostPacketHistorySize)
+	}
+	if c.config.CongestionTraceSize > 0 {
+		c.congestionTrace = congestion.NewTrace(c.config.CongestionTraceSize)
+	}
 	c.connFlowController = flowcontrol.NewConnectionFlowController(
 		protocol.ByteCount(c.config.InitialConnectionReceiveWindow),
 		protocol.ByteCount(c.config.MaxConnectionReceiveWindow),
@@ -762,6 +730,14 @@ runLoop:
 		}
 
 		c.connIDGenerator.RemoveRetiredConnIDs(now)
+		c.maybeSampleThroughput(now)
+
+		if c.hasBandwidthProbes.Load() {
+			c.startBandwidthProbes(now)
+		}
+		if ci := c.chaosInjection.Swap(nil); ci != nil {
+			c.sentPacketHandler.InjectChaos(now, ci.LossRate, ci.ExtraRTT, ci.Duration)
+		}
 
 		if c.perspective == protocol.PerspectiveClient {
 			pm := c.pathManagerOutgoing.Load()
@@ -886,24 +862,126 @@ type ConnectionStats struct {
 	// (does not monotonically increase, because packets that are declared lost
 	// can subsequently be received).
 	PacketsLost uint64
+	// BytesRetransmitted is the number of bytes of packets whose data was queued for retransmission,
+	// because the packet was declared lost. It approximates the number of retransmitted bytes included in BytesSent.
+	BytesRetransmitted uint64
+	// Goodput is the number of unique bytes delivered to the peer. Unlike BytesSent, it doesn't include
+	// retransmissions, nor packets that were lost. Does not include UDP or any other outer framing.
+	Goodput uint64
+	// DeliveryRate is the measured goodput: the smoothed rate at which data is acknowledged by the peer.
+	// Unlike the sending rate estimated from the congestion window and the RTT, it reflects what is actually delivered.
+	// It is 0 until enough acknowledgments were received, and it is reset when the connection migrates to a new path.
+	DeliveryRate Bandwidth
+	// MinRateProtectionActive is set while the congestion window is held at the floor of the minimum rate protection
+	// (see CongestionControlConfig.MinRatePolicy). The window then doesn't reflect the capacity of the path,
+	// and the connection might send more aggressively than the path supports.
+	// It is only set by the CUBIC and Reno congestion controllers.
+	MinRateProtectionActive bool
+
+	// TimeInSlowStart is the time the congestion controller spent in slow start.
+	TimeInSlowStart time.Duration
+	// TimeInCongestionAvoidance is the time the congestion controller spent in congestion avoidance.
+	TimeInCongestionAvoidance time.Duration
+	// TimeInRecovery is the time the congestion controller spent in recovery.
+	TimeInRecovery time.Duration
+	// TimeApplicationLimited is the time the application didn't send enough data to fill the congestion window.
+	TimeApplicationLimited time.Duration
+
+	// SendOpportunities is the number of times the connection checked whether
+	// the congestion controller allowed it to send a packet.
+	SendOpportunities uint64
+	// CongestionWindowLimited is the number of send opportunities where the congestion window was full.
+	CongestionWindowLimited uint64
+	// PacingLimited is the number of send opportunities where the pacer delayed sending,
+	// even though the congestion window wasn't full.
+	PacingLimited uint64
+}
+
+// CongestionWindowLimitedFraction is the fraction of send opportunities where the congestion window was full.
+// A high value indicates that the congestion window is limiting the throughput.
+func (s ConnectionStats) CongestionWindowLimitedFraction() float64 {
+	if s.SendOpportunities == 0 {
+		return 0
+	}
+	return float64(s.CongestionWindowLimited) / float64(s.SendOpportunities)
+}
+
+// PacingLimitedFraction is the fraction of send opportunities where the pacer delayed sending.
+// A high value indicates that the pacing rate is limiting the throughput.
+func (s ConnectionStats) PacingLimitedFraction() float64 {
+	if s.SendOpportunities == 0 {
+		return 0
+	}
+	return float64(s.PacingLimited) / float64(s.SendOpportunities)
 }
 
 func (c *Conn) ConnectionStats() ConnectionStats {
+	var timeInState [len(c.connStats.TimeInCongestionState)]time.Duration
+	for i := range timeInState {
+		timeInState[i] = time.Duration(c.connStats.TimeInCongestionState[i].Load())
+	}
+	// account for the time spent in the current state
+	if since := monotime.Time(c.connStats.CongestionStateSince.Load()); !since.IsZero() {
+		timeInState[c.connStats.CongestionState.Load()] += max(monotime.Since(since), 0)
+	}
+
 	return ConnectionStats{
 		MinRTT:        c.rttStats.MinRTT(),
 		LatestRTT:     c.rttStats.LatestRTT(),
 		SmoothedRTT:   c.rttStats.SmoothedRTT(),
 		MeanDeviation: c.rttStats.MeanDeviation(),
 
-		BytesSent:       c.connStats.BytesSent.Load(),
-		PacketsSent:     c.connStats.PacketsSent.Load(),
-		BytesReceived:   c.connStats.BytesReceived.Load(),
-		PacketsReceived: c.connStats.PacketsReceived.Load(),
-		BytesLost:       c.connStats.BytesLost.Load(),
-		PacketsLost:     c.connStats.PacketsLost.Load(),
+		BytesSent:          c.connStats.BytesSent.Load(),
+		PacketsSent:        c.connStats.PacketsSent.Load(),
+		BytesReceived:      c.connStats.BytesReceived.Load(),
+		PacketsReceived:    c.connStats.PacketsReceived.Load(),
+		BytesLost:          c.connStats.BytesLost.Load(),
+		PacketsLost:        c.connStats.PacketsLost.Load(),
+		BytesRetransmitted: c.connStats.BytesRetransmitted.Load(),
+		Goodput:            c.connStats.Goodput(),
+		DeliveryRate:       Bandwidth(c.connStats.DeliveryRate.Load()),
+
+		MinRateProtectionActive: c.connStats.MinRateProtectionActive.Load(),
+
+		TimeInSlowStart:           timeInState[CongestionStateSlowStart],
+		TimeInCongestionAvoidance: timeInState[CongestionStateCongestionAvoidance],
+		TimeInRecovery:            timeInState[CongestionStateRecovery],
+		TimeApplicationLimited:    timeInState[CongestionStateApplicationLimited],
+
+		SendOpportunities:       c.connStats.SendOpportunities.Load(),
+		CongestionWindowLimited: c.connStats.CongestionWindowLimited.Load(),
+		PacingLimited:           c.connStats.PacingLimited.Load(),
 	}
 }
 
+// A LostPacket is a packet that was declared lost.
+type LostPacket struct {
+	PacketNumber int64
+	// SendTime is the time the packet was sent.
+	SendTime time.Time
+	// LossTime is the time the packet was declared lost.
+	LossTime time.Time
+}
+
+// LostPackets returns the most recently lost packets, oldest first.
+// Lost packets are only tracked if enabled using Config.LostPacketHistorySize.
+// Otherwise, nil is returned.
+func (c *Conn) LostPackets() []LostPacket {
+	if c.connStats.LostPackets == nil {
+		return nil
+	}
+	snapshot := c.connStats.LostPackets.Snapshot()
+	lostPackets := make([]LostPacket, 0, len(snapshot))
+	for _, p := range snapshot {
+		lostPackets = append(lostPackets, LostPacket{
+			PacketNumber: int64(p.PacketNumber),
+			SendTime:     p.SendTime.ToTime(),
+			LossTime:     p.LossTime.ToTime(),
+		})
+	}
+	return lostPackets
+}
+
 // Time when the connection should time out
 func (c *Conn) nextIdleTimeoutTime() monotime.Time {
 	idleTimeout := max(c.idleTimeout, c.rttStats.PTO(true)*3)
@@ -940,6 +1018,9 @@ func (c *Conn) maybeResetTimer() {
 			}
 		}
 	}
+	if t := c.nextThroughputSample; !t.IsZero() && t.Before(deadline) {
+		deadline = t
+	}
 	// If the connection is hard-blocked, we can't even send acknowledgments,
 	// nor can we send PTO probe packets.
 	if c.blocked == blockModeHardBlocked {
@@ -974,7 +1055,12 @@ func (c *Conn) idleTimeoutStartTime() monotime.Time {
 
 func (c *Conn) switchToNewPath(tr *Transport, now monotime.Time) {
 	initialPacketSize := protocol.ByteCount(c.config.InitialPacketSize)
-	c.sentPacketHandler.MigratedPath(now, initialPacketSize)
+	c.sentPacketHandler.MigratedPath(
+		now,
+		initialPacketSize,
+		congestionPathKey(c.LocalAddr(), c.RemoteAddr()),
+		congestionPathKey(tr.conn.LocalAddr(), c.RemoteAddr()),
+	)
 	maxPacketSize := protocol.ByteCount(protocol.MaxPacketBufferSize)
 	if c.peerParams.MaxUDPPayloadSize > 0 && c.peerParams.MaxUDPPayloadSize < maxPacketSize {
 		maxPacketSize = c.peerParams.MaxUDPPayloadSize
@@ -1352,7 +1438,12 @@ func (c *Conn) handleShortHeaderPacket(
 		return true, nil
 	}
 	c.pathManager.SwitchToPath(p.remoteAddr)
-	c.sentPacketHandler.MigratedPath(p.rcvTime, protocol.ByteCount(c.config.InitialPacketSize))
+	c.sentPacketHandler.MigratedPath(
+		p.rcvTime,
+		protocol.ByteCount(c.config.InitialPacketSize),
+		congestionPathKey(c.LocalAddr(), c.RemoteAddr()),
+		congestionPathKey(c.LocalAddr(), p.remoteAddr),
+	)
 	maxPacketSize := protocol.ByteCount(protocol.MaxPacketBufferSize)
 	if c.peerParams.MaxUDPPayloadSize > 0 && c.peerParams.MaxUDPPayloadSize < maxPacketSize {
 		maxPacketSize = c.peerParams.MaxUDPPayloadSize
@@ -2587,6 +2678,7 @@ func (c *Conn) sendPackets(now monotime.Time) error {
 	if offset := c.connFlowController.GetWindowUpdate(now); offset > 0 {
 		c.framer.QueueControlFrame(&wire.MaxDataFrame{MaximumData: offset})
 	}
+	c.sentPacketHandler.SetFlowControlLimited(c.connFlowController.SendWindowSize() == 0)
 	if cf := c.cryptoStreamManager.GetPostHandshakeData(protocol.MaxPostHandshakeCryptoFrameSize); cf != nil {
 		c.queueControlFrame(cf)
 	}
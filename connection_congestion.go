@@ -0,0 +1,574 @@
+package quic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"net"
+	"slices"
+	"time"
+
+	"github.com/quic-go/quic-go/internal/ackhandler"
+	"github.com/quic-go/quic-go/internal/congestion"
+	"github.com/quic-go/quic-go/internal/monotime"
+	"github.com/quic-go/quic-go/internal/protocol"
+	"github.com/quic-go/quic-go/internal/utils"
+	"github.com/quic-go/quic-go/qlogwriter"
+)
+
+// A CongestionState is the phase the congestion controller of a connection is in.
+type CongestionState = congestion.State
+
+const (
+	// CongestionStateSlowStart is the slow start phase
+	CongestionStateSlowStart = congestion.StateSlowStart
+	// CongestionStateCongestionAvoidance is the congestion avoidance phase.
+	// The Hysteria congestion controller, which is rate-based, is always in this phase.
+	CongestionStateCongestionAvoidance = congestion.StateCongestionAvoidance
+	// CongestionStateRecovery is the recovery phase, entered after packet loss was detected
+	CongestionStateRecovery = congestion.StateRecovery
+	// CongestionStateApplicationLimited means that the application doesn't send enough data to fill the congestion window
+	CongestionStateApplicationLimited = congestion.StateApplicationLimited
+)
+
+// A TrafficClass classifies the data sent on a stream, see SendStream.SetTrafficClass.
+// When a packet is lost, the congestion controller is informed about the traffic class of the data it carried,
+// so that it can take into account which kind of data was affected.
+// The built-in congestion controllers currently treat all traffic classes the same.
+type TrafficClass = congestion.TrafficClass
+
+const (
+	// TrafficClassDefault is the traffic class of streams that weren't classified
+	TrafficClassDefault = congestion.TrafficClassDefault
+	// TrafficClassControl is latency-sensitive control data.
+	// A lost packet is considered to carry control data if any of its STREAM frames belongs to a control stream.
+	TrafficClassControl = congestion.TrafficClassControl
+	// TrafficClassBulk is bulk data, for which throughput matters more than latency.
+	// A lost packet is only considered to carry bulk data if all of its STREAM frames belong to bulk streams.
+	TrafficClassBulk = congestion.TrafficClassBulk
+)
+
+// A MinRatePolicy determines the lower bound of the congestion window after packet loss.
+type MinRatePolicy = congestion.MinRatePolicy
+
+const (
+	// MinRatePolicyBDP keeps the congestion window at or above the bandwidth-delay product of a 5 Mbps link
+	MinRatePolicyBDP = congestion.MinRatePolicyBDP
+	// MinRatePolicyPackets keeps the congestion window at or above a fixed number of packets, independent of the RTT
+	MinRatePolicyPackets = congestion.MinRatePolicyPackets
+)
+
+// A LossTolerancePolicy determines which congestion events don't reduce the congestion window.
+type LossTolerancePolicy = congestion.LossTolerancePolicy
+
+const (
+	// LossTolerancePolicyLossRate tolerates losses as long as the aggregate loss rate of the connection stays below 10%
+	LossTolerancePolicyLossRate = congestion.LossTolerancePolicyLossRate
+	// LossTolerancePolicyEpisode only tolerates losses in the first RTT of a congestion episode,
+	// and reduces the congestion window if losses continue in the following RTTs
+	LossTolerancePolicyEpisode = congestion.LossTolerancePolicyEpisode
+)
+
+// An OptimizationGoal selects presets for the congestion control parameters that trade latency for throughput,
+// see CongestionControlConfig.OptimizeFor.
+type OptimizationGoal uint8
+
+const (
+	// OptimizeForBalanced uses the default parameters.
+	OptimizeForBalanced OptimizationGoal = iota
+	// OptimizeForThroughput accepts more queuing delay, and ramps up faster:
+	//   - HysteriaMaxQueuingDelay: 50ms
+	//   - HysteriaGrowthFactor: 1.25
+	//   - HysteriaHighRTTGrowthFactor: 1.5
+	OptimizeForThroughput
+	// OptimizeForLatency keeps bursts small, and backs off quickly when a queue builds up:
+	//   - MaxPacingBurst: 6000 bytes (4 full-size packets)
+	//   - HysteriaMaxQueuingDelay: 5ms
+	//   - HysteriaDrainGain: 0.5
+	//   - LossTolerancePolicy: LossTolerancePolicyEpisode
+	//   - IdleRestartThreshold: 1s
+	OptimizeForLatency
+)
+
+// Bandwidth is a data rate, in bits per second.
+type Bandwidth = congestion.Bandwidth
+
+const (
+	// BitsPerSecond is 1 bit per second
+	BitsPerSecond = congestion.BitsPerSecond
+	// BytesPerSecond is 1 byte per second
+	BytesPerSecond = congestion.BytesPerSecond
+)
+
+// BDP calculates the bandwidth-delay product: the number of bytes in flight when sending at rate for one rtt.
+// It can be used to size buffers. The result is rounded up to the next byte. If rtt is not positive, it is 0.
+func BDP(rate Bandwidth, rtt time.Duration) ByteCount {
+	return congestion.BDP(rate, rtt)
+}
+
+// A HysteriaLossThreshold is the loss rate (between 0 and 1) above which the Hysteria congestion controller
+// considers packet loss to be caused by congestion, for RTTs below RTTBelow.
+type HysteriaLossThreshold = congestion.LossThreshold
+
+// A HysteriaPenaltyEvent is reported when the Hysteria congestion controller enters or leaves
+// its loss penalty period, see CongestionControlConfig.OnHysteriaPenalty.
+type HysteriaPenaltyEvent = congestion.HysteriaPenaltyEvent
+
+// A ShadowDecision is the state of the shadow congestion controller,
+// see CongestionControlConfig.ShadowAlgorithm.
+type ShadowDecision = congestion.ShadowDecision
+
+// A CongestionWindowPolicy can veto or modify the congestion window reductions of the CUBIC / Reno congestion controller,
+// see CongestionControlConfig.WindowPolicy.
+type CongestionWindowPolicy = congestion.WindowPolicy
+
+// CongestionControlName returns the name of the congestion control algorithm used by the connection,
+// as accepted by CongestionControlConfig.Algorithm, e.g. "cubic" or "hysteria".
+func (c *Conn) CongestionControlName() string {
+	return c.config.Congestion.Algorithm
+}
+
+// CongestionControlConfig returns the congestion control configuration that the connection actually uses.
+// Compared to the configuration passed to Dial or Listen, the algorithm and the target sending rate (MaxBandwidth)
+// are resolved, and the values set using the deprecated fields of the Config are taken into account.
+// Other fields that are not set still select the default of the respective congestion controller.
+func (c *Conn) CongestionControlConfig() CongestionControlConfig {
+	cc := c.config.Congestion
+	cc.HysteriaLossThresholds = slices.Clone(cc.HysteriaLossThresholds)
+	return cc
+}
+
+// CongestionState returns the phase the congestion controller is currently in.
+// The value is a snapshot, and might change at any time.
+func (c *Conn) CongestionState() CongestionState {
+	return CongestionState(c.connStats.CongestionState.Load())
+}
+
+// A CongestionSnapshot is the state of the congestion controller.
+// It can be used to warm-start a later connection to the same peer, see CongestionControlConfig.Resume.
+type CongestionSnapshot struct {
+	// CongestionWindow is the congestion window, in bytes.
+	CongestionWindow ByteCount
+	// BytesInFlight is the number of bytes sent, but not yet acknowledged or declared lost.
+	// The difference to the CongestionWindow is the headroom available for sending.
+	// It is not used when resuming a connection.
+	BytesInFlight ByteCount
+	// SlowStartThreshold is the slow start threshold, in bytes.
+	// It is 0 if the congestion controller never left slow start.
+	SlowStartThreshold ByteCount
+}
+
+// CongestionSnapshot returns the current congestion window, bytes in flight and slow start threshold.
+// It can be polled for troubleshooting. It is also typically called when the connection is closed,
+// and the result is then used to configure a new connection to the same peer.
+// The values are updated whenever packets are sent or acknowledged, and are not necessarily consistent with each other.
+func (c *Conn) CongestionSnapshot() CongestionSnapshot {
+	ssthresh := ByteCount(c.connStats.SlowStartThreshold.Load())
+	if ssthresh == protocol.MaxByteCount {
+		ssthresh = 0
+	}
+	return CongestionSnapshot{
+		CongestionWindow:   ByteCount(c.connStats.CongestionWindow.Load()),
+		BytesInFlight:      ByteCount(c.connStats.BytesInFlight.Load()),
+		SlowStartThreshold: ssthresh,
+	}
+}
+
+// PacingInfo is the state of the pacer.
+type PacingInfo struct {
+	// NextSendTime is the time when the pacer allows sending the next packet.
+	// It is the zero value if a packet can be sent immediately.
+	NextSendTime time.Time
+	// Budget is the number of bytes that the pacer allows to be sent without delay.
+	// If packets are not paced (as with the fixed congestion controller without a MaxPacingRate), the budget is unlimited,
+	// and Budget is set to the largest value a ByteCount can hold.
+	Budget ByteCount
+	// UpdateTime is the time when NextSendTime and Budget were determined.
+	UpdateTime time.Time
+}
+
+// PacingInfo returns the current state of the pacer. It can be polled for troubleshooting.
+// The values are updated whenever packets are sent or acknowledged, and are therefore not
+// necessarily accurate at the time of the call, see UpdateTime.
+// The pacer doesn't take the congestion window into account. Sending might additionally be limited
+// by the congestion window, see CongestionSnapshot.
+func (c *Conn) PacingInfo() PacingInfo {
+	var info PacingInfo
+	if t := monotime.Time(c.connStats.NextSendTime.Load()); !t.IsZero() {
+		info.NextSendTime = t.ToTime()
+	}
+	info.Budget = ByteCount(c.connStats.PacingBudget.Load())
+	if t := monotime.Time(c.connStats.PacingUpdateTime.Load()); !t.IsZero() {
+		info.UpdateTime = t.ToTime()
+	}
+	return info
+}
+
+// EstimatedSendTime returns how long it takes to send the given number of bytes at the current sending rate
+// of the congestion controller. It can be used to schedule data with a deadline.
+// For window-based congestion controllers, the sending rate is one congestion window per smoothed RTT.
+// The estimate doesn't account for data that was already sent but not yet acknowledged, nor for changes of the
+// sending rate while the data is sent. It is updated whenever packets are acknowledged or declared lost.
+func (c *Conn) EstimatedSendTime(bytes ByteCount) time.Duration {
+	if bytes <= 0 {
+		return 0
+	}
+	// the congestion controller publishes the time it takes to send 1 MB
+	const megabyte = 1 << 20
+	hi, lo := bits.Mul64(uint64(bytes), uint64(c.connStats.SendTimePerMegabyte.Load()))
+	lo, carry := bits.Add64(lo, megabyte-1, 0)
+	hi += carry
+	if hi >= megabyte {
+		return math.MaxInt64
+	}
+	d, _ := bits.Div64(hi, lo, megabyte)
+	return time.Duration(min(d, math.MaxInt64))
+}
+
+// PacingRateBitsPerSecond returns the rate the congestion controller currently sends at, in bits per second.
+// For window-based congestion controllers, this is one congestion window per smoothed RTT,
+// and for the Hysteria congestion controller the rate it paces packets at.
+// Like EstimatedSendTime, it is updated whenever packets are acknowledged or declared lost.
+func (c *Conn) PacingRateBitsPerSecond() int64 {
+	return int64(min(c.connStats.PacingRate.Load(), math.MaxInt64))
+}
+
+// A CongestionTraceEntry is an acknowledgment or a congestion event processed by the congestion controller,
+// see Config.CongestionTraceSize.
+type CongestionTraceEntry struct {
+	Time time.Time
+	// Loss is set for congestion events, and unset for acknowledgments.
+	Loss         bool
+	PacketNumber int64
+	// Bytes is the number of bytes acknowledged or lost.
+	Bytes ByteCount
+	// PriorInFlight is the number of bytes in flight before the packet was acknowledged or declared lost.
+	PriorInFlight ByteCount
+	// ReorderingLikely is set if the loss was likely caused by packet reordering, in which case
+	// the CUBIC / Reno congestion controller doesn't reduce its window.
+	ReorderingLikely bool
+
+	// PriorCongestionWindow and CongestionWindow are the congestion window before and after the event.
+	PriorCongestionWindow ByteCount
+	CongestionWindow      ByteCount
+	// SlowStartThreshold, InSlowStart and InRecovery are the state of the congestion controller after the event.
+	SlowStartThreshold ByteCount
+	InSlowStart        bool
+	InRecovery         bool
+}
+
+// CongestionTrace returns the most recent decisions of the congestion controller, oldest first.
+// They are only recorded if enabled using Config.CongestionTraceSize. Otherwise, nil is returned.
+func (c *Conn) CongestionTrace() []CongestionTraceEntry {
+	if c.congestionTrace == nil {
+		return nil
+	}
+	snapshot := c.congestionTrace.Snapshot()
+	trace := make([]CongestionTraceEntry, 0, len(snapshot))
+	for _, e := range snapshot {
+		trace = append(trace, CongestionTraceEntry{
+			Time:                  e.Time.ToTime(),
+			Loss:                  e.Event == congestion.TraceEventCongestion,
+			PacketNumber:          int64(e.PacketNumber),
+			Bytes:                 e.Bytes,
+			PriorInFlight:         e.PriorInFlight,
+			ReorderingLikely:      e.ReorderingLikely,
+			PriorCongestionWindow: e.PriorCongestionWindow,
+			CongestionWindow:      e.CongestionWindow,
+			SlowStartThreshold:    e.SlowStartThreshold,
+			InSlowStart:           e.InSlowStart,
+			InRecovery:            e.InRecovery,
+		})
+	}
+	return trace
+}
+
+// ProbeBandwidth probes how much more data the path can take right now, without waiting for the
+// congestion controller to ramp up on its own. For one RTT, rate-based congestion controllers (Hysteria)
+// temporarily increase their sending rate, and window-based congestion controllers grow their congestion window
+// even if the application doesn't fully utilize it.
+// It returns the delivery rate measured for the packets sent during the probe.
+//
+// The probe only measures what the application actually sends: If the application doesn't have enough data to send,
+// the result reflects the application's sending rate. Conversely, if the connection is already limited by the
+// congestion window, probing doesn't change the congestion controller's behavior, and only costs the measurement.
+// Probing has no effect on the rate of the Hysteria congestion controller in brutal mode.
+//
+// ProbeBandwidth blocks until the probe completes, the context is canceled, or the connection is closed.
+func (c *Conn) ProbeBandwidth(ctx context.Context) (Bandwidth, error) {
+	result := make(chan Bandwidth, 1)
+	c.bandwidthProbesMx.Lock()
+	c.bandwidthProbes = append(c.bandwidthProbes, func(b Bandwidth) { result <- b })
+	c.hasBandwidthProbes.Store(true)
+	c.bandwidthProbesMx.Unlock()
+	c.scheduleSending()
+
+	select {
+	case b := <-result:
+		return b, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-c.ctx.Done():
+		return 0, context.Cause(c.ctx)
+	}
+}
+
+// startBandwidthProbes starts the bandwidth probes requested by ProbeBandwidth.
+// It must be called from the run loop.
+func (c *Conn) startBandwidthProbes(now monotime.Time) {
+	c.bandwidthProbesMx.Lock()
+	probes := c.bandwidthProbes
+	c.bandwidthProbes = nil
+	c.hasBandwidthProbes.Store(false)
+	c.bandwidthProbesMx.Unlock()
+
+	for _, onResult := range probes {
+		c.sentPacketHandler.ProbeBandwidth(now, onResult)
+	}
+}
+
+// A ThroughputSample is a periodic snapshot of the throughput of a connection, see Config.OnThroughputSample.
+// The byte counts refer to the time since the previous sample.
+type ThroughputSample struct {
+	// Interval is the time since the previous sample.
+	Interval time.Duration
+	// BytesSent is the number of bytes sent, including retransmissions.
+	BytesSent uint64
+	// BytesAcked is the number of bytes acknowledged by the peer.
+	BytesAcked uint64
+	// BytesLost is the number of bytes declared lost.
+	BytesLost uint64
+	// BytesRetransmitted is the number of bytes of lost packets whose data was queued for retransmission.
+	// BytesSent minus BytesRetransmitted approximates the number of unique bytes sent.
+	BytesRetransmitted uint64
+	// CongestionWindow is the congestion window at the time of the sample.
+	CongestionWindow ByteCount
+	// SmoothedRTT is the smoothed RTT at the time of the sample.
+	SmoothedRTT time.Duration
+}
+
+// throughputCounters are the counters at the time of a throughput sample
+type throughputCounters struct {
+	time                                                 monotime.Time
+	bytesSent, bytesAcked, bytesLost, bytesRetransmitted uint64
+}
+
+func (c *Conn) throughputCounters(now monotime.Time) throughputCounters {
+	return throughputCounters{
+		time:               now,
+		bytesSent:          c.connStats.BytesSent.Load(),
+		bytesAcked:         c.connStats.BytesAcked.Load(),
+		bytesLost:          c.connStats.BytesLost.Load(),
+		bytesRetransmitted: c.connStats.BytesRetransmitted.Load(),
+	}
+}
+
+// maybeSampleThroughput calls the OnThroughputSample callback once the sampling interval has passed.
+// It must be called from the run loop.
+func (c *Conn) maybeSampleThroughput(now monotime.Time) {
+	if c.config.OnThroughputSample == nil {
+		return
+	}
+	if c.nextThroughputSample.IsZero() {
+		c.lastThroughputSample = c.throughputCounters(now)
+		c.nextThroughputSample = now.Add(c.config.throughputSampleInterval())
+		return
+	}
+	if now.Before(c.nextThroughputSample) {
+		return
+	}
+	last := c.lastThroughputSample
+	cur := c.throughputCounters(now)
+	c.lastThroughputSample = cur
+	c.nextThroughputSample = now.Add(c.config.throughputSampleInterval())
+	c.config.OnThroughputSample(ThroughputSample{
+		Interval:           now.Sub(last.time),
+		BytesSent:          counterDelta(cur.bytesSent, last.bytesSent),
+		BytesAcked:         counterDelta(cur.bytesAcked, last.bytesAcked),
+		BytesLost:          counterDelta(cur.bytesLost, last.bytesLost),
+		BytesRetransmitted: counterDelta(cur.bytesRetransmitted, last.bytesRetransmitted),
+		CongestionWindow:   ByteCount(c.connStats.CongestionWindow.Load()),
+		SmoothedRTT:        c.rttStats.SmoothedRTT(),
+	})
+}
+
+// counterDelta is the increase of a counter since the previous value.
+// The loss counters can be reset, in which case the current value is the increase since the reset.
+func counterDelta(cur, prev uint64) uint64 {
+	if cur < prev {
+		return cur
+	}
+	return cur - prev
+}
+
+// A ChaosInjection impairs the congestion controller's view of the network, see Conn.InjectChaos.
+type ChaosInjection struct {
+	// LossRate is the fraction of acknowledged packets that is reported to the congestion controller as lost.
+	// It must be between 0 and 1.
+	LossRate float64
+	// ExtraRTT is added to every RTT sample.
+	ExtraRTT time.Duration
+	// Duration is how long the impairment lasts. A zero duration stops a running injection.
+	Duration time.Duration
+}
+
+// InjectChaos feeds synthetic loss and RTT inflation into the congestion controller, for chaos testing.
+// For example, a LossRate of 0.05 and an ExtraRTT of 50ms for 10s simulates 5% loss and 50ms of additional
+// latency for the next 10 seconds.
+// Only the congestion controller and the RTT estimate are affected: packets are not actually dropped or delayed,
+// and packets that are reported as lost are not retransmitted.
+// A new injection replaces the one currently running.
+//
+// InjectChaos must be enabled using CongestionControlConfig.EnableChaosInjection.
+// It is intended for testing only, and must not be used in production.
+func (c *Conn) InjectChaos(ci ChaosInjection) error {
+	if !c.config.Congestion.EnableChaosInjection {
+		return errors.New("chaos injection is not enabled")
+	}
+	if ci.LossRate < 0 || ci.LossRate > 1 {
+		return fmt.Errorf("invalid chaos injection loss rate: %f", ci.LossRate)
+	}
+	if ci.ExtraRTT < 0 || ci.Duration < 0 {
+		return errors.New("chaos injection durations must not be negative")
+	}
+	c.chaosInjection.Store(&ci)
+	c.scheduleSending()
+	return nil
+}
+
+// newCongestionController creates the congestion controller for a path.
+// The sent packet handler creates one congestion controller for every path the connection uses.
+func (c *Conn) newCongestionController(initialMaxDatagramSize protocol.ByteCount) congestion.SendAlgorithmWithDebugInfos {
+	sender := c.newSender(c.config.Congestion.Algorithm, initialMaxDatagramSize, &c.connStats, c.congestionConfig(), c.qlogger)
+	if c.config.Congestion.ShadowAlgorithm != "" {
+		sender = c.newShadowSender(sender, initialMaxDatagramSize)
+	}
+	if c.congestionTrace != nil {
+		sender = congestion.NewTracingSender(sender, congestion.DefaultClock{}, c.congestionTrace)
+	}
+	return sender
+}
+
+// newShadowSender runs a sender for the shadow algorithm alongside sender.
+func (c *Conn) newShadowSender(sender congestion.SendAlgorithmWithDebugInfos, initialMaxDatagramSize protocol.ByteCount) congestion.SendAlgorithmWithDebugInfos {
+	// The shadow keeps its own statistics, and doesn't report to the connection's callbacks.
+	shadowConf := c.congestionConfig()
+	shadowConf.OnCongestionWindowChange = nil
+	shadowConf.OnHysteriaPenalty = nil
+	shadowConf.Logger = nil
+	var shadowStats utils.ConnectionStats
+	shadow := c.newSender(c.config.Congestion.ShadowAlgorithm, initialMaxDatagramSize, &shadowStats, shadowConf, nil)
+	return congestion.NewShadowSender(sender, shadow, &shadowStats, c.config.Congestion.OnShadowDecision)
+}
+
+// newSender creates a sender for the given congestion control algorithm.
+func (c *Conn) newSender(
+	algorithm string,
+	initialMaxDatagramSize protocol.ByteCount,
+	connStats *utils.ConnectionStats,
+	conf *congestion.Config,
+	qlogger qlogwriter.Recorder,
+) congestion.SendAlgorithmWithDebugInfos {
+	switch algorithm {
+	case "hysteria":
+		return congestion.NewHysteriaSender(congestion.DefaultClock{}, c.rttStats, initialMaxDatagramSize, c.config.Congestion.MaxBandwidth, conf, qlogger)
+	case "hybrid":
+		return congestion.NewHybridSender(
+			congestion.DefaultClock{},
+			c.rttStats,
+			connStats,
+			initialMaxDatagramSize,
+			c.config.Congestion.MaxBandwidth,
+			conf,
+			qlogger,
+		)
+	case "westwood":
+		return congestion.NewWestwoodSender(c.rttStats, initialMaxDatagramSize, conf, qlogger)
+	case "vegas":
+		return congestion.NewVegasSender(c.rttStats, initialMaxDatagramSize, conf, qlogger)
+	case "prague":
+		return congestion.NewPragueSender(c.rttStats, initialMaxDatagramSize, conf, qlogger)
+	case "fixed":
+		return congestion.NewFixedSender(c.rttStats, initialMaxDatagramSize, conf)
+	case "cubic":
+		return congestion.NewCubicSender(
+			congestion.DefaultClock{},
+			c.rttStats,
+			connStats,
+			initialMaxDatagramSize,
+			false,
+			conf,
+			qlogger,
+		)
+	default:
+		return congestion.NewCubicSender(
+			congestion.DefaultClock{},
+			c.rttStats,
+			connStats,
+			initialMaxDatagramSize,
+			true, // use Reno
+			conf,
+			qlogger,
+		)
+	}
+}
+
+// congestionConfig translates the Config into the parameters used by the congestion controllers.
+func (c *Conn) congestionConfig() *congestion.Config {
+	return &congestion.Config{
+		OnCongestionWindowChange:         c.config.Congestion.OnCWNDChange,
+		Logger:                           c.config.Logger,
+		WindowPolicy:                     c.config.Congestion.WindowPolicy,
+		InitialCongestionWindowPackets:   c.config.Congestion.InitialCongestionWindowPackets,
+		MinRatePolicy:                    c.config.Congestion.MinRatePolicy,
+		LossTolerancePolicy:              c.config.Congestion.LossTolerancePolicy,
+		HoldWindowOnToleratedLoss:        c.config.Congestion.HoldWindowOnToleratedLoss,
+		MinSlowStartDuration:             c.config.Congestion.MinSlowStartDuration,
+		MinSlowStartBytes:                c.config.Congestion.MinSlowStartBytes,
+		MinRatePackets:                   c.config.Congestion.MinRatePackets,
+		CubicBeta:                        c.config.Congestion.CubicBeta,
+		CubicBetaLastMax:                 c.config.Congestion.CubicBetaLastMax,
+		CubicCongestionWindowScale:       c.config.Congestion.CubicCongestionWindowScale,
+		DisableCubicTCPFriendliness:      c.config.Congestion.DisableCubicTCPFriendliness,
+		RenoBeta:                         c.config.Congestion.RenoBeta,
+		NumEmulatedConnections:           c.config.Congestion.NumEmulatedConnections,
+		RenoByteCounting:                 c.config.Congestion.RenoByteCounting,
+		HybridSlowStartMinSamples:        c.config.Congestion.HybridSlowStartMinSamples,
+		HybridSlowStartMinDelayThreshold: c.config.Congestion.HybridSlowStartMinDelayThreshold,
+		HybridSlowStartMaxDelayThreshold: c.config.Congestion.HybridSlowStartMaxDelayThreshold,
+		SlowStartPacingGain:              c.config.Congestion.SlowStartPacingGain,
+		CongestionAvoidancePacingGain:    c.config.Congestion.CongestionAvoidancePacingGain,
+		MaxPacingRate:                    c.config.Congestion.MaxPacingRate,
+		MaxPacingBurst:                   c.config.Congestion.MaxPacingBurst,
+		DisablePacing:                    c.config.Congestion.DisablePacing,
+		HysteriaBrutal:                   c.config.Congestion.HysteriaBrutal,
+		HysteriaRTTOnly:                  c.config.Congestion.HysteriaRTTOnly,
+		HysteriaInitialBandwidth:         c.config.Congestion.HysteriaInitialBandwidth,
+		HysteriaAutoBandwidth:            c.config.Congestion.HysteriaAutoBandwidth,
+		HysteriaLossThresholds:           c.config.Congestion.HysteriaLossThresholds,
+		HysteriaJitterFilterWindow:       c.config.Congestion.HysteriaJitterFilterWindow,
+		HysteriaRTOBackoff:               c.config.Congestion.HysteriaRTOBackoff,
+		HysteriaStableRTTs:               c.config.Congestion.HysteriaStableRTTs,
+		HysteriaPacingAlpha:              c.config.Congestion.HysteriaPacingAlpha,
+		HysteriaGrowthFactor:             c.config.Congestion.HysteriaGrowthFactor,
+		HysteriaHighRTTGrowthFactor:      c.config.Congestion.HysteriaHighRTTGrowthFactor,
+		HysteriaVeryHighRTTGrowthFactor:  c.config.Congestion.HysteriaVeryHighRTTGrowthFactor,
+		HysteriaDrainGain:                c.config.Congestion.HysteriaDrainGain,
+		HysteriaMaxRateCut:               c.config.Congestion.HysteriaMaxRateCut,
+		HysteriaMaxQueuingDelay:          c.config.Congestion.HysteriaMaxQueuingDelay,
+		HysteriaLossCooldown:             c.config.Congestion.HysteriaLossCooldown,
+		OnHysteriaPenalty:                c.config.Congestion.OnHysteriaPenalty,
+		IdleRestartThreshold:             c.config.Congestion.IdleRestartThreshold,
+		ResumeCongestionWindow:           c.config.Congestion.Resume.CongestionWindow,
+		ResumeSlowStartThreshold:         c.config.Congestion.Resume.SlowStartThreshold,
+		FixedWindowPackets:               c.config.Congestion.FixedWindowPackets,
+		MaxDatagramSizeCeiling:           c.config.Congestion.MaxDatagramSizeCeiling,
+	}
+}
+
+// congestionPathKey identifies the path the congestion controller state is kept for.
+func congestionPathKey(local, remote net.Addr) ackhandler.PathKey {
+	return ackhandler.PathKey(local.String() + "-" + remote.String())
+}
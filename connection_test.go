@@ -7,6 +7,7 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"math"
 	"net"
 	"net/netip"
 	"strconv"
@@ -15,6 +16,7 @@ import (
 	"time"
 
 	"github.com/quic-go/quic-go/internal/ackhandler"
+	"github.com/quic-go/quic-go/internal/congestion"
 	"github.com/quic-go/quic-go/internal/flowcontrol"
 	"github.com/quic-go/quic-go/internal/handshake"
 	"github.com/quic-go/quic-go/internal/mocks"
@@ -1787,6 +1789,7 @@ func TestConnectionPacketPacing(t *testing.T) {
 	synctest.Test(t, func(t *testing.T) {
 		mockCtrl := gomock.NewController(t)
 		sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+		sph.EXPECT().SetFlowControlLimited(gomock.Any()).AnyTimes()
 		sender := NewMockSender(mockCtrl)
 
 		tc := newServerTestConnection(t,
@@ -1906,6 +1909,7 @@ func TestConnectionPacingAndSendQueue(t *testing.T) {
 	synctest.Test(t, func(t *testing.T) {
 		mockCtrl := gomock.NewController(t)
 		sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+		sph.EXPECT().SetFlowControlLimited(gomock.Any()).AnyTimes()
 		sender := NewMockSender(mockCtrl)
 
 		tc := newServerTestConnection(t,
@@ -1961,6 +1965,7 @@ func TestConnectionIdleTimeout(t *testing.T) {
 	synctest.Test(t, func(t *testing.T) {
 		mockCtrl := gomock.NewController(t)
 		sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+		sph.EXPECT().SetFlowControlLimited(gomock.Any()).AnyTimes()
 		tc := newServerTestConnection(t,
 			mockCtrl,
 			&Config{MaxIdleTimeout: time.Minute},
@@ -2109,6 +2114,7 @@ func TestConnectionACKTimer(t *testing.T) {
 	synctest.Test(t, func(t *testing.T) {
 		mockCtrl := gomock.NewController(t)
 		sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+		sph.EXPECT().SetFlowControlLimited(gomock.Any()).AnyTimes()
 		tc := newServerTestConnection(t,
 			mockCtrl,
 			&Config{MaxIdleTimeout: time.Second},
@@ -2195,6 +2201,7 @@ func TestConnectionGSOBatch(t *testing.T) {
 	synctest.Test(t, func(t *testing.T) {
 		mockCtrl := gomock.NewController(t)
 		sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+		sph.EXPECT().SetFlowControlLimited(gomock.Any()).AnyTimes()
 		tc := newServerTestConnection(t,
 			mockCtrl,
 			nil,
@@ -2261,6 +2268,7 @@ func TestConnectionGSOBatchPacketSize(t *testing.T) {
 	synctest.Test(t, func(t *testing.T) {
 		mockCtrl := gomock.NewController(t)
 		sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+		sph.EXPECT().SetFlowControlLimited(gomock.Any()).AnyTimes()
 		tc := newServerTestConnection(t,
 			mockCtrl,
 			nil,
@@ -2348,6 +2356,7 @@ func TestConnectionGSOBatchECN(t *testing.T) {
 	synctest.Test(t, func(t *testing.T) {
 		mockCtrl := gomock.NewController(t)
 		sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+		sph.EXPECT().SetFlowControlLimited(gomock.Any()).AnyTimes()
 		tc := newServerTestConnection(t,
 			mockCtrl,
 			nil,
@@ -2446,6 +2455,7 @@ func testConnectionPTOProbePackets(t *testing.T, encLevel protocol.EncryptionLev
 	synctest.Test(t, func(t *testing.T) {
 		mockCtrl := gomock.NewController(t)
 		sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+		sph.EXPECT().SetFlowControlLimited(gomock.Any()).AnyTimes()
 		tc := newServerTestConnection(t,
 			mockCtrl,
 			nil,
@@ -2513,6 +2523,7 @@ func TestConnectionCongestionControl(t *testing.T) {
 	synctest.Test(t, func(t *testing.T) {
 		mockCtrl := gomock.NewController(t)
 		sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+		sph.EXPECT().SetFlowControlLimited(gomock.Any()).AnyTimes()
 		tc := newServerTestConnection(t,
 			mockCtrl,
 			nil,
@@ -2595,6 +2606,474 @@ func TestConnectionCongestionControl(t *testing.T) {
 	})
 }
 
+func TestConnectionCongestionControlConfig(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		tc := newServerTestConnection(t, nil, nil, false)
+		require.Equal(t, "reno", tc.conn.CongestionControlName())
+		require.Equal(t, LossTolerancePolicyLossRate, tc.conn.CongestionControlConfig().LossTolerancePolicy)
+	})
+
+	t.Run("Hysteria", func(t *testing.T) {
+		tc := newServerTestConnection(t, nil, &Config{
+			CongestionControl: "hysteria",
+			MaxBandwidthMbps:  20,
+			Congestion:        CongestionControlConfig{HysteriaLossThresholds: []HysteriaLossThreshold{{Threshold: 0.1}}},
+		}, false)
+		require.Equal(t, "hysteria", tc.conn.CongestionControlName())
+		cc := tc.conn.CongestionControlConfig()
+		require.Equal(t, "hysteria", cc.Algorithm)
+		require.Equal(t, 20*1024*1024*BitsPerSecond, cc.MaxBandwidth)
+		require.Equal(t, []HysteriaLossThreshold{{Threshold: 0.1}}, cc.HysteriaLossThresholds)
+		// modifying the returned config doesn't affect the connection
+		cc.HysteriaLossThresholds[0].Threshold = 0.5
+		require.Equal(t, 0.1, tc.conn.CongestionControlConfig().HysteriaLossThresholds[0].Threshold)
+	})
+}
+
+func TestConnectionCongestionSnapshot(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	tc := newServerTestConnection(t, mockCtrl, nil, false)
+	tc.conn.connStats.CongestionWindow.Store(50000)
+	tc.conn.connStats.BytesInFlight.Store(20000)
+	tc.conn.connStats.SlowStartThreshold.Store(int64(protocol.MaxByteCount))
+	require.Equal(t,
+		CongestionSnapshot{CongestionWindow: 50000, BytesInFlight: 20000},
+		tc.conn.CongestionSnapshot(),
+	)
+
+	tc.conn.connStats.SlowStartThreshold.Store(40000)
+	require.Equal(t, ByteCount(40000), tc.conn.CongestionSnapshot().SlowStartThreshold)
+}
+
+func TestConnectionPacingInfo(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	tc := newServerTestConnection(t, mockCtrl, nil, false)
+	now := monotime.Now()
+	tc.conn.connStats.NextSendTime.Store(int64(now.Add(5 * time.Millisecond)))
+	tc.conn.connStats.PacingBudget.Store(0)
+	tc.conn.connStats.PacingUpdateTime.Store(int64(now))
+	info := tc.conn.PacingInfo()
+	require.Equal(t, now.Add(5*time.Millisecond).ToTime(), info.NextSendTime)
+	require.Zero(t, info.Budget)
+	require.Equal(t, now.ToTime(), info.UpdateTime)
+
+	// a packet can be sent immediately
+	tc.conn.connStats.NextSendTime.Store(0)
+	tc.conn.connStats.PacingBudget.Store(2400)
+	info = tc.conn.PacingInfo()
+	require.True(t, info.NextSendTime.IsZero())
+	require.Equal(t, ByteCount(2400), info.Budget)
+}
+
+func TestConnectionEstimatedSendTime(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	tc := newServerTestConnection(t, mockCtrl, nil, false)
+	tc.conn.connStats.SendTimePerMegabyte.Store(int64(100 * time.Millisecond))
+	require.Equal(t, 100*time.Millisecond, tc.conn.EstimatedSendTime(1<<20))
+	require.Equal(t, 25*time.Millisecond, tc.conn.EstimatedSendTime(1<<18))
+	require.Equal(t, time.Second, tc.conn.EstimatedSendTime(10<<20))
+	require.Zero(t, tc.conn.EstimatedSendTime(0))
+	// no overflow
+	require.Equal(t, time.Duration(math.MaxInt64), tc.conn.EstimatedSendTime(protocol.MaxByteCount))
+}
+
+func TestConnectionPacingRate(t *testing.T) {
+	tc := newServerTestConnection(t, nil, nil, false)
+	tc.conn.connStats.PacingRate.Store(uint64(25 * 1000 * 1000 * BitsPerSecond))
+	require.Equal(t, int64(25_000_000), tc.conn.PacingRateBitsPerSecond())
+	// no overflow
+	tc.conn.connStats.PacingRate.Store(math.MaxUint64)
+	require.Equal(t, int64(math.MaxInt64), tc.conn.PacingRateBitsPerSecond())
+}
+
+func TestConnectionCubicParameters(t *testing.T) {
+	windowAfterLoss := func(t *testing.T, cc CongestionControlConfig) (before, after ByteCount) {
+		cc.MinRatePolicy = MinRatePolicyPackets
+		cc.MinRatePackets = 2
+		conf := &Config{Congestion: cc}
+		require.NoError(t, validateConfig(conf))
+		tc := newServerTestConnection(t, nil, conf, false)
+		sender := tc.conn.newCongestionController(1200)
+		now := monotime.Now()
+		for pn := range protocol.PacketNumber(10) {
+			sender.OnPacketSent(now, ByteCount(pn)*1200, pn, 1200, true)
+		}
+		before = sender.GetCongestionWindow()
+		sender.OnCongestionEvent(0, 1200, 10*1200, TrafficClassDefault, false)
+		return before, sender.GetCongestionWindow()
+	}
+
+	before, after := windowAfterLoss(t, CongestionControlConfig{Algorithm: "cubic", CubicBeta: 0.5})
+	require.Equal(t, before/2, after)
+	before, after = windowAfterLoss(t, CongestionControlConfig{Algorithm: "reno", RenoBeta: 0.8})
+	require.Equal(t, ByteCount(float64(before)*0.8), after)
+}
+
+// windowGrowthAfterLoss returns how much the congestion window of a connection's congestion controller
+// grows in congestion avoidance, within one second after a loss.
+func windowGrowthAfterLoss(t *testing.T, cc CongestionControlConfig) ByteCount {
+	t.Helper()
+	cc.MinRatePolicy = MinRatePolicyPackets
+	cc.MinRatePackets = 2
+	conf := &Config{Congestion: cc}
+	require.NoError(t, validateConfig(conf))
+	tc := newServerTestConnection(t, nil, conf, false)
+	sender := tc.conn.newCongestionController(1200)
+	now := monotime.Now()
+	pn := protocol.PacketNumber(0)
+	for ; pn < 100; pn++ {
+		sender.OnPacketSent(now, ByteCount(pn)*1200, pn, 1200, true)
+	}
+	sender.OnCongestionEvent(0, 1200, 100*1200, TrafficClassDefault, false)
+	afterLoss := sender.GetCongestionWindow()
+	for i := range 1000 {
+		pn++
+		sender.OnPacketSent(now, 0, pn, 1200, true)
+		sender.OnPacketAcked(pn, 1200, sender.GetCongestionWindow(), now.Add(time.Duration(i)*time.Millisecond))
+	}
+	return sender.GetCongestionWindow() - afterLoss
+}
+
+func TestConnectionCubicTCPFriendliness(t *testing.T) {
+	// within a short time after the loss, the window of the emulated Reno sender grows faster than the cubic function
+	friendly := windowGrowthAfterLoss(t, CongestionControlConfig{Algorithm: "cubic"})
+	unfriendly := windowGrowthAfterLoss(t, CongestionControlConfig{Algorithm: "cubic", DisableCubicTCPFriendliness: true})
+	require.Less(t, unfriendly, friendly)
+}
+
+func TestConnectionCubicCongestionWindowScale(t *testing.T) {
+	// disable the TCP-friendly region, so that the window follows the cubic function
+	growth := func(scale int) ByteCount {
+		return windowGrowthAfterLoss(t, CongestionControlConfig{
+			Algorithm:                   "cubic",
+			CubicCongestionWindowScale:  scale,
+			DisableCubicTCPFriendliness: true,
+		})
+	}
+	defaultGrowth := growth(0)
+	require.Equal(t, defaultGrowth, growth(410))
+	require.Greater(t, growth(4100), defaultGrowth)
+}
+
+func TestConnectionCongestionTrace(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		tc := newServerTestConnection(t, nil, nil, false)
+		require.Nil(t, tc.conn.CongestionTrace())
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		tc := newServerTestConnection(t, nil, &Config{CongestionTraceSize: 10}, false)
+		require.Empty(t, tc.conn.CongestionTrace())
+
+		sender := tc.conn.newCongestionController(1200)
+		cwnd := sender.GetCongestionWindow()
+		now := monotime.Now()
+		sender.OnPacketSent(now, 0, 1, 1200, true)
+		sender.OnPacketSent(now, 1200, 2, 1200, true)
+		sender.OnPacketAcked(1, 1200, 2400, now.Add(10*time.Millisecond))
+		sender.OnCongestionEvent(2, 1200, 1200, TrafficClassDefault, false)
+
+		trace := tc.conn.CongestionTrace()
+		require.Len(t, trace, 2)
+		require.Equal(t, CongestionTraceEntry{
+			Time:                  now.Add(10 * time.Millisecond).ToTime(),
+			PacketNumber:          1,
+			Bytes:                 1200,
+			PriorInFlight:         2400,
+			PriorCongestionWindow: cwnd,
+			CongestionWindow:      cwnd,
+			SlowStartThreshold:    protocol.MaxByteCount,
+			InSlowStart:           true,
+		}, trace[0])
+		require.True(t, trace[1].Loss)
+		require.Equal(t, int64(2), trace[1].PacketNumber)
+		require.Equal(t, ByteCount(1200), trace[1].Bytes)
+		require.Equal(t, ByteCount(1200), trace[1].PriorInFlight)
+	})
+}
+
+func TestConnectionInjectChaos(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		tc := newServerTestConnection(t, nil, nil, false)
+		require.EqualError(t, tc.conn.InjectChaos(ChaosInjection{LossRate: 0.05, Duration: time.Second}), "chaos injection is not enabled")
+		require.Nil(t, tc.conn.chaosInjection.Load())
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		tc := newServerTestConnection(t, nil, &Config{Congestion: CongestionControlConfig{EnableChaosInjection: true}}, false)
+		require.Error(t, tc.conn.InjectChaos(ChaosInjection{LossRate: 1.5, Duration: time.Second}))
+		require.Error(t, tc.conn.InjectChaos(ChaosInjection{ExtraRTT: -time.Millisecond, Duration: time.Second}))
+		require.Nil(t, tc.conn.chaosInjection.Load())
+
+		ci := ChaosInjection{LossRate: 0.05, ExtraRTT: 50 * time.Millisecond, Duration: 10 * time.Second}
+		require.NoError(t, tc.conn.InjectChaos(ci))
+		require.Equal(t, &ci, tc.conn.chaosInjection.Load())
+	})
+}
+
+func TestConnectionStatsDeliveryRate(t *testing.T) {
+	tc := newServerTestConnection(t, nil, nil, false)
+	require.Zero(t, tc.conn.ConnectionStats().DeliveryRate)
+	tc.conn.connStats.DeliveryRate.Store(uint64(20 * 1024 * 1024 * BitsPerSecond))
+	require.Equal(t, 20*1024*1024*BitsPerSecond, tc.conn.ConnectionStats().DeliveryRate)
+}
+
+func TestConnectionStatsMinRateProtection(t *testing.T) {
+	tc := newServerTestConnection(t, nil, nil, false)
+	require.False(t, tc.conn.ConnectionStats().MinRateProtectionActive)
+	tc.conn.connStats.MinRateProtectionActive.Store(true)
+	require.True(t, tc.conn.ConnectionStats().MinRateProtectionActive)
+}
+
+func TestConnectionStatsTimeInCongestionState(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		tc := newServerTestConnection(t, nil, nil, false)
+		mockCtrl := gomock.NewController(t)
+		// every path has its own congestion controller, which reports the state stored in states
+		var states []*CongestionState
+		newCongestion := func(protocol.ByteCount) congestion.SendAlgorithmWithDebugInfos {
+			state := CongestionStateSlowStart
+			states = append(states, &state)
+			cong := mocks.NewMockSendAlgorithmWithDebugInfos(mockCtrl)
+			cong.EXPECT().State(gomock.Any()).DoAndReturn(func(ByteCount) CongestionState { return state }).AnyTimes()
+			cong.EXPECT().GetCongestionWindow().AnyTimes()
+			cong.EXPECT().SlowStartThreshold().AnyTimes()
+			cong.EXPECT().TimeUntilSend(gomock.Any()).AnyTimes()
+			cong.EXPECT().PacingBudget(gomock.Any()).AnyTimes()
+			cong.EXPECT().EstimatedSendTime(gomock.Any()).AnyTimes()
+			cong.EXPECT().BandwidthEstimate().AnyTimes()
+			cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+			cong.EXPECT().OnFlowControlLimited(gomock.Any()).AnyTimes()
+			return cong
+		}
+		sph := ackhandler.NewSentPacketHandler(
+			0,
+			1200,
+			utils.NewRTTStats(),
+			&tc.conn.connStats,
+			false,
+			false,
+			nil,
+			protocol.PerspectiveServer,
+			newCongestion,
+			nil,
+			utils.DefaultLogger,
+		)
+		require.Len(t, states, 1)
+		// the congestion state is updated when a packet is sent
+		transition := func(state *CongestionState, next CongestionState, d time.Duration) {
+			*state = next
+			pn := sph.PopPacketNumber(protocol.Encryption1RTT)
+			sph.SentPacket(monotime.Now(), pn, protocol.InvalidPacketNumber, nil, []ackhandler.Frame{{Frame: &wire.PingFrame{}}}, protocol.Encryption1RTT, protocol.ECNNon, 1000, false, false)
+			time.Sleep(d)
+		}
+
+		time.Sleep(100 * time.Millisecond)
+		transition(states[0], CongestionStateCongestionAvoidance, 50*time.Millisecond)
+		transition(states[0], CongestionStateRecovery, 30*time.Millisecond)
+		stats := tc.conn.ConnectionStats()
+		require.Equal(t, 100*time.Millisecond, stats.TimeInSlowStart)
+		require.Equal(t, 50*time.Millisecond, stats.TimeInCongestionAvoidance)
+		// the time in the current state is accounted for, even though the state didn't change yet
+		require.Equal(t, 30*time.Millisecond, stats.TimeInRecovery)
+		require.Zero(t, stats.TimeApplicationLimited)
+
+		// the new path starts in slow start
+		sph.MigratedPath(monotime.Now(), 1200, "path 1", "path 2")
+		require.Len(t, states, 2)
+		time.Sleep(20 * time.Millisecond)
+		transition(states[1], CongestionStateApplicationLimited, 40*time.Millisecond)
+		// migrating back, the congestion controller of the first path is still in recovery
+		sph.MigratedPath(monotime.Now(), 1200, "path 2", "path 1")
+		require.Len(t, states, 2)
+		time.Sleep(10 * time.Millisecond)
+
+		stats = tc.conn.ConnectionStats()
+		require.Equal(t, 120*time.Millisecond, stats.TimeInSlowStart)
+		require.Equal(t, 50*time.Millisecond, stats.TimeInCongestionAvoidance)
+		require.Equal(t, 40*time.Millisecond, stats.TimeInRecovery)
+		require.Equal(t, 40*time.Millisecond, stats.TimeApplicationLimited)
+	})
+}
+
+func TestConnectionThroughputSampling(t *testing.T) {
+	var samples []ThroughputSample
+	tc := newServerTestConnection(t, nil, &Config{
+		OnThroughputSample:       func(s ThroughputSample) { samples = append(samples, s) },
+		ThroughputSampleInterval: 100 * time.Millisecond,
+	}, false)
+	tc.conn.connStats.BytesSent.Store(1000)
+	now := monotime.Now()
+	tc.conn.maybeSampleThroughput(now)
+	require.Equal(t, now.Add(100*time.Millisecond), tc.conn.nextThroughputSample)
+	require.Empty(t, samples)
+
+	tc.conn.connStats.BytesSent.Store(5000)
+	tc.conn.connStats.BytesAcked.Store(3000)
+	tc.conn.connStats.BytesLost.Store(200)
+	tc.conn.connStats.CongestionWindow.Store(12000)
+	tc.conn.maybeSampleThroughput(now.Add(99 * time.Millisecond))
+	require.Empty(t, samples)
+	tc.conn.maybeSampleThroughput(now.Add(110 * time.Millisecond))
+	require.Equal(t, []ThroughputSample{{
+		Interval:         110 * time.Millisecond,
+		BytesSent:        4000,
+		BytesAcked:       3000,
+		BytesLost:        200,
+		CongestionWindow: 12000,
+		SmoothedRTT:      tc.conn.rttStats.SmoothedRTT(),
+	}}, samples)
+	require.Equal(t, now.Add(210*time.Millisecond), tc.conn.nextThroughputSample)
+
+	// the loss counters were reset
+	tc.conn.connStats.Reset()
+	tc.conn.connStats.BytesLost.Store(100)
+	tc.conn.maybeSampleThroughput(now.Add(210 * time.Millisecond))
+	require.Len(t, samples, 2)
+	require.Equal(t, uint64(100), samples[1].BytesLost)
+	require.Zero(t, samples[1].BytesSent)
+}
+
+func TestConnectionThroughputSamplingLosses(t *testing.T) {
+	for _, algorithm := range []string{"reno", "hysteria"} {
+		t.Run(algorithm, func(t *testing.T) {
+			var samples []ThroughputSample
+			tc := newServerTestConnection(t, nil, &Config{
+				Congestion:               CongestionControlConfig{Algorithm: algorithm},
+				OnThroughputSample:       func(s ThroughputSample) { samples = append(samples, s) },
+				ThroughputSampleInterval: 100 * time.Millisecond,
+			}, false)
+			now := monotime.Now()
+			tc.conn.maybeSampleThroughput(now)
+
+			sph := tc.conn.sentPacketHandler
+			var pns []protocol.PacketNumber
+			for range 5 {
+				pn := sph.PopPacketNumber(protocol.Encryption1RTT)
+				sph.SentPacket(now, pn, protocol.InvalidPacketNumber, nil, []ackhandler.Frame{{Frame: &wire.PingFrame{}}}, protocol.Encryption1RTT, protocol.ECNNon, 1000, false, false)
+				pns = append(pns, pn)
+			}
+			// acknowledging the last packets declares the first one lost (packet threshold)
+			_, err := sph.ReceivedAck(&wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: pns[1], Largest: pns[4]}}}, protocol.Encryption1RTT, now.Add(10*time.Millisecond))
+			require.NoError(t, err)
+
+			tc.conn.maybeSampleThroughput(now.Add(100 * time.Millisecond))
+			require.Len(t, samples, 1)
+			require.Equal(t, uint64(1000), samples[0].BytesLost)
+			require.Equal(t, uint64(4000), samples[0].BytesAcked)
+		})
+	}
+}
+
+func TestConnectionThroughputSamplingDisabled(t *testing.T) {
+	tc := newServerTestConnection(t, nil, nil, false)
+	tc.conn.maybeSampleThroughput(monotime.Now())
+	require.Zero(t, tc.conn.nextThroughputSample)
+}
+
+func TestConnectionStatsSendLimits(t *testing.T) {
+	tc := newServerTestConnection(t, nil, nil, false)
+	stats := tc.conn.ConnectionStats()
+	require.Zero(t, stats.CongestionWindowLimitedFraction())
+	require.Zero(t, stats.PacingLimitedFraction())
+
+	tc.conn.connStats.SendOpportunities.Store(8)
+	tc.conn.connStats.CongestionWindowLimited.Store(2)
+	tc.conn.connStats.PacingLimited.Store(4)
+	stats = tc.conn.ConnectionStats()
+	require.Equal(t, uint64(8), stats.SendOpportunities)
+	require.Equal(t, uint64(2), stats.CongestionWindowLimited)
+	require.Equal(t, uint64(4), stats.PacingLimited)
+	require.Equal(t, 0.25, stats.CongestionWindowLimitedFraction())
+	require.Equal(t, 0.5, stats.PacingLimitedFraction())
+}
+
+func TestConnectionInitialRTT(t *testing.T) {
+	tc := newServerTestConnection(t, nil, &Config{InitialRTT: 5 * time.Millisecond}, false)
+	require.Equal(t, 5*time.Millisecond, tc.conn.rttStats.InitialRTT())
+	require.Equal(t, 10*time.Millisecond, tc.conn.rttStats.PTO(false))
+	// the RTT restored from the client's token takes precedence
+	require.Equal(t, 1337*time.Millisecond, tc.conn.rttStats.SmoothedRTT())
+}
+
+func TestConnectionProbeBandwidth(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+		sph.EXPECT().SetFlowControlLimited(gomock.Any()).AnyTimes()
+		tc := newServerTestConnection(t,
+			mockCtrl,
+			nil,
+			false,
+			connectionOptHandshakeConfirmed(),
+			connectionOptSentPacketHandler(sph),
+		)
+		sph.EXPECT().TimeUntilSend().AnyTimes()
+		sph.EXPECT().GetLossDetectionTimeout().AnyTimes()
+		sph.EXPECT().SendMode(gomock.Any()).Return(ackhandler.SendNone).AnyTimes()
+
+		errChan := make(chan error, 1)
+		go func() { errChan <- tc.conn.run() }()
+
+		// the probe is started on the run loop, and the result is returned once the probe completes
+		var onResult []func(Bandwidth)
+		sph.EXPECT().ProbeBandwidth(gomock.Any(), gomock.Any()).Do(func(_ monotime.Time, f func(Bandwidth)) {
+			onResult = append(onResult, f)
+		}).Times(2)
+		type result struct {
+			bw  Bandwidth
+			err error
+		}
+		resultChan := make(chan result, 2)
+		for range 2 {
+			go func() {
+				bw, err := tc.conn.ProbeBandwidth(context.Background())
+				resultChan <- result{bw: bw, err: err}
+			}()
+		}
+		synctest.Wait()
+		require.Len(t, onResult, 2)
+		require.Empty(t, resultChan)
+		for _, f := range onResult {
+			f(42 * BitsPerSecond)
+		}
+		synctest.Wait()
+		for range 2 {
+			select {
+			case r := <-resultChan:
+				require.NoError(t, r.err)
+				require.Equal(t, 42*BitsPerSecond, r.bw)
+			default:
+				t.Fatal("should have returned the probe result")
+			}
+		}
+
+		// the probe is aborted when the context is canceled
+		sph.EXPECT().ProbeBandwidth(gomock.Any(), gomock.Any())
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := tc.conn.ProbeBandwidth(ctx)
+		require.ErrorIs(t, err, context.Canceled)
+		synctest.Wait()
+
+		// test teardown
+		tc.connRunner.EXPECT().Remove(gomock.Any()).AnyTimes()
+		tc.conn.destroy(nil)
+		synctest.Wait()
+
+		select {
+		case err := <-errChan:
+			require.NoError(t, err)
+		default:
+			t.Fatal("timeout")
+		}
+
+		// probing a closed connection returns the close error
+		_, err = tc.conn.ProbeBandwidth(context.Background())
+		require.Error(t, err)
+	})
+}
+
 func TestConnectionSendQueue(t *testing.T) {
 	t.Run("with GSO", func(t *testing.T) {
 		testConnectionSendQueue(t, true)
@@ -2608,6 +3087,7 @@ func testConnectionSendQueue(t *testing.T, enableGSO bool) {
 	synctest.Test(t, func(t *testing.T) {
 		mockCtrl := gomock.NewController(t)
 		sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+		sph.EXPECT().SetFlowControlLimited(gomock.Any()).AnyTimes()
 		sender := NewMockSender(mockCtrl)
 		tc := newServerTestConnection(t,
 			mockCtrl,
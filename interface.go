@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/tls"
 	"errors"
+	"log/slog"
 	"net"
 	"slices"
 	"time"
@@ -19,6 +20,9 @@ type StreamID = protocol.StreamID
 // A Version is a QUIC version number.
 type Version = protocol.Version
 
+// A ByteCount is a number of bytes.
+type ByteCount = protocol.ByteCount
+
 const (
 	// Version1 is RFC 9000
 	Version1 = protocol.Version1
@@ -163,6 +167,18 @@ type Config struct {
 	// If set too high, the path might not support packets of that size, leading to a timeout of the QUIC handshake.
 	// Values below 1200 are invalid.
 	InitialPacketSize uint16
+	// InitialRTT is the RTT assumed before the first RTT sample is taken, and after a connection migration.
+	// It determines the initial PTO, as well as the initial pacing rate and all other RTT-dependent
+	// values of the congestion controller. On a network with a known RTT, for example on a LAN,
+	// setting it avoids a poorly paced first flight.
+	// An RTT restored from a token received on a previous connection takes precedence.
+	// If not set, it defaults to 100ms.
+	InitialRTT time.Duration
+	// OnRTTSample is called with every RTT sample: the time between sending a packet and receiving its acknowledgment,
+	// the ack delay reported by the peer, and the time the sample was taken.
+	// The sample is not corrected for the ack delay.
+	// It is called from the connection's run loop, and must not block.
+	OnRTTSample func(sample, ackDelay time.Duration, now time.Time)
 	// DisablePathMTUDiscovery disables Path MTU Discovery (RFC 8899).
 	// This allows the sending of QUIC packets that fully utilize the available MTU of the path.
 	// Path MTU discovery is only available on systems that allow setting of the Don't Fragment (DF) bit.
@@ -176,14 +192,317 @@ type Config struct {
 	// See https://datatracker.ietf.org/doc/html/draft-ietf-quic-reliable-stream-reset-07.
 	EnableStreamResetPartialDelivery bool
 
-	// 新增：拥塞控制算法选择。可选值: "cubic" (默认), "hysteria"
+	// Congestion configures the congestion controller.
+	Congestion CongestionControlConfig
+	// LostPacketHistorySize is the number of lost packets that are kept track of, for debugging purposes.
+	// They can be retrieved using Conn.LostPackets.
+	// If set to 0 (the default), lost packets are not tracked.
+	LostPacketHistorySize int
+	// CongestionTraceSize is the number of congestion control decisions that are kept track of, for debugging purposes.
+	// Every acknowledgment and congestion event passed to the congestion controller is recorded,
+	// together with the resulting congestion window. They can be retrieved using Conn.CongestionTrace.
+	// Unlike the congestion events recorded by the Tracer, which are only emitted when the state changes,
+	// this allows reconstructing exactly how the congestion window evolved during a short period of interest.
+	// Recording adds overhead to the processing of every acknowledgment, and should only be enabled for debugging.
+	// If set to 0 (the default), nothing is recorded.
+	CongestionTraceSize int
+	// OnThroughputSample is called periodically with a snapshot of the connection's throughput,
+	// e.g. for building a live graph without polling Conn.ConnectionStats.
+	// It is called from the connection's run loop, and must not block.
+	OnThroughputSample func(ThroughputSample)
+	// ThroughputSampleInterval is the interval at which OnThroughputSample is called.
+	// If not set, it defaults to 1 second.
+	ThroughputSampleInterval time.Duration
+	// Logger, if set, receives the events of the congestion controller as structured log records at debug level:
+	// congestion state changes, reductions of the congestion window or the sending rate,
+	// and losses that were tolerated without a reduction.
+	// This complements the congestion events recorded by the Tracer.
+	Logger *slog.Logger
+
+	// Deprecated: use Congestion.Algorithm instead.
 	CongestionControl string
-	// 新增：最大带宽上限，单位 Mbps (仅在 CongestionControl 为 "hysteria" 时生效)
+	// Deprecated: use Congestion.MaxBandwidthMbps instead.
 	MaxBandwidthMbps int
+	// Deprecated: use Congestion.HysteriaBrutal instead.
+	HysteriaBrutal bool
+	// Deprecated: use Congestion.OnCWNDChange instead.
+	OnCWNDChange func(old, new ByteCount)
+	// Deprecated: use Congestion.InitialCongestionWindowPackets instead.
+	InitialCongestionWindowPackets int
 
 	Tracer func(ctx context.Context, isClient bool, connID ConnectionID) qlogwriter.Trace
 }
 
+// CongestionControlConfig configures the congestion controller of a connection.
+// The zero value selects the default (Reno) congestion controller with its default parameters.
+type CongestionControlConfig struct {
+	// Algorithm selects the congestion control algorithm.
+	// Valid values are "reno" (the default), "cubic", "hysteria", "westwood" (Westwood+), "hybrid", "vegas", "prague" and "fixed".
+	// The reno and cubic congestion controllers share their implementation, and most of their parameters:
+	// they only differ in how the congestion window grows in congestion avoidance, and how much it is reduced on loss.
+	// The hybrid congestion controller ramps up like Hysteria, and switches to CUBIC
+	// on the first congestion event, or once it reaches MaxBandwidth.
+	// Vegas is delay-based: it keeps queues short, but yields to loss-based congestion controllers on shared links.
+	// Prague is a scalable congestion controller for L4S (RFC 9330): it sends packets with the ECT(1) codepoint,
+	// and reduces the congestion window in proportion to the fraction of CE-marked packets, keeping queuing delay
+	// very low on L4S bottlenecks. It requires ECN, which is used if the Transport.Conn supports it (e.g. a *net.UDPConn).
+	// Without ECN feedback, it behaves like Reno.
+	// The fixed congestion controller uses a constant congestion window of FixedWindowPackets, and doesn't react
+	// to packet loss or RTT changes at all. Packets are paced at MaxPacingRate, or not paced if it is not set.
+	// This is only appropriate on dedicated links with a known capacity, or to rule out the congestion controller when debugging.
+	Algorithm string
+	// ShadowAlgorithm runs a second congestion controller in observe-only mode, alongside the one selected by Algorithm.
+	// It accepts the same values as Algorithm, and uses the same parameters. The shadow is informed about all packets
+	// that are sent, acknowledged and lost, but it never delays sending: all sending decisions are made by Algorithm.
+	// Its congestion window and sending rate are reported to OnShadowDecision, which allows A/B comparisons
+	// of two congestion controllers on the same traffic. Since the shadow sees the bytes in flight of the primary,
+	// its congestion window only grows as far as the traffic allowed by the primary permits.
+	// The shadow doesn't call OnCWNDChange or OnHysteriaPenalty, and isn't reflected in the ConnectionStats.
+	// If not set, no shadow congestion controller is used.
+	ShadowAlgorithm string
+	// OnShadowDecision is called whenever the congestion window, the sending rate or the phase
+	// of the shadow congestion controller (see ShadowAlgorithm) changes.
+	// It is called from the connection's run loop, and must not block.
+	OnShadowDecision func(ShadowDecision)
+	// MaxBandwidth is the target sending rate.
+	// Only used by the Hysteria and the hybrid congestion controller. If not set, MaxBandwidthMbps is used.
+	// It must not exceed 1 Tbps.
+	MaxBandwidth Bandwidth
+	// MaxBandwidthMbps is the target sending rate, in Mbps, where 1 Mbps is 2^20 bits per second.
+	// It is only used if MaxBandwidth is not set. If neither is set, the target sending rate defaults to 10 Mbps.
+	MaxBandwidthMbps int
+	// HysteriaInitialBandwidth is the rate the Hysteria congestion controller starts sending at.
+	// It determines how fast the first seconds of a connection go, which matters most for short transfers.
+	// It must be at least 1 Mbps, and must not exceed the target sending rate.
+	// If not set, the initial rate is 60% of the target sending rate, and at most 100 Mbps.
+	// It is not used in brutal mode, which always sends at the target sending rate.
+	HysteriaInitialBandwidth Bandwidth
+	// HysteriaBrutal makes the Hysteria congestion controller send at MaxBandwidth at all times,
+	// ignoring packet loss, RTT fluctuations and retransmission timeouts. Packets are still paced.
+	// This is only appropriate on links with a known (and reserved) capacity.
+	HysteriaBrutal bool
+	// HysteriaRTTOnly makes the Hysteria congestion controller ignore packet loss, and only reduce its sending rate
+	// when the RTT inflates, i.e. when a queue builds up at the bottleneck. Retransmission timeouts still reduce the rate.
+	// This suits links with a high rate of random loss, but a reliable latency. Unlike in brutal mode,
+	// the sending rate still follows the link capacity, as long as the queuing at the bottleneck is visible in the RTT.
+	// RTT inflation is not evaluated on paths with a smoothed RTT of 20ms or less.
+	// It has no effect if HysteriaBrutal is set.
+	HysteriaRTTOnly bool
+	// HysteriaAutoBandwidth makes the Hysteria congestion controller discover the available bandwidth:
+	// Instead of ramping up to MaxBandwidth, it keeps probing for a rate above the measured delivery rate,
+	// and backs off when packet loss or RTT inflation signals that the link capacity was reached.
+	// MaxBandwidth is only used as the initial target rate.
+	// It has no effect if HysteriaBrutal is set.
+	HysteriaAutoBandwidth bool
+	// HysteriaLossThresholds are the loss rates above which the Hysteria congestion controller
+	// considers packet loss to be caused by congestion, depending on the RTT.
+	// Entries are ordered by ascending RTTBelow, and RTTs exceeding all breakpoints use the threshold of the last entry.
+	// If not set, it defaults to 10% below 50ms, 15% below 100ms, 20% below 180ms and 30% otherwise.
+	HysteriaLossThresholds []HysteriaLossThreshold
+	// HysteriaJitterFilterWindow makes the Hysteria congestion controller use the minimum of the last
+	// HysteriaJitterFilterWindow RTT samples to detect RTT inflation, instead of the latest sample.
+	// The Hysteria congestion controller reduces the sending rate when the RTT trends upwards over multiple RTTs,
+	// which indicates that a queue is building up, and ignores isolated RTT spikes.
+	// The filter additionally smooths out samples inflated by delayed or aggregated acknowledgements.
+	// If not set, every RTT sample is used.
+	HysteriaJitterFilterWindow int
+	// HysteriaRTOBackoff is the fraction of the last stable sending rate that the Hysteria congestion controller
+	// reduces its rate to after a retransmission timeout. Every consecutive timeout applies the factor again,
+	// down to a minimum of 1 Mbps. It must be in the range (0, 1]. If not set, it defaults to 0.5.
+	HysteriaRTOBackoff float64
+	// HysteriaStableRTTs is the number of RTTs that the Hysteria congestion controller needs to sustain its sending rate
+	// without congestion loss, before it considers the rate stable. After congestion loss, the sending rate is reduced
+	// relative to the last stable rate, so a rate that was only briefly reached while probing doesn't inflate it.
+	// If not set, it defaults to 3 RTTs.
+	HysteriaStableRTTs int
+	// HysteriaPacingAlpha is the weight that the Hysteria congestion controller gives to its target sending rate
+	// per RTT when updating the pacing rate. The pacing rate is a moving average of the sending rate,
+	// so that packets aren't paced at an abruptly different rate every time the sending rate is adjusted.
+	// It must be in the range (0, 1]. 1 disables the smoothing. If not set, it defaults to 0.5.
+	HysteriaPacingAlpha float64
+	// HysteriaGrowthFactor is the factor the Hysteria congestion controller multiplies its sending rate with
+	// in every probing cycle, while the smoothed RTT is at most 150ms. If not set, it defaults to 1.1.
+	// HysteriaHighRTTGrowthFactor is used for smoothed RTTs above 150ms, to fill long fat pipes faster.
+	// If not set, it defaults to 1.25.
+	// HysteriaVeryHighRTTGrowthFactor is used for smoothed RTTs above 300ms, for example on satellite links.
+	// If not set, the high-RTT factor is used.
+	// All factors must be larger than 1.
+	HysteriaGrowthFactor            float64
+	HysteriaHighRTTGrowthFactor     float64
+	HysteriaVeryHighRTTGrowthFactor float64
+	// HysteriaDrainGain determines how aggressively the Hysteria congestion controller drains the queue
+	// after it detected that its sending rate overshot the bottleneck bandwidth, causing a sustained increase of the RTT.
+	// For one RTT, it paces at this fraction of the measured delivery rate, before returning to the reduced sending rate.
+	// It must be in the range (0, 1]. 1 disables the drain phase. If not set, it defaults to 0.75.
+	HysteriaDrainGain float64
+	// HysteriaMaxRateCut is the largest fraction by which the Hysteria congestion controller reduces its sending rate
+	// in response to congestion loss. The reduction is proportional to the severity of the loss: when the loss rate
+	// just exceeds the loss threshold (see HysteriaLossThresholds), the rate is reduced by 25%, and at twice
+	// the threshold by 50%, up to this maximum. It must be in the range (0, 1). If not set, it defaults to 0.5.
+	HysteriaMaxRateCut float64
+	// HysteriaMaxQueuingDelay is the amount of queuing delay that the Hysteria congestion controller is willing to cause
+	// at the bottleneck, i.e. how much latency it trades for throughput.
+	// The congestion window is set to the sending rate multiplied by the sum of the min RTT and this delay.
+	// If not set, the congestion window is between 1.1 (for RTTs above 180ms) and 1.5 (for RTTs below 100ms)
+	// times the bandwidth-delay product. It must not be negative.
+	HysteriaMaxQueuingDelay time.Duration
+	// HysteriaLossCooldown makes the penalty period after a rate reduction of the Hysteria congestion controller
+	// time-based: the sending rate isn't increased until this time has passed since the last rate reduction.
+	// On flapping links, a cooldown of a few hundred milliseconds prevents repeated small losses from keeping
+	// the sending rate pinned, since the rate only resumes probing once the link has been stable for a while.
+	// If not set, the penalty period ends after a fixed number of acknowledgments. It must not be negative.
+	HysteriaLossCooldown time.Duration
+	// OnHysteriaPenalty is called when the Hysteria congestion controller enters or leaves the penalty period
+	// that follows a rate reduction due to congestion loss. During the penalty period, the sending rate isn't increased.
+	// Frequent or long penalty periods indicate sustained congestion, as opposed to transient loss events.
+	// It is called from the connection's run loop, and must not block.
+	OnHysteriaPenalty func(HysteriaPenaltyEvent)
+	// OnCWNDChange is called whenever the congestion window changes.
+	// It is called from the connection's run loop, and must not block.
+	// It is not supported by the Hysteria congestion controller.
+	OnCWNDChange func(old, new ByteCount)
+	// WindowPolicy is consulted by the CUBIC / Reno congestion controller before it reduces the congestion window
+	// in response to packet loss. It receives the current and the proposed congestion window, and returns the window to use,
+	// which allows implementing site-specific policies, e.g. a minimum rate for a certain class of customers during business hours.
+	// It is called from the connection's run loop, and must not block.
+	// If not set, the proposed congestion window is used.
+	WindowPolicy CongestionWindowPolicy
+	// InitialCongestionWindowPackets is the initial congestion window, in packets.
+	// If not set, it defaults to 32 packets. Values outside of the range of valid congestion windows are clamped.
+	// It is not used by the Hysteria congestion controller.
+	InitialCongestionWindowPackets int
+	// MinRatePolicy determines how far the CUBIC / Reno congestion controller reduces
+	// the congestion window in response to packet loss or a retransmission timeout.
+	// By default (MinRatePolicyBDP), the congestion window is kept large enough to sustain 5 Mbps,
+	// which can result in very large windows on high-RTT paths.
+	MinRatePolicy MinRatePolicy
+	// MinRatePackets is the minimum congestion window, in packets, when using MinRatePolicyPackets.
+	// If not set, it defaults to 32 packets.
+	MinRatePackets int
+	// LossTolerancePolicy determines which losses the CUBIC / Reno congestion controller tolerates
+	// without reducing the congestion window.
+	// By default (LossTolerancePolicyLossRate), losses are tolerated as long as the loss rate of the connection stays below 10%.
+	// With LossTolerancePolicyEpisode, only the losses in the first RTT of a congestion episode are tolerated.
+	LossTolerancePolicy LossTolerancePolicy
+	// HoldWindowOnToleratedLoss makes the CUBIC / Reno congestion controller stop growing the congestion window
+	// while it tolerates losses. By default, a tolerated loss only suppresses the window reduction,
+	// so on a lossy link the window keeps growing, causing even more loss.
+	// With this option, the window holds steady until the packets sent after the last tolerated loss are acknowledged.
+	HoldWindowOnToleratedLoss bool
+	// MinSlowStartDuration and MinSlowStartBytes keep an early loss from ending the initial slow start
+	// of the CUBIC / Reno congestion controller. Until the connection has been in slow start for MinSlowStartDuration,
+	// and MinSlowStartBytes were acknowledged, losses are tolerated, and the congestion window keeps growing exponentially.
+	// This prevents a transient loss in the first RTT from capping the whole transfer, at the cost of overshooting
+	// if the loss was caused by congestion. The guard only applies to the initial slow start, not to the slow start
+	// after a retransmission timeout or an idle period. If not set, the respective condition is disabled.
+	MinSlowStartDuration time.Duration
+	MinSlowStartBytes    ByteCount
+	// CubicBeta is the multiplicative decrease factor of CUBIC: the congestion window is multiplied by this factor on packet loss.
+	// CubicBetaLastMax is the factor applied to the last maximum congestion window if a loss occurs
+	// before the window recovered to that maximum (fast convergence).
+	// Both values must be in the range (0, 1). If not set, they default to 0.7 and 0.85, respectively.
+	// They are used by the cubic congestion controller, and by the hybrid congestion controller once it switched to CUBIC.
+	// Setting them is an error if neither Algorithm nor ShadowAlgorithm uses CUBIC.
+	CubicBeta        float64
+	CubicBetaLastMax float64
+	// CubicCongestionWindowScale is the scaling constant C of CUBIC's window growth function, in units of 1/1024.
+	// It must be positive. If not set, it defaults to 410, which corresponds to C = 0.4 (RFC 9438).
+	// This is meant for experiments, e.g. for reproducing results obtained with a different constant.
+	// A larger value makes the window grow faster once it exceeds the window at the last loss, and recover faster
+	// after a loss. Changing it alters the growth rate of the window, and the fairness towards other CUBIC and Reno flows.
+	// It is used by the cubic congestion controller, and by the hybrid congestion controller once it switched to CUBIC.
+	// Setting it is an error if neither Algorithm nor ShadowAlgorithm uses CUBIC.
+	CubicCongestionWindowScale int
+	// DisableCubicTCPFriendliness disables CUBIC's TCP-friendly region. By default, CUBIC keeps its congestion window
+	// at least at the window that a Reno connection would have reached in the same time, so that it gets at least
+	// its fair share when competing with Reno flows. Without it, the window only follows the cubic function.
+	// This is often faster on links with a short RTT, but CUBIC then loses bandwidth to competing Reno / NewReno flows,
+	// and converges more slowly to a fair share. It is only meant for experiments on dedicated links.
+	// It is used by the cubic congestion controller, and by the hybrid congestion controller once it switched to CUBIC.
+	// Setting it is an error if neither Algorithm nor ShadowAlgorithm uses CUBIC.
+	DisableCubicTCPFriendliness bool
+	// RenoBeta is the multiplicative decrease factor of Reno: the congestion window is multiplied by this factor on packet loss.
+	// NewReno as specified in RFC 5681 uses 0.5. It must be in the range (0, 1). If not set, it defaults to 0.7.
+	// It is used by the reno congestion controller.
+	RenoBeta float64
+	// NumEmulatedConnections makes the CUBIC / Reno congestion controller behave like this many TCP connections
+	// sharing the path: the window grows faster, and is reduced less on packet loss.
+	// This is useful when bonding multiple connections that should collectively compete like a number of TCP flows.
+	// If not set, it defaults to 1. It is used by the hybrid congestion controller once it switched to CUBIC.
+	NumEmulatedConnections int
+	// RenoByteCounting makes the Reno congestion controller use Appropriate Byte Counting (RFC 3465):
+	// the congestion window grows based on the number of bytes acknowledged, instead of the number of acknowledged packets.
+	// This avoids underestimating the window growth when the acknowledged packets are smaller than the max datagram size.
+	// In slow start, the window grows by at most 2 max datagram sizes per acknowledgment.
+	RenoByteCounting bool
+	// HybridSlowStartMinSamples, HybridSlowStartMinDelayThreshold and HybridSlowStartMaxDelayThreshold tune
+	// hybrid slow start (HyStart), which ends slow start of the CUBIC / Reno congestion controller early
+	// when the RTT increases, before packets are lost.
+	// At the beginning of each round trip, HyStart takes HybridSlowStartMinSamples RTT samples (8 by default).
+	// If the smallest of them exceeds the min RTT by more than 1/8th of the min RTT, clamped to the range
+	// [HybridSlowStartMinDelayThreshold, HybridSlowStartMaxDelayThreshold] (4ms and 16ms by default), slow start ends.
+	// On paths with a lot of jitter, this can end slow start prematurely, capping the throughput.
+	// Increasing the thresholds makes HyStart less sensitive.
+	// They are used by the hybrid congestion controller once it switched to CUBIC.
+	HybridSlowStartMinSamples        int
+	HybridSlowStartMinDelayThreshold time.Duration
+	HybridSlowStartMaxDelayThreshold time.Duration
+	// SlowStartPacingGain and CongestionAvoidancePacingGain determine how fast the CUBIC / Reno congestion controller
+	// paces out packets, relative to its bandwidth estimate (one congestion window per smoothed RTT).
+	// In slow start, the congestion window doubles every RTT, and a large gain makes sure that pacing doesn't
+	// hold back the probing for more bandwidth. In congestion avoidance, a smaller gain is sufficient to prevent
+	// RTT variations from leaving the congestion window under-utilized.
+	// Both gains must be at least 1. If not set, they default to 2 and 1.25, respectively.
+	SlowStartPacingGain           float64
+	CongestionAvoidancePacingGain float64
+	// MaxPacingRate caps the sending rate of the connection, independent of the congestion controller.
+	// The congestion window keeps growing and shrinking as usual, but packets are never paced out faster than this rate.
+	// This applies to all congestion control algorithms. If not set, the sending rate is not capped.
+	// The Hysteria congestion controller always allows sending at least one packet per RTT, even if this rate is lower.
+	MaxPacingRate Bandwidth
+	// MaxPacingBurst caps the number of bytes that the pacer allows to be sent back-to-back.
+	// While the connection is idle, the pacer accumulates credit for sending, up to this limit, such that
+	// the connection doesn't send a huge burst of packets when it resumes sending.
+	// This applies to all congestion control algorithms. A burst always allows sending at least a single packet.
+	// If not set, it defaults to the amount of data sent within 2ms at the current pacing rate, but at least 10 packets.
+	MaxPacingBurst ByteCount
+	// DisablePacing turns off pacing, for all congestion control algorithms.
+	// Packets are then sent as soon as the congestion window allows, which is useful for debugging and benchmarking.
+	// Without pacing, the congestion window is the only limit on bursts: after an idle period, or when a large number
+	// of acknowledgments arrives at once, a full congestion window can be sent back-to-back.
+	// MaxPacingRate and MaxPacingBurst have no effect when pacing is disabled.
+	DisablePacing bool
+	// IdleRestartThreshold makes the CUBIC / Reno congestion controller restart from the initial congestion window
+	// when the connection resumes sending after having been idle for longer than this period (see RFC 7661).
+	// After an idle period, the congestion window reflects the network conditions from before the idle period,
+	// and sending a full window at once could cause heavy loss. The slow start threshold remembers 3/4 of the
+	// previous congestion window, so the window quickly grows back if the network conditions didn't change.
+	// If not set, the congestion window is kept across idle periods. It must not be negative.
+	IdleRestartThreshold time.Duration
+	// Resume seeds the CUBIC / Reno congestion controller with the congestion window and slow start threshold
+	// of a previous connection to the same peer over the same path, as returned by Conn.CongestionSnapshot.
+	// Similar to careful resume, the connection starts with half of the previous congestion window,
+	// and not below the initial congestion window. Values outside of the range of valid congestion windows are clamped.
+	Resume CongestionSnapshot
+	// FixedWindowPackets is the congestion window, in packets, of the fixed congestion controller.
+	// It is required when using the fixed congestion controller, and not used otherwise.
+	// Values outside of the range of valid congestion windows are clamped.
+	FixedWindowPackets int
+	// MaxDatagramSizeCeiling bounds the datagram size the congestion controller uses to compute its windows.
+	// Path MTU discovery can still increase the packet size beyond this value, but the congestion window, its bounds
+	// and its growth per acknowledged packet stop scaling with the path MTU.
+	// If set, it must be at least 1200 bytes. If not set, the window math follows the path MTU.
+	MaxDatagramSizeCeiling ByteCount
+	// OptimizeFor applies a set of presets to the parameters above that trade latency for throughput,
+	// for users who don't want to tune each of them. The parameters changed by each preset are listed
+	// with the OptimizationGoal constants. Presets only apply to parameters that are not set,
+	// so individual parameters can still be overridden. If not set, the default parameters are used.
+	OptimizeFor OptimizationGoal
+	// EnableChaosInjection allows impairing the congestion controller's view of the network
+	// with Conn.InjectChaos. It is intended for testing only, and must not be used in production.
+	EnableChaosInjection bool
+}
+
 // ClientInfo contains information about an incoming connection attempt.
 type ClientInfo struct {
 	// RemoteAddr is the remote address on the Initial packet.
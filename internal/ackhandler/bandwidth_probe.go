@@ -0,0 +1,48 @@
+package ackhandler
+
+import (
+	"github.com/quic-go/quic-go/internal/congestion"
+	"github.com/quic-go/quic-go/internal/monotime"
+	"github.com/quic-go/quic-go/internal/protocol"
+)
+
+// A bandwidthProbe measures the delivery rate of the packets sent while the
+// congestion controller probes for additional bandwidth.
+type bandwidthProbe struct {
+	// packets sent between start and end belong to the probe
+	start, end monotime.Time
+	// the probe completes once an ACK is received after the deadline
+	deadline monotime.Time
+
+	ackedBytes protocol.ByteCount
+	firstAck   monotime.Time
+	lastAck    monotime.Time
+
+	onResult []func(congestion.Bandwidth)
+}
+
+func (p *bandwidthProbe) OnPacketAcked(sendTime monotime.Time, size protocol.ByteCount, rcvTime monotime.Time) {
+	if sendTime.Before(p.start) || !sendTime.Before(p.end) {
+		return
+	}
+	p.ackedBytes += size
+	if p.firstAck.IsZero() {
+		p.firstAck = rcvTime
+	}
+	p.lastAck = rcvTime
+}
+
+func (p *bandwidthProbe) Done(now monotime.Time) bool {
+	return !now.Before(p.deadline)
+}
+
+// DeliveryRate is the rate at which the packets sent during the probe were acknowledged.
+// The measurement interval is the longer of the send and the ACK interval,
+// so that neither bursts of packets nor compressed ACKs overestimate the rate.
+func (p *bandwidthProbe) DeliveryRate() congestion.Bandwidth {
+	if p.ackedBytes == 0 {
+		return 0
+	}
+	interval := max(p.end.Sub(p.start), p.lastAck.Sub(p.firstAck))
+	return congestion.BandwidthFromDelta(p.ackedBytes, interval)
+}
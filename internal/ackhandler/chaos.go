@@ -0,0 +1,27 @@
+package ackhandler
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"github.com/quic-go/quic-go/internal/monotime"
+)
+
+// chaosInjection impairs the signals the congestion controller receives, for testing purposes.
+// While active, acknowledged packets are reported to the congestion controller as lost with probability lossRate,
+// and extraRTT is added to every RTT sample.
+// Packets are still acknowledged as far as loss recovery is concerned, so no data is retransmitted.
+type chaosInjection struct {
+	lossRate float64
+	extraRTT time.Duration
+	end      monotime.Time
+}
+
+func (c *chaosInjection) Active(now monotime.Time) bool {
+	return c != nil && now.Before(c.end)
+}
+
+// SimulateLoss decides if an acknowledged packet is reported to the congestion controller as lost.
+func (c *chaosInjection) SimulateLoss() bool {
+	return c.lossRate > 0 && rand.Float64() < c.lossRate
+}
@@ -42,6 +42,10 @@ type ecnHandler interface {
 	SentPacket(protocol.PacketNumber, protocol.ECN)
 	Mode() protocol.ECN
 	HandleNewlyAcked(packets []packetWithPacketNumber, ect0, ect1, ecnce int64) (congested bool)
+	// NewlyReported returns the increase of the ECN counts processed by the last call to HandleNewlyAcked:
+	// the number of ECN-marked packets the peer received, and how many of them were CE-marked.
+	// Both are 0 unless the path was validated to be ECN-capable.
+	NewlyReported() (ecnMarked, ceMarked int64)
 	LostPacket(protocol.PacketNumber)
 }
 
@@ -59,8 +63,13 @@ type ecnTracker struct {
 	lastTestingPacket  protocol.PacketNumber
 	firstCapablePacket protocol.PacketNumber
 
+	// the codepoint used for ECN-marked packets
+	codepoint protocol.ECN
+
 	numSentECT0, numSentECT1                  int64
 	numAckedECT0, numAckedECT1, numAckedECNCE int64
+	// the increase of the ECN counts processed by the last call to HandleNewlyAcked
+	newlyReportedECN, newlyReportedCE int64
 
 	qlogger qlogwriter.Recorder
 	logger  utils.Logger
@@ -73,6 +82,7 @@ func newECNTracker(logger utils.Logger, qlogger qlogwriter.Recorder) *ecnTracker
 		firstTestingPacket: protocol.InvalidPacketNumber,
 		lastTestingPacket:  protocol.InvalidPacketNumber,
 		firstCapablePacket: protocol.InvalidPacketNumber,
+		codepoint:          protocol.ECT0,
 		state:              ecnStateInitial,
 		logger:             logger,
 		qlogger:            qlogger,
@@ -130,7 +140,7 @@ func (e *ecnTracker) Mode() protocol.ECN {
 		e.state = ecnStateTesting
 		return e.Mode()
 	case ecnStateTesting, ecnStateCapable:
-		return protocol.ECT0
+		return e.codepoint
 	case ecnStateUnknown, ecnStateFailed:
 		return protocol.ECNNon
 	default:
@@ -169,6 +179,7 @@ func (e *ecnTracker) LostPacket(pn protocol.PacketNumber) {
 // It must only be called for ACK frames that increase the largest acknowledged packet number,
 // see section 13.4.2.1 of RFC 9000.
 func (e *ecnTracker) HandleNewlyAcked(packets []packetWithPacketNumber, ect0, ect1, ecnce int64) (congested bool) {
+	e.newlyReportedECN, e.newlyReportedCE = 0, 0
 	if e.state == ecnStateFailed {
 		return false
 	}
@@ -299,7 +310,15 @@ func (e *ecnTracker) HandleNewlyAcked(packets []packetWithPacketNumber, ect0, ec
 
 	// Don't trust CE marks before having confirmed ECN capability of the path.
 	// Otherwise, mangling would be misinterpreted as actual congestion.
-	return e.state == ecnStateCapable && newECNCE > 0
+	if e.state != ecnStateCapable {
+		return false
+	}
+	e.newlyReportedECN, e.newlyReportedCE = newECT0+newECT1+newECNCE, newECNCE
+	return newECNCE > 0
+}
+
+func (e *ecnTracker) NewlyReported() (ecnMarked, ceMarked int64) {
+	return e.newlyReportedECN, e.newlyReportedCE
 }
 
 // failIfMangled fails ECN validation if all testing packets are lost or CE-marked.
@@ -322,14 +341,14 @@ func (e *ecnTracker) ecnMarking(pn protocol.PacketNumber) protocol.ECN {
 		return protocol.ECNNon
 	}
 	if pn < e.lastTestingPacket || e.lastTestingPacket == protocol.InvalidPacketNumber {
-		return protocol.ECT0
+		return e.codepoint
 	}
 	if pn < e.firstCapablePacket || e.firstCapablePacket == protocol.InvalidPacketNumber {
 		return protocol.ECNNon
 	}
 	// We don't need to deal with the case when ECN validation fails,
 	// since we're ignoring any ECN counts reported in ACK frames in that case.
-	return protocol.ECT0
+	return e.codepoint
 }
 
 func (e *ecnTracker) isTestingPacket(pn protocol.PacketNumber) bool {
@@ -342,12 +342,52 @@ func TestECNCongestionDetection(t *testing.T) {
 		[]qlogwriter.Event{qlog.ECNStateUpdated{State: qlog.ECNStateCapable}},
 		eventRecorder.Events(),
 	)
+	ecnMarked, ceMarked := ecnTracker.NewlyReported()
+	require.Equal(t, int64(3), ecnMarked)
+	require.Equal(t, int64(1), ceMarked)
 
 	// No increase in CE. No congestion.
 	require.False(t, ecnTracker.HandleNewlyAcked(getAckedPackets(4, 5, 6, 13), 5, 0, 1))
 	eventRecorder.Clear()
+	ecnMarked, ceMarked = ecnTracker.NewlyReported()
+	require.Equal(t, int64(3), ecnMarked)
+	require.Zero(t, ceMarked)
 
 	// Increase in CE. More congestion.
 	require.True(t, ecnTracker.HandleNewlyAcked(getAckedPackets(7, 8, 9, 14), 7, 0, 2))
 	require.Empty(t, eventRecorder.Events())
+	ecnMarked, ceMarked = ecnTracker.NewlyReported()
+	require.Equal(t, int64(3), ecnMarked)
+	require.Equal(t, int64(1), ceMarked)
+}
+
+func TestECNNewlyReportedBeforeValidation(t *testing.T) {
+	var eventRecorder events.Recorder
+	ecnTracker := newECNTracker(utils.DefaultLogger, &eventRecorder)
+
+	sendECNTestingPackets(t, ecnTracker, &eventRecorder)
+	// ECN counts are only reported once the path was validated
+	require.False(t, ecnTracker.HandleNewlyAcked(getAckedPackets(1), 0, 0, 1))
+	ecnMarked, ceMarked := ecnTracker.NewlyReported()
+	require.Zero(t, ecnMarked)
+	require.Zero(t, ceMarked)
+}
+
+func TestECNECT1Codepoint(t *testing.T) {
+	var eventRecorder events.Recorder
+	ecnTracker := newECNTracker(utils.DefaultLogger, &eventRecorder)
+	ecnTracker.codepoint = protocol.ECT1
+
+	for i := range protocol.PacketNumber(10) {
+		require.Equal(t, protocol.ECT1, ecnTracker.Mode())
+		ecnTracker.SentPacket(i, protocol.ECT1)
+	}
+	require.Equal(t, protocol.ECNNon, ecnTracker.Mode())
+	// ECT(1) counts validate the path
+	require.False(t, ecnTracker.HandleNewlyAcked(getAckedPackets(1, 2, 3), 0, 3, 0))
+	require.Equal(t, protocol.ECT1, ecnTracker.Mode())
+	// fewer ECT(1) counts than acknowledged ECT(1) packets fail the validation
+	require.False(t, ecnTracker.HandleNewlyAcked(getAckedPackets(4, 5, 6), 0, 4, 0))
+	require.Equal(t, ecnStateFailed, ecnTracker.state)
+	require.Equal(t, protocol.ECNNon, ecnTracker.Mode())
 }
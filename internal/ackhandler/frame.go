@@ -1,6 +1,7 @@
 package ackhandler
 
 import (
+	"github.com/quic-go/quic-go/internal/congestion"
 	"github.com/quic-go/quic-go/internal/wire"
 )
 
@@ -10,6 +11,12 @@ type FrameHandler interface {
 	OnLost(wire.Frame)
 }
 
+// A TrafficClassifier is a FrameHandler that reports the traffic class of the data it sends.
+// When a packet is lost, the traffic class is passed to the congestion controller.
+type TrafficClassifier interface {
+	TrafficClass() congestion.TrafficClass
+}
+
 type Frame struct {
 	Frame   wire.Frame // nil if the frame has already been acknowledged in another packet
 	Handler FrameHandler
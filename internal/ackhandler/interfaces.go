@@ -1,6 +1,9 @@
 package ackhandler
 
 import (
+	"time"
+
+	"github.com/quic-go/quic-go/internal/congestion"
 	"github.com/quic-go/quic-go/internal/monotime"
 	"github.com/quic-go/quic-go/internal/protocol"
 	"github.com/quic-go/quic-go/internal/wire"
@@ -25,10 +28,6 @@ type SentPacketHandler interface {
 	TimeUntilSend() monotime.Time
 	SetMaxDatagramSize(count protocol.ByteCount)
 
-	// 新增这一行
-	// 确保这里写的是 SendAlgorithm
-	//SetCongestionControl(algo congestion.SendAlgorithm)
-
 	// only to be called once the handshake is complete
 	QueueProbePacket(protocol.EncryptionLevel) bool /* was a packet queued */
 
@@ -39,5 +38,20 @@ type SentPacketHandler interface {
 	GetLossDetectionTimeout() monotime.Time
 	OnLossDetectionTimeout(now monotime.Time) error
 
-	MigratedPath(now monotime.Time, initialMaxPacketSize protocol.ByteCount)
+	// MigratedPath is called when the connection migrated from one path to another.
+	MigratedPath(now monotime.Time, initialMaxPacketSize protocol.ByteCount, from, to PathKey)
+
+	// ProbeBandwidth makes the congestion controller probe for additional bandwidth for one RTT.
+	// Once the probe completes, onResult is called with the delivery rate of the packets sent during the probe.
+	// If a probe is already running, onResult is called when that probe completes.
+	ProbeBandwidth(now monotime.Time, onResult func(congestion.Bandwidth))
+
+	// InjectChaos impairs the signals the congestion controller receives, for testing purposes.
+	// For the given duration, acknowledged packets are reported to the congestion controller as lost
+	// with probability lossRate, and extraRTT is added to every RTT sample.
+	// A non-positive duration stops a running injection.
+	InjectChaos(now monotime.Time, lossRate float64, extraRTT, duration time.Duration)
+
+	// SetFlowControlLimited tells the congestion controller whether sending is blocked by the peer's flow control window.
+	SetFlowControlLimited(limited bool)
 }
@@ -152,6 +152,45 @@ func (c *MockECNHandlerModeCall) DoAndReturn(f func() protocol.ECN) *MockECNHand
 	return c
 }
 
+// NewlyReported mocks base method.
+func (m *MockECNHandler) NewlyReported() (int64, int64) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NewlyReported")
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(int64)
+	return ret0, ret1
+}
+
+// NewlyReported indicates an expected call of NewlyReported.
+func (mr *MockECNHandlerMockRecorder) NewlyReported() *MockECNHandlerNewlyReportedCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewlyReported", reflect.TypeOf((*MockECNHandler)(nil).NewlyReported))
+	return &MockECNHandlerNewlyReportedCall{Call: call}
+}
+
+// MockECNHandlerNewlyReportedCall wrap *gomock.Call
+type MockECNHandlerNewlyReportedCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockECNHandlerNewlyReportedCall) Return(ecnMarked, ceMarked int64) *MockECNHandlerNewlyReportedCall {
+	c.Call = c.Call.Return(ecnMarked, ceMarked)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockECNHandlerNewlyReportedCall) Do(f func() (int64, int64)) *MockECNHandlerNewlyReportedCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockECNHandlerNewlyReportedCall) DoAndReturn(f func() (int64, int64)) *MockECNHandlerNewlyReportedCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // SentPacket mocks base method.
 func (m *MockECNHandler) SentPacket(arg0 protocol.PacketNumber, arg1 protocol.ECN) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,70 @@
+package ackhandler
+
+import (
+	"slices"
+
+	"github.com/quic-go/quic-go/internal/congestion"
+	"github.com/quic-go/quic-go/internal/protocol"
+)
+
+// A PathKey identifies a network path, e.g. by its local and remote address.
+type PathKey string
+
+// The maximum number of paths to keep the congestion controller state for.
+// When a new path is used and the limit is reached, the state of the least recently used path is discarded.
+const maxPathCongestionStates = 4
+
+type pathCongestionState struct {
+	key        PathKey
+	congestion congestion.SendAlgorithmWithDebugInfos
+	// the largest max datagram size used with the congestion controller on this path
+	maxDatagramSize protocol.ByteCount
+}
+
+// pathCongestionStates holds the congestion controller state of every path used by the connection.
+// Only one path is active at any time. When the connection migrates back to a path it used before,
+// the congestion controller continues with the state it had on that path, instead of starting from scratch.
+type pathCongestionStates struct {
+	newCongestion func(initialMaxDatagramSize protocol.ByteCount) congestion.SendAlgorithmWithDebugInfos
+	// ordered by last use, with the active path at the end
+	paths []*pathCongestionState
+}
+
+func newPathCongestionStates(
+	initialMaxDatagramSize protocol.ByteCount,
+	newCongestion func(initialMaxDatagramSize protocol.ByteCount) congestion.SendAlgorithmWithDebugInfos,
+) *pathCongestionStates {
+	paths := make([]*pathCongestionState, 0, maxPathCongestionStates)
+	paths = append(paths, &pathCongestionState{
+		congestion:      newCongestion(initialMaxDatagramSize),
+		maxDatagramSize: initialMaxDatagramSize,
+	})
+	return &pathCongestionStates{newCongestion: newCongestion, paths: paths}
+}
+
+func (s *pathCongestionStates) Active() *pathCongestionState {
+	return s.paths[len(s.paths)-1]
+}
+
+// Activate makes path to the active path.
+// from is the path the connection migrated away from, and becomes the key of the previously active state.
+// A path that is used for the first time gets a new congestion controller.
+// If the state limit is reached, the congestion controller of the least recently used path is reset and reused.
+func (s *pathCongestionStates) Activate(from, to PathKey, initialMaxDatagramSize protocol.ByteCount) *pathCongestionState {
+	s.Active().key = from
+	if idx := slices.IndexFunc(s.paths, func(p *pathCongestionState) bool { return p.key == to }); idx != -1 {
+		p := s.paths[idx]
+		s.paths = append(slices.Delete(s.paths, idx, idx+1), p)
+		return p
+	}
+	p := &pathCongestionState{key: to, maxDatagramSize: initialMaxDatagramSize}
+	if len(s.paths) < maxPathCongestionStates {
+		p.congestion = s.newCongestion(initialMaxDatagramSize)
+	} else {
+		p.congestion = s.paths[0].congestion
+		p.congestion.OnConnectionMigration()
+		s.paths = slices.Delete(s.paths, 0, 1)
+	}
+	s.paths = append(s.paths, p)
+	return p
+}
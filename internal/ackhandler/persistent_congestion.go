@@ -0,0 +1,62 @@
+package ackhandler
+
+import (
+	"time"
+
+	"github.com/quic-go/quic-go/internal/monotime"
+	"github.com/quic-go/quic-go/internal/protocol"
+)
+
+// A persistentCongestionDetector detects persistent congestion, as defined in RFC 9002, section 7.6:
+// All ack-eliciting packets sent over a period longer than the persistent congestion duration were lost,
+// and none of the packets sent in between were acknowledged.
+// Only packets sent after the first RTT sample start a period.
+//
+// The packets of a packet number space are passed in ascending order.
+// Packets that were declared lost earlier, and acknowledged packets, have already been removed from the history,
+// and end the period. This might miss some cases of persistent congestion, but never wrongly detects it.
+type persistentCongestionDetector struct {
+	enabled        bool
+	firstRTTSample monotime.Time
+	duration       time.Duration
+
+	hasPrev bool
+	prev    protocol.PacketNumber
+	// send time of the first lost packet of the current period, 0 if there's no period
+	start    monotime.Time
+	detected bool
+}
+
+func (d *persistentCongestionDetector) Start(firstRTTSample monotime.Time, duration time.Duration) {
+	*d = persistentCongestionDetector{
+		enabled:        true,
+		firstRTTSample: firstRTTSample,
+		duration:       duration,
+	}
+}
+
+func (d *persistentCongestionDetector) OnPacket(pn protocol.PacketNumber, sendTime monotime.Time, lost bool, history *sentPacketHistory) {
+	if !d.enabled {
+		return
+	}
+	contiguous := d.hasPrev && history.Difference(pn, d.prev) == 1
+	d.hasPrev = true
+	d.prev = pn
+	if !lost || !contiguous {
+		d.start = 0
+	}
+	if !lost {
+		return
+	}
+	if d.start.IsZero() {
+		if sendTime.After(d.firstRTTSample) {
+			d.start = sendTime
+		}
+		return
+	}
+	if sendTime.Sub(d.start) > d.duration {
+		d.detected = true
+	}
+}
+
+func (d *persistentCongestionDetector) Detected() bool { return d.detected }
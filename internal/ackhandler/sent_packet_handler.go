@@ -27,6 +27,8 @@ const (
 	minRTTAfterRetry = 5 * time.Millisecond
 	// The PTO duration uses exponential backoff, but is truncated to a maximum value, as allowed by RFC 8961, section 4.4.
 	maxPTODuration = 60 * time.Second
+	// Losses spanning more than this many PTO durations are persistent congestion, see RFC 9002, section 7.6.
+	persistentCongestionThreshold = 3
 )
 
 // Path probe packets are declared lost after this time.
@@ -71,6 +73,10 @@ type sentPacketHandler struct {
 	lostPackets      lostPacketTracker // only for application-data packet number space
 	// send time of the largest acknowledged packet, across all packet number spaces
 	largestAckedTime monotime.Time
+	// time the first RTT sample was taken, on the current path
+	firstRTTSampleTime monotime.Time
+	// the largest reordering distance (in packets) of a spurious loss, on the current path
+	maxPacketReordering protocol.PacketNumber
 
 	// Do we know that the peer completed address validation yet?
 	// Always true for the server.
@@ -89,6 +95,19 @@ type sentPacketHandler struct {
 
 	bytesInFlight protocol.ByteCount
 
+	// the currently running bandwidth probe, if any
+	bandwidthProbe *bandwidthProbe
+	// the delivery rate of the active path, independent of the congestion controller
+	deliveryRate congestion.BandwidthSampler
+
+	// whether sending is blocked by the peer's connection-level flow control window
+	flowControlLimited bool
+
+	// synthetic loss and RTT inflation, for testing purposes
+	chaos *chaosInjection
+
+	// the congestion controller state of all paths, and the congestion controller of the active path
+	paths      *pathCongestionStates
 	congestion congestion.SendAlgorithmWithDebugInfos
 	rttStats   *utils.RTTStats
 	connStats  *utils.ConnectionStats
@@ -111,12 +130,34 @@ type sentPacketHandler struct {
 	qlogger     qlogwriter.Recorder
 	lastMetrics qlog.MetricsUpdated
 	logger      utils.Logger
+
+	// published are the values last published to connStats by updateCongestionState
+	published publishedCongestionState
+}
+
+// publishedCongestionState mirrors the congestion state published to the ConnectionStats.
+// Values are only stored to the ConnectionStats when they change, since this happens for every packet sent.
+type publishedCongestionState struct {
+	congestionWindow    protocol.ByteCount
+	slowStartThreshold  protocol.ByteCount
+	sendTimePerMegabyte time.Duration
+	pacingRate          congestion.Bandwidth
+	deliveryRate        congestion.Bandwidth
+
+	bytesInFlight protocol.ByteCount
+	nextSendTime  monotime.Time
+	pacingBudget  protocol.ByteCount
+
+	state      congestion.State
+	stateSince monotime.Time
 }
 
 var _ SentPacketHandler = &sentPacketHandler{}
 
 // clientAddressValidated indicates whether the address was validated beforehand by an address validation token.
 // If the address was validated, the amplification limit doesn't apply. It has no effect for a client.
+// newCongestion is used to create the congestion controller, once for every path.
+// If nil, a Reno sender with the default parameters is used.
 func NewSentPacketHandler(
 	initialPN protocol.PacketNumber,
 	initialMaxDatagramSize protocol.ByteCount,
@@ -126,17 +167,23 @@ func NewSentPacketHandler(
 	enableECN bool,
 	ignorePacketsBelow func(protocol.PacketNumber),
 	pers protocol.Perspective,
+	newCongestion func(initialMaxDatagramSize protocol.ByteCount) congestion.SendAlgorithmWithDebugInfos,
 	qlogger qlogwriter.Recorder,
 	logger utils.Logger,
 ) SentPacketHandler {
-	congestion := congestion.NewCubicSender(
-		congestion.DefaultClock{},
-		rttStats,
-		connStats,
-		initialMaxDatagramSize,
-		true, // use Reno
-		qlogger,
-	)
+	if newCongestion == nil {
+		newCongestion = func(initialMaxDatagramSize protocol.ByteCount) congestion.SendAlgorithmWithDebugInfos {
+			return congestion.NewCubicSender(
+				congestion.DefaultClock{},
+				rttStats,
+				connStats,
+				initialMaxDatagramSize,
+				true, // use Reno
+				nil,
+				qlogger,
+			)
+		}
+	}
 
 	h := &sentPacketHandler{
 		peerCompletedAddressValidation: pers == protocol.PerspectiveServer,
@@ -147,16 +194,22 @@ func NewSentPacketHandler(
 		lostPackets:                    *newLostPacketTracker(64),
 		rttStats:                       rttStats,
 		connStats:                      connStats,
-		congestion:                     congestion,
+		paths:                          newPathCongestionStates(initialMaxDatagramSize, newCongestion),
 		ignorePacketsBelow:             ignorePacketsBelow,
 		perspective:                    pers,
 		qlogger:                        qlogger,
 		logger:                         logger,
 	}
+	h.congestion = h.paths.Active().congestion
 	if enableECN {
 		h.enableECN = true
-		h.ecnTracker = newECNTracker(logger, qlogger)
+		ecnTracker := newECNTracker(logger, qlogger)
+		if _, ok := h.congestion.(congestion.ScalableSender); ok {
+			ecnTracker.codepoint = protocol.ECT1
+		}
+		h.ecnTracker = ecnTracker
 	}
+	h.updateCongestionState(monotime.Now())
 	return h
 }
 
@@ -313,9 +366,79 @@ func (h *sentPacketHandler) SentPacket(
 	if h.qlogger != nil {
 		h.qlogMetricsUpdated()
 	}
+	h.updateSendingState(t)
 	h.setLossDetectionTimer(t)
 }
 
+// updateCongestionState publishes the state of the congestion controller,
+// such that it can be read by the application without synchronization.
+// It also accounts for the time spent in the previous state.
+func (h *sentPacketHandler) updateCongestionState(now monotime.Time) {
+	if cwnd := h.congestion.GetCongestionWindow(); cwnd != h.published.congestionWindow {
+		h.published.congestionWindow = cwnd
+		h.connStats.CongestionWindow.Store(int64(cwnd))
+	}
+	if ssthresh := h.congestion.SlowStartThreshold(); ssthresh != h.published.slowStartThreshold {
+		h.published.slowStartThreshold = ssthresh
+		h.connStats.SlowStartThreshold.Store(int64(ssthresh))
+	}
+	if d := h.congestion.EstimatedSendTime(1 << 20); d != h.published.sendTimePerMegabyte {
+		h.published.sendTimePerMegabyte = d
+		h.connStats.SendTimePerMegabyte.Store(int64(d))
+	}
+	if rate := h.congestion.BandwidthEstimate(); rate != h.published.pacingRate {
+		h.published.pacingRate = rate
+		h.connStats.PacingRate.Store(uint64(rate))
+	}
+	if rate := h.deliveryRate.BandwidthEstimate(); rate != h.published.deliveryRate {
+		h.published.deliveryRate = rate
+		h.connStats.DeliveryRate.Store(uint64(rate))
+	}
+	h.updateSendingState(now)
+}
+
+// updateSendingState publishes the part of the congestion state that changes when a packet is sent:
+// the bytes in flight, the state of the pacer, and the congestion state.
+// The congestion window and the sending rate only change when packets are acknowledged or lost.
+func (h *sentPacketHandler) updateSendingState(now monotime.Time) {
+	if h.bytesInFlight != h.published.bytesInFlight {
+		h.published.bytesInFlight = h.bytesInFlight
+		h.connStats.BytesInFlight.Store(int64(h.bytesInFlight))
+	}
+	var nextSendTime monotime.Time
+	if t := h.congestion.TimeUntilSend(h.bytesInFlight); t.After(now) {
+		nextSendTime = t
+	}
+	if nextSendTime != h.published.nextSendTime {
+		h.published.nextSendTime = nextSendTime
+		h.connStats.NextSendTime.Store(int64(nextSendTime))
+	}
+	if budget := h.congestion.PacingBudget(now); budget != h.published.pacingBudget {
+		h.published.pacingBudget = budget
+		h.connStats.PacingBudget.Store(int64(budget))
+	}
+	h.connStats.PacingUpdateTime.Store(int64(now))
+
+	state := h.congestion.State(h.bytesInFlight)
+	if h.published.stateSince.IsZero() {
+		h.published.state = state
+		h.published.stateSince = now
+		h.connStats.CongestionStateSince.Store(int64(now))
+		h.connStats.CongestionState.Store(uint32(state))
+		return
+	}
+	if state == h.published.state {
+		return
+	}
+	if now.After(h.published.stateSince) {
+		h.connStats.TimeInCongestionState[h.published.state].Add(int64(now.Sub(h.published.stateSince)))
+	}
+	h.published.state = state
+	h.published.stateSince = now
+	h.connStats.CongestionStateSince.Store(int64(now))
+	h.connStats.CongestionState.Store(uint32(state))
+}
+
 func (h *sentPacketHandler) qlogMetricsUpdated() {
 	var metricsUpdatedEvent qlog.MetricsUpdated
 	var updated bool
@@ -346,6 +469,17 @@ func (h *sentPacketHandler) qlogMetricsUpdated() {
 		h.lastMetrics.CongestionWindow = metricsUpdatedEvent.CongestionWindow
 		updated = true
 	}
+	// the slow start threshold is not logged before it was set for the first time
+	if ssthresh := h.congestion.SlowStartThreshold(); ssthresh != protocol.MaxByteCount && h.lastMetrics.SSThresh != int(ssthresh) {
+		metricsUpdatedEvent.SSThresh = int(ssthresh)
+		h.lastMetrics.SSThresh = metricsUpdatedEvent.SSThresh
+		updated = true
+	}
+	if pacingRate := h.congestion.BandwidthEstimate(); h.lastMetrics.PacingRate != int(pacingRate) {
+		metricsUpdatedEvent.PacingRate = int(pacingRate)
+		h.lastMetrics.PacingRate = metricsUpdatedEvent.PacingRate
+		updated = true
+	}
 	if h.lastMetrics.BytesInFlight != int(h.bytesInFlight) {
 		metricsUpdatedEvent.BytesInFlight = int(h.bytesInFlight)
 		h.lastMetrics.BytesInFlight = metricsUpdatedEvent.BytesInFlight
@@ -411,7 +545,14 @@ func (h *sentPacketHandler) ReceivedAck(ack *wire.AckFrame, encLevel protocol.En
 				ackDelay = min(ack.DelayTime, h.rttStats.MaxAckDelay())
 			}
 			if h.largestAckedTime.IsZero() || !p.SendTime.Before(h.largestAckedTime) {
-				h.rttStats.UpdateRTT(rcvTime.Sub(p.SendTime), ackDelay)
+				rtt := rcvTime.Sub(p.SendTime)
+				if h.chaos.Active(rcvTime) {
+					rtt += h.chaos.extraRTT
+				}
+				h.rttStats.UpdateRTT(rtt, ackDelay)
+				if h.firstRTTSampleTime.IsZero() {
+					h.firstRTTSampleTime = rcvTime
+				}
 				if h.logger.Debug() {
 					h.logger.Debugf("\tupdated RTT: %s (σ: %s)", h.rttStats.SmoothedRTT(), h.rttStats.MeanDeviation())
 				}
@@ -424,8 +565,12 @@ func (h *sentPacketHandler) ReceivedAck(ack *wire.AckFrame, encLevel protocol.En
 	// Only inform the ECN tracker about new 1-RTT ACKs if the ACK increases the largest acked.
 	if encLevel == protocol.Encryption1RTT && h.ecnTracker != nil && largestAcked > pnSpace.largestAcked {
 		congested := h.ecnTracker.HandleNewlyAcked(ackedPackets, int64(ack.ECT0), int64(ack.ECT1), int64(ack.ECNCE))
-		if congested {
-			h.congestion.OnCongestionEvent(largestAcked, 0, priorInFlight)
+		if s, ok := h.congestion.(congestion.ScalableSender); ok {
+			if ecnMarked, ceMarked := h.ecnTracker.NewlyReported(); ecnMarked > 0 {
+				s.OnECNFeedback(ecnMarked, ceMarked)
+			}
+		} else if congested {
+			h.congestion.OnCongestionEvent(largestAcked, 0, priorInFlight, congestion.TrafficClassDefault, false)
 		}
 	}
 
@@ -436,9 +581,21 @@ func (h *sentPacketHandler) ReceivedAck(ack *wire.AckFrame, encLevel protocol.En
 		h.detectLostPathProbes(rcvTime)
 	}
 	var acked1RTTPacket bool
+	chaosActive := h.chaos.Active(rcvTime)
 	for _, p := range ackedPackets {
 		if p.includedInBytesInFlight {
-			h.congestion.OnPacketAcked(p.PacketNumber, p.Length, priorInFlight, rcvTime)
+			if chaosActive && h.chaos.SimulateLoss() {
+				h.onPacketLost(p.PacketNumber, p.Length, priorInFlight, congestion.TrafficClassDefault, false)
+			} else {
+				h.congestion.OnPacketAcked(p.PacketNumber, p.Length, priorInFlight, rcvTime)
+			}
+			h.connStats.BytesAcked.Add(uint64(p.Length))
+			if encLevel == protocol.Encryption1RTT {
+				h.deliveryRate.OnPacketAcked(p.Length, rcvTime, h.rttStats.SmoothedRTT())
+			}
+			if h.bandwidthProbe != nil {
+				h.bandwidthProbe.OnPacketAcked(p.SendTime, p.Length, rcvTime)
+			}
 		}
 		if p.EncryptionLevel == protocol.Encryption1RTT {
 			acked1RTTPacket = true
@@ -477,11 +634,57 @@ func (h *sentPacketHandler) ReceivedAck(ack *wire.AckFrame, encLevel protocol.En
 	if h.qlogger != nil {
 		h.qlogMetricsUpdated()
 	}
+	h.updateCongestionState(rcvTime)
+	h.maybeCompleteBandwidthProbe(rcvTime)
 
 	h.setLossDetectionTimer(rcvTime)
 	return acked1RTTPacket, nil
 }
 
+func (h *sentPacketHandler) ProbeBandwidth(now monotime.Time, onResult func(congestion.Bandwidth)) {
+	if h.bandwidthProbe != nil {
+		h.bandwidthProbe.onResult = append(h.bandwidthProbe.onResult, onResult)
+		return
+	}
+	rtt := h.rttStats.SmoothedRTT()
+	if rtt == 0 {
+		rtt = h.rttStats.InitialRTT()
+	}
+	end := now.Add(rtt)
+	h.bandwidthProbe = &bandwidthProbe{
+		start: now,
+		end:   end,
+		// allow for the ACKs for the last packets sent during the probe to arrive
+		deadline: end.Add(h.rttStats.PTO(true)),
+		onResult: []func(congestion.Bandwidth){onResult},
+	}
+	h.congestion.ProbeBandwidth(end)
+}
+
+func (h *sentPacketHandler) InjectChaos(now monotime.Time, lossRate float64, extraRTT, duration time.Duration) {
+	if duration <= 0 {
+		h.chaos = nil
+		return
+	}
+	h.chaos = &chaosInjection{
+		lossRate: lossRate,
+		extraRTT: extraRTT,
+		end:      now.Add(duration),
+	}
+}
+
+func (h *sentPacketHandler) maybeCompleteBandwidthProbe(now monotime.Time) {
+	if h.bandwidthProbe == nil || !h.bandwidthProbe.Done(now) {
+		return
+	}
+	probe := h.bandwidthProbe
+	h.bandwidthProbe = nil
+	rate := probe.DeliveryRate()
+	for _, onResult := range probe.onResult {
+		onResult(rate)
+	}
+}
+
 func (h *sentPacketHandler) detectSpuriousLosses(ack *wire.AckFrame, ackTime monotime.Time) {
 	var maxPacketReordering protocol.PacketNumber
 	var maxTimeReordering time.Duration
@@ -505,6 +708,7 @@ func (h *sentPacketHandler) detectSpuriousLosses(ack *wire.AckFrame, ackTime mon
 			timeReordering := ackTime.Sub(sendTime)
 			maxPacketReordering = max(maxPacketReordering, packetReordering)
 			maxTimeReordering = max(maxTimeReordering, timeReordering)
+			h.maxPacketReordering = max(h.maxPacketReordering, packetReordering)
 
 			if h.qlogger != nil {
 				h.qlogger.RecordEvent(qlog.SpuriousLoss{
@@ -519,6 +723,7 @@ func (h *sentPacketHandler) detectSpuriousLosses(ack *wire.AckFrame, ackTime mon
 	}
 	for _, pn := range spuriousLosses {
 		h.lostPackets.Delete(pn)
+		h.congestion.OnSpuriousLoss(pn)
 	}
 }
 
@@ -798,12 +1003,16 @@ func (h *sentPacketHandler) detectLostPackets(now monotime.Time, encLevel protoc
 	lostSendTime := now.Add(-lossDelay)
 
 	priorInFlight := h.bytesInFlight
+	var pc persistentCongestionDetector
+	if !h.firstRTTSampleTime.IsZero() {
+		pc.Start(h.firstRTTSampleTime, persistentCongestionThreshold*h.rttStats.PTO(true))
+	}
 	for pn, p := range pnSpace.history.Packets() {
 		if pn > pnSpace.largestAcked {
 			break
 		}
 
-		var packetLost bool
+		var packetLost, reorderingLikely bool
 		if !p.SendTime.After(lostSendTime) {
 			packetLost = true
 			if !p.isPathProbePacket && p.IsAckEliciting() {
@@ -820,8 +1029,10 @@ func (h *sentPacketHandler) detectLostPackets(now monotime.Time, encLevel protoc
 					})
 				}
 			}
-		} else if pnSpace.history.Difference(pnSpace.largestAcked, pn) >= packetThreshold {
+		} else if reordering := pnSpace.history.Difference(pnSpace.largestAcked, pn); reordering >= packetThreshold {
 			packetLost = true
+			// Packets that arrived this far out of order were already observed on this path.
+			reorderingLikely = encLevel == protocol.Encryption1RTT && reordering <= h.maxPacketReordering
 			if !p.isPathProbePacket && p.IsAckEliciting() {
 				if h.logger.Debug() {
 					h.logger.Debugf("\tlost packet %d (reordering threshold)", pn)
@@ -844,17 +1055,25 @@ func (h *sentPacketHandler) detectLostPackets(now monotime.Time, encLevel protoc
 			}
 			pnSpace.lossTime = lossTime
 		}
+		if !packetLost || (!p.isPathProbePacket && p.IsAckEliciting()) {
+			pc.OnPacket(pn, p.SendTime, packetLost, &pnSpace.history)
+		}
 		if packetLost {
 			if encLevel == protocol.Encryption0RTT || encLevel == protocol.Encryption1RTT {
 				h.lostPackets.Add(pn, p.SendTime)
 			}
+			if h.connStats.LostPackets != nil {
+				h.connStats.LostPackets.Add(pn, p.SendTime, now)
+			}
 			pnSpace.history.DeclareLost(pn)
 			if !p.isPathProbePacket && p.IsAckEliciting() {
 				// the bytes in flight need to be reduced no matter if the frames in this packet will be retransmitted
 				h.removeFromBytesInFlight(p)
+				// the frames are removed from the packet when they are queued for retransmission
+				class := trafficClass(p)
 				h.queueFramesForRetransmission(p)
 				if !p.IsPathMTUProbePacket {
-					h.congestion.OnCongestionEvent(pn, p.Length, priorInFlight)
+					h.onPacketLost(pn, p.Length, priorInFlight, class, reorderingLikely)
 				}
 				if encLevel == protocol.Encryption1RTT && h.ecnTracker != nil {
 					h.ecnTracker.LostPacket(pn)
@@ -862,10 +1081,25 @@ func (h *sentPacketHandler) detectLostPackets(now monotime.Time, encLevel protoc
 			}
 		}
 	}
+	if pc.Detected() {
+		if h.logger.Debug() {
+			h.logger.Debugf("\tpersistent congestion (%s)", encLevel)
+		}
+		h.congestion.OnPersistentCongestion()
+	}
+}
+
+// onPacketLost records a lost packet in the connection stats, and informs the congestion controller.
+// The loss is recorded first, such that the congestion controller's loss tolerance takes it into account.
+func (h *sentPacketHandler) onPacketLost(pn protocol.PacketNumber, length, priorInFlight protocol.ByteCount, class congestion.TrafficClass, reorderingLikely bool) {
+	h.connStats.PacketsLost.Add(1)
+	h.connStats.BytesLost.Add(uint64(length))
+	h.congestion.OnCongestionEvent(pn, length, priorInFlight, class, reorderingLikely)
 }
 
 func (h *sentPacketHandler) OnLossDetectionTimeout(now monotime.Time) error {
 	defer h.setLossDetectionTimer(now)
+	defer h.updateCongestionState(now)
 
 	if h.handshakeConfirmed {
 		h.detectLostPathProbes(now)
@@ -1004,8 +1238,10 @@ func (h *sentPacketHandler) SendMode(now monotime.Time) SendMode {
 	if h.numProbesToSend > 0 {
 		return h.ptoMode
 	}
+	h.connStats.SendOpportunities.Add(1)
 	// Only send ACKs if we're congestion limited.
 	if !h.congestion.CanSend(h.bytesInFlight) {
+		h.connStats.CongestionWindowLimited.Add(1)
 		if h.logger.Debug() {
 			h.logger.Debugf("Congestion limited: bytes in flight %d, window %d", h.bytesInFlight, h.congestion.GetCongestionWindow())
 		}
@@ -1018,6 +1254,7 @@ func (h *sentPacketHandler) SendMode(now monotime.Time) SendMode {
 		return SendAck
 	}
 	if !h.congestion.HasPacingBudget(now) {
+		h.connStats.PacingLimited.Add(1)
 		return SendPacingLimited
 	}
 	return SendAny
@@ -1028,6 +1265,13 @@ func (h *sentPacketHandler) TimeUntilSend() monotime.Time {
 }
 
 func (h *sentPacketHandler) SetMaxDatagramSize(s protocol.ByteCount) {
+	// After migrating back to a path, the congestion controller might already use a larger datagram size
+	// than the one the MTU discovery restarted with.
+	path := h.paths.Active()
+	if s < path.maxDatagramSize {
+		return
+	}
+	path.maxDatagramSize = s
 	h.congestion.SetMaxDatagramSize(s)
 }
 
@@ -1053,10 +1297,35 @@ func (h *sentPacketHandler) QueueProbePacket(encLevel protocol.EncryptionLevel)
 	return true
 }
 
+// trafficClass determines the traffic class of the data carried in a packet.
+// Control data takes precedence: a packet is only considered bulk data if all its STREAM frames are bulk data.
+// Packets that don't carry any STREAM frames use the default traffic class.
+func trafficClass(p *packet) congestion.TrafficClass {
+	if len(p.StreamFrames) == 0 {
+		return congestion.TrafficClassDefault
+	}
+	class := congestion.TrafficClassBulk
+	for _, f := range p.StreamFrames {
+		c, ok := f.Handler.(TrafficClassifier)
+		if !ok {
+			class = congestion.TrafficClassDefault
+			continue
+		}
+		switch c.TrafficClass() {
+		case congestion.TrafficClassControl:
+			return congestion.TrafficClassControl
+		case congestion.TrafficClassDefault:
+			class = congestion.TrafficClassDefault
+		}
+	}
+	return class
+}
+
 func (h *sentPacketHandler) queueFramesForRetransmission(p *packet) {
 	if len(p.Frames) == 0 && len(p.StreamFrames) == 0 {
 		panic("no frames")
 	}
+	h.connStats.BytesRetransmitted.Add(uint64(p.Length))
 	for _, f := range p.Frames {
 		if f.Handler != nil {
 			f.Handler.OnLost(f.Frame)
@@ -1095,6 +1364,7 @@ func (h *sentPacketHandler) ResetForRetry(now monotime.Time) {
 	if h.ptoCount == 0 {
 		// Don't set the RTT to a value lower than 5ms here.
 		h.rttStats.UpdateRTT(max(minRTTAfterRetry, now.Sub(firstPacketSendTime)), 0)
+		h.firstRTTSampleTime = now
 		if h.logger.Debug() {
 			h.logger.Debugf("\tupdated RTT: %s (σ: %s)", h.rttStats.SmoothedRTT(), h.rttStats.MeanDeviation())
 		}
@@ -1117,8 +1387,14 @@ func (h *sentPacketHandler) ResetForRetry(now monotime.Time) {
 	h.ptoCount = 0
 }
 
-func (h *sentPacketHandler) MigratedPath(now monotime.Time, initialMaxDatagramSize protocol.ByteCount) {
+// MigratedPath resets the state after the connection migrated from one path to another.
+// The congestion controller state of the old path is kept, and the one of the new path is activated.
+// On a path that wasn't used before, the congestion controller starts from scratch.
+func (h *sentPacketHandler) MigratedPath(now monotime.Time, initialMaxPacketSize protocol.ByteCount, from, to PathKey) {
 	h.rttStats.ResetForPathMigration()
+	h.firstRTTSampleTime = 0
+	h.maxPacketReordering = 0
+	h.deliveryRate.Reset()
 	for pn, p := range h.appDataPackets.history.Packets() {
 		h.appDataPackets.history.DeclareLost(pn)
 		if !p.isPathProbePacket {
@@ -1131,17 +1407,16 @@ func (h *sentPacketHandler) MigratedPath(now monotime.Time, initialMaxDatagramSi
 	for pn := range h.appDataPackets.history.PathProbes() {
 		h.appDataPackets.history.RemovePathProbe(pn)
 	}
-	h.congestion = congestion.NewCubicSender(
-		congestion.DefaultClock{},
-		h.rttStats,
-		h.connStats,
-		initialMaxDatagramSize,
-		true, // use Reno
-		h.qlogger,
-	)
+	h.congestion = h.paths.Activate(from, to, initialMaxPacketSize).congestion
+	h.congestion.OnFlowControlLimited(h.flowControlLimited)
+	h.updateCongestionState(now)
 	h.setLossDetectionTimer(now)
 }
 
-func (h *sentPacketHandler) SetCongestionControl(algo congestion.SendAlgorithmWithDebugInfos) {
-	h.congestion = algo
+func (h *sentPacketHandler) SetFlowControlLimited(limited bool) {
+	if limited == h.flowControlLimited {
+		return
+	}
+	h.flowControlLimited = limited
+	h.congestion.OnFlowControlLimited(limited)
 }
@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/quic-go/quic-go/internal/congestion"
 	"github.com/quic-go/quic-go/internal/mocks"
 	"github.com/quic-go/quic-go/internal/monotime"
 	"github.com/quic-go/quic-go/internal/protocol"
@@ -38,6 +39,13 @@ func (h *customFrameHandler) OnAcked(f wire.Frame) {
 	}
 }
 
+type trafficClassFrameHandler struct {
+	customFrameHandler
+	class congestion.TrafficClass
+}
+
+func (h *trafficClassFrameHandler) TrafficClass() congestion.TrafficClass { return h.class }
+
 type packetTracker struct {
 	Acked []protocol.PacketNumber
 	Lost  []protocol.PacketNumber
@@ -118,6 +126,7 @@ func testSentPacketHandlerSendAndAcknowledge(t *testing.T, encLevel protocol.Enc
 		nil,
 		protocol.PerspectiveClient,
 		nil,
+		nil,
 		utils.DefaultLogger,
 	)
 
@@ -173,6 +182,7 @@ func TestSentPacketHandlerAcknowledgeSkippedPacket(t *testing.T) {
 		nil,
 		protocol.PerspectiveClient,
 		nil,
+		nil,
 		utils.DefaultLogger,
 	)
 
@@ -215,6 +225,7 @@ func TestSentPacketHandlerRTTAckEliciting(t *testing.T) {
 		false,
 		nil,
 		protocol.PerspectiveClient,
+		nil,
 		&eventRecorder,
 		utils.DefaultLogger,
 	)
@@ -292,6 +303,66 @@ func TestSentPacketHandlerRTTAckEliciting(t *testing.T) {
 	require.Zero(t, getBytesInFlight())
 }
 
+func TestSentPacketHandlerQlogCongestionMetrics(t *testing.T) {
+	var eventRecorder events.Recorder
+	mockCtrl := gomock.NewController(t)
+	cong := mocks.NewMockSendAlgorithmWithDebugInfos(mockCtrl)
+	sph := NewSentPacketHandler(
+		0,
+		1200,
+		utils.NewRTTStats(),
+		&utils.ConnectionStats{},
+		false,
+		false,
+		nil,
+		protocol.PerspectiveClient,
+		nil,
+		&eventRecorder,
+		utils.DefaultLogger,
+	)
+	sph.(*sentPacketHandler).congestion = cong
+	cong.EXPECT().State(gomock.Any()).Return(congestion.StateSlowStart).AnyTimes()
+	cong.EXPECT().TimeUntilSend(gomock.Any()).AnyTimes()
+	cong.EXPECT().PacingBudget(gomock.Any()).AnyTimes()
+	cong.EXPECT().EstimatedSendTime(gomock.Any()).AnyTimes()
+
+	sendPacket := func(now monotime.Time) {
+		pn := sph.PopPacketNumber(protocol.Encryption1RTT)
+		cong.EXPECT().OnPacketSent(now, gomock.Any(), pn, protocol.ByteCount(1000), true)
+		sph.SentPacket(now, pn, protocol.InvalidPacketNumber, nil, []Frame{{Frame: &wire.PingFrame{}}}, protocol.Encryption1RTT, protocol.ECNNon, 1000, false, false)
+	}
+	lastMetrics := func() qlog.MetricsUpdated {
+		evs := eventRecorder.Events(qlog.MetricsUpdated{})
+		return evs[len(evs)-1].(qlog.MetricsUpdated)
+	}
+
+	// The slow start threshold is not logged as long as it's not set.
+	cong.EXPECT().GetCongestionWindow().Return(protocol.ByteCount(10000))
+	cong.EXPECT().SlowStartThreshold().Return(protocol.MaxByteCount)
+	cong.EXPECT().BandwidthEstimate().Return(congestion.Bandwidth(5_000_000))
+	now := monotime.Now()
+	sendPacket(now)
+	require.Equal(t, 10000, lastMetrics().CongestionWindow)
+	require.Zero(t, lastMetrics().SSThresh)
+	require.Equal(t, 5_000_000, lastMetrics().PacingRate)
+
+	cong.EXPECT().GetCongestionWindow().Return(protocol.ByteCount(7000))
+	cong.EXPECT().SlowStartThreshold().Return(protocol.ByteCount(7000))
+	cong.EXPECT().BandwidthEstimate().Return(congestion.Bandwidth(3_500_000))
+	sendPacket(now)
+	require.Equal(t, 7000, lastMetrics().CongestionWindow)
+	require.Equal(t, 7000, lastMetrics().SSThresh)
+	require.Equal(t, 3_500_000, lastMetrics().PacingRate)
+
+	// unchanged values are not logged again
+	cong.EXPECT().GetCongestionWindow().Return(protocol.ByteCount(7000))
+	cong.EXPECT().SlowStartThreshold().Return(protocol.ByteCount(7000))
+	cong.EXPECT().BandwidthEstimate().Return(congestion.Bandwidth(3_500_000))
+	sendPacket(now)
+	require.Zero(t, lastMetrics().SSThresh)
+	require.Zero(t, lastMetrics().PacingRate)
+}
+
 func TestSentPacketHandlerRTTAcrossPacketNumberSpaces(t *testing.T) {
 	rttStats := utils.NewRTTStats()
 	sph := NewSentPacketHandler(
@@ -304,6 +375,7 @@ func TestSentPacketHandlerRTTAcrossPacketNumberSpaces(t *testing.T) {
 		nil,
 		protocol.PerspectiveClient,
 		nil,
+		nil,
 		utils.DefaultLogger,
 	)
 
@@ -365,6 +437,7 @@ func testSentPacketHandlerRTTAckDelays(t *testing.T, encLevel protocol.Encryptio
 		nil,
 		protocol.PerspectiveClient,
 		nil,
+		nil,
 		utils.DefaultLogger,
 	)
 
@@ -456,6 +529,7 @@ func testSentPacketHandlerAmplificationLimitServer(t *testing.T, addressValidate
 		nil,
 		protocol.PerspectiveServer,
 		nil,
+		nil,
 		utils.DefaultLogger,
 	)
 
@@ -527,6 +601,7 @@ func testSentPacketHandlerAmplificationLimitClient(t *testing.T, dropHandshake b
 		nil,
 		protocol.PerspectiveClient,
 		nil,
+		nil,
 		utils.DefaultLogger,
 	)
 
@@ -583,6 +658,7 @@ func TestSentPacketHandlerDelayBasedLossDetection(t *testing.T) {
 		nil,
 		protocol.PerspectiveServer,
 		nil,
+		nil,
 		utils.DefaultLogger,
 	)
 
@@ -628,16 +704,18 @@ func TestSentPacketHandlerDelayBasedLossDetection(t *testing.T) {
 
 func TestSentPacketHandlerPacketBasedLossDetection(t *testing.T) {
 	rttStats := utils.NewRTTStats()
+	connStats := utils.ConnectionStats{LostPackets: utils.NewLostPacketLog(10)}
 	sph := NewSentPacketHandler(
 		0,
 		1200,
 		rttStats,
-		&utils.ConnectionStats{},
+		&connStats,
 		true,
 		false,
 		nil,
 		protocol.PerspectiveServer,
 		nil,
+		nil,
 		utils.DefaultLogger,
 	)
 
@@ -667,6 +745,62 @@ func TestSentPacketHandlerPacketBasedLossDetection(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, []protocol.PacketNumber{pns[3], pns[4]}, packets.Acked)
 	require.Equal(t, []protocol.PacketNumber{pns[0], pns[1]}, packets.Lost)
+	require.Equal(t, []utils.LostPacket{
+		{PacketNumber: pns[0], SendTime: now, LossTime: now.Add(time.Second)},
+		{PacketNumber: pns[1], SendTime: now, LossTime: now.Add(time.Second)},
+	}, connStats.LostPackets.Snapshot())
+}
+
+func TestSentPacketHandlerRetransmissionAccounting(t *testing.T) {
+	var connStats utils.ConnectionStats
+	sph := NewSentPacketHandler(
+		0,
+		1200,
+		utils.NewRTTStats(),
+		&connStats,
+		true,
+		false,
+		nil,
+		protocol.PerspectiveServer,
+		nil,
+		nil,
+		utils.DefaultLogger,
+	)
+
+	var packets packetTracker
+	now := monotime.Now()
+	sendPacket := func() protocol.PacketNumber {
+		pn := sph.PopPacketNumber(protocol.Encryption1RTT)
+		sph.SentPacket(now, pn, protocol.InvalidPacketNumber, nil, []Frame{packets.NewPingFrame(pn)}, protocol.Encryption1RTT, protocol.ECNNon, 1000, false, false)
+		return pn
+	}
+	var pns []protocol.PacketNumber
+	for range 5 {
+		pns = append(pns, sendPacket())
+	}
+	now = now.Add(time.Second)
+	_, err := sph.ReceivedAck(&wire.AckFrame{AckRanges: ackRanges(pns[3], pns[4])}, protocol.Encryption1RTT, now)
+	require.NoError(t, err)
+	require.Equal(t, []protocol.PacketNumber{pns[0], pns[1]}, packets.Lost)
+	require.Equal(t, uint64(2000), connStats.BytesRetransmitted.Load())
+
+	// retransmit the frames of the lost packets
+	retransmissions := []protocol.PacketNumber{sendPacket(), sendPacket()}
+	now = now.Add(time.Second)
+	_, err = sph.ReceivedAck(&wire.AckFrame{AckRanges: ackRanges(retransmissions[1], retransmissions[0], pns[4], pns[3])}, protocol.Encryption1RTT, now)
+	require.NoError(t, err)
+	// by now, pns[2] is declared lost as well
+	require.Equal(t, []protocol.PacketNumber{pns[0], pns[1], pns[2]}, packets.Lost)
+	require.Equal(t, uint64(7000), connStats.BytesSent.Load())
+	require.Equal(t, uint64(3000), connStats.BytesRetransmitted.Load())
+	require.Equal(t, uint64(4000), connStats.UniqueBytesSent())
+	require.Equal(t, uint64(4000), connStats.Goodput())
+
+	// a late acknowledgment for the lost packets doesn't count their data twice
+	now = now.Add(time.Millisecond)
+	_, err = sph.ReceivedAck(&wire.AckFrame{AckRanges: ackRanges(retransmissions[1], retransmissions[0], pns[4], pns[3], pns[2], pns[1], pns[0])}, protocol.Encryption1RTT, now)
+	require.NoError(t, err)
+	require.Equal(t, uint64(4000), connStats.Goodput())
 }
 
 func TestSentPacketHandlerPTO(t *testing.T) {
@@ -699,6 +833,7 @@ func testSentPacketHandlerPTO(t *testing.T, encLevel protocol.EncryptionLevel, p
 		false,
 		nil,
 		protocol.PerspectiveServer,
+		nil,
 		&eventRecorder,
 		utils.DefaultLogger,
 	)
@@ -918,6 +1053,7 @@ func TestSentPacketHandlerPacketNumberSpacesPTO(t *testing.T) {
 		nil,
 		protocol.PerspectiveServer,
 		nil,
+		nil,
 		utils.DefaultLogger,
 	)
 
@@ -1011,6 +1147,7 @@ func TestSentPacketHandler0RTT(t *testing.T) {
 		nil,
 		protocol.PerspectiveClient,
 		nil,
+		nil,
 		utils.DefaultLogger,
 	)
 
@@ -1053,19 +1190,30 @@ func TestSentPacketHandlerCongestion(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	cong := mocks.NewMockSendAlgorithmWithDebugInfos(mockCtrl)
 	rttStats := utils.NewRTTStats()
+	var connStats utils.ConnectionStats
 	sph := NewSentPacketHandler(
 		0,
 		1200,
 		rttStats,
-		&utils.ConnectionStats{},
+		&connStats,
 		true,
 		false,
 		nil,
 		protocol.PerspectiveServer,
 		nil,
+		nil,
 		utils.DefaultLogger,
 	)
 	sph.(*sentPacketHandler).congestion = cong
+	cong.EXPECT().State(gomock.Any()).Return(congestion.StateSlowStart).AnyTimes()
+	cong.EXPECT().GetCongestionWindow().AnyTimes()
+	cong.EXPECT().SlowStartThreshold().AnyTimes()
+	cong.EXPECT().PacingBudget(gomock.Any()).AnyTimes()
+	cong.EXPECT().EstimatedSendTime(gomock.Any()).AnyTimes()
+	cong.EXPECT().BandwidthEstimate().AnyTimes()
+	// TimeUntilSend is also called to publish the pacer state to the connection stats
+	var pacingDeadline monotime.Time
+	cong.EXPECT().TimeUntilSend(gomock.Any()).DoAndReturn(func(protocol.ByteCount) monotime.Time { return pacingDeadline }).AnyTimes()
 
 	var packets packetTracker
 	// Send the first 5 packets: not congestion-limited, not pacing-limited.
@@ -1096,15 +1244,20 @@ func TestSentPacketHandlerCongestion(t *testing.T) {
 		cong.EXPECT().HasPacingBudget(now).Return(false),
 	)
 	require.Equal(t, SendPacingLimited, sph.SendMode(now))
+	require.Equal(t, uint64(6), connStats.SendOpportunities.Load())
+	require.Equal(t, uint64(1), connStats.PacingLimited.Load())
+	require.Zero(t, connStats.CongestionWindowLimited.Load())
 	// the connection would call TimeUntilSend, to find out when a new packet can be sent again
-	pacingDeadline := now.Add(500 * time.Millisecond)
-	cong.EXPECT().TimeUntilSend(bytesInFlight).Return(pacingDeadline)
+	pacingDeadline = now.Add(500 * time.Millisecond)
 	require.Equal(t, pacingDeadline, sph.TimeUntilSend())
 
 	// try to send another packet, but now we're congestion limited
 	now = now.Add(100 * time.Millisecond)
 	cong.EXPECT().CanSend(bytesInFlight).Return(false)
 	require.Equal(t, SendAck, sph.SendMode(now)) // ACKs are allowed even if congestion limited
+	require.Equal(t, uint64(7), connStats.SendOpportunities.Load())
+	require.Equal(t, uint64(1), connStats.PacingLimited.Load())
+	require.Equal(t, uint64(1), connStats.CongestionWindowLimited.Load())
 
 	// Receive an ACK for packet 3 and 4 (which declares the 1st and 2nd packet lost).
 	// However, since the 2nd packet was a Path MTU probe packet, it won't get reported
@@ -1112,7 +1265,7 @@ func TestSentPacketHandlerCongestion(t *testing.T) {
 	ackTime := sendTimes[3].Add(time.Second)
 	gomock.InOrder(
 		cong.EXPECT().MaybeExitSlowStart(),
-		cong.EXPECT().OnCongestionEvent(pns[0], protocol.ByteCount(1000), protocol.ByteCount(5000)),
+		cong.EXPECT().OnCongestionEvent(pns[0], protocol.ByteCount(1000), protocol.ByteCount(5000), congestion.TrafficClassDefault, false),
 		cong.EXPECT().OnPacketAcked(pns[2], protocol.ByteCount(1000), protocol.ByteCount(5000), ackTime),
 		cong.EXPECT().OnPacketAcked(pns[3], protocol.ByteCount(1000), protocol.ByteCount(5000), ackTime),
 	)
@@ -1164,6 +1317,7 @@ func testSentPacketHandlerRetry(t *testing.T, rtt, expectedRTT time.Duration) {
 		nil,
 		protocol.PerspectiveClient,
 		nil,
+		nil,
 		utils.DefaultLogger,
 	)
 
@@ -1216,6 +1370,7 @@ func TestSentPacketHandlerRetryAfterPTO(t *testing.T) {
 		nil,
 		protocol.PerspectiveClient,
 		nil,
+		nil,
 		utils.DefaultLogger,
 	)
 
@@ -1261,10 +1416,18 @@ func TestSentPacketHandlerECN(t *testing.T) {
 		nil,
 		protocol.PerspectiveClient,
 		nil,
+		nil,
 		utils.DefaultLogger,
 	)
 	sph.(*sentPacketHandler).ecnTracker = ecnHandler
 	sph.(*sentPacketHandler).congestion = cong
+	cong.EXPECT().State(gomock.Any()).Return(congestion.StateSlowStart).AnyTimes()
+	cong.EXPECT().GetCongestionWindow().AnyTimes()
+	cong.EXPECT().SlowStartThreshold().AnyTimes()
+	cong.EXPECT().TimeUntilSend(gomock.Any()).AnyTimes()
+	cong.EXPECT().PacingBudget(gomock.Any()).AnyTimes()
+	cong.EXPECT().EstimatedSendTime(gomock.Any()).AnyTimes()
+	cong.EXPECT().BandwidthEstimate().AnyTimes()
 
 	// ECN marks on non-1-RTT packets are ignored
 	sph.SentPacket(monotime.Now(), sph.PopPacketNumber(protocol.EncryptionInitial), protocol.InvalidPacketNumber, nil, nil, protocol.EncryptionInitial, protocol.ECT1, 1200, false, false)
@@ -1289,7 +1452,7 @@ func TestSentPacketHandlerECN(t *testing.T) {
 	pns[3] = sendPacket(t, now, protocol.ECT0)
 
 	// Receive an ACK with a short RTT, such that the first packet is lost.
-	cong.EXPECT().OnCongestionEvent(gomock.Any(), gomock.Any(), gomock.Any())
+	cong.EXPECT().OnCongestionEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
 	ecnHandler.EXPECT().LostPacket(pns[0])
 	ecnHandler.EXPECT().HandleNewlyAcked(gomock.Any(), int64(10), int64(11), int64(12)).DoAndReturn(func(packets []packetWithPacketNumber, _, _, _ int64) bool {
 		require.Len(t, packets, 2)
@@ -1345,12 +1508,72 @@ func TestSentPacketHandlerECN(t *testing.T) {
 
 	gomock.InOrder(
 		ecnHandler.EXPECT().HandleNewlyAcked(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(true),
-		cong.EXPECT().OnCongestionEvent(pns[0], protocol.ByteCount(0), gomock.Any()),
+		cong.EXPECT().OnCongestionEvent(pns[0], protocol.ByteCount(0), gomock.Any(), congestion.TrafficClassDefault, false),
 	)
 	_, err = sph.ReceivedAck(&wire.AckFrame{AckRanges: ackRanges(pns[0])}, protocol.Encryption1RTT, now.Add(100*time.Millisecond))
 	require.NoError(t, err)
 }
 
+type mockScalableSender struct {
+	*mocks.MockSendAlgorithmWithDebugInfos
+	ecnFeedback [][2]int64
+}
+
+func (s *mockScalableSender) OnECNFeedback(ecnMarked, ceMarked int64) {
+	s.ecnFeedback = append(s.ecnFeedback, [2]int64{ecnMarked, ceMarked})
+}
+
+func TestSentPacketHandlerECNScalableSender(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	cong := &mockScalableSender{MockSendAlgorithmWithDebugInfos: mocks.NewMockSendAlgorithmWithDebugInfos(mockCtrl)}
+	cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	cong.EXPECT().OnPacketAcked(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	cong.EXPECT().MaybeExitSlowStart().AnyTimes()
+	cong.EXPECT().State(gomock.Any()).Return(congestion.StateSlowStart).AnyTimes()
+	cong.EXPECT().GetCongestionWindow().AnyTimes()
+	cong.EXPECT().SlowStartThreshold().AnyTimes()
+	cong.EXPECT().TimeUntilSend(gomock.Any()).AnyTimes()
+	cong.EXPECT().PacingBudget(gomock.Any()).AnyTimes()
+	cong.EXPECT().EstimatedSendTime(gomock.Any()).AnyTimes()
+	cong.EXPECT().BandwidthEstimate().AnyTimes()
+	sph := NewSentPacketHandler(
+		0,
+		1200,
+		utils.NewRTTStats(),
+		&utils.ConnectionStats{},
+		true,
+		true,
+		nil,
+		protocol.PerspectiveClient,
+		func(protocol.ByteCount) congestion.SendAlgorithmWithDebugInfos { return cong },
+		nil,
+		utils.DefaultLogger,
+	)
+	// packets are sent with ECT(1)
+	require.Equal(t, protocol.ECT1, sph.ECNMode(true))
+
+	ecnHandler := NewMockECNHandler(mockCtrl)
+	sph.(*sentPacketHandler).ecnTracker = ecnHandler
+	var packets packetTracker
+	now := monotime.Now()
+	pn := sph.PopPacketNumber(protocol.Encryption1RTT)
+	ecnHandler.EXPECT().SentPacket(pn, protocol.ECT1)
+	sph.SentPacket(now, pn, protocol.InvalidPacketNumber, nil, []Frame{packets.NewPingFrame(pn)}, protocol.Encryption1RTT, protocol.ECT1, 1200, false, false)
+
+	// CE marks are reported to the congestion controller as ECN feedback, not as a congestion event
+	gomock.InOrder(
+		ecnHandler.EXPECT().HandleNewlyAcked(gomock.Any(), int64(0), int64(3), int64(1)).Return(true),
+		ecnHandler.EXPECT().NewlyReported().Return(int64(4), int64(1)),
+	)
+	_, err := sph.ReceivedAck(
+		&wire.AckFrame{AckRanges: ackRanges(pn), ECT1: 3, ECNCE: 1},
+		protocol.Encryption1RTT,
+		now.Add(100*time.Millisecond),
+	)
+	require.NoError(t, err)
+	require.Equal(t, [][2]int64{{4, 1}}, cong.ecnFeedback)
+}
+
 func TestSentPacketHandlerPathProbe(t *testing.T) {
 	const rtt = 10 * time.Millisecond // RTT of the original path
 	rttStats := utils.NewRTTStats()
@@ -1366,6 +1589,7 @@ func TestSentPacketHandlerPathProbe(t *testing.T) {
 		nil,
 		protocol.PerspectiveClient,
 		nil,
+		nil,
 		utils.DefaultLogger,
 	)
 	sph.DropPackets(protocol.EncryptionInitial, monotime.Now())
@@ -1426,7 +1650,7 @@ func TestSentPacketHandlerPathProbe(t *testing.T) {
 	require.NoError(t, err)
 
 	packets.Lost = packets.Lost[:0]
-	sph.MigratedPath(now, 1200)
+	sph.MigratedPath(now, 1200, "old path", "new path")
 	require.Zero(t, sph.(*sentPacketHandler).getBytesInFlight())
 	require.Equal(t, utils.DefaultInitialRTT, rttStats.SmoothedRTT())
 	require.Equal(t, []protocol.PacketNumber{pn1, pn2}, packets.Lost)
@@ -1447,6 +1671,7 @@ func TestSentPacketHandlerPathProbeAckAndLoss(t *testing.T) {
 		nil,
 		protocol.PerspectiveClient,
 		nil,
+		nil,
 		utils.DefaultLogger,
 	)
 	sph.DropPackets(protocol.EncryptionInitial, monotime.Now())
@@ -1524,6 +1749,7 @@ func testSentPacketHandlerRandomized(t *testing.T, seed uint64) {
 		nil,
 		protocol.PerspectiveClient,
 		nil,
+		nil,
 		utils.DefaultLogger,
 	)
 	sph.DropPackets(protocol.EncryptionInitial, monotime.Now())
@@ -1571,7 +1797,7 @@ func testSentPacketHandlerRandomized(t *testing.T, seed uint64) {
 			now = now.Add(randDuration(0, 500*time.Millisecond))
 		}
 		if r.Int()%10 == 0 {
-			sph.MigratedPath(now, 1200)
+			sph.MigratedPath(now, 1200, "old path", "new path")
 			now = now.Add(randDuration(0, 500*time.Millisecond))
 		}
 	}
@@ -1593,6 +1819,7 @@ func TestSentPacketHandlerSpuriousLoss(t *testing.T) {
 		false,
 		nil,
 		protocol.PerspectiveClient,
+		nil,
 		&eventRecorder,
 		utils.DefaultLogger,
 	)
@@ -1730,6 +1957,7 @@ func benchmarkSendAndAcknowledge(b *testing.B, ackEvery, inFlight int) {
 		nil,
 		protocol.PerspectiveClient,
 		nil,
+		nil,
 		utils.DefaultLogger,
 	)
 	now := monotime.Now()
@@ -1770,3 +1998,532 @@ func benchmarkSendAndAcknowledge(b *testing.B, ackEvery, inFlight int) {
 		}
 	}
 }
+
+func TestSentPacketHandlerCongestionState(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	cong := mocks.NewMockSendAlgorithmWithDebugInfos(mockCtrl)
+	var connStats utils.ConnectionStats
+	cong.EXPECT().State(protocol.ByteCount(0)).Return(congestion.StateApplicationLimited)
+	cong.EXPECT().GetCongestionWindow().Return(protocol.ByteCount(12000)).AnyTimes()
+	cong.EXPECT().SlowStartThreshold().Return(protocol.MaxByteCount).AnyTimes()
+	cong.EXPECT().TimeUntilSend(protocol.ByteCount(0)).Return(monotime.Time(0))
+	cong.EXPECT().PacingBudget(gomock.Any()).Return(protocol.ByteCount(12000))
+	cong.EXPECT().EstimatedSendTime(protocol.ByteCount(1 << 20)).Return(100 * time.Millisecond)
+	cong.EXPECT().BandwidthEstimate().Return(10 * congestion.BytesPerSecond * (1 << 20))
+	sph := NewSentPacketHandler(
+		0,
+		1200,
+		utils.NewRTTStats(),
+		&connStats,
+		false,
+		false,
+		nil,
+		protocol.PerspectiveClient,
+		func(protocol.ByteCount) congestion.SendAlgorithmWithDebugInfos { return cong },
+		nil,
+		utils.DefaultLogger,
+	)
+	require.Equal(t, congestion.StateApplicationLimited, congestion.State(connStats.CongestionState.Load()))
+	require.Equal(t, int64(12000), connStats.CongestionWindow.Load())
+	require.Equal(t, int64(protocol.MaxByteCount), connStats.SlowStartThreshold.Load())
+	require.Zero(t, connStats.NextSendTime.Load())
+	require.Equal(t, int64(12000), connStats.PacingBudget.Load())
+	require.Equal(t, int64(100*time.Millisecond), connStats.SendTimePerMegabyte.Load())
+	require.Equal(t, uint64(10*congestion.BytesPerSecond*(1<<20)), connStats.PacingRate.Load())
+
+	cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	cong.EXPECT().CanSend(gomock.Any()).Return(true).AnyTimes()
+	cong.EXPECT().OnPacketAcked(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	cong.EXPECT().MaybeExitSlowStart().AnyTimes()
+
+	now := monotime.Now()
+	pn := sph.PopPacketNumber(protocol.Encryption1RTT)
+	cong.EXPECT().State(protocol.ByteCount(1000)).Return(congestion.StateSlowStart)
+	cong.EXPECT().TimeUntilSend(protocol.ByteCount(1000)).Return(now.Add(10 * time.Millisecond))
+	cong.EXPECT().PacingBudget(now).Return(protocol.ByteCount(0))
+	sph.SentPacket(now, pn, protocol.InvalidPacketNumber, nil, []Frame{{Frame: &wire.PingFrame{}}}, protocol.Encryption1RTT, protocol.ECNNon, 1000, false, false)
+	require.Equal(t, congestion.StateSlowStart, congestion.State(connStats.CongestionState.Load()))
+	require.Equal(t, int64(1000), connStats.BytesInFlight.Load())
+	require.Equal(t, int64(now.Add(10*time.Millisecond)), connStats.NextSendTime.Load())
+	require.Zero(t, connStats.PacingBudget.Load())
+	require.Equal(t, int64(now), connStats.PacingUpdateTime.Load())
+	// the sending rate only changes when packets are acknowledged or lost
+	require.Equal(t, int64(100*time.Millisecond), connStats.SendTimePerMegabyte.Load())
+	require.Equal(t, uint64(10*congestion.BytesPerSecond*(1<<20)), connStats.PacingRate.Load())
+
+	cong.EXPECT().State(protocol.ByteCount(0)).Return(congestion.StateApplicationLimited)
+	// the pacing deadline has passed
+	cong.EXPECT().TimeUntilSend(protocol.ByteCount(0)).Return(now.Add(10 * time.Millisecond))
+	cong.EXPECT().PacingBudget(now.Add(time.Second)).Return(protocol.ByteCount(2400))
+	cong.EXPECT().EstimatedSendTime(protocol.ByteCount(1 << 20)).Return(50 * time.Millisecond)
+	cong.EXPECT().BandwidthEstimate().Return(20 * congestion.BytesPerSecond * (1 << 20))
+	_, err := sph.ReceivedAck(&wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: pn, Largest: pn}}}, protocol.Encryption1RTT, now.Add(time.Second))
+	require.NoError(t, err)
+	require.Equal(t, congestion.StateApplicationLimited, congestion.State(connStats.CongestionState.Load()))
+	require.Zero(t, connStats.BytesInFlight.Load())
+	require.Zero(t, connStats.NextSendTime.Load())
+	require.Equal(t, int64(2400), connStats.PacingBudget.Load())
+	require.Equal(t, int64(now.Add(time.Second)), connStats.PacingUpdateTime.Load())
+	require.Equal(t, int64(50*time.Millisecond), connStats.SendTimePerMegabyte.Load())
+	require.Equal(t, uint64(20*congestion.BytesPerSecond*(1<<20)), connStats.PacingRate.Load())
+	// the time spent in each state is accounted for
+	require.Equal(t, time.Second, time.Duration(connStats.TimeInCongestionState[congestion.StateSlowStart].Load()))
+	require.Equal(t, int64(now.Add(time.Second)), connStats.CongestionStateSince.Load())
+}
+
+func TestSentPacketHandlerMigratedPathCongestionState(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	var created []*mocks.MockSendAlgorithmWithDebugInfos
+	sph := NewSentPacketHandler(
+		0,
+		1200,
+		utils.NewRTTStats(),
+		&utils.ConnectionStats{},
+		false,
+		false,
+		nil,
+		protocol.PerspectiveClient,
+		func(size protocol.ByteCount) congestion.SendAlgorithmWithDebugInfos {
+			require.Equal(t, protocol.ByteCount(1200), size)
+			cong := mocks.NewMockSendAlgorithmWithDebugInfos(mockCtrl)
+			cong.EXPECT().State(gomock.Any()).AnyTimes()
+			cong.EXPECT().GetCongestionWindow().AnyTimes()
+			cong.EXPECT().SlowStartThreshold().AnyTimes()
+			cong.EXPECT().TimeUntilSend(gomock.Any()).AnyTimes()
+			cong.EXPECT().PacingBudget(gomock.Any()).AnyTimes()
+			cong.EXPECT().EstimatedSendTime(gomock.Any()).AnyTimes()
+			cong.EXPECT().BandwidthEstimate().AnyTimes()
+			cong.EXPECT().OnFlowControlLimited(false).AnyTimes()
+			created = append(created, cong)
+			return cong
+		},
+		nil,
+		utils.DefaultLogger,
+	)
+	require.Len(t, created, 1)
+	created[0].EXPECT().SetMaxDatagramSize(protocol.ByteCount(1400))
+	sph.SetMaxDatagramSize(1400)
+
+	// a new path gets a new congestion controller
+	sph.MigratedPath(monotime.Now(), 1200, "path 1", "path 2")
+	require.Len(t, created, 2)
+	require.Same(t, created[1], sph.(*sentPacketHandler).congestion)
+
+	// migrating back to the first path restores its congestion controller
+	sph.MigratedPath(monotime.Now(), 1200, "path 2", "path 1")
+	require.Len(t, created, 2)
+	require.Same(t, created[0], sph.(*sentPacketHandler).congestion)
+	// the MTU discovery restarts, but the congestion controller already uses a larger datagram size
+	sph.SetMaxDatagramSize(1300)
+	created[0].EXPECT().SetMaxDatagramSize(protocol.ByteCount(1450))
+	sph.SetMaxDatagramSize(1450)
+
+	// once the limit is reached, the congestion controller of the least recently used path is reset and reused
+	sph.MigratedPath(monotime.Now(), 1200, "path 1", "path 3")
+	sph.MigratedPath(monotime.Now(), 1200, "path 3", "path 4")
+	require.Len(t, created, maxPathCongestionStates)
+	created[1].EXPECT().OnConnectionMigration()
+	sph.MigratedPath(monotime.Now(), 1200, "path 4", "path 5")
+	require.Len(t, created, maxPathCongestionStates)
+	require.Same(t, created[1], sph.(*sentPacketHandler).congestion)
+}
+
+func TestSentPacketHandlerFlowControlLimited(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	cong := mocks.NewMockSendAlgorithmWithDebugInfos(mockCtrl)
+	cong.EXPECT().State(gomock.Any()).AnyTimes()
+	cong.EXPECT().GetCongestionWindow().AnyTimes()
+	cong.EXPECT().SlowStartThreshold().AnyTimes()
+	cong.EXPECT().TimeUntilSend(gomock.Any()).AnyTimes()
+	cong.EXPECT().PacingBudget(gomock.Any()).AnyTimes()
+	cong.EXPECT().EstimatedSendTime(gomock.Any()).AnyTimes()
+	cong.EXPECT().BandwidthEstimate().AnyTimes()
+	sph := NewSentPacketHandler(
+		0,
+		1200,
+		utils.NewRTTStats(),
+		&utils.ConnectionStats{},
+		false,
+		false,
+		nil,
+		protocol.PerspectiveClient,
+		func(protocol.ByteCount) congestion.SendAlgorithmWithDebugInfos { return cong },
+		nil,
+		utils.DefaultLogger,
+	)
+	// the congestion controller is only notified when the state changes
+	sph.SetFlowControlLimited(false)
+	cong.EXPECT().OnFlowControlLimited(true)
+	sph.SetFlowControlLimited(true)
+	sph.SetFlowControlLimited(true)
+	cong.EXPECT().OnFlowControlLimited(false)
+	sph.SetFlowControlLimited(false)
+}
+
+func TestSentPacketHandlerBandwidthProbe(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	cong := mocks.NewMockSendAlgorithmWithDebugInfos(mockCtrl)
+	cong.EXPECT().State(gomock.Any()).AnyTimes()
+	cong.EXPECT().GetCongestionWindow().AnyTimes()
+	cong.EXPECT().SlowStartThreshold().AnyTimes()
+	cong.EXPECT().TimeUntilSend(gomock.Any()).AnyTimes()
+	cong.EXPECT().PacingBudget(gomock.Any()).AnyTimes()
+	cong.EXPECT().EstimatedSendTime(gomock.Any()).AnyTimes()
+	cong.EXPECT().BandwidthEstimate().AnyTimes()
+	cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	cong.EXPECT().OnPacketAcked(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	cong.EXPECT().MaybeExitSlowStart().AnyTimes()
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(100*time.Millisecond, 0)
+	sph := NewSentPacketHandler(
+		0,
+		1200,
+		rttStats,
+		&utils.ConnectionStats{},
+		true,
+		false,
+		nil,
+		protocol.PerspectiveClient,
+		func(protocol.ByteCount) congestion.SendAlgorithmWithDebugInfos { return cong },
+		nil,
+		utils.DefaultLogger,
+	)
+	now := monotime.Now()
+	sph.DropPackets(protocol.EncryptionInitial, now)
+	sph.DropPackets(protocol.EncryptionHandshake, now)
+
+	sendPacket := func(t monotime.Time) protocol.PacketNumber {
+		pn := sph.PopPacketNumber(protocol.Encryption1RTT)
+		sph.SentPacket(t, pn, protocol.InvalidPacketNumber, nil, []Frame{{Frame: &wire.PingFrame{}}}, protocol.Encryption1RTT, protocol.ECNNon, 1000, false, false)
+		return pn
+	}
+	ackPacket := func(pn protocol.PacketNumber, rcvTime monotime.Time) {
+		_, err := sph.ReceivedAck(&wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: pn, Largest: pn}}}, protocol.Encryption1RTT, rcvTime)
+		require.NoError(t, err)
+	}
+
+	// packets sent before the probe don't count
+	pnBefore := sendPacket(now.Add(-time.Millisecond))
+
+	// the congestion controller probes for one RTT
+	cong.EXPECT().ProbeBandwidth(now.Add(100 * time.Millisecond))
+	var results []congestion.Bandwidth
+	sph.ProbeBandwidth(now, func(b congestion.Bandwidth) { results = append(results, b) })
+	// a second probe joins the running probe
+	sph.ProbeBandwidth(now.Add(10*time.Millisecond), func(b congestion.Bandwidth) { results = append(results, b) })
+
+	// send 10 packets during the probe
+	var pns []protocol.PacketNumber
+	for i := range 10 {
+		pns = append(pns, sendPacket(now.Add(time.Duration(i)*10*time.Millisecond)))
+	}
+	ackPacket(pnBefore, now.Add(100*time.Millisecond))
+	for i, pn := range pns {
+		ackPacket(pn, now.Add(100*time.Millisecond+time.Duration(i)*10*time.Millisecond))
+	}
+	require.Empty(t, results)
+
+	// the probe completes with the next ACK received after the deadline
+	pn := sendPacket(now.Add(time.Second))
+	ackPacket(pn, now.Add(time.Second+100*time.Millisecond))
+	// 10 packets of 1000 bytes were delivered in 100ms
+	require.Equal(t, []congestion.Bandwidth{800 * 1000 * congestion.BitsPerSecond, 800 * 1000 * congestion.BitsPerSecond}, results)
+}
+
+func TestSentPacketHandlerSpuriousLossCongestionControl(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	cong := mocks.NewMockSendAlgorithmWithDebugInfos(mockCtrl)
+	cong.EXPECT().State(gomock.Any()).AnyTimes()
+	cong.EXPECT().GetCongestionWindow().AnyTimes()
+	cong.EXPECT().SlowStartThreshold().AnyTimes()
+	cong.EXPECT().TimeUntilSend(gomock.Any()).AnyTimes()
+	cong.EXPECT().PacingBudget(gomock.Any()).AnyTimes()
+	cong.EXPECT().EstimatedSendTime(gomock.Any()).AnyTimes()
+	cong.EXPECT().BandwidthEstimate().AnyTimes()
+	cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	cong.EXPECT().OnPacketAcked(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	cong.EXPECT().MaybeExitSlowStart().AnyTimes()
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(100*time.Millisecond, 0)
+	sph := NewSentPacketHandler(
+		0,
+		1200,
+		rttStats,
+		&utils.ConnectionStats{},
+		true,
+		false,
+		nil,
+		protocol.PerspectiveClient,
+		func(protocol.ByteCount) congestion.SendAlgorithmWithDebugInfos { return cong },
+		nil,
+		utils.DefaultLogger,
+	)
+	now := monotime.Now()
+	sph.DropPackets(protocol.EncryptionInitial, now)
+	sph.DropPackets(protocol.EncryptionHandshake, now)
+
+	var pns []protocol.PacketNumber
+	for i := range 5 {
+		pn := sph.PopPacketNumber(protocol.Encryption1RTT)
+		sph.SentPacket(now.Add(time.Duration(i)*time.Millisecond), pn, protocol.InvalidPacketNumber, nil, []Frame{{Frame: &wire.PingFrame{}}}, protocol.Encryption1RTT, protocol.ECNNon, 1000, false, false)
+		pns = append(pns, pn)
+	}
+
+	// the first packet is declared lost by the packet threshold
+	cong.EXPECT().OnCongestionEvent(pns[0], protocol.ByteCount(1000), gomock.Any(), congestion.TrafficClassDefault, false)
+	_, err := sph.ReceivedAck(&wire.AckFrame{AckRanges: ackRanges(pns[1], pns[2], pns[3])}, protocol.Encryption1RTT, now.Add(100*time.Millisecond))
+	require.NoError(t, err)
+
+	// it then arrives after all, 4 packets out of order
+	cong.EXPECT().OnSpuriousLoss(pns[0])
+	_, err = sph.ReceivedAck(&wire.AckFrame{AckRanges: ackRanges(pns[0], pns[1], pns[2], pns[3], pns[4])}, protocol.Encryption1RTT, now.Add(110*time.Millisecond))
+	require.NoError(t, err)
+
+	for i := range 7 {
+		pn := sph.PopPacketNumber(protocol.Encryption1RTT)
+		sph.SentPacket(now.Add(200*time.Millisecond+time.Duration(i)*time.Millisecond), pn, protocol.InvalidPacketNumber, nil, []Frame{{Frame: &wire.PingFrame{}}}, protocol.Encryption1RTT, protocol.ECNNon, 1000, false, false)
+		pns = append(pns, pn)
+	}
+	// Losses within the observed reordering distance are likely caused by reordering.
+	// pns[5] and pns[6] are 6 and 5 packets below the largest acknowledged, pns[7] and pns[8] 4 and 3.
+	cong.EXPECT().OnCongestionEvent(pns[5], protocol.ByteCount(1000), gomock.Any(), congestion.TrafficClassDefault, false)
+	cong.EXPECT().OnCongestionEvent(pns[6], protocol.ByteCount(1000), gomock.Any(), congestion.TrafficClassDefault, false)
+	cong.EXPECT().OnCongestionEvent(pns[7], protocol.ByteCount(1000), gomock.Any(), congestion.TrafficClassDefault, true)
+	cong.EXPECT().OnCongestionEvent(pns[8], protocol.ByteCount(1000), gomock.Any(), congestion.TrafficClassDefault, true)
+	_, err = sph.ReceivedAck(&wire.AckFrame{AckRanges: ackRanges(pns[0], pns[1], pns[2], pns[3], pns[4], pns[11])}, protocol.Encryption1RTT, now.Add(210*time.Millisecond))
+	require.NoError(t, err)
+}
+
+func TestSentPacketHandlerTrafficClass(t *testing.T) {
+	streamFrames := func(classes ...congestion.TrafficClass) []StreamFrame {
+		var frames []StreamFrame
+		for _, c := range classes {
+			frames = append(frames, StreamFrame{Frame: &wire.StreamFrame{}, Handler: &trafficClassFrameHandler{class: c}})
+		}
+		return frames
+	}
+
+	require.Equal(t, congestion.TrafficClassDefault, trafficClass(&packet{Frames: []Frame{{Frame: &wire.PingFrame{}}}}))
+	require.Equal(t, congestion.TrafficClassBulk, trafficClass(&packet{StreamFrames: streamFrames(congestion.TrafficClassBulk)}))
+	// control data takes precedence
+	require.Equal(t,
+		congestion.TrafficClassControl,
+		trafficClass(&packet{StreamFrames: streamFrames(congestion.TrafficClassBulk, congestion.TrafficClassControl)}),
+	)
+	// packets are only considered bulk data if all STREAM frames carry bulk data
+	require.Equal(t,
+		congestion.TrafficClassDefault,
+		trafficClass(&packet{StreamFrames: streamFrames(congestion.TrafficClassBulk, congestion.TrafficClassDefault)}),
+	)
+	require.Equal(t,
+		congestion.TrafficClassDefault,
+		trafficClass(&packet{StreamFrames: append(streamFrames(congestion.TrafficClassBulk), StreamFrame{Frame: &wire.StreamFrame{}, Handler: &customFrameHandler{}})}),
+	)
+
+	// the traffic class of a lost packet is passed to the congestion controller
+	mockCtrl := gomock.NewController(t)
+	cong := mocks.NewMockSendAlgorithmWithDebugInfos(mockCtrl)
+	cong.EXPECT().State(gomock.Any()).AnyTimes()
+	cong.EXPECT().GetCongestionWindow().AnyTimes()
+	cong.EXPECT().SlowStartThreshold().AnyTimes()
+	cong.EXPECT().TimeUntilSend(gomock.Any()).AnyTimes()
+	cong.EXPECT().PacingBudget(gomock.Any()).AnyTimes()
+	cong.EXPECT().EstimatedSendTime(gomock.Any()).AnyTimes()
+	cong.EXPECT().BandwidthEstimate().AnyTimes()
+	cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	cong.EXPECT().OnPacketAcked(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	cong.EXPECT().MaybeExitSlowStart().AnyTimes()
+	sph := NewSentPacketHandler(
+		0,
+		1200,
+		utils.NewRTTStats(),
+		&utils.ConnectionStats{},
+		true,
+		false,
+		nil,
+		protocol.PerspectiveClient,
+		func(protocol.ByteCount) congestion.SendAlgorithmWithDebugInfos { return cong },
+		nil,
+		utils.DefaultLogger,
+	)
+	now := monotime.Now()
+	sph.DropPackets(protocol.EncryptionInitial, now)
+	sph.DropPackets(protocol.EncryptionHandshake, now)
+
+	var pns []protocol.PacketNumber
+	for i := range 4 {
+		pn := sph.PopPacketNumber(protocol.Encryption1RTT)
+		var frames []StreamFrame
+		if i == 0 {
+			frames = streamFrames(congestion.TrafficClassBulk)
+		} else {
+			frames = streamFrames(congestion.TrafficClassControl)
+		}
+		sph.SentPacket(now, pn, protocol.InvalidPacketNumber, frames, nil, protocol.Encryption1RTT, protocol.ECNNon, 1000, false, false)
+		pns = append(pns, pn)
+	}
+	cong.EXPECT().OnCongestionEvent(pns[0], protocol.ByteCount(1000), gomock.Any(), congestion.TrafficClassBulk, false)
+	_, err := sph.ReceivedAck(&wire.AckFrame{AckRanges: ackRanges(pns[1], pns[2], pns[3])}, protocol.Encryption1RTT, now.Add(100*time.Millisecond))
+	require.NoError(t, err)
+}
+
+func TestSentPacketHandlerPersistentCongestion(t *testing.T) {
+	t.Run("all packets lost", func(t *testing.T) {
+		testSentPacketHandlerPersistentCongestion(t, false)
+	})
+	t.Run("packet acknowledged in between", func(t *testing.T) {
+		testSentPacketHandlerPersistentCongestion(t, true)
+	})
+}
+
+func testSentPacketHandlerPersistentCongestion(t *testing.T, ackInBetween bool) {
+	mockCtrl := gomock.NewController(t)
+	cong := mocks.NewMockSendAlgorithmWithDebugInfos(mockCtrl)
+	cong.EXPECT().State(gomock.Any()).AnyTimes()
+	cong.EXPECT().GetCongestionWindow().AnyTimes()
+	cong.EXPECT().SlowStartThreshold().AnyTimes()
+	cong.EXPECT().TimeUntilSend(gomock.Any()).AnyTimes()
+	cong.EXPECT().PacingBudget(gomock.Any()).AnyTimes()
+	cong.EXPECT().EstimatedSendTime(gomock.Any()).AnyTimes()
+	cong.EXPECT().BandwidthEstimate().AnyTimes()
+	cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	cong.EXPECT().OnPacketAcked(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	cong.EXPECT().MaybeExitSlowStart().AnyTimes()
+	cong.EXPECT().OnCongestionEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	rttStats := utils.NewRTTStats()
+	sph := NewSentPacketHandler(
+		0,
+		1200,
+		rttStats,
+		&utils.ConnectionStats{},
+		true,
+		false,
+		nil,
+		protocol.PerspectiveClient,
+		func(protocol.ByteCount) congestion.SendAlgorithmWithDebugInfos { return cong },
+		nil,
+		utils.DefaultLogger,
+	)
+	now := monotime.Now()
+	sph.DropPackets(protocol.EncryptionInitial, now)
+	sph.DropPackets(protocol.EncryptionHandshake, now)
+
+	sendPacket := func(sendTime monotime.Time) protocol.PacketNumber {
+		pn := sph.PopPacketNumber(protocol.Encryption1RTT)
+		sph.SentPacket(sendTime, pn, protocol.InvalidPacketNumber, nil, []Frame{{Frame: &wire.PingFrame{}}}, protocol.Encryption1RTT, protocol.ECNNon, 1000, false, false)
+		return pn
+	}
+
+	// the persistent congestion period only starts after the first RTT sample
+	pn := sendPacket(now)
+	_, err := sph.ReceivedAck(&wire.AckFrame{AckRanges: ackRanges(pn)}, protocol.Encryption1RTT, now.Add(50*time.Millisecond))
+	require.NoError(t, err)
+	duration := persistentCongestionThreshold * rttStats.PTO(true)
+
+	// send 5 packets spanning more than the persistent congestion duration
+	var pns []protocol.PacketNumber
+	for i := range 5 {
+		pns = append(pns, sendPacket(now.Add(100*time.Millisecond+time.Duration(i)*duration/3)))
+	}
+	largest := sendPacket(now.Add(100*time.Millisecond + 2*duration))
+	acked := []protocol.PacketNumber{largest}
+	if ackInBetween {
+		// the remaining lost packets only span 1/3 of the persistent congestion duration
+		acked = append(acked, pns[2])
+	} else {
+		cong.EXPECT().OnPersistentCongestion()
+	}
+	_, err = sph.ReceivedAck(
+		&wire.AckFrame{AckRanges: ackRanges(acked...)},
+		protocol.Encryption1RTT,
+		now.Add(150*time.Millisecond+2*duration),
+	)
+	require.NoError(t, err)
+}
+
+func TestSentPacketHandlerChaosInjection(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	cong := mocks.NewMockSendAlgorithmWithDebugInfos(mockCtrl)
+	cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	cong.EXPECT().MaybeExitSlowStart().AnyTimes()
+	cong.EXPECT().State(gomock.Any()).Return(congestion.StateSlowStart).AnyTimes()
+	cong.EXPECT().GetCongestionWindow().AnyTimes()
+	cong.EXPECT().SlowStartThreshold().AnyTimes()
+	cong.EXPECT().TimeUntilSend(gomock.Any()).AnyTimes()
+	cong.EXPECT().PacingBudget(gomock.Any()).AnyTimes()
+	cong.EXPECT().EstimatedSendTime(gomock.Any()).AnyTimes()
+	cong.EXPECT().BandwidthEstimate().AnyTimes()
+	rttStats := utils.NewRTTStats()
+	sph := NewSentPacketHandler(
+		0,
+		1200,
+		rttStats,
+		&utils.ConnectionStats{},
+		true,
+		false,
+		nil,
+		protocol.PerspectiveClient,
+		func(protocol.ByteCount) congestion.SendAlgorithmWithDebugInfos { return cong },
+		nil,
+		utils.DefaultLogger,
+	)
+	var packets packetTracker
+	now := monotime.Now()
+	sph.InjectChaos(now, 1, 50*time.Millisecond, time.Second)
+
+	// while the injection is active, acknowledged packets are reported as lost, and the RTT is inflated
+	pn := sph.PopPacketNumber(protocol.Encryption1RTT)
+	sph.SentPacket(now, pn, protocol.InvalidPacketNumber, nil, []Frame{packets.NewPingFrame(pn)}, protocol.Encryption1RTT, protocol.ECNNon, 1200, false, false)
+	cong.EXPECT().OnCongestionEvent(pn, protocol.ByteCount(1200), protocol.ByteCount(1200), congestion.TrafficClassDefault, false)
+	_, err := sph.ReceivedAck(&wire.AckFrame{AckRanges: ackRanges(pn)}, protocol.Encryption1RTT, now.Add(100*time.Millisecond))
+	require.NoError(t, err)
+	require.Equal(t, 150*time.Millisecond, rttStats.LatestRTT())
+	// the packet was still acknowledged
+	require.Equal(t, []protocol.PacketNumber{pn}, packets.Acked)
+	require.Zero(t, sph.(*sentPacketHandler).bytesInFlight)
+
+	// once the injection ends, everything is back to normal
+	now = now.Add(2 * time.Second)
+	pn = sph.PopPacketNumber(protocol.Encryption1RTT)
+	sph.SentPacket(now, pn, protocol.InvalidPacketNumber, nil, []Frame{packets.NewPingFrame(pn)}, protocol.Encryption1RTT, protocol.ECNNon, 1200, false, false)
+	cong.EXPECT().OnPacketAcked(pn, protocol.ByteCount(1200), protocol.ByteCount(1200), gomock.Any())
+	_, err = sph.ReceivedAck(&wire.AckFrame{AckRanges: ackRanges(pn)}, protocol.Encryption1RTT, now.Add(100*time.Millisecond))
+	require.NoError(t, err)
+	require.Equal(t, 100*time.Millisecond, rttStats.LatestRTT())
+}
+
+func TestSentPacketHandlerDeliveryRate(t *testing.T) {
+	connStats := &utils.ConnectionStats{}
+	sph := NewSentPacketHandler(
+		0,
+		1200,
+		utils.NewRTTStats(),
+		connStats,
+		true,
+		false,
+		nil,
+		protocol.PerspectiveClient,
+		nil,
+		nil,
+		utils.DefaultLogger,
+	)
+	sph.DropPackets(protocol.EncryptionInitial, monotime.Now())
+	sph.DropPackets(protocol.EncryptionHandshake, monotime.Now())
+
+	// send one packet every 10ms, and receive an ACK for it 50ms later
+	var packets packetTracker
+	start := monotime.Now()
+	for i := range 50 {
+		now := start.Add(time.Duration(i) * 10 * time.Millisecond)
+		pn := sph.PopPacketNumber(protocol.Encryption1RTT)
+		sph.SentPacket(now, pn, protocol.InvalidPacketNumber, nil, []Frame{packets.NewPingFrame(pn)}, protocol.Encryption1RTT, protocol.ECNNon, 1200, false, false)
+		_, err := sph.ReceivedAck(&wire.AckFrame{AckRanges: ackRanges(pn)}, protocol.Encryption1RTT, now.Add(50*time.Millisecond))
+		require.NoError(t, err)
+	}
+	require.InEpsilon(t, float64(1200*100*congestion.BytesPerSecond), float64(connStats.DeliveryRate.Load()), 0.1)
+	require.Equal(t, uint64(50*1200), connStats.BytesAcked.Load())
+
+	// the delivery rate is reset when the connection migrates
+	sph.MigratedPath(start.Add(time.Second), 1200, "old", "new")
+	require.Zero(t, connStats.DeliveryRate.Load())
+}
@@ -1,6 +1,8 @@
 package congestion
 
 import (
+	"math"
+	"math/bits"
 	"time"
 
 	"github.com/quic-go/quic-go/internal/protocol"
@@ -16,7 +18,50 @@ const (
 	BytesPerSecond = 8 * BitsPerSecond
 )
 
+// BandwidthFromMbps converts a bandwidth given in Mbps, as used by the Hysteria sender, into a Bandwidth.
+// For historic reasons, 1 Mbps is 2^20 bits per second.
+func BandwidthFromMbps(mbps int) Bandwidth {
+	return Bandwidth(max(mbps, 0)) * 1024 * 1024 * BitsPerSecond
+}
+
 // BandwidthFromDelta calculates the bandwidth from a number of bytes and a time delta
 func BandwidthFromDelta(bytes protocol.ByteCount, delta time.Duration) Bandwidth {
 	return Bandwidth(bytes) * Bandwidth(time.Second) / Bandwidth(delta) * BytesPerSecond
 }
+
+// BDP calculates the bandwidth-delay product, i.e. the number of bytes in flight when sending at rate for one rtt.
+// The result is rounded up to the next byte. If rtt is not positive, BDP returns 0.
+func BDP(rate Bandwidth, rtt time.Duration) protocol.ByteCount {
+	if rtt <= 0 {
+		return 0
+	}
+	const divisor = uint64(BytesPerSecond) * uint64(time.Second)
+	hi, lo := bits.Mul64(uint64(rate), uint64(rtt))
+	lo, carry := bits.Add64(lo, divisor-1, 0)
+	hi += carry
+	if hi >= divisor {
+		return protocol.MaxByteCount
+	}
+	bdp, _ := bits.Div64(hi, lo, divisor)
+	return protocol.ByteCount(min(bdp, uint64(protocol.MaxByteCount)))
+}
+
+// SendTime calculates how long it takes to send a number of bytes at rate.
+// The result is rounded up to the next nanosecond. If rate is 0, SendTime returns the maximum duration.
+func SendTime(bytes protocol.ByteCount, rate Bandwidth) time.Duration {
+	if bytes <= 0 {
+		return 0
+	}
+	if rate == 0 {
+		return math.MaxInt64
+	}
+	const multiplier = uint64(BytesPerSecond) * uint64(time.Second)
+	hi, lo := bits.Mul64(uint64(bytes), multiplier)
+	lo, carry := bits.Add64(lo, uint64(rate)-1, 0)
+	hi += carry
+	if hi >= uint64(rate) {
+		return math.MaxInt64
+	}
+	d, _ := bits.Div64(hi, lo, uint64(rate))
+	return time.Duration(min(d, math.MaxInt64))
+}
@@ -0,0 +1,55 @@
+package congestion
+
+import (
+	"time"
+
+	"github.com/quic-go/quic-go/internal/monotime"
+	"github.com/quic-go/quic-go/internal/protocol"
+)
+
+// The minimum duration of a bandwidth sampling interval.
+// This prevents ack compression from producing overly large samples on short RTT paths.
+const minBandwidthSampleInterval = 50 * time.Millisecond
+
+// A BandwidthSampler estimates the delivery rate of a connection.
+// Following Westwood+, the bytes acknowledged are accumulated over (at least) one RTT,
+// and every interval produces a sample that is smoothed by an EWMA filter.
+type BandwidthSampler struct {
+	intervalStart monotime.Time
+	ackedBytes    protocol.ByteCount
+
+	estimate Bandwidth
+}
+
+// OnPacketAcked is called for every acknowledged packet.
+// rtt is the current (smoothed) RTT, and determines the length of the sampling interval.
+func (s *BandwidthSampler) OnPacketAcked(ackedBytes protocol.ByteCount, eventTime monotime.Time, rtt time.Duration) {
+	if s.intervalStart.IsZero() {
+		s.intervalStart = eventTime
+		return
+	}
+	s.ackedBytes += ackedBytes
+	elapsed := eventTime.Sub(s.intervalStart)
+	if elapsed < max(rtt, minBandwidthSampleInterval) {
+		return
+	}
+	sample := BandwidthFromDelta(s.ackedBytes, elapsed)
+	if s.estimate == 0 {
+		s.estimate = sample
+	} else {
+		s.estimate = (7*s.estimate + sample) / 8
+	}
+	s.intervalStart = eventTime
+	s.ackedBytes = 0
+}
+
+// BandwidthEstimate returns the smoothed delivery rate.
+// It returns 0 until the first sampling interval has completed.
+func (s *BandwidthSampler) BandwidthEstimate() Bandwidth {
+	return s.estimate
+}
+
+// Reset discards all samples.
+func (s *BandwidthSampler) Reset() {
+	*s = BandwidthSampler{}
+}
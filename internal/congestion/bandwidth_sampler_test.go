@@ -0,0 +1,51 @@
+package congestion
+
+import (
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go/internal/monotime"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBandwidthSamplerEstimate(t *testing.T) {
+	var s BandwidthSampler
+	now := monotime.Now()
+	const rtt = 100 * time.Millisecond
+
+	// 10 packets per RTT
+	ackPackets := func(n int) {
+		for range n {
+			now = now.Add(rtt / 10)
+			s.OnPacketAcked(maxDatagramSize, now, rtt)
+		}
+	}
+	s.OnPacketAcked(maxDatagramSize, now, rtt) // starts the first interval
+	ackPackets(9)
+	require.Zero(t, s.BandwidthEstimate())
+	ackPackets(1)
+	expected := BandwidthFromDelta(10*maxDatagramSize, rtt)
+	require.Equal(t, expected, s.BandwidthEstimate())
+
+	// the rate drops to 5 packets per RTT, the estimate decreases smoothly
+	for range 10 {
+		now = now.Add(rtt / 5)
+		s.OnPacketAcked(maxDatagramSize, now, rtt)
+	}
+	require.Less(t, s.BandwidthEstimate(), expected)
+	require.Greater(t, s.BandwidthEstimate(), expected/2)
+
+	s.Reset()
+	require.Zero(t, s.BandwidthEstimate())
+}
+
+func TestBandwidthSamplerMinInterval(t *testing.T) {
+	var s BandwidthSampler
+	now := monotime.Now()
+	s.OnPacketAcked(maxDatagramSize, now, time.Millisecond)
+	s.OnPacketAcked(maxDatagramSize, now.Add(10*time.Millisecond), time.Millisecond)
+	require.Zero(t, s.BandwidthEstimate())
+	s.OnPacketAcked(maxDatagramSize, now.Add(minBandwidthSampleInterval), time.Millisecond)
+	require.Equal(t, BandwidthFromDelta(2*maxDatagramSize, minBandwidthSampleInterval), s.BandwidthEstimate())
+}
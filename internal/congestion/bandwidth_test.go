@@ -1,12 +1,43 @@
 package congestion
 
 import (
+	"math"
 	"testing"
 	"time"
 
+	"github.com/quic-go/quic-go/internal/protocol"
+
 	"github.com/stretchr/testify/require"
 )
 
 func TestBandwidthFromDelta(t *testing.T) {
 	require.Equal(t, 1000*BytesPerSecond, BandwidthFromDelta(1, time.Millisecond))
 }
+
+func TestBDP(t *testing.T) {
+	require.Equal(t, protocol.ByteCount(125_000), BDP(10_000_000*BitsPerSecond, 100*time.Millisecond))
+	require.Equal(t, protocol.ByteCount(65536), BDP(BandwidthFromMbps(5), 100*time.Millisecond))
+	// the result is rounded up
+	require.Equal(t, protocol.ByteCount(1), BDP(BitsPerSecond, time.Millisecond))
+	require.Equal(t, protocol.ByteCount(2), BDP(9*BytesPerSecond, 200*time.Millisecond))
+	// no RTT
+	require.Zero(t, BDP(10_000_000*BitsPerSecond, 0))
+	require.Zero(t, BDP(10_000_000*BitsPerSecond, -time.Second))
+	require.Zero(t, BDP(0, time.Second))
+	// no overflow for large rates and RTTs
+	require.Equal(t, protocol.ByteCount(1_250_000_000_000), BDP(1_000_000_000_000*BitsPerSecond, 10*time.Second))
+	require.Equal(t, protocol.MaxByteCount, BDP(Bandwidth(math.MaxUint64), time.Duration(math.MaxInt64)))
+}
+
+func TestSendTime(t *testing.T) {
+	require.Equal(t, 100*time.Millisecond, SendTime(125_000, 10_000_000*BitsPerSecond))
+	require.Equal(t, time.Second, SendTime(655_360, BandwidthFromMbps(5)))
+	// the result is rounded up
+	require.Equal(t, time.Duration(2), SendTime(4, 3*BytesPerSecond*Bandwidth(time.Second)))
+	require.Zero(t, SendTime(0, 10_000_000*BitsPerSecond))
+	// no rate
+	require.Equal(t, time.Duration(math.MaxInt64), SendTime(1, 0))
+	// no overflow for large byte counts
+	require.Equal(t, 1_000_000*time.Second, SendTime(1_250_000_000_000, 10_000_000*BitsPerSecond))
+	require.Equal(t, time.Duration(math.MaxInt64), SendTime(protocol.MaxByteCount, BitsPerSecond))
+}
@@ -0,0 +1,123 @@
+package congestion
+
+import (
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go/internal/protocol"
+	"github.com/quic-go/quic-go/internal/utils"
+)
+
+type senderBenchmark struct {
+	name      string
+	newSender func(Clock, *utils.RTTStats, *utils.ConnectionStats) SendAlgorithmWithDebugInfos
+}
+
+var benchmarkSenders = []senderBenchmark{
+	{
+		name: "reno",
+		newSender: func(clock Clock, rttStats *utils.RTTStats, connStats *utils.ConnectionStats) SendAlgorithmWithDebugInfos {
+			return NewCubicSender(clock, rttStats, connStats, maxDatagramSize, true, nil, nil)
+		},
+	},
+	{
+		name: "cubic",
+		newSender: func(clock Clock, rttStats *utils.RTTStats, connStats *utils.ConnectionStats) SendAlgorithmWithDebugInfos {
+			return NewCubicSender(clock, rttStats, connStats, maxDatagramSize, false, nil, nil)
+		},
+	},
+	{
+		name: "hysteria",
+		newSender: func(clock Clock, rttStats *utils.RTTStats, _ *utils.ConnectionStats) SendAlgorithmWithDebugInfos {
+			return NewHysteriaSender(clock, rttStats, maxDatagramSize, BandwidthFromMbps(100), nil, nil)
+		},
+	},
+	{
+		name: "hybrid",
+		newSender: func(clock Clock, rttStats *utils.RTTStats, connStats *utils.ConnectionStats) SendAlgorithmWithDebugInfos {
+			return NewHybridSender(clock, rttStats, connStats, maxDatagramSize, BandwidthFromMbps(100), nil, nil)
+		},
+	},
+	{
+		name: "westwood",
+		newSender: func(_ Clock, rttStats *utils.RTTStats, connStats *utils.ConnectionStats) SendAlgorithmWithDebugInfos {
+			return NewWestwoodSender(rttStats, maxDatagramSize, nil, nil)
+		},
+	},
+	{
+		name: "vegas",
+		newSender: func(_ Clock, rttStats *utils.RTTStats, connStats *utils.ConnectionStats) SendAlgorithmWithDebugInfos {
+			return NewVegasSender(rttStats, maxDatagramSize, nil, nil)
+		},
+	},
+	{
+		name: "fixed",
+		newSender: func(_ Clock, rttStats *utils.RTTStats, connStats *utils.ConnectionStats) SendAlgorithmWithDebugInfos {
+			return NewFixedSender(rttStats, maxDatagramSize, &Config{
+				FixedWindowPackets: 100,
+				MaxPacingRate:      100 * 1000 * 1000 * BitsPerSecond,
+			})
+		},
+	},
+}
+
+// BenchmarkSenders measures the cost of every sender per acknowledged (or lost) packet.
+// The scenarios are deterministic: the time is simulated, and the RTT samples and losses follow a fixed pattern.
+func BenchmarkSenders(b *testing.B) {
+	for _, s := range benchmarkSenders {
+		b.Run(s.name, func(b *testing.B) {
+			b.Run("no loss", func(b *testing.B) { benchmarkSender(b, s, 0) })
+			b.Run("1% loss", func(b *testing.B) { benchmarkSender(b, s, 100) })
+		})
+	}
+}
+
+// benchmarkSender keeps a constant number of packets in flight. In every iteration, the oldest packet
+// in flight is acknowledged, or declared lost if lossEvery is non-zero and its packet number is a multiple of it,
+// and a new packet is sent, following the sequence of calls made by the sent packet handler.
+func benchmarkSender(b *testing.B, s senderBenchmark, lossEvery protocol.PacketNumber) {
+	const (
+		inFlight = 100
+		rtt      = 50 * time.Millisecond
+	)
+
+	var clock mockClock
+	clock.Advance(time.Second)
+	rttStats := utils.NewRTTStats()
+	sender := s.newSender(&clock, rttStats, &utils.ConnectionStats{})
+
+	var pn protocol.PacketNumber
+	var bytesInFlight protocol.ByteCount
+	send := func() {
+		now := clock.Now()
+		if sender.CanSend(bytesInFlight) {
+			sender.HasPacingBudget(now)
+		} else {
+			sender.TimeUntilSend(bytesInFlight)
+		}
+		sender.OnPacketSent(now, bytesInFlight, pn, maxDatagramSize, true)
+		bytesInFlight += maxDatagramSize
+		pn++
+	}
+	for range inFlight {
+		send()
+	}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		clock.Advance(rtt / inFlight)
+		priorInFlight := bytesInFlight
+		bytesInFlight -= maxDatagramSize
+		if largest := pn - inFlight; lossEvery > 0 && largest%lossEvery == 0 {
+			sender.OnCongestionEvent(largest, maxDatagramSize, priorInFlight, TrafficClassDefault, false)
+		} else {
+			// the RTT fluctuates by up to 3ms
+			rttStats.UpdateRTT(rtt+time.Duration(largest%7)*500*time.Microsecond, 0)
+			sender.MaybeExitSlowStart()
+			sender.OnPacketAcked(largest, maxDatagramSize, priorInFlight, clock.Now())
+		}
+		sender.GetCongestionWindow()
+		sender.State(bytesInFlight)
+		send()
+	}
+}
@@ -0,0 +1,314 @@
+package congestion
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/quic-go/quic-go/internal/protocol"
+)
+
+// A MinRatePolicy determines the lower bound of the congestion window
+// that the cubicSender applies after a congestion event or a retransmission timeout.
+type MinRatePolicy uint8
+
+const (
+	// MinRatePolicyBDP keeps the congestion window at or above the bandwidth-delay product of a 5 Mbps link.
+	MinRatePolicyBDP MinRatePolicy = iota
+	// MinRatePolicyPackets keeps the congestion window at or above a fixed number of packets, independent of the RTT.
+	MinRatePolicyPackets
+)
+
+// A LossTolerancePolicy determines which congestion events the cubicSender tolerates without reducing the congestion window.
+type LossTolerancePolicy uint8
+
+const (
+	// LossTolerancePolicyLossRate tolerates losses as long as the connection's aggregate loss rate stays below 10%.
+	LossTolerancePolicyLossRate LossTolerancePolicy = iota
+	// LossTolerancePolicyEpisode only tolerates the losses in the first RTT of a congestion episode.
+	// If losses continue in the following RTTs, the congestion window is reduced.
+	// The episode ends once an RTT passes without any loss.
+	LossTolerancePolicyEpisode
+)
+
+// A LossThreshold is the loss rate above which the Hysteria sender considers
+// packet loss to be caused by congestion, for RTTs below RTTBelow.
+type LossThreshold struct {
+	RTTBelow  time.Duration
+	Threshold float64
+}
+
+// A HysteriaPenaltyEvent is reported when the Hysteria sender enters or leaves the penalty period
+// that follows a rate reduction due to congestion loss. During the penalty period, the sender doesn't increase its rate.
+type HysteriaPenaltyEvent struct {
+	// Entered is true when the penalty period is entered, and false when it is left.
+	// Congestion loss during the penalty period reduces the rate again, and is reported as another entry.
+	Entered bool
+	// RateBefore and RateAfter are the sending rate before and after the rate reduction.
+	// When leaving the penalty period, both are the current sending rate.
+	RateBefore, RateAfter Bandwidth
+	// Duration is the time spent in the penalty period. It is only set when leaving the penalty period.
+	Duration time.Duration
+}
+
+// A WindowPolicy is consulted by the cubicSender before it reduces the congestion window in response to packet loss.
+// It allows implementing site-specific policies, e.g. a minimum rate for a certain class of customers.
+type WindowPolicy interface {
+	// AllowedCongestionWindow receives the current and the proposed congestion window, and returns the window to use.
+	// The proposed window already takes the min rate policy into account.
+	// Returning current vetoes the reduction. The result is clamped to the range between the minimum congestion window and current.
+	AllowedCongestionWindow(current, proposed protocol.ByteCount) protocol.ByteCount
+}
+
+// Config contains the tunable parameters of the congestion controllers.
+// A nil Config, as well as the zero value of any field, selects the defaults.
+type Config struct {
+	// OnCongestionWindowChange is called every time the congestion window changes.
+	OnCongestionWindowChange func(old, new protocol.ByteCount)
+	// Logger receives congestion events (state changes, window and rate reductions, tolerated losses)
+	// as structured log records at debug level. If nil, nothing is logged.
+	Logger *slog.Logger
+	// WindowPolicy, if set, can veto or modify the window reductions of the cubicSender.
+	WindowPolicy WindowPolicy
+	// InitialCongestionWindowPackets is the initial congestion window, in packets.
+	// It is clamped to the range of valid congestion windows.
+	InitialCongestionWindowPackets int
+	// CubicBeta is the multiplicative decrease factor of CUBIC. It must be in the range (0, 1).
+	CubicBeta float64
+	// CubicBetaLastMax is the factor that CUBIC applies to the last maximum congestion window for fast convergence.
+	// It must be in the range (0, 1).
+	CubicBetaLastMax float64
+	// CubicCongestionWindowScale is the scaling constant C of the cubic function, in units of 1/1024.
+	// If not positive, the default of 410 (C = 0.4) is used.
+	CubicCongestionWindowScale int
+	// DisableCubicTCPFriendliness makes the window follow the cubic function only,
+	// instead of keeping it at least at the window of an emulated Reno sender.
+	DisableCubicTCPFriendliness bool
+	// RenoBeta is the multiplicative decrease factor of Reno. It must be in the range (0, 1).
+	RenoBeta float64
+	// HybridSlowStartMinSamples is the number of RTT samples that hybrid slow start takes at the beginning of each round
+	// to determine the RTT of the round.
+	HybridSlowStartMinSamples int
+	// HybridSlowStartMinDelayThreshold and HybridSlowStartMaxDelayThreshold bound the RTT increase
+	// (1/8th of the min RTT) that makes the CUBIC / Reno sender exit slow start.
+	// If the max threshold is smaller than the min threshold, the min threshold is used.
+	HybridSlowStartMinDelayThreshold time.Duration
+	HybridSlowStartMaxDelayThreshold time.Duration
+	// NumEmulatedConnections is the number of TCP connections the CUBIC / Reno sender emulates.
+	// It scales the aggressiveness of window growth and the multiplicative decrease. Values below 1 select 1.
+	NumEmulatedConnections int
+	// RenoByteCounting makes the Reno sender use Appropriate Byte Counting (RFC 3465).
+	RenoByteCounting bool
+	// SlowStartPacingGain and CongestionAvoidancePacingGain are the factors the cubicSender multiplies its
+	// bandwidth estimate with to obtain the pacing rate. Values below 1 select the defaults of 2 and 1.25.
+	SlowStartPacingGain           float64
+	CongestionAvoidancePacingGain float64
+	// MaxPacingRate caps the rate at which packets are sent, independent of the congestion window.
+	// 0 means no cap.
+	MaxPacingRate Bandwidth
+	// MaxPacingBurst caps the number of bytes the pacer allows to be sent in a burst.
+	// 0 selects the default burst size of the pacer.
+	MaxPacingBurst protocol.ByteCount
+	// DisablePacing turns the pacer into a passthrough: packets are sent as soon as the congestion window allows.
+	// MaxPacingRate and MaxPacingBurst have no effect then.
+	DisablePacing bool
+	// MinRatePolicy is the policy used to bound the congestion window from below.
+	MinRatePolicy MinRatePolicy
+	// MinRatePackets is the lower bound of the congestion window, in packets, when using MinRatePolicyPackets.
+	// It defaults to the default initial congestion window.
+	MinRatePackets int
+	// LossTolerancePolicy is the policy used to decide if a congestion event reduces the congestion window.
+	LossTolerancePolicy LossTolerancePolicy
+	// MinSlowStartDuration and MinSlowStartBytes protect the initial slow start of the cubicSender:
+	// until it has been in slow start for MinSlowStartDuration, and MinSlowStartBytes were acknowledged,
+	// congestion events don't end slow start. 0 disables the respective condition.
+	MinSlowStartDuration time.Duration
+	MinSlowStartBytes    protocol.ByteCount
+	// HoldWindowOnToleratedLoss makes the cubicSender suppress window growth until the packets sent
+	// after the last tolerated loss are acknowledged.
+	HoldWindowOnToleratedLoss bool
+	// HysteriaBrutal makes the Hysteria sender send at the target rate at all times,
+	// without reacting to packet loss or RTT fluctuations.
+	HysteriaBrutal bool
+	// HysteriaRTTOnly makes the Hysteria sender ignore packet loss, such that its rate is only reduced
+	// when the RTT inflates, and on retransmission timeouts.
+	HysteriaRTTOnly bool
+	// HysteriaInitialBandwidth is the rate the Hysteria sender starts at, clamped to the range from
+	// MinHysteriaInitialBandwidth to the target rate. 0 selects 60% of the target rate, but at most 100 Mbps.
+	HysteriaInitialBandwidth Bandwidth
+	// HysteriaAutoBandwidth makes the Hysteria sender derive its target rate from the measured delivery rate,
+	// instead of using a fixed target rate.
+	HysteriaAutoBandwidth bool
+	// HysteriaLossThresholds are the RTT-dependent loss thresholds used by the Hysteria sender,
+	// ordered by ascending RTTBelow. RTTs exceeding all breakpoints use the threshold of the last entry.
+	HysteriaLossThresholds []LossThreshold
+	// HysteriaJitterFilterWindow is the number of RTT samples the Hysteria sender takes the minimum of
+	// before using the RTT for the detection of RTT inflation. 0 uses the latest RTT sample.
+	HysteriaJitterFilterWindow int
+	// HysteriaRTOBackoff is the fraction of the stable rate the Hysteria sender reduces its rate to after a retransmission timeout.
+	// Consecutive timeouts apply it repeatedly. Values outside of the range (0, 1] select the default of 0.5.
+	HysteriaRTOBackoff float64
+	// HysteriaStableRTTs is the number of RTTs the Hysteria sender needs to sustain its rate without congestion loss
+	// before it uses the rate as the stable rate, which it falls back to after packet loss. 0 selects the default of 3.
+	HysteriaStableRTTs int
+	// HysteriaPacingAlpha is the weight per smoothed RTT that the Hysteria sender gives to its current rate
+	// when updating the pacing rate. Values outside of the range (0, 1] select the default of 0.5.
+	HysteriaPacingAlpha float64
+	// HysteriaGrowthFactor, HysteriaHighRTTGrowthFactor and HysteriaVeryHighRTTGrowthFactor are the factors the Hysteria sender
+	// multiplies its rate with in every probing cycle, for smoothed RTTs up to 150ms, above 150ms, and above 300ms.
+	// Values not above 1 select the defaults of 1.1 and 1.25. The very-high-RTT factor defaults to the high-RTT factor.
+	HysteriaGrowthFactor            float64
+	HysteriaHighRTTGrowthFactor     float64
+	HysteriaVeryHighRTTGrowthFactor float64
+	// HysteriaDrainGain is the fraction of the delivery rate the Hysteria sender paces at for one RTT
+	// after detecting a queue buildup, to drain the queue. 1 disables the drain phase.
+	// Values outside of the range (0, 1] select the default of 0.75.
+	HysteriaDrainGain float64
+	// HysteriaMaxRateCut is the largest fraction by which the Hysteria sender reduces its rate after congestion loss.
+	// The cut is proportional to how far the loss rate exceeds the loss threshold, starting at 25% just above it.
+	// Values outside of the range (0, 1) select the default of 0.5.
+	HysteriaMaxRateCut float64
+	// HysteriaMaxQueuingDelay is the queuing delay the Hysteria sender tolerates on top of the min RTT.
+	// If set, the congestion window is the current rate multiplied by the min RTT plus this delay.
+	// 0 selects the default window, which is 1.1 to 1.5 times the BDP, depending on the RTT.
+	HysteriaMaxQueuingDelay time.Duration
+	// HysteriaLossCooldown is the time after a rate reduction during which the Hysteria sender doesn't increase its rate.
+	// If set, it replaces the default penalty period of two acknowledgments.
+	HysteriaLossCooldown time.Duration
+	// OnHysteriaPenalty is called when the Hysteria sender enters or leaves its loss penalty period.
+	OnHysteriaPenalty func(HysteriaPenaltyEvent)
+	// IdleRestartThreshold is the idle period after which the cubicSender restarts from the initial congestion window.
+	// 0 disables the idle restart.
+	IdleRestartThreshold time.Duration
+	// ResumeCongestionWindow is the congestion window, in bytes, observed on a previous connection over the same path.
+	// The cubicSender starts with half of this window (but at least the initial congestion window).
+	ResumeCongestionWindow protocol.ByteCount
+	// ResumeSlowStartThreshold is the slow start threshold, in bytes, observed on a previous connection over the same path.
+	ResumeSlowStartThreshold protocol.ByteCount
+	// FixedWindowPackets is the congestion window, in packets, used by the fixed sender.
+	// It is clamped to the range of valid congestion windows.
+	FixedWindowPackets int
+	// MaxDatagramSizeCeiling bounds the datagram size the senders use for their window math.
+	// Path MTU discovery can still grow the packet size beyond it, but the windows,
+	// and the window growth per acknowledged packet, are computed using this size.
+	// 0 means no limit.
+	MaxDatagramSizeCeiling protocol.ByteCount
+}
+
+func (c *Config) initialCongestionWindow(maxDatagramSize protocol.ByteCount) protocol.ByteCount {
+	packets := initialCongestionWindow
+	if c.InitialCongestionWindowPackets > 0 {
+		packets = min(max(c.InitialCongestionWindowPackets, minCongestionWindowPackets), protocol.MaxCongestionWindowPackets)
+	}
+	return protocol.ByteCount(packets) * maxDatagramSize
+}
+
+func (c *Config) fixedWindowPackets() protocol.ByteCount {
+	return protocol.ByteCount(min(max(c.FixedWindowPackets, minCongestionWindowPackets), protocol.MaxCongestionWindowPackets))
+}
+
+// renoBeta returns the multiplicative decrease factor of Reno.
+// Values outside of the range (0, 1) select the default.
+func (c *Config) renoBeta() float64 {
+	if c.RenoBeta > 0 && c.RenoBeta < 1 {
+		return c.RenoBeta
+	}
+	return renoBeta
+}
+
+func (c *Config) pacingGains() (slowStart, congestionAvoidance float64) {
+	slowStart, congestionAvoidance = defaultSlowStartPacingGain, defaultCongestionAvoidancePacingGain
+	if c.SlowStartPacingGain >= 1 {
+		slowStart = c.SlowStartPacingGain
+	}
+	if c.CongestionAvoidancePacingGain >= 1 {
+		congestionAvoidance = c.CongestionAvoidancePacingGain
+	}
+	return slowStart, congestionAvoidance
+}
+
+func (c *Config) numEmulatedConnections() int {
+	return max(c.NumEmulatedConnections, defaultNumConnections)
+}
+
+func (c *Config) hysteriaRTOBackoff() float64 {
+	if c.HysteriaRTOBackoff > 0 && c.HysteriaRTOBackoff <= 1 {
+		return c.HysteriaRTOBackoff
+	}
+	return defaultRTOBackoff
+}
+
+func (c *Config) hysteriaStableRTTs() int {
+	if c.HysteriaStableRTTs > 0 {
+		return c.HysteriaStableRTTs
+	}
+	return defaultStableRTTs
+}
+
+func (c *Config) hysteriaPacingAlpha() float64 {
+	if c.HysteriaPacingAlpha > 0 && c.HysteriaPacingAlpha <= 1 {
+		return c.HysteriaPacingAlpha
+	}
+	return defaultPacingAlpha
+}
+
+func (c *Config) hysteriaGrowthFactors() (normal, highRTT, veryHighRTT float64) {
+	normal, highRTT = defaultGrowthFactor, defaultHighRTTGrowthFactor
+	if c.HysteriaGrowthFactor > 1 {
+		normal = c.HysteriaGrowthFactor
+	}
+	if c.HysteriaHighRTTGrowthFactor > 1 {
+		highRTT = c.HysteriaHighRTTGrowthFactor
+	}
+	veryHighRTT = highRTT
+	if c.HysteriaVeryHighRTTGrowthFactor > 1 {
+		veryHighRTT = c.HysteriaVeryHighRTTGrowthFactor
+	}
+	return normal, highRTT, veryHighRTT
+}
+
+func (c *Config) hysteriaDrainGain() float64 {
+	if c.HysteriaDrainGain > 0 && c.HysteriaDrainGain <= 1 {
+		return c.HysteriaDrainGain
+	}
+	return defaultDrainGain
+}
+
+func (c *Config) hysteriaMaxRateCut() float64 {
+	if c.HysteriaMaxRateCut > 0 && c.HysteriaMaxRateCut < 1 {
+		return c.HysteriaMaxRateCut
+	}
+	return defaultMaxRateCut
+}
+
+func (c *Config) maxDatagramSizeCeiling() protocol.ByteCount {
+	if c.MaxDatagramSizeCeiling > 0 {
+		return c.MaxDatagramSizeCeiling
+	}
+	return protocol.MaxByteCount
+}
+
+func (c *Config) minRatePackets() protocol.ByteCount {
+	if c.MinRatePackets > 0 {
+		return protocol.ByteCount(c.MinRatePackets)
+	}
+	return initialCongestionWindow
+}
+
+// hybridSlowStartParameters returns the parameters for HybridSlowStart.SetParameters.
+// Values that are not positive select the defaults.
+func (c *Config) hybridSlowStartParameters() (minSamples uint32, delayMinThreshold, delayMaxThreshold time.Duration) {
+	return uint32(max(c.HybridSlowStartMinSamples, 0)), max(c.HybridSlowStartMinDelayThreshold, 0), max(c.HybridSlowStartMaxDelayThreshold, 0)
+}
+
+// cubicParameters returns beta and betaLastMax for CUBIC.
+// Values outside of the range (0, 1) select the defaults.
+func (c *Config) cubicParameters() (float32, float32) {
+	b, bLastMax := beta, betaLastMax
+	if c.CubicBeta > 0 && c.CubicBeta < 1 {
+		b = float32(c.CubicBeta)
+	}
+	if c.CubicBetaLastMax > 0 && c.CubicBetaLastMax < 1 {
+		bLastMax = float32(c.CubicBetaLastMax)
+	}
+	return b, bLastMax
+}
@@ -9,13 +9,23 @@ import (
 )
 
 const (
-	cubeScale                 = 40
+	cubeScale = 40
+	// cubeCongestionWindowScale is the default scaling constant C of the cubic function (0.4, see RFC 9438),
+	// in units of 1/1024
 	cubeCongestionWindowScale = 410
-	cubeFactor                = 1 << cubeScale / cubeCongestionWindowScale / maxDatagramSize
 	maxDatagramSize           = protocol.ByteCount(protocol.InitialPacketSize)
 )
 
 const defaultNumConnections = 1
+
+// maxCubicOffset is the maximum distance from the origin point used to evaluate the cubic function,
+// in units of 1/1024 seconds (about 4.5 minutes). It keeps cubeCongestionWindowScale*offset^3 within the range of an int64.
+// Far from the origin point, the window growth is limited by the bytes acknowledged anyway.
+// For larger scaling constants, the maximum offset is reduced accordingly.
+const maxCubicOffset = 1 << 18
+
+// Default values for the multiplicative decrease factor, and for the factor applied to
+// the last maximum congestion window during fast convergence.
 const beta float32 = 0.7
 const betaLastMax float32 = 0.85
 
@@ -29,12 +39,36 @@ type Cubic struct {
 	originPointCongestionWindow  protocol.ByteCount
 	timeToOriginPoint            uint32
 	lastTargetCongestionWindow   protocol.ByteCount
+	maxDatagramSize              protocol.ByteCount
+	cubicBeta                    float32
+	cubicBetaLastMax             float32
+	tcpFriendly                  bool
+	// the scaling constant of the cubic function, in units of 1/1024,
+	// and the maximum offset from the origin point that can be evaluated with it
+	congestionWindowScale int64
+	maxOffset             int64
+
+	// the last maximum congestion window before the last packet loss, restored if the loss was spurious
+	priorLastMaxCongestionWindow protocol.ByteCount
 }
 
-func NewCubic(clock Clock) *Cubic {
+// NewCubic creates a new Cubic. congestionWindowScale is the scaling constant C of the cubic function,
+// in units of 1/1024. If it is not positive, the default of 410 (C = 0.4) is used.
+// A larger scale makes the window grow faster away from the last maximum, and return to it more quickly after a loss.
+// Changing it also changes how the bandwidth is shared with other CUBIC flows.
+func NewCubic(clock Clock, congestionWindowScale int64) *Cubic {
+	if congestionWindowScale <= 0 {
+		congestionWindowScale = cubeCongestionWindowScale
+	}
 	c := &Cubic{
-		clock:          clock,
-		numConnections: defaultNumConnections,
+		clock:                 clock,
+		numConnections:        defaultNumConnections,
+		maxDatagramSize:       maxDatagramSize,
+		cubicBeta:             beta,
+		cubicBetaLastMax:      betaLastMax,
+		tcpFriendly:           true,
+		congestionWindowScale: congestionWindowScale,
+		maxOffset:             min(maxCubicOffset, int64(math.Cbrt(float64(math.MaxInt64/congestionWindowScale)))),
 	}
 	c.Reset()
 	return c
@@ -43,6 +77,7 @@ func NewCubic(clock Clock) *Cubic {
 func (c *Cubic) Reset() {
 	c.epoch = 0
 	c.lastMaxCongestionWindow = 0
+	c.priorLastMaxCongestionWindow = 0
 	c.ackedBytesCount = 0
 	c.estimatedTCPcongestionWindow = 0
 	c.originPointCongestionWindow = 0
@@ -50,17 +85,22 @@ func (c *Cubic) Reset() {
 	c.lastTargetCongestionWindow = 0
 }
 
+// cubeFactor is the scaling factor of the cubic function, in units of the max datagram size.
+func (c *Cubic) cubeFactor() protocol.ByteCount {
+	return 1 << cubeScale / protocol.ByteCount(c.congestionWindowScale) / c.maxDatagramSize
+}
+
 func (c *Cubic) alpha() float32 {
 	b := c.beta()
 	return 3 * float32(c.numConnections) * float32(c.numConnections) * (1 - b) / (1 + b)
 }
 
 func (c *Cubic) beta() float32 {
-	return (float32(c.numConnections) - 1 + beta) / float32(c.numConnections)
+	return (float32(c.numConnections) - 1 + c.cubicBeta) / float32(c.numConnections)
 }
 
 func (c *Cubic) betaLastMax() float32 {
-	return (float32(c.numConnections) - 1 + betaLastMax) / float32(c.numConnections)
+	return (float32(c.numConnections) - 1 + c.cubicBetaLastMax) / float32(c.numConnections)
 }
 
 func (c *Cubic) OnApplicationLimited() {
@@ -68,7 +108,8 @@ func (c *Cubic) OnApplicationLimited() {
 }
 
 func (c *Cubic) CongestionWindowAfterPacketLoss(currentCongestionWindow protocol.ByteCount) protocol.ByteCount {
-	if currentCongestionWindow+maxDatagramSize < c.lastMaxCongestionWindow {
+	c.priorLastMaxCongestionWindow = c.lastMaxCongestionWindow
+	if currentCongestionWindow+c.maxDatagramSize < c.lastMaxCongestionWindow {
 		c.lastMaxCongestionWindow = protocol.ByteCount(c.betaLastMax() * float32(currentCongestionWindow))
 	} else {
 		c.lastMaxCongestionWindow = currentCongestionWindow
@@ -77,6 +118,13 @@ func (c *Cubic) CongestionWindowAfterPacketLoss(currentCongestionWindow protocol
 	return protocol.ByteCount(float32(currentCongestionWindow) * c.beta())
 }
 
+// UndoPacketLoss reverts the effect of the last call to CongestionWindowAfterPacketLoss.
+// The cubic epoch is restarted from the restored congestion window.
+func (c *Cubic) UndoPacketLoss() {
+	c.lastMaxCongestionWindow = c.priorLastMaxCongestionWindow
+	c.epoch = 0
+}
+
 func (c *Cubic) CongestionWindowAfterAck(
 	ackedBytes protocol.ByteCount,
 	currentCongestionWindow protocol.ByteCount,
@@ -93,7 +141,7 @@ func (c *Cubic) CongestionWindowAfterAck(
 			c.timeToOriginPoint = 0
 			c.originPointCongestionWindow = currentCongestionWindow
 		} else {
-			c.timeToOriginPoint = uint32(math.Cbrt(float64(cubeFactor * (c.lastMaxCongestionWindow - currentCongestionWindow))))
+			c.timeToOriginPoint = uint32(math.Cbrt(float64(c.cubeFactor() * (c.lastMaxCongestionWindow - currentCongestionWindow))))
 			c.originPointCongestionWindow = c.lastMaxCongestionWindow
 		}
 	}
@@ -103,8 +151,15 @@ func (c *Cubic) CongestionWindowAfterAck(
 	if offset < 0 {
 		offset = -offset
 	}
+	offset = min(offset, c.maxOffset)
 
-	deltaCongestionWindow := protocol.ByteCount(cubeCongestionWindowScale*offset*offset*offset) * maxDatagramSize >> cubeScale
+	var deltaCongestionWindow protocol.ByteCount
+	if cube := c.congestionWindowScale * offset * offset * offset; cube <= math.MaxInt64/int64(c.maxDatagramSize) {
+		deltaCongestionWindow = protocol.ByteCount(cube) * c.maxDatagramSize >> cubeScale
+	} else {
+		// Multiplying first would overflow. At this magnitude, the rounding error of shifting first is negligible.
+		deltaCongestionWindow = protocol.ByteCount(cube>>cubeScale) * c.maxDatagramSize
+	}
 	var targetCongestionWindow protocol.ByteCount
 	if elapsedTime > int64(c.timeToOriginPoint) {
 		targetCongestionWindow = c.originPointCongestionWindow + deltaCongestionWindow
@@ -112,16 +167,36 @@ func (c *Cubic) CongestionWindowAfterAck(
 		targetCongestionWindow = c.originPointCongestionWindow - deltaCongestionWindow
 	}
 	targetCongestionWindow = min(targetCongestionWindow, currentCongestionWindow+c.ackedBytesCount/2)
-	c.estimatedTCPcongestionWindow += protocol.ByteCount(float32(c.ackedBytesCount) * c.alpha() * float32(maxDatagramSize) / float32(c.estimatedTCPcongestionWindow))
+	c.estimatedTCPcongestionWindow += protocol.ByteCount(float32(c.ackedBytesCount) * c.alpha() * float32(c.maxDatagramSize) / float32(c.estimatedTCPcongestionWindow))
 	c.ackedBytesCount = 0
 	c.lastTargetCongestionWindow = targetCongestionWindow
 
-	if targetCongestionWindow < c.estimatedTCPcongestionWindow {
+	if c.tcpFriendly && targetCongestionWindow < c.estimatedTCPcongestionWindow {
 		targetCongestionWindow = c.estimatedTCPcongestionWindow
 	}
 	return targetCongestionWindow
 }
 
+// SetParameters sets the multiplicative decrease factor (beta), and the factor applied to the last
+// maximum congestion window when a loss occurs before the window recovered to its previous maximum
+// (betaLastMax, used for fast convergence). Both values must be in the range (0, 1).
+func (c *Cubic) SetParameters(beta, betaLastMax float32) {
+	c.cubicBeta = beta
+	c.cubicBetaLastMax = betaLastMax
+}
+
+// SetTCPFriendliness sets whether the window is kept at least at the window a Reno sender would have.
+// Without it, the window only follows the cubic function.
+func (c *Cubic) SetTCPFriendliness(enabled bool) {
+	c.tcpFriendly = enabled
+}
+
 func (c *Cubic) SetNumConnections(n int) {
 	c.numConnections = n
 }
+
+// SetMaxDatagramSize sets the max datagram size used to scale the cubic function.
+// It is called when the path MTU changes.
+func (c *Cubic) SetMaxDatagramSize(s protocol.ByteCount) {
+	c.maxDatagramSize = s
+}
@@ -2,6 +2,8 @@ package congestion
 
 import (
 	"fmt"
+	"log/slog"
+	"slices"
 	"time"
 
 	"github.com/quic-go/quic-go/internal/monotime"
@@ -17,6 +19,11 @@ const (
 	renoBeta                   = 0.7
 	minCongestionWindowPackets = 2
 	initialCongestionWindow    = 32
+	// the pacing gains applied to the bandwidth estimate in slow start and in congestion avoidance
+	defaultSlowStartPacingGain           = 2.0
+	defaultCongestionAvoidancePacingGain = 1.25
+	// the limit L of Appropriate Byte Counting (RFC 3465): the maximum window growth per ACK in slow start, in packets
+	abcLimit = 2
 
 	// 新增：功能优化常量
 	minBandwidthLimit      = 5 * 1024 * 1024 // 5Mbps
@@ -32,6 +39,12 @@ type cubicSender struct {
 	clock           Clock
 
 	reno bool
+	// the multiplicative decrease factor used in Reno mode
+	renoBeta float64
+	// the number of TCP connections emulated by the Reno sender
+	numConnections int
+	// use Appropriate Byte Counting (RFC 3465) in Reno mode
+	byteCounting bool
 
 	largestSentPacketNumber  protocol.PacketNumber
 	largestAckedPacketNumber protocol.PacketNumber
@@ -41,14 +54,95 @@ type cubicSender struct {
 	congestionWindow           protocol.ByteCount
 	slowStartThreshold         protocol.ByteCount
 	numAckedPackets            uint64
+	numAckedBytes              protocol.ByteCount
 
 	initialCongestionWindow    protocol.ByteCount
 	initialMaxCongestionWindow protocol.ByteCount
 
-	maxDatagramSize protocol.ByteCount
+	initialMaxDatagramSize protocol.ByteCount
+	maxDatagramSize        protocol.ByteCount
+	maxDatagramSizeCeiling protocol.ByteCount
+
+	onCongestionWindowChange func(old, new protocol.ByteCount)
+	windowPolicy             WindowPolicy
+
+	slowStartPacingGain           float64
+	congestionAvoidancePacingGain float64
+
+	minRatePolicy  MinRatePolicy
+	minRatePackets protocol.ByteCount
+	// minRateProtectionActive 表示当前窗口是否由最小速率保护抬高到下限，
+	// 窗口增长超过下限后清除
+	minRateProtectionActive bool
+
+	lossTolerancePolicy LossTolerancePolicy
+	// 拥塞周期：lossEpisodeRounds 是当前周期内发生丢包的 RTT 轮数，
+	// 上次丢包后经过一个 RTT 没有丢包时，周期结束，计数归零
+	lossEpisodeRounds     int
+	lossEpisodeRoundStart monotime.Time
+	lastLossTime          monotime.Time
+	// 容忍丢包时保持窗口不增长：在确认到最后一次被容忍的丢包之后发出的数据包之前，窗口不增长
+	holdWindowOnToleratedLoss  bool
+	largestSentAtToleratedLoss protocol.PacketNumber
+
+	// 初始慢启动保护：慢启动持续 minSlowStartDuration 且确认 minSlowStartBytes 之前，丢包不结束慢启动。
+	// 保护只作用于连接的初始慢启动，一旦结束（或超时、空闲重启）就不再生效
+	minSlowStartDuration  time.Duration
+	minSlowStartBytes     protocol.ByteCount
+	slowStartStartTime    monotime.Time
+	slowStartAckedBytes   protocol.ByteCount
+	slowStartGuardExpired bool
+
+	// while probing for bandwidth, the congestion window grows even if the sender is not cwnd-limited
+	probeUntil monotime.Time
+
+	// the time the last retransmittable packet was sent, and the idle period after which the window is restarted
+	lastSentTime         monotime.Time
+	idleRestartThreshold time.Duration
+
+	// if non-zero, the congestion window is frozen at this value, see FreezeCongestionWindow
+	frozenCongestionWindow protocol.ByteCount
+
+	// The state before the last window reduction.
+	// It is restored if all packets declared lost during the recovery period turn out to be spurious losses.
+	undo cubicUndoState
 
 	lastState qlog.CongestionState
-	qlogger   qlogwriter.Recorder
+	// when the transition to the recovery state was logged, see maybeQlogStateChange
+	lastRecoveryQlogTime monotime.Time
+	qlogger              qlogwriter.Recorder
+	logger               *slog.Logger
+}
+
+type cubicUndoState struct {
+	congestionWindow         protocol.ByteCount
+	slowStartThreshold       protocol.ByteCount
+	largestSentAtLastCutback protocol.PacketNumber
+	// the packets declared lost during the recovery period that were not yet acknowledged
+	lost []protocol.PacketNumber
+}
+
+// If more packets are lost during a recovery period, the losses are unlikely to all be spurious.
+const maxUndoLostPackets = 32
+
+func (s *cubicUndoState) Valid() bool { return s.congestionWindow > 0 }
+
+func (s *cubicUndoState) OnPacketLost(pn protocol.PacketNumber) {
+	if len(s.lost) == maxUndoLostPackets {
+		*s = cubicUndoState{}
+		return
+	}
+	s.lost = append(s.lost, pn)
+}
+
+// OnSpuriousLoss returns true if all packets declared lost have been acknowledged.
+func (s *cubicUndoState) OnSpuriousLoss(pn protocol.PacketNumber) bool {
+	idx := slices.Index(s.lost, pn)
+	if idx == -1 {
+		return false
+	}
+	s.lost = slices.Delete(s.lost, idx, idx+1)
+	return len(s.lost) == 0
 }
 
 var (
@@ -56,30 +150,81 @@ var (
 	_ SendAlgorithmWithDebugInfos = &cubicSender{}
 )
 
-func NewCubicSender(clock Clock, rttStats *utils.RTTStats, connStats *utils.ConnectionStats, initialMaxDatagramSize protocol.ByteCount, reno bool, qlogger qlogwriter.Recorder) *cubicSender {
-	return newCubicSender(clock, rttStats, connStats, reno, initialMaxDatagramSize, initialCongestionWindow*initialMaxDatagramSize, protocol.MaxCongestionWindowPackets*initialMaxDatagramSize, qlogger)
+// NewCubicSender creates a CUBIC sender, or a Reno sender if reno is set.
+// The loss tolerance uses the bytes sent and lost recorded in connStats. If connStats is nil,
+// every congestion event reduces the congestion window, as in standard CUBIC and Reno.
+func NewCubicSender(clock Clock, rttStats *utils.RTTStats, connStats *utils.ConnectionStats, initialMaxDatagramSize protocol.ByteCount, reno bool, conf *Config, qlogger qlogwriter.Recorder) *cubicSender {
+	if conf == nil {
+		conf = &Config{}
+	}
+	initialMaxDatagramSize = min(initialMaxDatagramSize, conf.maxDatagramSizeCeiling())
+	c := newCubicSender(clock, rttStats, connStats, reno, initialMaxDatagramSize, conf.initialCongestionWindow(initialMaxDatagramSize), protocol.MaxCongestionWindowPackets*initialMaxDatagramSize, conf, qlogger)
+	c.resume(conf.ResumeCongestionWindow, conf.ResumeSlowStartThreshold)
+	return c
 }
 
-func newCubicSender(clock Clock, rttStats *utils.RTTStats, connStats *utils.ConnectionStats, reno bool, initialMaxDatagramSize, initialCongestionWindow, initialMaxCongestionWindow protocol.ByteCount, qlogger qlogwriter.Recorder) *cubicSender {
+// resume seeds the congestion window and the slow start threshold with the values of a previous connection.
+// Similar to careful resume, only half of the previous congestion window is used,
+// since the path might have changed since then.
+// The initial congestion window is not modified, so a connection migration still starts from scratch.
+func (c *cubicSender) resume(congestionWindow, slowStartThreshold protocol.ByteCount) {
+	if congestionWindow > 0 {
+		c.congestionWindow = min(max(congestionWindow/2, c.initialCongestionWindow), c.maxCongestionWindow())
+	}
+	if slowStartThreshold > 0 && slowStartThreshold < protocol.MaxByteCount {
+		c.slowStartThreshold = min(max(slowStartThreshold, c.minCongestionWindow()), c.maxCongestionWindow())
+	}
+}
+
+func newCubicSender(clock Clock, rttStats *utils.RTTStats, connStats *utils.ConnectionStats, reno bool, initialMaxDatagramSize, initialCongestionWindow, initialMaxCongestionWindow protocol.ByteCount, conf *Config, qlogger qlogwriter.Recorder) *cubicSender {
+	if conf == nil {
+		conf = &Config{}
+	}
 	c := &cubicSender{
 		rttStats:                   rttStats,
 		connStats:                  connStats,
 		largestSentPacketNumber:    protocol.InvalidPacketNumber,
 		largestAckedPacketNumber:   protocol.InvalidPacketNumber,
 		largestSentAtLastCutback:   protocol.InvalidPacketNumber,
+		largestSentAtToleratedLoss: protocol.InvalidPacketNumber,
 		initialCongestionWindow:    initialCongestionWindow,
 		initialMaxCongestionWindow: initialMaxCongestionWindow,
 		congestionWindow:           initialCongestionWindow,
 		slowStartThreshold:         protocol.MaxByteCount,
-		cubic:                      NewCubic(clock),
+		cubic:                      NewCubic(clock, int64(conf.CubicCongestionWindowScale)),
 		clock:                      clock,
 		reno:                       reno,
+		renoBeta:                   conf.renoBeta(),
+		numConnections:             conf.numEmulatedConnections(),
+		byteCounting:               conf.RenoByteCounting,
 		qlogger:                    qlogger,
+		logger:                     conf.Logger,
+		initialMaxDatagramSize:     initialMaxDatagramSize,
 		maxDatagramSize:            initialMaxDatagramSize,
+		maxDatagramSizeCeiling:     conf.maxDatagramSizeCeiling(),
+		onCongestionWindowChange:   conf.OnCongestionWindowChange,
+		windowPolicy:               conf.WindowPolicy,
+		minRatePolicy:              conf.MinRatePolicy,
+		minRatePackets:             conf.minRatePackets(),
+		lossTolerancePolicy:        conf.LossTolerancePolicy,
+		holdWindowOnToleratedLoss:  conf.HoldWindowOnToleratedLoss,
+		idleRestartThreshold:       conf.IdleRestartThreshold,
+		minSlowStartDuration:       conf.MinSlowStartDuration,
+		minSlowStartBytes:          conf.MinSlowStartBytes,
 	}
-	c.pacer = newPacer(c.BandwidthEstimate)
+	c.cubic.SetMaxDatagramSize(initialMaxDatagramSize)
+	c.cubic.SetParameters(conf.cubicParameters())
+	c.cubic.SetTCPFriendliness(!conf.DisableCubicTCPFriendliness)
+	c.hybridSlowStart.SetParameters(conf.hybridSlowStartParameters())
+	c.cubic.SetNumConnections(c.numConnections)
+	c.slowStartPacingGain, c.congestionAvoidancePacingGain = conf.pacingGains()
+	c.pacer = newRatePacer(c.pacingRate)
+	c.pacer.SetMaxBandwidth(conf.MaxPacingRate)
+	c.pacer.SetMaxBurst(conf.MaxPacingBurst)
+	c.pacer.SetDisabled(conf.DisablePacing)
+	c.pacer.SetMaxDatagramSize(initialMaxDatagramSize)
+	c.lastState = qlog.CongestionStateSlowStart
 	if c.qlogger != nil {
-		c.lastState = qlog.CongestionStateSlowStart
 		c.qlogger.RecordEvent(qlog.CongestionStateUpdated{State: qlog.CongestionStateSlowStart})
 	}
 	return c
@@ -88,6 +233,9 @@ func newCubicSender(clock Clock, rttStats *utils.RTTStats, connStats *utils.Conn
 func (c *cubicSender) TimeUntilSend(_ protocol.ByteCount) monotime.Time {
 	return c.pacer.TimeUntilSend()
 }
+func (c *cubicSender) PacingBudget(now monotime.Time) protocol.ByteCount {
+	return c.pacer.Budget(now)
+}
 func (c *cubicSender) HasPacingBudget(now monotime.Time) bool {
 	return c.pacer.Budget(now) >= c.maxDatagramSize
 }
@@ -98,23 +246,60 @@ func (c *cubicSender) minCongestionWindow() protocol.ByteCount {
 	return c.maxDatagramSize * minCongestionWindowPackets
 }
 
-func (c *cubicSender) OnPacketSent(sentTime monotime.Time, _ protocol.ByteCount, packetNumber protocol.PacketNumber, bytes protocol.ByteCount, isRetransmittable bool) {
+func (c *cubicSender) OnPacketSent(sentTime monotime.Time, bytesInFlight protocol.ByteCount, packetNumber protocol.PacketNumber, bytes protocol.ByteCount, isRetransmittable bool) {
 	c.pacer.SentPacket(sentTime, bytes)
 	if !isRetransmittable {
 		return
 	}
+	// bytesInFlight includes this packet
+	if bytesInFlight <= bytes {
+		c.maybeRestartAfterIdle(sentTime)
+	}
+	c.lastSentTime = sentTime
+	if c.slowStartStartTime.IsZero() {
+		c.slowStartStartTime = sentTime
+	}
 	c.largestSentPacketNumber = packetNumber
 	c.hybridSlowStart.OnPacketSent(packetNumber)
 }
 
+// maybeRestartAfterIdle restarts the congestion window when the sender resumes sending after an idle period.
+// After an idle period, the congestion window (and the bandwidth estimate derived from it) reflects the state
+// of the network before the idle period, which might be wildly wrong by now.
+// Following RFC 5681, Section 4.1, the window is reduced to the initial window, such that the sender re-probes
+// the path instead of sending a burst at a stale rate. As in RFC 7661, the slow start threshold remembers
+// 3/4 of the previous window, so the window quickly grows back if the network conditions didn't change.
+func (c *cubicSender) maybeRestartAfterIdle(now monotime.Time) {
+	if c.idleRestartThreshold <= 0 || c.lastSentTime.IsZero() || now.Sub(c.lastSentTime) < c.idleRestartThreshold {
+		return
+	}
+	if c.frozenCongestionWindow > 0 || c.congestionWindow <= c.initialCongestionWindow {
+		return
+	}
+	oldCongestionWindow := c.congestionWindow
+	c.slowStartThreshold = max(c.slowStartThreshold, c.congestionWindow*3/4)
+	c.congestionWindow = c.initialCongestionWindow
+	c.slowStartGuardExpired = true
+	c.cubic.OnApplicationLimited()
+	c.hybridSlowStart.Restart()
+	c.maybeQlogStateChange(qlog.CongestionStateSlowStart)
+	c.maybeNotifyCongestionWindowChange(oldCongestionWindow)
+}
+
 func (c *cubicSender) CanSend(bytesInFlight protocol.ByteCount) bool {
 	return bytesInFlight < c.GetCongestionWindow()
 }
 func (c *cubicSender) InRecovery() bool {
 	return c.largestAckedPacketNumber != protocol.InvalidPacketNumber && c.largestAckedPacketNumber <= c.largestSentAtLastCutback
 }
-func (c *cubicSender) InSlowStart() bool                       { return c.GetCongestionWindow() < c.slowStartThreshold }
-func (c *cubicSender) GetCongestionWindow() protocol.ByteCount { return c.congestionWindow }
+func (c *cubicSender) InSlowStart() bool { return c.GetCongestionWindow() < c.slowStartThreshold }
+func (c *cubicSender) GetCongestionWindow() protocol.ByteCount {
+	if c.frozenCongestionWindow > 0 {
+		return c.frozenCongestionWindow
+	}
+	return c.congestionWindow
+}
+func (c *cubicSender) SlowStartThreshold() protocol.ByteCount { return c.slowStartThreshold }
 func (c *cubicSender) MaybeExitSlowStart() {
 	if c.InSlowStart() && c.hybridSlowStart.ShouldExitSlowStart(c.rttStats.LatestRTT(), c.rttStats.MinRTT(), c.GetCongestionWindow()/c.maxDatagramSize) {
 		c.slowStartThreshold = c.congestionWindow
@@ -130,66 +315,217 @@ func (c *cubicSender) OnPacketAcked(ackedPacketNumber protocol.PacketNumber, ack
 	c.maybeIncreaseCwnd(ackedPacketNumber, ackedBytes, priorInFlight, eventTime)
 	if c.InSlowStart() {
 		c.hybridSlowStart.OnPacketAcked(ackedPacketNumber)
+		if !c.slowStartGuardExpired {
+			c.slowStartAckedBytes += ackedBytes
+		}
 	}
 }
 
 // 核心优化：OnCongestionEvent
-func (c *cubicSender) OnCongestionEvent(packetNumber protocol.PacketNumber, lostBytes, priorInFlight protocol.ByteCount) {
-	c.connStats.PacketsLost.Add(1)
-	c.connStats.BytesLost.Add(uint64(lostBytes))
+func (c *cubicSender) OnCongestionEvent(packetNumber protocol.PacketNumber, lostBytes, priorInFlight protocol.ByteCount, _ TrafficClass, reorderingLikely bool) {
+	if c.frozenCongestionWindow > 0 {
+		return
+	}
+	// 丢包检测认为该包很可能只是被乱序（乱序距离不超过此前观测到的最大乱序距离），
+	// 不论总体丢包率如何都不削减窗口，也不计入拥塞周期
+	if reorderingLikely {
+		logToleratedLoss(c.logger, packetNumber, lostBytes)
+		return
+	}
+	c.updateLossEpisode()
 
 	if packetNumber <= c.largestSentAtLastCutback {
+		if c.undo.Valid() {
+			c.undo.OnPacketLost(packetNumber)
+		}
 		return
 	}
 
-	// 优化1：10% 丢包容忍度
-	// 使用 connStats 中的总发送字节和总丢包字节计算丢包率
-	totalSent := c.connStats.BytesSent.Load()
-	totalLost := c.connStats.BytesLost.Load()
-	if totalSent > 0 && float64(totalLost)/float64(totalSent) < lossToleranceThreshold {
-		// 丢包率低于10%，视为网络抖动或非拥塞丢包，不进行窗口削减
+	// 初始慢启动的早期丢包（例如第一个 RTT 内的瞬时丢包）不结束慢启动
+	if c.inSlowStartGuard() {
+		logToleratedLoss(c.logger, packetNumber, lostBytes)
+		return
+	}
+
+	// 优化1：丢包容忍
+	if c.isToleratedLoss() {
+		// 视为网络抖动或非拥塞丢包，不进行窗口削减
+		if c.holdWindowOnToleratedLoss {
+			c.largestSentAtToleratedLoss = c.largestSentPacketNumber
+		}
+		logToleratedLoss(c.logger, packetNumber, lostBytes)
 		return
 	}
+	c.cutBack(packetNumber)
+}
 
+// onHandOffLoss handles the loss that made the hybridSender hand off to this sender.
+// Hysteria already judged it to be caused by congestion, so it is never tolerated:
+// the loss tolerance would compare it to all data sent during the ramp, and the window would not be cut.
+func (c *cubicSender) onHandOffLoss(packetNumber protocol.PacketNumber) {
+	if c.frozenCongestionWindow > 0 {
+		return
+	}
+	c.updateLossEpisode()
+	c.cutBack(packetNumber)
+}
+
+// cutBack reduces the congestion window in response to a loss, and enters recovery.
+func (c *cubicSender) cutBack(packetNumber protocol.PacketNumber) {
 	c.lastCutbackExitedSlowstart = c.InSlowStart()
 	c.maybeQlogStateChange(qlog.CongestionStateRecovery)
 
+	oldCongestionWindow := c.congestionWindow
+	c.undo = cubicUndoState{
+		congestionWindow:         c.congestionWindow,
+		slowStartThreshold:       c.slowStartThreshold,
+		largestSentAtLastCutback: c.largestSentAtLastCutback,
+		lost:                     []protocol.PacketNumber{packetNumber},
+	}
 	if c.reno {
-		c.congestionWindow = protocol.ByteCount(float64(c.congestionWindow) * renoBeta)
+		c.congestionWindow = protocol.ByteCount(float64(c.congestionWindow) * c.beta())
 	} else {
 		c.congestionWindow = c.cubic.CongestionWindowAfterPacketLoss(c.congestionWindow)
 	}
 
 	// 优化2：5Mbps 最小速率保护
 	c.applyMinRateProtection()
+	c.applyWindowPolicy(oldCongestionWindow)
 
 	c.slowStartThreshold = c.congestionWindow
 	c.largestSentAtLastCutback = c.largestSentPacketNumber
 	c.numAckedPackets = 0
+	c.numAckedBytes = 0
+	logWindowReduction(c.logger, packetNumber, oldCongestionWindow, c.congestionWindow)
+	c.maybeNotifyCongestionWindowChange(oldCongestionWindow)
 }
 
-// applyMinRateProtection 确保 CWND 不低于维持 5Mbps 所需的 BDP
-func (c *cubicSender) applyMinRateProtection() {
-	srtt := c.rttStats.SmoothedRTT()
-	if srtt <= 0 {
-		srtt = 100 * time.Millisecond // 兜底 RTT
+// updateLossEpisode 记录一次丢包，并更新当前拥塞周期内发生丢包的 RTT 轮数。
+func (c *cubicSender) updateLossEpisode() {
+	now := c.clock.Now()
+	rtt := c.rttStats.SmoothedRTT()
+	if rtt == 0 {
+		rtt = c.rttStats.InitialRTT()
+	}
+	switch {
+	case c.lossEpisodeRounds == 0 || now.Sub(c.lastLossTime) > rtt:
+		// 上次丢包后一个 RTT 内没有丢包，开始新的拥塞周期
+		c.lossEpisodeRounds = 1
+		c.lossEpisodeRoundStart = now
+	case now.Sub(c.lossEpisodeRoundStart) > rtt:
+		c.lossEpisodeRounds++
+		c.lossEpisodeRoundStart = now
 	}
-	// BDP = (Bandwidth in bps * RTT in seconds) / 8 bits per byte
-	minCwnd := protocol.ByteCount((float64(minBandwidthLimit) * srtt.Seconds()) / 8)
+	c.lastLossTime = now
+}
 
-	// 取系统默认最小窗口与 5Mbps 对应窗口的较大值
-	absoluteMin := c.minCongestionWindow()
-	if minCwnd < absoluteMin {
-		minCwnd = absoluteMin
+// inSlowStartGuard 判断初始慢启动保护是否仍然生效。
+// 同时设置了最短时长和字节数时，两个条件都满足后保护才结束。
+func (c *cubicSender) inSlowStartGuard() bool {
+	if c.slowStartGuardExpired {
+		return false
 	}
+	if c.InSlowStart() {
+		if c.minSlowStartDuration > 0 && c.clock.Now().Sub(c.slowStartStartTime) < c.minSlowStartDuration {
+			return true
+		}
+		if c.minSlowStartBytes > 0 && c.slowStartAckedBytes < c.minSlowStartBytes {
+			return true
+		}
+	}
+	c.slowStartGuardExpired = true
+	return false
+}
+
+// isToleratedLoss 判断是否容忍本次拥塞事件，不削减窗口。
+// LossTolerancePolicyLossRate：使用 connStats 中的总发送字节（不含重传，否则重传会稀释丢包率）和总丢包字节计算丢包率，
+// 低于容忍度时不削减窗口。
+// 没有 connStats 时无法计算丢包率，每次拥塞事件都削减窗口。
+// connStats 被 Reset 后，在重新发送数据之前同样无法计算丢包率；重置前发出的数据包的丢失计入新的统计，
+// 只会高估丢包率，不会导致错误地容忍拥塞。
+// LossTolerancePolicyEpisode：只容忍拥塞周期第一个 RTT 内的丢包。
+func (c *cubicSender) isToleratedLoss() bool {
+	if c.lossTolerancePolicy == LossTolerancePolicyEpisode {
+		return c.lossEpisodeRounds <= 1
+	}
+	if c.connStats == nil {
+		return false
+	}
+	totalSent := c.connStats.UniqueBytesSent()
+	totalLost := c.connStats.BytesLost.Load()
+	return totalSent > 0 && float64(totalLost)/float64(totalSent) < lossToleranceThreshold
+}
+
+// beta is the multiplicative decrease factor of Reno, emulating numConnections connections:
+// only one of the emulated connections reduces its window in response to a loss.
+func (c *cubicSender) beta() float64 {
+	return (float64(c.numConnections) - 1 + c.renoBeta) / float64(c.numConnections)
+}
 
+// applyMinRateProtection 确保 CWND 不低于最小速率策略给出的下限：
+// 默认为维持 5Mbps 所需的 BDP，也可配置为固定的包数（与 RTT 无关，避免高 RTT 路径上窗口过大）
+func (c *cubicSender) applyMinRateProtection() {
+	minCwnd := c.minRateWindow()
 	if c.congestionWindow < minCwnd {
 		c.congestionWindow = minCwnd
+		c.setMinRateProtectionActive(true)
+		return
+	}
+	c.setMinRateProtectionActive(false)
+}
+
+// maybeClearMinRateProtection 在窗口超过最小速率保护的下限后清除保护状态
+func (c *cubicSender) maybeClearMinRateProtection() {
+	if c.minRateProtectionActive && c.congestionWindow > c.minRateWindow() {
+		c.setMinRateProtectionActive(false)
+	}
+}
+
+func (c *cubicSender) setMinRateProtectionActive(active bool) {
+	c.minRateProtectionActive = active
+	if c.connStats != nil {
+		c.connStats.MinRateProtectionActive.Store(active)
+	}
+}
+
+// MinRateProtectionActive says if the congestion window is currently held at the floor of the minimum rate protection,
+// i.e. if it doesn't reflect the capacity of the path.
+func (c *cubicSender) MinRateProtectionActive() bool {
+	return c.minRateProtectionActive
+}
+
+// applyWindowPolicy lets the window policy veto or modify the reduction of the congestion window from current.
+func (c *cubicSender) applyWindowPolicy(current protocol.ByteCount) {
+	if c.windowPolicy == nil {
+		return
 	}
+	allowed := c.windowPolicy.AllowedCongestionWindow(current, c.congestionWindow)
+	c.congestionWindow = min(max(allowed, c.minCongestionWindow()), current)
+}
+
+// minRateWindow 返回最小速率保护下的窗口下限
+func (c *cubicSender) minRateWindow() protocol.ByteCount {
+	var minCwnd protocol.ByteCount
+	switch c.minRatePolicy {
+	case MinRatePolicyPackets:
+		minCwnd = c.minRatePackets * c.maxDatagramSize
+	default:
+		srtt := c.rttStats.SmoothedRTT()
+		if srtt <= 0 {
+			srtt = c.rttStats.InitialRTT() // 兜底 RTT
+		}
+		minCwnd = BDP(minBandwidthLimit*BitsPerSecond, srtt)
+	}
+
+	// 取系统默认最小窗口与策略下限的较大值，但不超过最大窗口（RTT 极高时 BDP 可能超过最大窗口）
+	return min(max(minCwnd, c.minCongestionWindow()), c.maxCongestionWindow())
 }
 
-func (c *cubicSender) maybeIncreaseCwnd(_ protocol.PacketNumber, ackedBytes protocol.ByteCount, priorInFlight protocol.ByteCount, eventTime monotime.Time) {
-	if !c.isCwndLimited(priorInFlight) {
+func (c *cubicSender) maybeIncreaseCwnd(ackedPacketNumber protocol.PacketNumber, ackedBytes protocol.ByteCount, priorInFlight protocol.ByteCount, eventTime monotime.Time) {
+	if c.frozenCongestionWindow > 0 {
+		return
+	}
+	if !c.isCwndLimited(priorInFlight) && !eventTime.Before(c.probeUntil) {
 		c.cubic.OnApplicationLimited()
 		c.maybeQlogStateChange(qlog.CongestionStateApplicationLimited)
 		return
@@ -197,21 +533,66 @@ func (c *cubicSender) maybeIncreaseCwnd(_ protocol.PacketNumber, ackedBytes prot
 	if c.congestionWindow >= c.maxCongestionWindow() {
 		return
 	}
+	// 丢包仍在被容忍：不削减窗口，但也不继续增长
+	if ackedPacketNumber <= c.largestSentAtToleratedLoss {
+		return
+	}
+	oldCongestionWindow := c.congestionWindow
 	if c.InSlowStart() {
-		c.congestionWindow += c.maxDatagramSize
+		if c.reno && c.byteCounting {
+			c.congestionWindow = min(c.maxCongestionWindow(), c.congestionWindow+min(ackedBytes, abcLimit*c.maxDatagramSize))
+		} else {
+			c.congestionWindow += c.maxDatagramSize
+		}
 		c.maybeQlogStateChange(qlog.CongestionStateSlowStart)
+		if c.onCongestionWindowChange != nil || c.minRateProtectionActive {
+			c.onCongestionWindowIncreased(oldCongestionWindow)
+		}
 		return
 	}
 	c.maybeQlogStateChange(qlog.CongestionStateCongestionAvoidance)
-	if c.reno {
+	if c.reno && c.byteCounting {
+		// Appropriate Byte Counting: the window grows by one packet per window of acknowledged bytes,
+		// and by N packets when emulating N connections
+		c.numAckedBytes += ackedBytes * protocol.ByteCount(c.numConnections)
+		if c.numAckedBytes >= c.congestionWindow {
+			c.numAckedBytes -= c.congestionWindow
+			c.congestionWindow += c.maxDatagramSize
+		}
+	} else if c.reno {
 		c.numAckedPackets++
-		if c.numAckedPackets >= uint64(c.congestionWindow/c.maxDatagramSize) {
+		// emulating N connections, the window grows by N packets per round trip
+		if c.numAckedPackets*uint64(c.numConnections) >= uint64(c.congestionWindow/c.maxDatagramSize) {
 			c.congestionWindow += c.maxDatagramSize
 			c.numAckedPackets = 0
 		}
 	} else {
 		c.congestionWindow = min(c.maxCongestionWindow(), c.cubic.CongestionWindowAfterAck(ackedBytes, c.congestionWindow, c.rttStats.MinRTT(), eventTime))
 	}
+	if c.onCongestionWindowChange != nil || c.minRateProtectionActive {
+		c.onCongestionWindowIncreased(oldCongestionWindow)
+	}
+}
+
+// onCongestionWindowIncreased is called when the congestion window grew on an acknowledgment.
+// This happens on every acknowledgment, so callers only call it if there's anything to do.
+func (c *cubicSender) onCongestionWindowIncreased(old protocol.ByteCount) {
+	c.maybeClearMinRateProtection()
+	c.maybeNotifyCongestionWindowChange(old)
+}
+
+// State returns the phase the sender is currently in.
+func (c *cubicSender) State(bytesInFlight protocol.ByteCount) State {
+	switch {
+	case c.InRecovery():
+		return StateRecovery
+	case !c.isCwndLimited(bytesInFlight):
+		return StateApplicationLimited
+	case c.InSlowStart():
+		return StateSlowStart
+	default:
+		return StateCongestionAvoidance
+	}
 }
 
 func (c *cubicSender) isCwndLimited(bytesInFlight protocol.ByteCount) bool {
@@ -227,41 +608,177 @@ func (c *cubicSender) isCwndLimited(bytesInFlight protocol.ByteCount) bool {
 func (c *cubicSender) BandwidthEstimate() Bandwidth {
 	srtt := c.rttStats.SmoothedRTT()
 	if srtt == 0 {
-		srtt = protocol.TimerGranularity
+		srtt = c.rttStats.InitialRTT()
 	}
 	return BandwidthFromDelta(c.GetCongestionWindow(), srtt)
 }
 
+// pacingRate is the rate the pacer sends at: the bandwidth estimate multiplied by the pacing gain.
+// A gain above 1 prevents RTT variations from leaving the congestion window under-utilized.
+// In slow start, the window doubles every RTT, and a larger gain lets the sender keep up with the growing window,
+// instead of smoothing out the bursts that probe for more bandwidth.
+func (c *cubicSender) pacingRate() Bandwidth {
+	gain := c.congestionAvoidancePacingGain
+	if c.InSlowStart() {
+		gain = c.slowStartPacingGain
+	}
+	return Bandwidth(float64(c.BandwidthEstimate()) * gain)
+}
+
+// EstimatedSendTime returns how long it takes to send bytes at the rate of one congestion window per smoothed RTT.
+func (c *cubicSender) EstimatedSendTime(bytes protocol.ByteCount) time.Duration {
+	return SendTime(bytes, c.BandwidthEstimate())
+}
+
 func (c *cubicSender) OnRetransmissionTimeout(packetsRetransmitted bool) {
+	if c.frozenCongestionWindow > 0 {
+		return
+	}
 	c.largestSentAtLastCutback = protocol.InvalidPacketNumber
+	c.undo = cubicUndoState{}
 	if !packetsRetransmitted {
 		return
 	}
 	c.hybridSlowStart.Restart()
 	c.cubic.Reset()
+	c.slowStartGuardExpired = true
+	oldCongestionWindow := c.congestionWindow
 	c.slowStartThreshold = c.congestionWindow / 2
 	c.congestionWindow = c.minCongestionWindow()
 	// 超时也应用 5Mbps 保护
 	c.applyMinRateProtection()
+	c.maybeNotifyCongestionWindowChange(oldCongestionWindow)
 }
 
-func (c *cubicSender) OnConnectionMigration() {
+// OnPersistentCongestion collapses the congestion window to the minimum congestion window.
+// Unlike after individual losses, neither the loss tolerance nor the minimum rate protection apply.
+// Losses of packets sent before the collapse don't reduce the window any further.
+func (c *cubicSender) OnPersistentCongestion() {
+	if c.frozenCongestionWindow > 0 {
+		return
+	}
 	c.hybridSlowStart.Restart()
+	c.cubic.Reset()
+	c.undo = cubicUndoState{}
+	oldCongestionWindow := c.congestionWindow
+	c.slowStartThreshold = c.congestionWindow / 2
+	c.congestionWindow = c.minCongestionWindow()
+	c.setMinRateProtectionActive(false)
+	c.largestSentAtLastCutback = c.largestSentPacketNumber
+	c.numAckedPackets = 0
+	c.numAckedBytes = 0
+	c.maybeNotifyCongestionWindowChange(oldCongestionWindow)
+}
+
+// OnSpuriousLoss restores the congestion window and the slow start threshold
+// once all packets declared lost during the last recovery period have been acknowledged.
+func (c *cubicSender) OnSpuriousLoss(packetNumber protocol.PacketNumber) {
+	if !c.undo.Valid() || !c.undo.OnSpuriousLoss(packetNumber) {
+		return
+	}
+	oldCongestionWindow := c.congestionWindow
+	c.congestionWindow = max(c.congestionWindow, c.undo.congestionWindow)
+	c.maybeClearMinRateProtection()
+	c.slowStartThreshold = max(c.slowStartThreshold, c.undo.slowStartThreshold)
+	c.largestSentAtLastCutback = c.undo.largestSentAtLastCutback
+	if !c.reno {
+		c.cubic.UndoPacketLoss()
+	}
+	c.undo = cubicUndoState{}
+	if c.InSlowStart() {
+		c.maybeQlogStateChange(qlog.CongestionStateSlowStart)
+	} else {
+		c.maybeQlogStateChange(qlog.CongestionStateCongestionAvoidance)
+	}
+	c.maybeNotifyCongestionWindowChange(oldCongestionWindow)
+}
+
+func (c *cubicSender) OnFlowControlLimited(bool) {}
+
+// FreezeCongestionWindow pins the congestion window to the given number of bytes, for testing and debugging.
+// While frozen, neither acknowledgments nor losses or timeouts modify the congestion window.
+// Losses are still accounted for in the connection stats.
+func (c *cubicSender) FreezeCongestionWindow(bytes protocol.ByteCount) {
+	c.setFrozenCongestionWindow(bytes)
+}
+
+// UnfreezeCongestionWindow unfreezes the congestion window.
+// It continues from the value it had before it was frozen.
+func (c *cubicSender) UnfreezeCongestionWindow() {
+	c.setFrozenCongestionWindow(0)
+}
+
+func (c *cubicSender) setFrozenCongestionWindow(bytes protocol.ByteCount) {
+	old := c.GetCongestionWindow()
+	c.frozenCongestionWindow = bytes
+	if c.onCongestionWindowChange != nil && old != c.GetCongestionWindow() {
+		c.onCongestionWindowChange(old, c.GetCongestionWindow())
+	}
+}
+
+func (c *cubicSender) ProbeBandwidth(until monotime.Time) {
+	c.probeUntil = until
+}
+
+func (c *cubicSender) OnConnectionMigration() {
+	c.probeUntil = 0
+	c.lastSentTime = 0
+	c.undo = cubicUndoState{}
+	c.lossEpisodeRounds = 0
+	c.lossEpisodeRoundStart = 0
+	c.lastLossTime = 0
+	c.slowStartStartTime = 0
+	c.slowStartAckedBytes = 0
+	c.slowStartGuardExpired = false
+	c.hybridSlowStart.Reset()
 	c.largestSentPacketNumber = protocol.InvalidPacketNumber
 	c.largestAckedPacketNumber = protocol.InvalidPacketNumber
 	c.largestSentAtLastCutback = protocol.InvalidPacketNumber
+	c.largestSentAtToleratedLoss = protocol.InvalidPacketNumber
 	c.lastCutbackExitedSlowstart = false
 	c.cubic.Reset()
 	c.numAckedPackets = 0
+	c.numAckedBytes = 0
 	c.congestionWindow = c.initialCongestionWindow
-	c.slowStartThreshold = c.initialMaxCongestionWindow
+	c.setMinRateProtectionActive(false)
+	c.slowStartThreshold = protocol.MaxByteCount
+	c.maxDatagramSize = c.initialMaxDatagramSize
+	c.cubic.SetMaxDatagramSize(c.initialMaxDatagramSize)
+	c.pacer.SetMaxDatagramSize(c.initialMaxDatagramSize)
+	c.pacer.Reset()
+	// The sender starts over in slow start. This transition must not be suppressed by the recovery hysteresis,
+	// and the next state change must be compared to slow start, not to the state on the old path.
+	c.lastRecoveryQlogTime = 0
+	c.maybeQlogStateChange(qlog.CongestionStateSlowStart)
 }
 
+func (c *cubicSender) maybeNotifyCongestionWindowChange(old protocol.ByteCount) {
+	if c.onCongestionWindowChange == nil || old == c.congestionWindow {
+		return
+	}
+	c.onCongestionWindowChange(old, c.congestionWindow)
+}
+
+// maybeQlogStateChange logs a state transition, to the qlog and to the slog logger.
+// During bursty loss, the sender can enter and leave recovery many times per RTT. To avoid flooding the logs,
+// leaving the recovery state is only logged once a smoothed RTT has passed since entering it.
+// Suppressed transitions are logged with the next state change after that.
+// This only affects logging, not the state of the sender.
 func (c *cubicSender) maybeQlogStateChange(new qlog.CongestionState) {
-	if c.qlogger == nil || new == c.lastState {
+	if (c.qlogger == nil && c.logger == nil) || new == c.lastState {
 		return
 	}
-	c.qlogger.RecordEvent(qlog.CongestionStateUpdated{State: new})
+	now := c.clock.Now()
+	if c.lastState == qlog.CongestionStateRecovery && now.Sub(c.lastRecoveryQlogTime) < c.rttStats.SmoothedRTT() {
+		return
+	}
+	if new == qlog.CongestionStateRecovery {
+		c.lastRecoveryQlogTime = now
+	}
+	if c.qlogger != nil {
+		c.qlogger.RecordEvent(qlog.CongestionStateUpdated{State: new})
+	}
+	logStateChange(c.logger, new)
 	c.lastState = new
 }
 
@@ -269,10 +786,21 @@ func (c *cubicSender) SetMaxDatagramSize(s protocol.ByteCount) {
 	if s < c.maxDatagramSize {
 		panic(fmt.Sprintf("congestion BUG: decreased max datagram size from %d to %d", c.maxDatagramSize, s))
 	}
+	// Beyond the ceiling, larger packets don't make the windows any larger.
+	s = min(s, c.maxDatagramSizeCeiling)
+	// A window at one of the lower bounds scales with the datagram size,
+	// since both bounds can be defined in packets.
+	cwndIsMinRateCwnd := c.congestionWindow == c.minRateWindow()
 	cwndIsMinCwnd := c.congestionWindow == c.minCongestionWindow()
+	oldCongestionWindow := c.congestionWindow
 	c.maxDatagramSize = s
-	if cwndIsMinCwnd {
+	switch {
+	case cwndIsMinRateCwnd:
+		c.congestionWindow = c.minRateWindow()
+	case cwndIsMinCwnd:
 		c.congestionWindow = c.minCongestionWindow()
 	}
+	c.cubic.SetMaxDatagramSize(s)
 	c.pacer.SetMaxDatagramSize(s)
+	c.maybeNotifyCongestionWindowChange(oldCongestionWindow)
 }
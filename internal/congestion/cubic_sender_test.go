@@ -8,6 +8,9 @@ import (
 	"github.com/quic-go/quic-go/internal/monotime"
 	"github.com/quic-go/quic-go/internal/protocol"
 	"github.com/quic-go/quic-go/internal/utils"
+	"github.com/quic-go/quic-go/qlog"
+	"github.com/quic-go/quic-go/qlogwriter"
+	"github.com/quic-go/quic-go/testutils/events"
 
 	"github.com/stretchr/testify/require"
 )
@@ -54,6 +57,7 @@ func newTestCubicSender(cubic bool) *testCubicSender {
 			initialCongestionWindowPackets*maxDatagramSize,
 			MaxCongestionWindow,
 			nil,
+			nil,
 		),
 	}
 }
@@ -83,13 +87,13 @@ func (s *testCubicSender) AckNPackets(n int) {
 func (s *testCubicSender) LoseNPacketsLen(n int, packetLength protocol.ByteCount) {
 	for range n {
 		s.ackedPacketNumber++
-		s.sender.OnCongestionEvent(s.ackedPacketNumber, packetLength, s.bytesInFlight)
+		s.sender.OnCongestionEvent(s.ackedPacketNumber, packetLength, s.bytesInFlight, TrafficClassDefault, false)
 	}
 	s.bytesInFlight -= protocol.ByteCount(n) * packetLength
 }
 
 func (s *testCubicSender) LosePacket(number protocol.PacketNumber) {
-	s.sender.OnCongestionEvent(number, maxDatagramSize, s.bytesInFlight)
+	s.sender.OnCongestionEvent(number, maxDatagramSize, s.bytesInFlight, TrafficClassDefault, false)
 	s.bytesInFlight -= maxDatagramSize
 }
 
@@ -156,6 +160,25 @@ func TestCubicSenderApplicationLimitedSlowStart(t *testing.T) {
 	require.Equal(t, defaultWindowTCP+maxDatagramSize*2*2, bytesToSend)
 }
 
+func TestCubicSenderProbeBandwidth(t *testing.T) {
+	sender := newTestCubicSender(false)
+	sender.clock.Advance(time.Second)
+	sender.SendAvailableSendWindow()
+	sender.AckNPackets(2)
+	cwnd := sender.sender.GetCongestionWindow()
+
+	// while probing, the window grows even though the application doesn't use it
+	sender.sender.ProbeBandwidth(sender.clock.Now().Add(time.Second))
+	sender.AckNPackets(2)
+	require.Equal(t, cwnd+2*maxDatagramSize, sender.sender.GetCongestionWindow())
+
+	// once the probe ends, application-limited ACKs don't increase the window any more
+	cwnd = sender.sender.GetCongestionWindow()
+	sender.clock.Advance(time.Second)
+	sender.AckNPackets(2)
+	require.Equal(t, cwnd, sender.sender.GetCongestionWindow())
+}
+
 func TestCubicSenderExponentialSlowStart(t *testing.T) {
 	sender := newTestCubicSender(false)
 
@@ -337,6 +360,556 @@ func TestCubicSenderRTOCongestionWindow(t *testing.T) {
 	require.Equal(t, 5*maxDatagramSize, sender.sender.slowStartThreshold)
 }
 
+func TestCubicSenderPersistentCongestion(t *testing.T) {
+	sender := newTestCubicSender(false)
+	sender.SendAvailableSendWindow()
+	// the loss rate is below the loss tolerance, so the loss doesn't reduce the congestion window
+	sender.sender.connStats.BytesSent.Store(1000 * uint64(maxDatagramSize))
+	sender.LosePacket(1)
+	require.Equal(t, defaultWindowTCP, sender.sender.GetCongestionWindow())
+
+	// persistent congestion always collapses the congestion window
+	sender.sender.OnPersistentCongestion()
+	require.Equal(t, sender.sender.minCongestionWindow(), sender.sender.GetCongestionWindow())
+	require.Equal(t, defaultWindowTCP/2, sender.sender.slowStartThreshold)
+	// losses of packets sent before the collapse don't reduce the window any further
+	sender.sender.connStats.BytesSent.Store(0)
+	sender.LosePacket(2)
+	require.Equal(t, sender.sender.minCongestionWindow(), sender.sender.GetCongestionWindow())
+	// the collapse can't be undone
+	sender.sender.OnSpuriousLoss(1)
+	require.Equal(t, sender.sender.minCongestionWindow(), sender.sender.GetCongestionWindow())
+}
+
+func TestCubicSenderLossTolerance(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		// the bytes sent, retransmitted and lost before the congestion event
+		bytesSent, bytesRetransmitted, bytesLost uint64
+		expectCut                                bool
+	}{
+		{name: "below the threshold", bytesSent: 100_000, bytesLost: 5_000, expectCut: false},
+		{name: "above the threshold", bytesSent: 100_000, bytesLost: 15_000, expectCut: true},
+		// the lost packet accounts for another 1000 bytes lost, resulting in a loss rate of exactly 10%
+		{name: "at the threshold", bytesSent: 100_000, bytesLost: 9_000, expectCut: true},
+		{name: "just below the threshold", bytesSent: 100_000, bytesLost: 8_999, expectCut: false},
+		{name: "no bytes sent", bytesSent: 0, bytesLost: 0, expectCut: true},
+		// retransmissions don't dilute the loss rate: 9,000 of 90,000 unique bytes sent were lost
+		{name: "with retransmissions", bytesSent: 100_000, bytesRetransmitted: 10_000, bytesLost: 8_000, expectCut: true},
+		{name: "with retransmissions, below the threshold", bytesSent: 100_000, bytesRetransmitted: 10_000, bytesLost: 5_000, expectCut: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var clock mockClock
+			connStats := &utils.ConnectionStats{}
+			// don't let the minimum rate protection prevent the window reduction
+			conf := &Config{MinRatePolicy: MinRatePolicyPackets, MinRatePackets: 2}
+			sender := NewCubicSender(&clock, utils.NewRTTStats(), connStats, maxDatagramSize, false, conf, nil)
+			var bytesInFlight protocol.ByteCount
+			for pn := protocol.PacketNumber(1); sender.CanSend(bytesInFlight); pn++ {
+				sender.OnPacketSent(clock.Now(), bytesInFlight, pn, maxDatagramSize, true)
+				bytesInFlight += maxDatagramSize
+			}
+			connStats.BytesSent.Store(tc.bytesSent)
+			connStats.BytesRetransmitted.Store(tc.bytesRetransmitted)
+			// the sent packet handler records the loss before informing the congestion controller
+			connStats.BytesLost.Store(tc.bytesLost + 1000)
+			cwnd := sender.GetCongestionWindow()
+
+			sender.OnCongestionEvent(1, 1000, bytesInFlight, TrafficClassDefault, false)
+			if tc.expectCut {
+				require.Less(t, sender.GetCongestionWindow(), cwnd)
+			} else {
+				require.Equal(t, cwnd, sender.GetCongestionWindow())
+			}
+		})
+	}
+}
+
+func TestCubicSenderHoldWindowOnToleratedLoss(t *testing.T) {
+	for _, hold := range []bool{false, true} {
+		t.Run(fmt.Sprintf("hold: %t", hold), func(t *testing.T) {
+			var clock mockClock
+			connStats := &utils.ConnectionStats{}
+			sender := NewCubicSender(&clock, utils.NewRTTStats(), connStats, maxDatagramSize, false, &Config{HoldWindowOnToleratedLoss: hold}, nil)
+			var bytesInFlight protocol.ByteCount
+			pn := protocol.PacketNumber(1)
+			for ; sender.CanSend(bytesInFlight); pn++ {
+				sender.OnPacketSent(clock.Now(), bytesInFlight, pn, maxDatagramSize, true)
+				bytesInFlight += maxDatagramSize
+			}
+			// the loss rate is below the loss tolerance, so the loss doesn't reduce the congestion window
+			connStats.BytesSent.Store(1000 * uint64(maxDatagramSize))
+			cwnd := sender.GetCongestionWindow()
+			sender.OnCongestionEvent(1, maxDatagramSize, bytesInFlight, TrafficClassDefault, false)
+			require.Equal(t, cwnd, sender.GetCongestionWindow())
+
+			// acknowledgments for the packets sent before the tolerated loss
+			for p := protocol.PacketNumber(2); p < pn; p++ {
+				sender.OnPacketAcked(p, maxDatagramSize, bytesInFlight, clock.Now())
+			}
+			if hold {
+				require.Equal(t, cwnd, sender.GetCongestionWindow())
+			} else {
+				require.Greater(t, sender.GetCongestionWindow(), cwnd)
+			}
+
+			// once a packet sent after the tolerated loss is acknowledged, the window grows again
+			cwnd = sender.GetCongestionWindow()
+			sender.OnPacketSent(clock.Now(), 0, pn, maxDatagramSize, true)
+			sender.OnPacketAcked(pn, maxDatagramSize, cwnd, clock.Now())
+			require.Greater(t, sender.GetCongestionWindow(), cwnd)
+		})
+	}
+}
+
+func TestCubicSenderLossToleranceAfterStatsReset(t *testing.T) {
+	var clock mockClock
+	connStats := &utils.ConnectionStats{}
+	// don't let the minimum rate protection prevent the window reduction
+	conf := &Config{MinRatePolicy: MinRatePolicyPackets, MinRatePackets: 2}
+	sender := NewCubicSender(&clock, utils.NewRTTStats(), connStats, maxDatagramSize, false, conf, nil)
+	var bytesInFlight protocol.ByteCount
+	pn := protocol.PacketNumber(1)
+	for ; sender.CanSend(bytesInFlight); pn++ {
+		sender.OnPacketSent(clock.Now(), bytesInFlight, pn, maxDatagramSize, true)
+		bytesInFlight += maxDatagramSize
+	}
+	connStats.BytesSent.Store(100_000)
+	connStats.BytesLost.Store(15_000)
+	cwnd := sender.GetCongestionWindow()
+	sender.OnCongestionEvent(1, maxDatagramSize, bytesInFlight, TrafficClassDefault, false)
+	require.Less(t, sender.GetCongestionWindow(), cwnd)
+	cwnd = sender.GetCongestionWindow()
+
+	// the stats are reset during the congestion episode
+	connStats.Reset()
+	// losses of packets sent before the cutback don't reduce the window again
+	connStats.BytesLost.Add(uint64(maxDatagramSize))
+	sender.OnCongestionEvent(2, maxDatagramSize, bytesInFlight, TrafficClassDefault, false)
+	require.Equal(t, cwnd, sender.GetCongestionWindow())
+
+	// nothing was sent since the reset, so the loss rate is unknown, and the loss isn't tolerated
+	sender.OnPacketSent(clock.Now(), 0, pn, maxDatagramSize, true)
+	connStats.BytesLost.Add(uint64(maxDatagramSize))
+	sender.OnCongestionEvent(pn, maxDatagramSize, maxDatagramSize, TrafficClassDefault, false)
+	pn++
+	require.Less(t, sender.GetCongestionWindow(), cwnd)
+	cwnd = sender.GetCongestionWindow()
+
+	// once enough data was sent, the loss rate is calculated from the new counters
+	connStats.BytesSent.Store(100_000)
+	sender.OnPacketSent(clock.Now(), 0, pn, maxDatagramSize, true)
+	connStats.BytesLost.Add(uint64(maxDatagramSize))
+	sender.OnCongestionEvent(pn, maxDatagramSize, maxDatagramSize, TrafficClassDefault, false)
+	require.Equal(t, cwnd, sender.GetCongestionWindow())
+}
+
+func TestCubicSenderIdleRestart(t *testing.T) {
+	var clock mockClock
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(50*time.Millisecond, 0)
+	var cwndChanges [][2]protocol.ByteCount
+	sender := NewCubicSender(&clock, rttStats, nil, maxDatagramSize, false, &Config{
+		IdleRestartThreshold:     time.Second,
+		OnCongestionWindowChange: func(old, new protocol.ByteCount) { cwndChanges = append(cwndChanges, [2]protocol.ByteCount{old, new}) },
+	}, nil)
+	initialCwnd := sender.GetCongestionWindow()
+
+	// grow the congestion window for a few rounds, and leave slow start
+	var pn protocol.PacketNumber
+	sendAndAckWindow := func() {
+		cwnd := sender.GetCongestionWindow()
+		first := pn
+		for bytesInFlight := protocol.ByteCount(0); bytesInFlight < cwnd; bytesInFlight += maxDatagramSize {
+			sender.OnPacketSent(clock.Now(), bytesInFlight+maxDatagramSize, pn, maxDatagramSize, true)
+			pn++
+		}
+		clock.Advance(50 * time.Millisecond)
+		for p := first; p < pn; p++ {
+			sender.OnPacketAcked(p, maxDatagramSize, cwnd, clock.Now())
+		}
+	}
+	for range 3 {
+		sendAndAckWindow()
+	}
+	cwnd := sender.GetCongestionWindow()
+	require.Equal(t, 8*initialCwnd, cwnd)
+	sender.slowStartThreshold = cwnd
+	require.False(t, sender.InSlowStart())
+
+	// a short pause doesn't affect the congestion window
+	clock.Advance(500 * time.Millisecond)
+	sender.OnPacketSent(clock.Now(), maxDatagramSize, pn, maxDatagramSize, true)
+	pn++
+	require.Equal(t, cwnd, sender.GetCongestionWindow())
+
+	// packets sent while data is in flight don't restart the window, even after a long pause
+	clock.Advance(2 * time.Second)
+	sender.OnPacketSent(clock.Now(), 2*maxDatagramSize, pn, maxDatagramSize, true)
+	pn++
+	require.Equal(t, cwnd, sender.GetCongestionWindow())
+
+	// after an idle period, the window restarts from the initial window, and the sender is in slow start
+	cwndChanges = nil
+	clock.Advance(2 * time.Second)
+	sender.OnPacketSent(clock.Now(), maxDatagramSize, pn, maxDatagramSize, true)
+	require.Equal(t, initialCwnd, sender.GetCongestionWindow())
+	require.Equal(t, cwnd, sender.SlowStartThreshold())
+	require.True(t, sender.InSlowStart())
+	require.Equal(t, [][2]protocol.ByteCount{{cwnd, initialCwnd}}, cwndChanges)
+}
+
+func TestCubicSenderIdleRestartDisabled(t *testing.T) {
+	var clock mockClock
+	sender := NewCubicSender(&clock, utils.NewRTTStats(), nil, maxDatagramSize, false, nil, nil)
+	sender.congestionWindow = 100 * maxDatagramSize
+	sender.OnPacketSent(clock.Now(), maxDatagramSize, 1, maxDatagramSize, true)
+	clock.Advance(time.Hour)
+	sender.OnPacketSent(clock.Now(), maxDatagramSize, 2, maxDatagramSize, true)
+	require.Equal(t, 100*maxDatagramSize, sender.GetCongestionWindow())
+}
+
+func TestRenoSenderByteCounting(t *testing.T) {
+	newSender := func(byteCounting bool) *cubicSender {
+		var clock mockClock
+		sender := NewCubicSender(&clock, utils.NewRTTStats(), nil, maxDatagramSize, true, &Config{RenoByteCounting: byteCounting}, nil)
+		sender.congestionWindow = 100 * maxDatagramSize
+		sender.slowStartThreshold = sender.congestionWindow
+		return sender
+	}
+	// acknowledge 2 windows worth of bytes, in packets half the max datagram size
+	ackSmallPackets := func(sender *cubicSender) {
+		for pn := range protocol.PacketNumber(400) {
+			sender.OnPacketAcked(pn, maxDatagramSize/2, sender.GetCongestionWindow(), monotime.Now())
+		}
+	}
+
+	// packet counting increases the window by one packet per window of acknowledged packets,
+	// no matter how large the packets are
+	packetCounting := newSender(false)
+	ackSmallPackets(packetCounting)
+	require.Equal(t, 103*maxDatagramSize, packetCounting.GetCongestionWindow())
+	// byte counting increases the window by one packet per window of acknowledged bytes
+	byteCounting := newSender(true)
+	ackSmallPackets(byteCounting)
+	require.Equal(t, 101*maxDatagramSize, byteCounting.GetCongestionWindow())
+
+	// for full-sized packets, both grow at the same rate
+	packetCounting = newSender(false)
+	byteCounting = newSender(true)
+	for pn := range protocol.PacketNumber(500) {
+		packetCounting.OnPacketAcked(pn, maxDatagramSize, packetCounting.GetCongestionWindow(), monotime.Now())
+		byteCounting.OnPacketAcked(pn, maxDatagramSize, byteCounting.GetCongestionWindow(), monotime.Now())
+		require.Equal(t, packetCounting.GetCongestionWindow(), byteCounting.GetCongestionWindow())
+	}
+	require.Greater(t, byteCounting.GetCongestionWindow(), 100*maxDatagramSize)
+}
+
+func TestRenoSenderByteCountingSlowStart(t *testing.T) {
+	var clock mockClock
+	sender := NewCubicSender(&clock, utils.NewRTTStats(), nil, maxDatagramSize, true, &Config{RenoByteCounting: true}, nil)
+	cwnd := sender.GetCongestionWindow()
+	require.True(t, sender.InSlowStart())
+	sender.OnPacketAcked(1, maxDatagramSize/2, cwnd, clock.Now())
+	require.Equal(t, cwnd+maxDatagramSize/2, sender.GetCongestionWindow())
+	// the growth per ACK is limited to 2 packets
+	cwnd = sender.GetCongestionWindow()
+	sender.OnPacketAcked(2, 5*maxDatagramSize, cwnd, clock.Now())
+	require.Equal(t, cwnd+2*maxDatagramSize, sender.GetCongestionWindow())
+}
+
+type testWindowPolicy struct {
+	allowed func(current, proposed protocol.ByteCount) protocol.ByteCount
+	calls   [][2]protocol.ByteCount
+}
+
+func (p *testWindowPolicy) AllowedCongestionWindow(current, proposed protocol.ByteCount) protocol.ByteCount {
+	p.calls = append(p.calls, [2]protocol.ByteCount{current, proposed})
+	return p.allowed(current, proposed)
+}
+
+func TestCubicSenderWindowPolicy(t *testing.T) {
+	newSender := func(policy WindowPolicy) (*cubicSender, protocol.ByteCount) {
+		var clock mockClock
+		// don't let the minimum rate protection prevent the window reduction
+		sender := NewCubicSender(&clock, utils.NewRTTStats(), nil, maxDatagramSize, true, &Config{
+			MinRatePolicy:  MinRatePolicyPackets,
+			MinRatePackets: 2,
+			WindowPolicy:   policy,
+		}, nil)
+		var bytesInFlight protocol.ByteCount
+		for pn := protocol.PacketNumber(1); sender.CanSend(bytesInFlight); pn++ {
+			sender.OnPacketSent(clock.Now(), bytesInFlight, pn, maxDatagramSize, true)
+			bytesInFlight += maxDatagramSize
+		}
+		return sender, bytesInFlight
+	}
+
+	t.Run("modify", func(t *testing.T) {
+		policy := &testWindowPolicy{allowed: func(current, _ protocol.ByteCount) protocol.ByteCount { return current * 9 / 10 }}
+		sender, bytesInFlight := newSender(policy)
+		cwnd := sender.GetCongestionWindow()
+		sender.OnCongestionEvent(1, maxDatagramSize, bytesInFlight, TrafficClassDefault, false)
+		require.Equal(t, [][2]protocol.ByteCount{{cwnd, protocol.ByteCount(float64(cwnd) * renoBeta)}}, policy.calls)
+		require.Equal(t, cwnd*9/10, sender.GetCongestionWindow())
+		require.Equal(t, cwnd*9/10, sender.SlowStartThreshold())
+	})
+
+	t.Run("veto", func(t *testing.T) {
+		policy := &testWindowPolicy{allowed: func(current, _ protocol.ByteCount) protocol.ByteCount { return current }}
+		sender, bytesInFlight := newSender(policy)
+		cwnd := sender.GetCongestionWindow()
+		sender.OnCongestionEvent(1, maxDatagramSize, bytesInFlight, TrafficClassDefault, false)
+		require.Equal(t, cwnd, sender.GetCongestionWindow())
+	})
+
+	t.Run("clamping", func(t *testing.T) {
+		policy := &testWindowPolicy{allowed: func(current, _ protocol.ByteCount) protocol.ByteCount { return 2 * current }}
+		sender, bytesInFlight := newSender(policy)
+		cwnd := sender.GetCongestionWindow()
+		sender.OnCongestionEvent(1, maxDatagramSize, bytesInFlight, TrafficClassDefault, false)
+		require.Equal(t, cwnd, sender.GetCongestionWindow())
+
+		policy = &testWindowPolicy{allowed: func(protocol.ByteCount, protocol.ByteCount) protocol.ByteCount { return 0 }}
+		sender, bytesInFlight = newSender(policy)
+		sender.OnCongestionEvent(1, maxDatagramSize, bytesInFlight, TrafficClassDefault, false)
+		require.Equal(t, sender.minCongestionWindow(), sender.GetCongestionWindow())
+	})
+}
+
+func TestCubicSenderWithoutConnectionStats(t *testing.T) {
+	var clock mockClock
+	rttStats := utils.NewRTTStats()
+	// don't let the minimum rate protection prevent the window reduction
+	conf := &Config{MinRatePolicy: MinRatePolicyPackets, MinRatePackets: 2}
+	sender := NewCubicSender(&clock, rttStats, nil, maxDatagramSize, false, conf, nil)
+	var bytesInFlight protocol.ByteCount
+	for pn := protocol.PacketNumber(1); sender.CanSend(bytesInFlight); pn++ {
+		sender.OnPacketSent(clock.Now(), bytesInFlight, pn, maxDatagramSize, true)
+		bytesInFlight += maxDatagramSize
+	}
+	cwnd := sender.GetCongestionWindow()
+	// without connection stats, the loss rate is unknown, and every congestion event reduces the window
+	sender.OnCongestionEvent(1, maxDatagramSize, bytesInFlight, TrafficClassDefault, false)
+	require.Less(t, sender.GetCongestionWindow(), cwnd)
+}
+
+func TestCubicSenderLossTolerancePolicyEpisode(t *testing.T) {
+	var clock mockClock
+	clock.Advance(time.Second)
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(100*time.Millisecond, 0)
+	conf := &Config{
+		LossTolerancePolicy: LossTolerancePolicyEpisode,
+		// don't let the minimum rate protection prevent the window reduction
+		MinRatePolicy:  MinRatePolicyPackets,
+		MinRatePackets: 2,
+	}
+	// with a loss rate of 100%, the loss rate policy would reduce the window on every loss
+	connStats := &utils.ConnectionStats{}
+	sender := NewCubicSender(&clock, rttStats, connStats, maxDatagramSize, false, conf, nil)
+	var pn protocol.PacketNumber
+	var bytesInFlight protocol.ByteCount
+	for sender.CanSend(bytesInFlight) {
+		pn++
+		sender.OnPacketSent(clock.Now(), bytesInFlight, pn, maxDatagramSize, true)
+		bytesInFlight += maxDatagramSize
+	}
+	cwnd := sender.GetCongestionWindow()
+
+	// all losses in the first RTT of the episode are tolerated
+	sender.OnCongestionEvent(1, maxDatagramSize, bytesInFlight, TrafficClassDefault, false)
+	clock.Advance(80 * time.Millisecond)
+	sender.OnCongestionEvent(2, maxDatagramSize, bytesInFlight, TrafficClassDefault, false)
+	require.Equal(t, cwnd, sender.GetCongestionWindow())
+
+	// an RTT passes without any loss, this ends the episode
+	clock.Advance(150 * time.Millisecond)
+	sender.OnCongestionEvent(3, maxDatagramSize, bytesInFlight, TrafficClassDefault, false)
+	require.Equal(t, cwnd, sender.GetCongestionWindow())
+
+	// losses continue in the next RTT
+	clock.Advance(80 * time.Millisecond)
+	sender.OnCongestionEvent(4, maxDatagramSize, bytesInFlight, TrafficClassDefault, false)
+	require.Equal(t, cwnd, sender.GetCongestionWindow())
+	clock.Advance(80 * time.Millisecond)
+	sender.OnCongestionEvent(5, maxDatagramSize, bytesInFlight, TrafficClassDefault, false)
+	require.Less(t, sender.GetCongestionWindow(), cwnd)
+}
+
+func TestCubicSenderReorderingLikelyLoss(t *testing.T) {
+	var clock mockClock
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(100*time.Millisecond, 0)
+	var connStats utils.ConnectionStats
+	sender := NewCubicSender(&clock, rttStats, &connStats, maxDatagramSize, false, &Config{MinRatePolicy: MinRatePolicyPackets, MinRatePackets: 2}, nil)
+	for pn := range protocol.PacketNumber(4) {
+		sender.OnPacketSent(clock.Now(), protocol.ByteCount(pn)*maxDatagramSize, pn, maxDatagramSize, true)
+	}
+	cwnd := sender.GetCongestionWindow()
+
+	// Without any bytes sent recorded in the connection stats, every other loss would reduce the window.
+	sender.OnCongestionEvent(0, maxDatagramSize, 4*maxDatagramSize, TrafficClassDefault, true)
+	require.Equal(t, cwnd, sender.GetCongestionWindow())
+	require.True(t, sender.InSlowStart())
+	require.False(t, sender.InRecovery())
+
+	sender.OnCongestionEvent(1, maxDatagramSize, 3*maxDatagramSize, TrafficClassDefault, false)
+	require.Less(t, sender.GetCongestionWindow(), cwnd)
+	require.False(t, sender.InSlowStart())
+}
+
+func TestCubicSenderMinSlowStart(t *testing.T) {
+	setup := func(conf *Config) (*cubicSender, *mockClock) {
+		var clock mockClock
+		clock.Advance(time.Second)
+		rttStats := utils.NewRTTStats()
+		rttStats.UpdateRTT(100*time.Millisecond, 0)
+		conf.MinRatePolicy = MinRatePolicyPackets
+		conf.MinRatePackets = 2
+		return NewCubicSender(&clock, rttStats, &utils.ConnectionStats{}, maxDatagramSize, false, conf, nil), &clock
+	}
+
+	t.Run("duration", func(t *testing.T) {
+		sender, clock := setup(&Config{MinSlowStartDuration: 200 * time.Millisecond})
+		sender.OnPacketSent(clock.Now(), 0, 1, maxDatagramSize, true)
+		sender.OnPacketSent(clock.Now(), maxDatagramSize, 2, maxDatagramSize, true)
+		cwnd := sender.GetCongestionWindow()
+
+		clock.Advance(150 * time.Millisecond)
+		sender.OnCongestionEvent(1, maxDatagramSize, 2*maxDatagramSize, TrafficClassDefault, false)
+		require.Equal(t, cwnd, sender.GetCongestionWindow())
+		require.True(t, sender.InSlowStart())
+
+		clock.Advance(100 * time.Millisecond)
+		sender.OnCongestionEvent(2, maxDatagramSize, maxDatagramSize, TrafficClassDefault, false)
+		require.Less(t, sender.GetCongestionWindow(), cwnd)
+		require.False(t, sender.InSlowStart())
+	})
+
+	t.Run("bytes", func(t *testing.T) {
+		sender, clock := setup(&Config{MinSlowStartBytes: 5 * maxDatagramSize})
+		var pn protocol.PacketNumber
+		ackPackets := func(n int) {
+			for range n {
+				pn++
+				sender.OnPacketSent(clock.Now(), 0, pn, maxDatagramSize, true)
+				sender.OnPacketAcked(pn, maxDatagramSize, sender.GetCongestionWindow(), clock.Now())
+			}
+		}
+		ackPackets(4)
+		pn++
+		sender.OnPacketSent(clock.Now(), 0, pn, maxDatagramSize, true)
+		cwnd := sender.GetCongestionWindow()
+		sender.OnCongestionEvent(pn, maxDatagramSize, maxDatagramSize, TrafficClassDefault, false)
+		require.Equal(t, cwnd, sender.GetCongestionWindow())
+		require.True(t, sender.InSlowStart())
+
+		// the window keeps growing exponentially
+		ackPackets(1)
+		require.Equal(t, cwnd+maxDatagramSize, sender.GetCongestionWindow())
+		pn++
+		sender.OnPacketSent(clock.Now(), 0, pn, maxDatagramSize, true)
+		cwnd = sender.GetCongestionWindow()
+		sender.OnCongestionEvent(pn, maxDatagramSize, maxDatagramSize, TrafficClassDefault, false)
+		require.Less(t, sender.GetCongestionWindow(), cwnd)
+	})
+
+	t.Run("only the initial slow start", func(t *testing.T) {
+		sender, clock := setup(&Config{MinSlowStartDuration: time.Hour})
+		sender.OnPacketSent(clock.Now(), 0, 1, maxDatagramSize, true)
+		sender.OnRetransmissionTimeout(true)
+		sender.OnPacketSent(clock.Now(), 0, 2, maxDatagramSize, true)
+		// the window is already at the minimum, but the loss starts a recovery period
+		sender.OnCongestionEvent(2, maxDatagramSize, maxDatagramSize, TrafficClassDefault, false)
+		require.Equal(t, protocol.PacketNumber(2), sender.largestSentAtLastCutback)
+
+		// the guard applies again on a new path
+		sender.OnConnectionMigration()
+		sender.OnPacketSent(clock.Now(), 0, 1, maxDatagramSize, true)
+		cwnd := sender.GetCongestionWindow()
+		sender.OnCongestionEvent(1, maxDatagramSize, maxDatagramSize, TrafficClassDefault, false)
+		require.Equal(t, cwnd, sender.GetCongestionWindow())
+		require.Equal(t, protocol.InvalidPacketNumber, sender.largestSentAtLastCutback)
+	})
+}
+
+func TestCubicSenderQlogRecoveryHysteresis(t *testing.T) {
+	var clock mockClock
+	clock.Advance(time.Second)
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(100*time.Millisecond, 0)
+	var eventRecorder events.Recorder
+	sender := NewCubicSender(&clock, rttStats, nil, maxDatagramSize, true, &Config{MinRatePolicy: MinRatePolicyPackets, MinRatePackets: 2}, &eventRecorder)
+	eventRecorder.Clear()
+
+	var pn protocol.PacketNumber
+	sendPackets := func(n int) {
+		for range n {
+			pn++
+			sender.OnPacketSent(clock.Now(), 0, pn, maxDatagramSize, true)
+		}
+	}
+	sendPackets(10)
+	sender.OnCongestionEvent(1, maxDatagramSize, 10*maxDatagramSize, TrafficClassDefault, false)
+	require.Equal(t,
+		[]qlogwriter.Event{qlog.CongestionStateUpdated{State: qlog.CongestionStateRecovery}},
+		eventRecorder.Events(),
+	)
+	eventRecorder.Clear()
+
+	// Recovery ends with the acknowledgment of a packet sent after the loss,
+	// and the sender enters another recovery period shortly after.
+	// Leaving the recovery state is not logged, since less than an RTT has passed.
+	clock.Advance(10 * time.Millisecond)
+	sendPackets(10)
+	sender.OnPacketAcked(pn, maxDatagramSize, sender.GetCongestionWindow(), clock.Now())
+	require.False(t, sender.InRecovery())
+	sendPackets(10)
+	sender.OnCongestionEvent(pn-5, maxDatagramSize, 10*maxDatagramSize, TrafficClassDefault, false)
+	require.True(t, sender.InRecovery())
+	require.Empty(t, eventRecorder.Events())
+
+	// after an RTT, leaving the recovery state is logged
+	clock.Advance(100 * time.Millisecond)
+	sendPackets(10)
+	sender.OnPacketAcked(pn, maxDatagramSize, sender.GetCongestionWindow(), clock.Now())
+	require.Equal(t,
+		[]qlogwriter.Event{qlog.CongestionStateUpdated{State: qlog.CongestionStateCongestionAvoidance}},
+		eventRecorder.Events(),
+	)
+}
+
+func TestCubicSenderFreezeCongestionWindow(t *testing.T) {
+	sender := newTestCubicSender(false)
+	var cwndChanges [][2]protocol.ByteCount
+	sender.sender.onCongestionWindowChange = func(old, new protocol.ByteCount) {
+		cwndChanges = append(cwndChanges, [2]protocol.ByteCount{old, new})
+	}
+	cwnd := sender.sender.GetCongestionWindow()
+	const frozen = 20 * maxDatagramSize
+	sender.sender.FreezeCongestionWindow(frozen)
+	require.Equal(t, protocol.ByteCount(frozen), sender.sender.GetCongestionWindow())
+	require.Equal(t, [][2]protocol.ByteCount{{cwnd, frozen}}, cwndChanges)
+
+	// neither acknowledgments nor losses change the window
+	sender.SendAvailableSendWindow()
+	sender.AckNPackets(5)
+	require.Equal(t, protocol.ByteCount(frozen), sender.sender.GetCongestionWindow())
+	sender.LoseNPackets(2)
+	require.Equal(t, protocol.ByteCount(frozen), sender.sender.GetCongestionWindow())
+	require.False(t, sender.sender.InRecovery())
+	sender.sender.OnRetransmissionTimeout(true)
+	sender.sender.OnPersistentCongestion()
+	require.Equal(t, protocol.ByteCount(frozen), sender.sender.GetCongestionWindow())
+	require.Len(t, cwndChanges, 1)
+
+	// the window continues from where it was before it was frozen
+	sender.sender.UnfreezeCongestionWindow()
+	require.Equal(t, cwnd, sender.sender.GetCongestionWindow())
+	require.Equal(t, [][2]protocol.ByteCount{{cwnd, frozen}, {frozen, cwnd}}, cwndChanges)
+}
+
 func TestCubicSenderTCPCubicResetEpochOnQuiescence(t *testing.T) {
 	sender := newTestCubicSender(true)
 
@@ -396,6 +969,54 @@ func TestCubicSenderMultipleLossesInOneWindow(t *testing.T) {
 	require.True(t, postLossWindow > sender.sender.GetCongestionWindow())
 }
 
+func TestCubicSenderSpuriousLoss(t *testing.T) {
+	for _, cubic := range []bool{false, true} {
+		t.Run(fmt.Sprintf("cubic: %t", cubic), func(t *testing.T) {
+			sender := newTestCubicSender(cubic)
+			// make sure the minimum rate protection doesn't hide the window reduction
+			sender.sender.minRatePolicy = MinRatePolicyPackets
+			sender.sender.minRatePackets = minCongestionWindowPackets
+			sender.SendAvailableSendWindow()
+			sender.AckNPackets(2)
+			sender.SendAvailableSendWindow()
+			cwnd := sender.sender.GetCongestionWindow()
+			ssthresh := sender.sender.SlowStartThreshold()
+
+			sender.LosePacket(3)
+			sender.LosePacket(5)
+			require.Less(t, sender.sender.GetCongestionWindow(), cwnd)
+			require.True(t, sender.sender.InRecovery())
+
+			// acknowledging only one of the lost packets doesn't restore the window
+			sender.sender.OnSpuriousLoss(3)
+			require.Less(t, sender.sender.GetCongestionWindow(), cwnd)
+			// a packet that wasn't lost during the recovery period is ignored
+			sender.sender.OnSpuriousLoss(1)
+			require.Less(t, sender.sender.GetCongestionWindow(), cwnd)
+
+			sender.sender.OnSpuriousLoss(5)
+			require.Equal(t, cwnd, sender.sender.GetCongestionWindow())
+			require.Equal(t, ssthresh, sender.sender.SlowStartThreshold())
+			require.False(t, sender.sender.InRecovery())
+
+			// the window is only restored once
+			sender.sender.OnSpuriousLoss(5)
+			require.Equal(t, cwnd, sender.sender.GetCongestionWindow())
+		})
+	}
+}
+
+func TestCubicSenderSpuriousLossAfterRTO(t *testing.T) {
+	sender := newTestCubicSender(false)
+	sender.SendAvailableSendWindow()
+	sender.LosePacket(1)
+	sender.sender.OnRetransmissionTimeout(true)
+	cwnd := sender.sender.GetCongestionWindow()
+
+	sender.sender.OnSpuriousLoss(1)
+	require.Equal(t, cwnd, sender.sender.GetCongestionWindow())
+}
+
 func TestCubicSender1ConnectionCongestionAvoidanceAtEndOfRecovery(t *testing.T) {
 	sender := newTestCubicSender(false)
 
@@ -501,6 +1122,7 @@ func TestCubicSenderSlowStartsUpToMaximumCongestionWindow(t *testing.T) {
 		initialCongestionWindowPackets*maxDatagramSize,
 		initialMaxCongestionWindow,
 		nil,
+		nil,
 	)
 
 	for i := 1; i < protocol.MaxCongestionWindowPackets; i++ {
@@ -510,6 +1132,49 @@ func TestCubicSenderSlowStartsUpToMaximumCongestionWindow(t *testing.T) {
 	require.Equal(t, initialMaxCongestionWindow, sender.GetCongestionWindow())
 }
 
+func TestCubicSenderHybridSlowStartParameters(t *testing.T) {
+	// roundsInSlowStart runs the sender through slow start, with the RTT increasing by 10ms after the second round.
+	// It returns the number of rounds until the sender exits slow start.
+	roundsInSlowStart := func(t *testing.T, conf *Config) int {
+		var clock mockClock
+		rttStats := utils.NewRTTStats()
+		sender := NewCubicSender(&clock, rttStats, &utils.ConnectionStats{}, maxDatagramSize, false, conf, nil)
+		var pn protocol.PacketNumber
+		for round := 1; round <= 8; round++ {
+			rtt := 60 * time.Millisecond
+			if round > 2 {
+				rtt += 10 * time.Millisecond
+			}
+			var bytesInFlight protocol.ByteCount
+			firstPN := pn + 1
+			for sender.CanSend(bytesInFlight) {
+				pn++
+				sender.OnPacketSent(clock.Now(), bytesInFlight, pn, maxDatagramSize, true)
+				bytesInFlight += maxDatagramSize
+			}
+			clock.Advance(rtt)
+			for acked := firstPN; acked <= pn; acked++ {
+				rttStats.UpdateRTT(rtt, 0)
+				sender.MaybeExitSlowStart()
+				if !sender.InSlowStart() {
+					return round
+				}
+				sender.OnPacketAcked(acked, maxDatagramSize, bytesInFlight, clock.Now())
+				bytesInFlight -= maxDatagramSize
+			}
+		}
+		return -1
+	}
+
+	// by default, an RTT increase of 10ms (more than 1/8th of 60ms) ends slow start
+	require.Equal(t, 3, roundsInSlowStart(t, nil))
+	// with a higher threshold, the connection stays in slow start
+	require.Equal(t, -1, roundsInSlowStart(t, &Config{HybridSlowStartMinDelayThreshold: 20 * time.Millisecond}))
+	require.Equal(t, -1, roundsInSlowStart(t, &Config{HybridSlowStartMinDelayThreshold: 12 * time.Millisecond, HybridSlowStartMaxDelayThreshold: 20 * time.Millisecond}))
+	// a lower max threshold caps the threshold derived from the min RTT (7.5ms)
+	require.Equal(t, 3, roundsInSlowStart(t, &Config{HybridSlowStartMaxDelayThreshold: 5 * time.Millisecond}))
+}
+
 func TestCubicSenderMaximumPacketSizeReduction(t *testing.T) {
 	sender := newTestCubicSender(false)
 	require.Panics(t, func() { sender.sender.SetMaxDatagramSize(initialMaxDatagramSize - 1) })
@@ -528,6 +1193,7 @@ func TestCubicSenderSlowStartsPacketSizeIncrease(t *testing.T) {
 		initialCongestionWindowPackets*maxDatagramSize,
 		initialMaxCongestionWindow,
 		nil,
+		nil,
 	)
 	const packetSize = initialMaxDatagramSize + 100
 	sender.SetMaxDatagramSize(packetSize)
@@ -539,6 +1205,100 @@ func TestCubicSenderSlowStartsPacketSizeIncrease(t *testing.T) {
 	require.True(t, sender.GetCongestionWindow() <= maxCwnd+packetSize)
 }
 
+func TestCubicSenderPacketSizeIncreaseScalesMinimumWindow(t *testing.T) {
+	const newPacketSize = 1400
+
+	for _, tc := range []struct {
+		name         string
+		conf         *Config
+		cwnd         protocol.ByteCount
+		expectedCwnd protocol.ByteCount
+	}{
+		{
+			name:         "at the minimum congestion window",
+			conf:         &Config{MinRatePolicy: MinRatePolicyPackets, MinRatePackets: 4},
+			cwnd:         minCongestionWindowPackets * maxDatagramSize,
+			expectedCwnd: minCongestionWindowPackets * newPacketSize,
+		},
+		{
+			name:         "at the minimum rate floor, in packets",
+			conf:         &Config{MinRatePolicy: MinRatePolicyPackets, MinRatePackets: 4},
+			cwnd:         4 * maxDatagramSize,
+			expectedCwnd: 4 * newPacketSize,
+		},
+		{
+			name:         "above the minimum rate floor",
+			conf:         &Config{MinRatePolicy: MinRatePolicyPackets, MinRatePackets: 4},
+			cwnd:         10 * maxDatagramSize,
+			expectedCwnd: 10 * maxDatagramSize,
+		},
+		{
+			// the BDP floor is defined in bytes, and doesn't depend on the datagram size
+			name:         "at the BDP floor",
+			conf:         &Config{},
+			cwnd:         protocol.ByteCount(float64(minBandwidthLimit) * utils.DefaultInitialRTT.Seconds() / 8),
+			expectedCwnd: protocol.ByteCount(float64(minBandwidthLimit) * utils.DefaultInitialRTT.Seconds() / 8),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var clock mockClock
+			var cwndChanges [][2]protocol.ByteCount
+			tc.conf.OnCongestionWindowChange = func(old, new protocol.ByteCount) {
+				cwndChanges = append(cwndChanges, [2]protocol.ByteCount{old, new})
+			}
+			sender := NewCubicSender(&clock, utils.NewRTTStats(), &utils.ConnectionStats{}, maxDatagramSize, false, tc.conf, nil)
+			sender.congestionWindow = tc.cwnd
+			sender.SetMaxDatagramSize(newPacketSize)
+			require.Equal(t, tc.expectedCwnd, sender.GetCongestionWindow())
+			if tc.expectedCwnd != tc.cwnd {
+				require.Equal(t, [][2]protocol.ByteCount{{tc.cwnd, tc.expectedCwnd}}, cwndChanges)
+			} else {
+				require.Empty(t, cwndChanges)
+			}
+		})
+	}
+}
+
+func TestCubicSenderPacketSizeIncreaseMidConnection(t *testing.T) {
+	const ceiling = 1400
+	var clock mockClock
+	sender := NewCubicSender(&clock, utils.NewRTTStats(), &utils.ConnectionStats{}, maxDatagramSize, false, &Config{
+		MinRatePolicy:          MinRatePolicyPackets,
+		MinRatePackets:         minCongestionWindowPackets,
+		MaxDatagramSizeCeiling: ceiling,
+	}, nil)
+
+	var pn protocol.PacketNumber
+	ackOnePacket := func(size protocol.ByteCount) {
+		pn++
+		sender.OnPacketSent(clock.Now(), 0, pn, size, true)
+		sender.OnPacketAcked(pn, size, sender.GetCongestionWindow(), clock.Now())
+	}
+
+	// in slow start, every acknowledged packet increases the window by the datagram size
+	cwnd := sender.GetCongestionWindow()
+	ackOnePacket(maxDatagramSize)
+	require.Equal(t, cwnd+maxDatagramSize, sender.GetCongestionWindow())
+
+	sender.SetMaxDatagramSize(1350)
+	cwnd = sender.GetCongestionWindow()
+	ackOnePacket(1350)
+	require.Equal(t, cwnd+1350, sender.GetCongestionWindow())
+	require.Equal(t, protocol.ByteCount(1350*protocol.MaxCongestionWindowPackets), sender.maxCongestionWindow())
+
+	// beyond the ceiling, the window math doesn't scale with the datagram size any more
+	sender.SetMaxDatagramSize(1500)
+	cwnd = sender.GetCongestionWindow()
+	ackOnePacket(1500)
+	require.Equal(t, cwnd+ceiling, sender.GetCongestionWindow())
+	require.Equal(t, protocol.ByteCount(ceiling*protocol.MaxCongestionWindowPackets), sender.maxCongestionWindow())
+	require.Equal(t, protocol.ByteCount(ceiling), sender.cubic.maxDatagramSize)
+	require.NotPanics(t, func() { sender.SetMaxDatagramSize(1452) })
+
+	sender.OnRetransmissionTimeout(true)
+	require.Equal(t, protocol.ByteCount(minCongestionWindowPackets*ceiling), sender.GetCongestionWindow())
+}
+
 func TestCubicSenderLimitCwndIncreaseInCongestionAvoidance(t *testing.T) {
 	// Enable Cubic.
 	var clock mockClock
@@ -552,6 +1312,7 @@ func TestCubicSenderLimitCwndIncreaseInCongestionAvoidance(t *testing.T) {
 		initialCongestionWindowPackets*maxDatagramSize,
 		MaxCongestionWindow,
 		nil,
+		nil,
 	)
 	testSender := &testCubicSender{
 		sender:   sender,
@@ -592,3 +1353,484 @@ func TestCubicSenderLimitCwndIncreaseInCongestionAvoidance(t *testing.T) {
 	testSender.AckNPackets(2)
 	require.Equal(t, savedCwnd+maxDatagramSize, sender.GetCongestionWindow())
 }
+
+func TestCubicSenderCongestionWindowChangeCallback(t *testing.T) {
+	type change struct{ old, new protocol.ByteCount }
+	var changes []change
+	var clock mockClock
+	rttStats := utils.RTTStats{}
+	sender := newCubicSender(
+		&clock,
+		&rttStats,
+		&utils.ConnectionStats{},
+		true,
+		protocol.InitialPacketSize,
+		initialCongestionWindowPackets*maxDatagramSize,
+		MaxCongestionWindow,
+		&Config{OnCongestionWindowChange: func(old, new protocol.ByteCount) { changes = append(changes, change{old, new}) }},
+		nil,
+	)
+	testSender := &testCubicSender{
+		sender:       sender,
+		clock:        &clock,
+		rttStats:     &rttStats,
+		packetNumber: 1,
+	}
+
+	// slow start: every ack increases the window by one packet
+	testSender.SendAvailableSendWindow()
+	testSender.AckNPackets(2)
+	require.Equal(t, []change{
+		{defaultWindowTCP, defaultWindowTCP + maxDatagramSize},
+		{defaultWindowTCP + maxDatagramSize, defaultWindowTCP + 2*maxDatagramSize},
+	}, changes)
+
+	// loss: the window is cut
+	changes = changes[:0]
+	cwnd := sender.GetCongestionWindow()
+	testSender.SendAvailableSendWindow()
+	testSender.LoseNPackets(1)
+	require.Equal(t, []change{{cwnd, sender.GetCongestionWindow()}}, changes)
+
+	// acks during recovery don't change the window
+	changes = changes[:0]
+	testSender.AckNPackets(1)
+	require.Empty(t, changes)
+
+	// RTO: the window is reset to the minimum rate protection floor, which it's already at
+	cwnd = sender.GetCongestionWindow()
+	sender.OnRetransmissionTimeout(true)
+	require.Equal(t, cwnd, sender.GetCongestionWindow())
+	require.Empty(t, changes)
+}
+
+func TestCubicSenderInitialCongestionWindow(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		packets  int
+		expected protocol.ByteCount
+	}{
+		{name: "default", packets: 0, expected: initialCongestionWindow * maxDatagramSize},
+		{name: "RFC 9002", packets: 10, expected: 10 * maxDatagramSize},
+		{name: "large", packets: 100, expected: 100 * maxDatagramSize},
+		{name: "below minimum", packets: 1, expected: minCongestionWindowPackets * maxDatagramSize},
+		{name: "above maximum", packets: 1e6, expected: protocol.MaxCongestionWindowPackets * maxDatagramSize},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sender := NewCubicSender(
+				DefaultClock{},
+				utils.NewRTTStats(),
+				&utils.ConnectionStats{},
+				maxDatagramSize,
+				true,
+				&Config{InitialCongestionWindowPackets: tc.packets},
+				nil,
+			)
+			require.Equal(t, tc.expected, sender.GetCongestionWindow())
+			sender.OnConnectionMigration()
+			require.Equal(t, tc.expected, sender.GetCongestionWindow())
+		})
+	}
+}
+
+func TestCubicSenderState(t *testing.T) {
+	sender := newTestCubicSender(false)
+	require.Equal(t, StateApplicationLimited, sender.sender.State(sender.bytesInFlight))
+
+	sender.SendAvailableSendWindow()
+	require.Equal(t, StateSlowStart, sender.sender.State(sender.bytesInFlight))
+
+	sender.AckNPackets(2)
+	sender.SendAvailableSendWindow()
+	sender.LoseNPackets(1)
+	require.Equal(t, StateRecovery, sender.sender.State(sender.bytesInFlight))
+
+	// acknowledge a packet sent after the loss, which ends recovery
+	sender.SendAvailableSendWindow()
+	sender.ackedPacketNumber = sender.packetNumber - 2
+	sender.AckNPackets(1)
+	sender.SendAvailableSendWindow()
+	require.Equal(t, StateCongestionAvoidance, sender.sender.State(sender.bytesInFlight))
+}
+
+func TestCubicSenderConnectionMigrationResetsMaxDatagramSize(t *testing.T) {
+	sender := newTestCubicSender(false)
+	sender.sender.SetMaxDatagramSize(1500)
+	sender.sender.OnConnectionMigration()
+	require.Equal(t, protocol.ByteCount(protocol.InitialPacketSize), sender.sender.maxDatagramSize)
+	// the path MTU is discovered anew on the new path
+	require.NotPanics(t, func() { sender.sender.SetMaxDatagramSize(1400) })
+}
+
+func TestCubicSenderConnectionMigrationResetsState(t *testing.T) {
+	var clock mockClock
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(100*time.Millisecond, 0)
+	conf := &Config{MinRatePolicy: MinRatePolicyPackets, MinRatePackets: minCongestionWindowPackets}
+	fresh := NewCubicSender(&clock, rttStats, &utils.ConnectionStats{}, maxDatagramSize, false, conf, nil)
+	sender := NewCubicSender(&clock, rttStats, &utils.ConnectionStats{}, maxDatagramSize, false, conf, nil)
+	for pn := protocol.PacketNumber(1); pn <= 10; pn++ {
+		sender.OnPacketSent(clock.Now(), protocol.ByteCount(pn-1)*maxDatagramSize, pn, maxDatagramSize, true)
+	}
+	clock.Advance(100 * time.Millisecond)
+	sender.OnPacketAcked(1, maxDatagramSize, 10*maxDatagramSize, clock.Now())
+	sender.OnCongestionEvent(2, maxDatagramSize, 9*maxDatagramSize, TrafficClassDefault, false)
+	// use up the pacing budget
+	for pn := protocol.PacketNumber(11); sender.HasPacingBudget(clock.Now()); pn++ {
+		sender.OnPacketSent(clock.Now(), 0, pn, maxDatagramSize, true)
+	}
+	require.NotEqual(t, snapshotSenderState(fresh), snapshotSenderState(sender))
+
+	sender.OnConnectionMigration()
+	require.Equal(t, snapshotSenderState(fresh), snapshotSenderState(sender))
+}
+
+func TestCubicSenderConnectionMigrationMatchesNewSender(t *testing.T) {
+	var clock mockClock
+	clock.Advance(time.Second)
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(100*time.Millisecond, 0)
+	var eventRecorder events.Recorder
+	conf := &Config{MinRatePolicy: MinRatePolicyPackets, MinRatePackets: 2, MinSlowStartBytes: maxDatagramSize}
+	sender := NewCubicSender(&clock, rttStats, &utils.ConnectionStats{}, maxDatagramSize, false, conf, &eventRecorder)
+	fresh := NewCubicSender(&clock, rttStats, &utils.ConnectionStats{}, maxDatagramSize, false, conf, nil)
+
+	// leave slow start, recover, and grow the window in congestion avoidance
+	var pn protocol.PacketNumber
+	for range 20 {
+		pn++
+		sender.OnPacketSent(clock.Now(), 0, pn, maxDatagramSize, true)
+		sender.OnPacketAcked(pn, maxDatagramSize, sender.GetCongestionWindow(), clock.Now())
+	}
+	pn++
+	sender.OnPacketSent(clock.Now(), 0, pn, maxDatagramSize, true)
+	sender.OnCongestionEvent(pn, maxDatagramSize, sender.GetCongestionWindow(), TrafficClassDefault, false)
+	clock.Advance(200 * time.Millisecond)
+	for range 20 {
+		pn++
+		sender.OnPacketSent(clock.Now(), 0, pn, maxDatagramSize, true)
+		sender.OnPacketAcked(pn, maxDatagramSize, sender.GetCongestionWindow(), clock.Now())
+	}
+	require.Equal(t, StateCongestionAvoidance, sender.State(sender.GetCongestionWindow()))
+	require.Equal(t, qlog.CongestionStateCongestionAvoidance, sender.lastState)
+	require.NotZero(t, sender.cubic.epoch)
+	eventRecorder.Clear()
+
+	sender.OnConnectionMigration()
+	// the transition back to slow start is logged
+	require.Equal(t,
+		[]qlogwriter.Event{qlog.CongestionStateUpdated{State: qlog.CongestionStateSlowStart}},
+		eventRecorder.Events(),
+	)
+	eventRecorder.Clear()
+
+	// Apart from the pacer (whose rate is a closure), the qlogger and the connection stats (which record the loss),
+	// the state equals that of a new sender.
+	migrated, expected := *sender, *fresh
+	migrated.pacer, expected.pacer = nil, nil
+	migrated.qlogger, expected.qlogger = nil, nil
+	migrated.connStats, expected.connStats = nil, nil
+	require.Equal(t, expected, migrated)
+
+	// the next state change is logged as well, once the slow start guard (MinSlowStartBytes) expired
+	pn++
+	sender.OnPacketSent(clock.Now(), 0, pn, maxDatagramSize, true)
+	sender.OnPacketAcked(pn, maxDatagramSize, sender.GetCongestionWindow(), clock.Now())
+	pn++
+	sender.OnPacketSent(clock.Now(), 0, pn, maxDatagramSize, true)
+	sender.OnCongestionEvent(pn, maxDatagramSize, sender.GetCongestionWindow(), TrafficClassDefault, false)
+	require.Equal(t,
+		[]qlogwriter.Event{qlog.CongestionStateUpdated{State: qlog.CongestionStateRecovery}},
+		eventRecorder.Events(),
+	)
+}
+
+func TestCubicSenderMinRatePolicy(t *testing.T) {
+	newSender := func(conf *Config) *cubicSender {
+		rttStats := utils.NewRTTStats()
+		// at 1s RTT, sustaining 5 Mbps requires a window of 625 KB
+		rttStats.UpdateRTT(time.Second, 0)
+		return NewCubicSender(DefaultClock{}, rttStats, &utils.ConnectionStats{}, maxDatagramSize, true, conf, nil)
+	}
+
+	t.Run("BDP", func(t *testing.T) {
+		sender := newSender(nil)
+		sender.OnRetransmissionTimeout(true)
+		require.Equal(t, protocol.ByteCount(minBandwidthLimit/8), sender.GetCongestionWindow())
+	})
+
+	t.Run("packets", func(t *testing.T) {
+		sender := newSender(&Config{MinRatePolicy: MinRatePolicyPackets, MinRatePackets: 10})
+		sender.OnRetransmissionTimeout(true)
+		require.Equal(t, 10*maxDatagramSize, sender.GetCongestionWindow())
+
+		sender.OnPacketSent(monotime.Now(), 0, 1, maxDatagramSize, true)
+		sender.OnPacketAcked(1, maxDatagramSize, sender.GetCongestionWindow(), monotime.Now())
+		sender.OnPacketSent(monotime.Now(), 0, 2, maxDatagramSize, true)
+		for i := 0; i < 10; i++ {
+			sender.OnCongestionEvent(2, maxDatagramSize, sender.GetCongestionWindow(), TrafficClassDefault, false)
+			sender.largestSentAtLastCutback = protocol.InvalidPacketNumber
+		}
+		require.Equal(t, 10*maxDatagramSize, sender.GetCongestionWindow())
+	})
+
+	t.Run("packets, using the default", func(t *testing.T) {
+		sender := newSender(&Config{MinRatePolicy: MinRatePolicyPackets})
+		sender.OnRetransmissionTimeout(true)
+		require.Equal(t, initialCongestionWindow*maxDatagramSize, sender.GetCongestionWindow())
+	})
+
+	t.Run("high RTT", func(t *testing.T) {
+		const maxCongestionWindow = protocol.MaxCongestionWindowPackets * maxDatagramSize
+		rttStats := utils.NewRTTStats()
+		// at 60s RTT, sustaining 5 Mbps would require a window of almost 40 MB
+		rttStats.UpdateRTT(time.Minute, 0)
+		sender := NewCubicSender(DefaultClock{}, rttStats, &utils.ConnectionStats{}, maxDatagramSize, true, nil, nil)
+		require.Greater(t, BDP(minBandwidthLimit*BitsPerSecond, time.Minute), maxCongestionWindow)
+
+		sender.OnPacketSent(monotime.Now(), 0, 1, maxDatagramSize, true)
+		sender.OnCongestionEvent(1, maxDatagramSize, maxDatagramSize, TrafficClassDefault, false)
+		require.Equal(t, maxCongestionWindow, sender.GetCongestionWindow())
+		sender.OnRetransmissionTimeout(true)
+		require.Equal(t, maxCongestionWindow, sender.GetCongestionWindow())
+	})
+}
+
+func TestCubicSenderMinRateProtectionActive(t *testing.T) {
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(100*time.Millisecond, 0)
+	var connStats utils.ConnectionStats
+	sender := NewCubicSender(DefaultClock{}, rttStats, &connStats, maxDatagramSize, true, &Config{MinRatePolicy: MinRatePolicyPackets, MinRatePackets: 10}, nil)
+	require.False(t, sender.MinRateProtectionActive())
+
+	// the retransmission timeout collapses the window, and the floor is applied
+	sender.OnRetransmissionTimeout(true)
+	require.Equal(t, 10*maxDatagramSize, sender.GetCongestionWindow())
+	require.True(t, sender.MinRateProtectionActive())
+	require.True(t, connStats.MinRateProtectionActive.Load())
+
+	// the window grows beyond the floor
+	sender.OnPacketSent(monotime.Now(), 0, 1, maxDatagramSize, true)
+	sender.OnPacketAcked(1, maxDatagramSize, sender.GetCongestionWindow(), monotime.Now())
+	require.Equal(t, 11*maxDatagramSize, sender.GetCongestionWindow())
+	require.False(t, sender.MinRateProtectionActive())
+	require.False(t, connStats.MinRateProtectionActive.Load())
+
+	// a loss reduces the window below the floor
+	sender.OnPacketSent(monotime.Now(), 0, 2, maxDatagramSize, true)
+	sender.OnCongestionEvent(2, maxDatagramSize, sender.GetCongestionWindow(), TrafficClassDefault, false)
+	require.Equal(t, 10*maxDatagramSize, sender.GetCongestionWindow())
+	require.True(t, sender.MinRateProtectionActive())
+	require.True(t, connStats.MinRateProtectionActive.Load())
+
+	// the sender starts over on the new path
+	sender.OnConnectionMigration()
+	require.False(t, sender.MinRateProtectionActive())
+	require.False(t, connStats.MinRateProtectionActive.Load())
+}
+
+func TestCubicSenderResume(t *testing.T) {
+	const maxCongestionWindow = protocol.MaxCongestionWindowPackets * maxDatagramSize
+	for _, tc := range []struct {
+		name                       string
+		cwnd, ssthresh             protocol.ByteCount
+		expectedCwnd, expectedSSTh protocol.ByteCount
+	}{
+		{name: "not set", expectedCwnd: initialCongestionWindow * maxDatagramSize, expectedSSTh: protocol.MaxByteCount},
+		{name: "half of the previous window", cwnd: 200 * maxDatagramSize, ssthresh: 150 * maxDatagramSize, expectedCwnd: 100 * maxDatagramSize, expectedSSTh: 150 * maxDatagramSize},
+		{name: "small window", cwnd: 10 * maxDatagramSize, expectedCwnd: initialCongestionWindow * maxDatagramSize, expectedSSTh: protocol.MaxByteCount},
+		{name: "above maximum", cwnd: 1 << 40, ssthresh: 1 << 40, expectedCwnd: maxCongestionWindow, expectedSSTh: maxCongestionWindow},
+		{name: "slow start threshold below minimum", ssthresh: 1, expectedCwnd: initialCongestionWindow * maxDatagramSize, expectedSSTh: minCongestionWindowPackets * maxDatagramSize},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sender := NewCubicSender(
+				DefaultClock{},
+				utils.NewRTTStats(),
+				&utils.ConnectionStats{},
+				maxDatagramSize,
+				true,
+				&Config{ResumeCongestionWindow: tc.cwnd, ResumeSlowStartThreshold: tc.ssthresh},
+				nil,
+			)
+			require.Equal(t, tc.expectedCwnd, sender.GetCongestionWindow())
+			require.Equal(t, tc.expectedSSTh, sender.SlowStartThreshold())
+			// after a migration, the connection starts from scratch
+			sender.OnConnectionMigration()
+			require.Equal(t, initialCongestionWindow*maxDatagramSize, sender.GetCongestionWindow())
+			require.Equal(t, protocol.MaxByteCount, sender.SlowStartThreshold())
+		})
+	}
+}
+
+func TestCubicSenderMaxPacingRate(t *testing.T) {
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(10*time.Millisecond, 0)
+	sender := NewCubicSender(DefaultClock{}, rttStats, &utils.ConnectionStats{}, maxDatagramSize, false, &Config{MaxPacingRate: Bandwidth(100*maxDatagramSize) * BytesPerSecond}, nil)
+
+	// the congestion window is not affected by the cap
+	require.Equal(t, initialCongestionWindow*maxDatagramSize, sender.GetCongestionWindow())
+	now := monotime.Now()
+	for sender.HasPacingBudget(now) {
+		sender.OnPacketSent(now, 0, 1, maxDatagramSize, true)
+	}
+	// without the cap, packets would be sent every 0.25ms
+	require.Equal(t, 10*time.Millisecond, sender.TimeUntilSend(0).Sub(now))
+}
+
+func TestCubicSenderMaxPacingBurst(t *testing.T) {
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(10*time.Millisecond, 0)
+	sender := NewCubicSender(DefaultClock{}, rttStats, &utils.ConnectionStats{}, maxDatagramSize, false, &Config{MaxPacingBurst: 2 * maxDatagramSize}, nil)
+
+	now := monotime.Now()
+	require.Equal(t, 2*maxDatagramSize, sender.PacingBudget(now))
+	sender.OnPacketSent(now, 0, 1, maxDatagramSize, true)
+	sender.OnPacketSent(now, maxDatagramSize, 2, maxDatagramSize, true)
+	require.False(t, sender.HasPacingBudget(now))
+	require.True(t, sender.TimeUntilSend(2*maxDatagramSize).After(now))
+
+	// the budget accumulated while idle is capped
+	require.Equal(t, 2*maxDatagramSize, sender.PacingBudget(now.Add(time.Second)))
+}
+
+func TestCubicSenderEstimatedSendTime(t *testing.T) {
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(100*time.Millisecond, 0)
+	sender := NewCubicSender(DefaultClock{}, rttStats, &utils.ConnectionStats{}, maxDatagramSize, false, nil, nil)
+
+	// one congestion window is sent per RTT
+	cwnd := sender.GetCongestionWindow()
+	require.Equal(t, 100*time.Millisecond, sender.EstimatedSendTime(cwnd))
+	require.Equal(t, 250*time.Millisecond, sender.EstimatedSendTime(cwnd*5/2))
+	require.Zero(t, sender.EstimatedSendTime(0))
+
+	// the estimate follows the RTT
+	rttStats.UpdateRTT(200*time.Millisecond, 0)
+	require.Greater(t, sender.EstimatedSendTime(cwnd), 100*time.Millisecond)
+}
+
+func TestCubicSenderPacingGain(t *testing.T) {
+	for _, tc := range []struct {
+		name                             string
+		conf                             *Config
+		slowStartGain, congAvoidanceGain float64
+	}{
+		{name: "default", conf: &Config{}, slowStartGain: defaultSlowStartPacingGain, congAvoidanceGain: defaultCongestionAvoidancePacingGain},
+		{name: "custom", conf: &Config{SlowStartPacingGain: 3, CongestionAvoidancePacingGain: 1}, slowStartGain: 3, congAvoidanceGain: 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.conf.MinRatePolicy = MinRatePolicyPackets
+			tc.conf.MinRatePackets = minCongestionWindowPackets
+			rttStats := utils.NewRTTStats()
+			rttStats.UpdateRTT(100*time.Millisecond, 0)
+			sender := NewCubicSender(DefaultClock{}, rttStats, &utils.ConnectionStats{}, maxDatagramSize, false, tc.conf, nil)
+			require.True(t, sender.InSlowStart())
+			require.Equal(t, Bandwidth(float64(sender.BandwidthEstimate())*tc.slowStartGain), sender.pacingRate())
+
+			// a loss ends slow start
+			sender.OnPacketSent(monotime.Now(), 0, 1, maxDatagramSize, true)
+			sender.OnCongestionEvent(1, maxDatagramSize, sender.GetCongestionWindow(), TrafficClassDefault, false)
+			require.False(t, sender.InSlowStart())
+			require.Equal(t, Bandwidth(float64(sender.BandwidthEstimate())*tc.congAvoidanceGain), sender.pacingRate())
+		})
+	}
+}
+
+func TestCubicSenderDisablePacing(t *testing.T) {
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(10*time.Millisecond, 0)
+	sender := NewCubicSender(DefaultClock{}, rttStats, &utils.ConnectionStats{}, maxDatagramSize, false, &Config{
+		DisablePacing:  true,
+		MaxPacingRate:  Bandwidth(maxDatagramSize) * BytesPerSecond,
+		MaxPacingBurst: maxDatagramSize,
+	}, nil)
+
+	now := monotime.Now()
+	// the whole congestion window can be sent back-to-back
+	for i := range sender.GetCongestionWindow() / maxDatagramSize {
+		require.True(t, sender.HasPacingBudget(now))
+		require.Zero(t, sender.TimeUntilSend(0))
+		sender.OnPacketSent(now, i*maxDatagramSize, protocol.PacketNumber(i+1), maxDatagramSize, true)
+	}
+	require.False(t, sender.CanSend(sender.GetCongestionWindow()))
+	require.True(t, sender.HasPacingBudget(now))
+	require.Zero(t, sender.TimeUntilSend(0))
+}
+
+func TestCubicSenderCubicParameters(t *testing.T) {
+	for _, tc := range []struct {
+		name                      string
+		conf                      *Config
+		expectedBeta, expectedMax float32
+	}{
+		{name: "default", conf: nil, expectedBeta: beta, expectedMax: betaLastMax},
+		{name: "custom", conf: &Config{CubicBeta: 0.5, CubicBetaLastMax: 0.6}, expectedBeta: 0.5, expectedMax: 0.6},
+		{name: "out of range", conf: &Config{CubicBeta: 1.5, CubicBetaLastMax: -1}, expectedBeta: beta, expectedMax: betaLastMax},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sender := NewCubicSender(DefaultClock{}, utils.NewRTTStats(), &utils.ConnectionStats{}, maxDatagramSize, false, tc.conf, nil)
+			require.Equal(t, tc.expectedBeta, sender.cubic.cubicBeta)
+			require.Equal(t, tc.expectedMax, sender.cubic.cubicBetaLastMax)
+		})
+	}
+}
+
+func TestCubicSenderRenoBeta(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		conf     *Config
+		expected float64
+	}{
+		{name: "default", conf: &Config{}, expected: renoBeta},
+		{name: "custom", conf: &Config{RenoBeta: 0.5}, expected: 0.5},
+		{name: "out of range", conf: &Config{RenoBeta: 1.2}, expected: renoBeta},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.conf.MinRatePolicy = MinRatePolicyPackets
+			tc.conf.MinRatePackets = minCongestionWindowPackets
+			sender := NewCubicSender(DefaultClock{}, utils.NewRTTStats(), &utils.ConnectionStats{}, maxDatagramSize, true, tc.conf, nil)
+			cwnd := sender.GetCongestionWindow()
+			sender.OnPacketSent(monotime.Now(), 0, 1, maxDatagramSize, true)
+			sender.OnCongestionEvent(1, maxDatagramSize, cwnd, TrafficClassDefault, false)
+			require.Equal(t, protocol.ByteCount(tc.expected*float64(cwnd)), sender.GetCongestionWindow())
+			require.Equal(t, sender.GetCongestionWindow(), sender.SlowStartThreshold())
+		})
+	}
+}
+
+func TestCubicSenderNumEmulatedConnections(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		reno     bool
+		expected float64 // the congestion window after loss, relative to the window before
+	}{
+		{name: "Reno", reno: true, expected: (1 + renoBeta) / 2},
+		{name: "CUBIC", reno: false, expected: float64(1+beta) / 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sender := NewCubicSender(DefaultClock{}, utils.NewRTTStats(), &utils.ConnectionStats{}, maxDatagramSize, tc.reno, &Config{
+				NumEmulatedConnections: 2,
+				MinRatePolicy:          MinRatePolicyPackets,
+				MinRatePackets:         minCongestionWindowPackets,
+			}, nil)
+			require.Equal(t, 2, sender.cubic.numConnections)
+			cwnd := sender.GetCongestionWindow()
+			sender.OnPacketSent(monotime.Now(), 0, 1, maxDatagramSize, true)
+			sender.OnCongestionEvent(1, maxDatagramSize, cwnd, TrafficClassDefault, false)
+			require.InEpsilon(t, tc.expected*float64(cwnd), float64(sender.GetCongestionWindow()), 0.001)
+		})
+	}
+
+	t.Run("Reno window growth", func(t *testing.T) {
+		rttStats := utils.NewRTTStats()
+		rttStats.UpdateRTT(100*time.Millisecond, 0)
+		sender := NewCubicSender(DefaultClock{}, rttStats, &utils.ConnectionStats{}, maxDatagramSize, true, &Config{NumEmulatedConnections: 2}, nil)
+		sender.slowStartThreshold = sender.GetCongestionWindow()
+		cwnd := sender.GetCongestionWindow()
+		// acknowledging half a window grows the window by one packet
+		for i := range cwnd / maxDatagramSize / 2 {
+			sender.OnPacketAcked(protocol.PacketNumber(i), maxDatagramSize, sender.GetCongestionWindow(), monotime.Now())
+		}
+		require.Equal(t, cwnd+maxDatagramSize, sender.GetCongestionWindow())
+	})
+}
@@ -29,7 +29,7 @@ func cubicConvexCwnd(initialCwnd protocol.ByteCount, rtt, elapsedTime time.Durat
 
 func TestCubicAboveOriginWithTighterBounds(t *testing.T) {
 	var clock mockClock
-	cubic := NewCubic(&clock)
+	cubic := NewCubic(&clock, 0)
 	cubic.SetNumConnections(int(numConnections))
 
 	// Convex growth.
@@ -80,7 +80,7 @@ func TestCubicAboveOriginWithTighterBounds(t *testing.T) {
 
 func TestCubicAboveOriginWithFineGrainedCubing(t *testing.T) {
 	var clock mockClock
-	cubic := NewCubic(&clock)
+	cubic := NewCubic(&clock, 0)
 	cubic.SetNumConnections(int(numConnections))
 
 	currentCwnd := 1000 * maxDatagramSize
@@ -107,7 +107,7 @@ func TestCubicAboveOriginWithFineGrainedCubing(t *testing.T) {
 
 func TestCubicHandlesPerAckUpdates(t *testing.T) {
 	var clock mockClock
-	cubic := NewCubic(&clock)
+	cubic := NewCubic(&clock, 0)
 	cubic.SetNumConnections(int(numConnections))
 
 	initialCwndPackets := 150
@@ -141,7 +141,7 @@ func TestCubicHandlesPerAckUpdates(t *testing.T) {
 
 func TestCubicHandlesLossEvents(t *testing.T) {
 	var clock mockClock
-	cubic := NewCubic(&clock)
+	cubic := NewCubic(&clock, 0)
 	cubic.SetNumConnections(int(numConnections))
 
 	rttMin := 100 * time.Millisecond
@@ -180,7 +180,7 @@ func TestCubicHandlesLossEvents(t *testing.T) {
 
 func TestCubicBelowOrigin(t *testing.T) {
 	var clock mockClock
-	cubic := NewCubic(&clock)
+	cubic := NewCubic(&clock, 0)
 	cubic.SetNumConnections(int(numConnections))
 
 	rttMin := 100 * time.Millisecond
@@ -203,3 +203,118 @@ func TestCubicBelowOrigin(t *testing.T) {
 	expectedCwnd = 553632 * maxDatagramSize / 1460
 	require.Equal(t, expectedCwnd, currentCwnd)
 }
+
+func TestCubicLongElapsedTime(t *testing.T) {
+	for _, elapsed := range []time.Duration{time.Minute, time.Hour, 30 * 24 * time.Hour, 10 * 365 * 24 * time.Hour} {
+		t.Run(elapsed.String(), func(t *testing.T) {
+			for _, datagramSize := range []protocol.ByteCount{maxDatagramSize, 65535} {
+				var clock mockClock
+				clock.Advance(time.Second)
+				cubic := NewCubic(&clock, 0)
+				cubic.SetMaxDatagramSize(datagramSize)
+
+				currentCwnd := 1000 * datagramSize
+				currentCwnd = cubic.CongestionWindowAfterPacketLoss(currentCwnd)
+				// start the epoch
+				currentCwnd = cubic.CongestionWindowAfterAck(datagramSize, currentCwnd, 100*time.Millisecond, clock.Now())
+
+				// the cubic function grows without bounds, but the window is limited by the bytes acknowledged
+				clock.Advance(elapsed)
+				const ackedBytes = 100 * maxDatagramSize
+				cwnd := cubic.CongestionWindowAfterAck(ackedBytes, currentCwnd, 100*time.Millisecond, clock.Now())
+				require.Equal(t, currentCwnd+ackedBytes/2, cwnd)
+			}
+		})
+	}
+}
+
+func TestCubicMaxDatagramSize(t *testing.T) {
+	var clock mockClock
+	clock.Advance(time.Second)
+
+	// The cubic function is calibrated in packets:
+	// Losing a window of 100 packets results in the same time to the origin point,
+	// independent of the packet size.
+	timeToOriginPoint := func(datagramSize protocol.ByteCount) uint32 {
+		cubic := NewCubic(&clock, 0)
+		cubic.SetMaxDatagramSize(datagramSize)
+		cwnd := cubic.CongestionWindowAfterPacketLoss(100 * datagramSize)
+		cubic.CongestionWindowAfterAck(datagramSize, cwnd, 100*time.Millisecond, clock.Now())
+		return cubic.timeToOriginPoint
+	}
+
+	require.NotZero(t, timeToOriginPoint(maxDatagramSize))
+	require.Equal(t, timeToOriginPoint(maxDatagramSize), timeToOriginPoint(2*maxDatagramSize))
+}
+
+func TestCubicParameters(t *testing.T) {
+	var clock mockClock
+	cubic := NewCubic(&clock, 0)
+	cubic.SetParameters(0.5, 0.6)
+
+	currentCwnd := 100 * maxDatagramSize
+	require.Equal(t, 50*maxDatagramSize, cubic.CongestionWindowAfterPacketLoss(currentCwnd))
+	require.Equal(t, currentCwnd, cubic.lastMaxCongestionWindow)
+
+	// fast convergence: the window didn't recover to the last maximum before the next loss
+	currentCwnd = 50 * maxDatagramSize
+	require.Equal(t, 25*maxDatagramSize, cubic.CongestionWindowAfterPacketLoss(currentCwnd))
+	require.Equal(t, 30*maxDatagramSize, cubic.lastMaxCongestionWindow)
+}
+
+func TestCubicWithoutTCPFriendliness(t *testing.T) {
+	var clock mockClock
+	cubic := NewCubic(&clock, 0)
+	cubic.SetNumConnections(int(numConnections))
+	cubic.SetTCPFriendliness(false)
+
+	// With a short RTT, the window of an emulated Reno sender grows faster than the cubic function.
+	const rttMin = 10 * time.Millisecond
+	currentCwnd := 10 * maxDatagramSize
+	initialCwnd := currentCwnd
+	clock.Advance(time.Millisecond)
+	initialTime := clock.Now()
+	for range 100 {
+		currentCwnd = cubic.CongestionWindowAfterAck(maxDatagramSize, currentCwnd, rttMin, clock.Now())
+		require.Equal(t, cubicConvexCwnd(initialCwnd, rttMin, clock.Now().Sub(initialTime)), currentCwnd)
+		clock.Advance(time.Millisecond)
+	}
+	require.Less(t, currentCwnd, cubic.estimatedTCPcongestionWindow)
+}
+
+func TestCubicCongestionWindowScale(t *testing.T) {
+	var clock mockClock
+	clock.Advance(time.Second)
+
+	// doubling the scale doubles the growth of the cubic function
+	const rttMin = 10 * time.Millisecond
+	cubic := NewCubic(&clock, 2*cubeCongestionWindowScale)
+	cubic.SetTCPFriendliness(false)
+	currentCwnd := 10 * maxDatagramSize
+	initialCwnd := currentCwnd
+	initialTime := clock.Now()
+	for range 100 {
+		currentCwnd = cubic.CongestionWindowAfterAck(maxDatagramSize, currentCwnd, rttMin, clock.Now())
+		expected := initialCwnd + 2*(cubicConvexCwnd(initialCwnd, rttMin, clock.Now().Sub(initialTime))-initialCwnd)
+		require.InDelta(t, float64(expected), float64(currentCwnd), 1)
+		clock.Advance(time.Millisecond)
+	}
+
+	// after a loss, the window returns to the last maximum sooner
+	timeToOriginPoint := func(scale int64) uint32 {
+		cubic := NewCubic(&clock, scale)
+		cwnd := cubic.CongestionWindowAfterPacketLoss(100 * maxDatagramSize)
+		cubic.CongestionWindowAfterAck(maxDatagramSize, cwnd, 100*time.Millisecond, clock.Now())
+		return cubic.timeToOriginPoint
+	}
+	require.Equal(t, timeToOriginPoint(cubeCongestionWindowScale), timeToOriginPoint(0))
+	require.Less(t, timeToOriginPoint(8*cubeCongestionWindowScale), timeToOriginPoint(cubeCongestionWindowScale))
+
+	// far from the origin point, a large scale doesn't overflow
+	cubic = NewCubic(&clock, 1<<30)
+	cubic.SetTCPFriendliness(false)
+	start := clock.Now()
+	cubic.CongestionWindowAfterAck(maxDatagramSize, initialCwnd, rttMin, start)
+	clock.Advance(time.Hour)
+	require.Equal(t, initialCwnd+100*maxDatagramSize/2, cubic.CongestionWindowAfterAck(100*maxDatagramSize, initialCwnd, rttMin, clock.Now()))
+}
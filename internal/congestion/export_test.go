@@ -0,0 +1,77 @@
+package congestion
+
+import (
+	"github.com/quic-go/quic-go/internal/monotime"
+	"github.com/quic-go/quic-go/internal/protocol"
+)
+
+// senderState is a snapshot of the internal state of a sender.
+// It allows tests to assert on the exact state after a sequence of events,
+// and to compare the state of two senders using require.Equal.
+// Fields that don't apply to a sender are zero.
+type senderState struct {
+	CongestionWindow   protocol.ByteCount
+	SlowStartThreshold protocol.ByteCount
+	InSlowStart        bool
+	InRecovery         bool
+	PacingBudget       protocol.ByteCount
+
+	// CUBIC / Reno
+	LargestSentPacketNumber     protocol.PacketNumber
+	LargestAckedPacketNumber    protocol.PacketNumber
+	LargestSentAtLastCutback    protocol.PacketNumber
+	NumAckedPackets             uint64
+	NumAckedBytes               protocol.ByteCount
+	Epoch                       monotime.Time
+	LastMaxCongestionWindow     protocol.ByteCount
+	OriginPointCongestionWindow protocol.ByteCount
+	TimeToOriginPoint           uint32
+
+	// Hysteria
+	TargetBps    protocol.ByteCount
+	CurrentBps   protocol.ByteCount
+	StableBps    protocol.ByteCount
+	PacedBps     protocol.ByteCount
+	RTTCount     int
+	PenaltyStart monotime.Time
+}
+
+// snapshotSenderState dumps the state of a sender.
+// For the hybrid sender, it returns the state of the sender currently in use.
+func snapshotSenderState(s SendAlgorithmWithDebugInfos) senderState {
+	state := senderState{
+		CongestionWindow:   s.GetCongestionWindow(),
+		SlowStartThreshold: s.SlowStartThreshold(),
+		InSlowStart:        s.InSlowStart(),
+		InRecovery:         s.InRecovery(),
+	}
+	switch s := s.(type) {
+	case *cubicSender:
+		state.PacingBudget = s.PacingBudget(s.clock.Now())
+		state.LargestSentPacketNumber = s.largestSentPacketNumber
+		state.LargestAckedPacketNumber = s.largestAckedPacketNumber
+		state.LargestSentAtLastCutback = s.largestSentAtLastCutback
+		state.NumAckedPackets = s.numAckedPackets
+		state.NumAckedBytes = s.numAckedBytes
+		state.Epoch = s.cubic.epoch
+		state.LastMaxCongestionWindow = s.cubic.lastMaxCongestionWindow
+		state.OriginPointCongestionWindow = s.cubic.originPointCongestionWindow
+		state.TimeToOriginPoint = s.cubic.timeToOriginPoint
+	case *hysteriaSender:
+		state.PacingBudget = s.PacingBudget(s.clock.Now())
+		state.LargestSentPacketNumber = s.largestSentPacketNumber
+		state.LargestSentAtLastCutback = s.largestSentAtLastCutback
+		state.TargetBps = s.targetBps
+		state.CurrentBps = s.currentBps
+		state.StableBps = s.stableBps
+		state.PacedBps = s.pacedBps
+		state.RTTCount = s.rttCount
+		state.PenaltyStart = s.penaltyStart
+	case *hybridSender:
+		if s.cubic != nil {
+			return snapshotSenderState(s.cubic)
+		}
+		return snapshotSenderState(s.hysteria)
+	}
+	return state
+}
@@ -0,0 +1,355 @@
+package congestion
+
+import (
+	"container/heap"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go/internal/monotime"
+	"github.com/quic-go/quic-go/internal/protocol"
+	"github.com/quic-go/quic-go/internal/utils"
+
+	"github.com/stretchr/testify/require"
+)
+
+// The fairness simulator runs multiple bulk-transfer flows over a shared bottleneck link.
+// It is a discrete-event simulation driven by a mockClock, and is therefore fast and deterministic.
+//
+// Packets travel to the bottleneck in half the base RTT. The bottleneck serializes them at a fixed rate,
+// and drops packets when its buffer (drop-tail) is full. Every packet is acknowledged individually,
+// and the acknowledgement takes the other half of the base RTT to arrive back at the sender.
+// Losses are detected using the packet and time thresholds of RFC 9002.
+
+// referenceNewReno is a textbook NewReno congestion controller (RFC 9002, Appendix B),
+// used as the reference flow when measuring fairness.
+type referenceNewReno struct {
+	rttStats *utils.RTTStats
+	pacer    *pacer
+
+	congestionWindow     protocol.ByteCount
+	slowStartThreshold   protocol.ByteCount
+	bytesAcked           protocol.ByteCount
+	largestSentPN        protocol.PacketNumber
+	congestionRecoveryPN protocol.PacketNumber
+}
+
+var _ SendAlgorithm = &referenceNewReno{}
+
+func newReferenceNewReno(rttStats *utils.RTTStats) *referenceNewReno {
+	r := &referenceNewReno{
+		rttStats:             rttStats,
+		congestionWindow:     initialCongestionWindow * maxDatagramSize,
+		slowStartThreshold:   protocol.MaxByteCount,
+		largestSentPN:        protocol.InvalidPacketNumber,
+		congestionRecoveryPN: protocol.InvalidPacketNumber,
+	}
+	// pace the same way as the senders under test, so that both flows are equally bursty
+	r.pacer = newPacer(r.bandwidthEstimate)
+	return r
+}
+
+func (r *referenceNewReno) bandwidthEstimate() Bandwidth {
+	srtt := r.rttStats.SmoothedRTT()
+	if srtt == 0 {
+		srtt = protocol.TimerGranularity
+	}
+	return BandwidthFromDelta(r.congestionWindow, srtt)
+}
+
+func (r *referenceNewReno) TimeUntilSend(protocol.ByteCount) monotime.Time {
+	return r.pacer.TimeUntilSend()
+}
+func (r *referenceNewReno) HasPacingBudget(now monotime.Time) bool {
+	return r.pacer.Budget(now) >= maxDatagramSize
+}
+
+func (r *referenceNewReno) OnPacketSent(t monotime.Time, _ protocol.ByteCount, pn protocol.PacketNumber, bytes protocol.ByteCount, _ bool) {
+	r.pacer.SentPacket(t, bytes)
+	r.largestSentPN = pn
+}
+
+func (r *referenceNewReno) CanSend(bytesInFlight protocol.ByteCount) bool {
+	return bytesInFlight < r.congestionWindow
+}
+
+func (r *referenceNewReno) MaybeExitSlowStart() {}
+
+func (r *referenceNewReno) OnPacketAcked(pn protocol.PacketNumber, ackedBytes, _ protocol.ByteCount, _ monotime.Time) {
+	if pn <= r.congestionRecoveryPN {
+		return
+	}
+	if r.congestionWindow < r.slowStartThreshold {
+		r.congestionWindow += ackedBytes
+		return
+	}
+	r.bytesAcked += ackedBytes
+	if r.bytesAcked >= r.congestionWindow {
+		r.bytesAcked -= r.congestionWindow
+		r.congestionWindow += maxDatagramSize
+	}
+}
+
+func (r *referenceNewReno) OnCongestionEvent(pn protocol.PacketNumber, _, _ protocol.ByteCount, _ TrafficClass, _ bool) {
+	if pn <= r.congestionRecoveryPN {
+		return
+	}
+	r.congestionRecoveryPN = r.largestSentPN
+	r.slowStartThreshold = max(r.congestionWindow/2, minCongestionWindowPackets*maxDatagramSize)
+	r.congestionWindow = r.slowStartThreshold
+	r.bytesAcked = 0
+}
+
+func (r *referenceNewReno) OnRetransmissionTimeout(bool)          {}
+func (r *referenceNewReno) SetMaxDatagramSize(protocol.ByteCount) {}
+
+const simMbps = 1_000_000 * BitsPerSecond
+
+type simEvent struct {
+	time monotime.Time
+	seq  uint64
+	fn   func()
+}
+
+type simEventQueue []simEvent
+
+func (q simEventQueue) Len() int { return len(q) }
+func (q simEventQueue) Less(i, j int) bool {
+	if q[i].time == q[j].time {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].time.Before(q[j].time)
+}
+func (q simEventQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *simEventQueue) Push(x any)   { *q = append(*q, x.(simEvent)) }
+func (q *simEventQueue) Pop() any {
+	old := *q
+	e := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return e
+}
+
+type simSentPacket struct {
+	sendTime monotime.Time
+	size     protocol.ByteCount
+}
+
+type simFlow struct {
+	sim       *fairnessSimulator
+	sender    SendAlgorithm
+	rttStats  *utils.RTTStats
+	connStats *utils.ConnectionStats
+
+	nextPacketNumber protocol.PacketNumber
+	bytesInFlight    protocol.ByteCount
+	outstanding      map[protocol.PacketNumber]simSentPacket
+	wakeupAt         monotime.Time
+
+	// bytes delivered through the bottleneck after the warmup period
+	delivered protocol.ByteCount
+}
+
+type fairnessSimulator struct {
+	clock mockClock
+	seq   uint64
+	queue simEventQueue
+
+	rate       Bandwidth
+	bufferSize protocol.ByteCount
+	baseRTT    time.Duration
+	// the time when the bottleneck link finishes transmitting the last packet in its buffer
+	busyUntil     monotime.Time
+	measureAfter  monotime.Time
+	flows         []*simFlow
+	droppedPacket int
+}
+
+func newFairnessSimulator(rate Bandwidth, baseRTT time.Duration, bufferSize protocol.ByteCount) *fairnessSimulator {
+	return &fairnessSimulator{
+		clock:      mockClock(monotime.Now()),
+		rate:       rate,
+		baseRTT:    baseRTT,
+		bufferSize: bufferSize,
+	}
+}
+
+func (s *fairnessSimulator) schedule(t monotime.Time, fn func()) {
+	s.seq++
+	heap.Push(&s.queue, simEvent{time: t, seq: s.seq, fn: fn})
+}
+
+// addFlow adds a flow. The constructor receives the clock, the RTT stats and the connection stats to use.
+func (s *fairnessSimulator) addFlow(newSender func(Clock, *utils.RTTStats, *utils.ConnectionStats) SendAlgorithm) *simFlow {
+	f := &simFlow{
+		sim:         s,
+		rttStats:    utils.NewRTTStats(),
+		connStats:   &utils.ConnectionStats{},
+		outstanding: make(map[protocol.PacketNumber]simSentPacket),
+	}
+	f.sender = newSender(&s.clock, f.rttStats, f.connStats)
+	s.flows = append(s.flows, f)
+	return f
+}
+
+// run runs the simulation for the given duration.
+// Only bytes delivered after the warmup period count towards the share of each flow.
+func (s *fairnessSimulator) run(warmup, duration time.Duration) {
+	start := s.clock.Now()
+	s.measureAfter = start.Add(warmup)
+	for _, f := range s.flows {
+		s.schedule(start, f.maybeSend)
+		s.schedule(start, f.detectLostPacketsPeriodically)
+	}
+	end := start.Add(duration)
+	for s.queue.Len() > 0 {
+		e := heap.Pop(&s.queue).(simEvent)
+		if e.time.After(end) {
+			return
+		}
+		s.clock = mockClock(e.time)
+		e.fn()
+	}
+}
+
+// share returns the fraction of the delivered bytes that belong to the flow, after the warmup period.
+func (s *fairnessSimulator) share(f *simFlow) float64 {
+	var total protocol.ByteCount
+	for _, flow := range s.flows {
+		total += flow.delivered
+	}
+	return float64(f.delivered) / float64(total)
+}
+
+func (s *fairnessSimulator) arriveAtBottleneck(f *simFlow, pn protocol.PacketNumber, size protocol.ByteCount) {
+	now := s.clock.Now()
+	var backlog protocol.ByteCount
+	if s.busyUntil.After(now) {
+		backlog = protocol.ByteCount(float64(s.busyUntil.Sub(now)) * float64(s.rate) / float64(BytesPerSecond) / float64(time.Second))
+	}
+	if backlog+size > s.bufferSize {
+		s.droppedPacket++
+		return
+	}
+	s.busyUntil = max(s.busyUntil, now).Add(time.Duration(float64(size) * float64(BytesPerSecond) / float64(s.rate) * float64(time.Second)))
+	departure := s.busyUntil
+	s.schedule(departure, func() {
+		if !departure.Before(s.measureAfter) {
+			f.delivered += size
+		}
+	})
+	s.schedule(departure.Add(s.baseRTT/2), func() { f.onAck(pn) })
+}
+
+func (f *simFlow) maybeSend() {
+	now := f.sim.clock.Now()
+	for f.sender.CanSend(f.bytesInFlight) {
+		if !f.sender.HasPacingBudget(now) {
+			if t := f.sender.TimeUntilSend(f.bytesInFlight); t.After(now) && (f.wakeupAt.Before(now) || t.Before(f.wakeupAt)) {
+				f.wakeupAt = t
+				f.sim.schedule(t, f.maybeSend)
+			}
+			return
+		}
+		pn := f.nextPacketNumber
+		f.nextPacketNumber++
+		f.sender.OnPacketSent(now, f.bytesInFlight, pn, maxDatagramSize, true)
+		f.connStats.BytesSent.Add(uint64(maxDatagramSize))
+		f.connStats.PacketsSent.Add(1)
+		f.bytesInFlight += maxDatagramSize
+		f.outstanding[pn] = simSentPacket{sendTime: now, size: maxDatagramSize}
+		f.sim.schedule(now.Add(f.sim.baseRTT/2), func() { f.sim.arriveAtBottleneck(f, pn, maxDatagramSize) })
+	}
+}
+
+func (f *simFlow) onAck(pn protocol.PacketNumber) {
+	now := f.sim.clock.Now()
+	p, ok := f.outstanding[pn]
+	if !ok { // already declared lost
+		return
+	}
+	f.rttStats.UpdateRTT(now.Sub(p.sendTime), 0)
+	delete(f.outstanding, pn)
+	f.detectLostPackets(pn)
+
+	priorInFlight := f.bytesInFlight
+	f.bytesInFlight -= p.size
+	f.sender.MaybeExitSlowStart()
+	f.sender.OnPacketAcked(pn, p.size, priorInFlight, now)
+	f.maybeSend()
+}
+
+// detectLostPackets declares packets lost according to the packet threshold and the time threshold of RFC 9002.
+func (f *simFlow) detectLostPackets(largestAcked protocol.PacketNumber) {
+	now := f.sim.clock.Now()
+	lossDelay := max(f.rttStats.SmoothedRTT(), f.rttStats.LatestRTT()) * 9 / 8
+	// iterate in packet number order to keep the simulation deterministic
+	for _, pn := range slices.Sorted(maps.Keys(f.outstanding)) {
+		p := f.outstanding[pn]
+		if pn+3 > largestAcked && now.Sub(p.sendTime) < lossDelay {
+			continue
+		}
+		priorInFlight := f.bytesInFlight
+		f.bytesInFlight -= p.size
+		delete(f.outstanding, pn)
+		f.connStats.PacketsLost.Add(1)
+		f.connStats.BytesLost.Add(uint64(p.size))
+		f.sender.OnCongestionEvent(pn, p.size, priorInFlight, TrafficClassDefault, false)
+	}
+}
+
+// detectLostPacketsPeriodically makes sure that the flow recovers if all its outstanding packets were dropped.
+func (f *simFlow) detectLostPacketsPeriodically() {
+	if f.rttStats.HasMeasurement() {
+		f.detectLostPackets(protocol.InvalidPacketNumber)
+		f.maybeSend()
+	}
+	f.sim.schedule(f.sim.clock.Now().Add(10*time.Millisecond), f.detectLostPacketsPeriodically)
+}
+
+func newReferenceFlow(_ Clock, rttStats *utils.RTTStats, _ *utils.ConnectionStats) SendAlgorithm {
+	return newReferenceNewReno(rttStats)
+}
+
+func TestFairnessSimulatorBaseline(t *testing.T) {
+	// two reference flows share the bottleneck equally
+	sim := newFairnessSimulator(20*simMbps, 50*time.Millisecond, 125_000)
+	flow1 := sim.addFlow(newReferenceFlow)
+	flow2 := sim.addFlow(newReferenceFlow)
+	sim.run(10*time.Second, 60*time.Second)
+
+	t.Logf("shares: %.2f / %.2f, %d packets dropped", sim.share(flow1), sim.share(flow2), sim.droppedPacket)
+	require.NotZero(t, sim.droppedPacket)
+	require.InDelta(t, 0.5, sim.share(flow1), 0.1)
+	// the bottleneck is fully utilized
+	total := flow1.delivered + flow2.delivered
+	require.Greater(t, float64(total), 0.9*50*float64(20*simMbps/BytesPerSecond))
+}
+
+func TestFairnessCubicSenderAgainstNewReno(t *testing.T) {
+	// The loss tolerance of the cubicSender ignores packet loss as long as the loss rate of the connection
+	// stays below 10%. On a shared bottleneck, the loss rate rarely exceeds this, so the cubicSender
+	// keeps growing its window while the reference flow backs off. In this simulation, the cubicSender
+	// takes 98% (Reno) and 93% (CUBIC) of the bottleneck capacity.
+	// This test documents this known unfairness. Once the loss tolerance is fixed, it will fail,
+	// and should then assert a fair share instead.
+	for _, tc := range []struct {
+		name string
+		reno bool
+	}{
+		{name: "Reno", reno: true},
+		{name: "CUBIC", reno: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sim := newFairnessSimulator(20*simMbps, 50*time.Millisecond, 125_000)
+			flow := sim.addFlow(func(clock Clock, rttStats *utils.RTTStats, connStats *utils.ConnectionStats) SendAlgorithm {
+				return NewCubicSender(clock, rttStats, connStats, maxDatagramSize, tc.reno, nil, nil)
+			})
+			reference := sim.addFlow(newReferenceFlow)
+			sim.run(10*time.Second, 60*time.Second)
+
+			t.Logf("shares: %.2f / %.2f (reference), %d packets dropped", sim.share(flow), sim.share(reference), sim.droppedPacket)
+			require.Greater(t, sim.share(flow), 0.85)
+		})
+	}
+}
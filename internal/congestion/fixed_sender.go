@@ -0,0 +1,164 @@
+package congestion
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/quic-go/quic-go/internal/monotime"
+	"github.com/quic-go/quic-go/internal/protocol"
+	"github.com/quic-go/quic-go/internal/utils"
+)
+
+// fixedSender uses a constant congestion window, and optionally a constant pacing rate.
+// It never reacts to packet loss or RTT changes, and is therefore only appropriate on dedicated links
+// with a known capacity, or to rule out the congestion controller when debugging.
+type fixedSender struct {
+	rttStats *utils.RTTStats
+	// nil if packets are not paced
+	pacer *pacer
+
+	windowPackets          protocol.ByteCount
+	pacingRate             Bandwidth
+	initialMaxDatagramSize protocol.ByteCount
+	maxDatagramSize        protocol.ByteCount
+	maxDatagramSizeCeiling protocol.ByteCount
+
+	onCongestionWindowChange func(old, new protocol.ByteCount)
+}
+
+var (
+	_ SendAlgorithm               = &fixedSender{}
+	_ SendAlgorithmWithDebugInfos = &fixedSender{}
+)
+
+// NewFixedSender creates a sender with a congestion window of conf.FixedWindowPackets packets.
+// If conf.MaxPacingRate is set, packets are paced at exactly this rate. Otherwise, packets are not paced.
+func NewFixedSender(rttStats *utils.RTTStats, initialMaxDatagramSize protocol.ByteCount, conf *Config) *fixedSender {
+	if conf == nil {
+		conf = &Config{}
+	}
+	initialMaxDatagramSize = min(initialMaxDatagramSize, conf.maxDatagramSizeCeiling())
+	f := &fixedSender{
+		rttStats:                 rttStats,
+		windowPackets:            conf.fixedWindowPackets(),
+		pacingRate:               conf.MaxPacingRate,
+		initialMaxDatagramSize:   initialMaxDatagramSize,
+		maxDatagramSize:          initialMaxDatagramSize,
+		maxDatagramSizeCeiling:   conf.maxDatagramSizeCeiling(),
+		onCongestionWindowChange: conf.OnCongestionWindowChange,
+	}
+	if f.pacingRate > 0 && !conf.DisablePacing {
+		f.pacer = newRatePacer(func() Bandwidth { return f.pacingRate })
+		f.pacer.SetMaxBurst(conf.MaxPacingBurst)
+		f.pacer.SetMaxDatagramSize(initialMaxDatagramSize)
+	}
+	return f
+}
+
+func (f *fixedSender) TimeUntilSend(protocol.ByteCount) monotime.Time {
+	if f.pacer == nil {
+		return 0
+	}
+	return f.pacer.TimeUntilSend()
+}
+
+func (f *fixedSender) HasPacingBudget(now monotime.Time) bool {
+	if f.pacer == nil {
+		return true
+	}
+	return f.pacer.Budget(now) >= f.maxDatagramSize
+}
+
+func (f *fixedSender) PacingBudget(now monotime.Time) protocol.ByteCount {
+	if f.pacer == nil {
+		return protocol.MaxByteCount
+	}
+	return f.pacer.Budget(now)
+}
+
+func (f *fixedSender) OnPacketSent(sentTime monotime.Time, _ protocol.ByteCount, _ protocol.PacketNumber, bytes protocol.ByteCount, _ bool) {
+	if f.pacer != nil {
+		f.pacer.SentPacket(sentTime, bytes)
+	}
+}
+
+func (f *fixedSender) CanSend(bytesInFlight protocol.ByteCount) bool {
+	return bytesInFlight < f.GetCongestionWindow()
+}
+
+func (f *fixedSender) MaybeExitSlowStart() {}
+
+func (f *fixedSender) OnPacketAcked(protocol.PacketNumber, protocol.ByteCount, protocol.ByteCount, monotime.Time) {
+}
+
+// OnCongestionEvent does nothing, the congestion window is not reduced.
+func (f *fixedSender) OnCongestionEvent(protocol.PacketNumber, protocol.ByteCount, protocol.ByteCount, TrafficClass, bool) {
+}
+
+func (f *fixedSender) OnRetransmissionTimeout(bool) {}
+
+func (f *fixedSender) OnSpuriousLoss(protocol.PacketNumber) {}
+
+func (f *fixedSender) OnPersistentCongestion() {}
+
+func (f *fixedSender) OnFlowControlLimited(bool) {}
+
+// ProbeBandwidth is a no-op: the sender always uses the configured window and rate.
+func (f *fixedSender) ProbeBandwidth(monotime.Time) {}
+
+func (f *fixedSender) InSlowStart() bool { return false }
+func (f *fixedSender) InRecovery() bool  { return false }
+
+func (f *fixedSender) GetCongestionWindow() protocol.ByteCount {
+	return f.windowPackets * f.maxDatagramSize
+}
+
+func (f *fixedSender) SlowStartThreshold() protocol.ByteCount { return protocol.MaxByteCount }
+
+// BandwidthEstimate returns the configured pacing rate.
+// If packets are not paced, it returns the rate derived from the congestion window and the smoothed RTT.
+func (f *fixedSender) BandwidthEstimate() Bandwidth {
+	if f.pacingRate > 0 {
+		return f.pacingRate
+	}
+	srtt := f.rttStats.SmoothedRTT()
+	if srtt == 0 {
+		srtt = f.rttStats.InitialRTT()
+	}
+	return BandwidthFromDelta(f.GetCongestionWindow(), srtt)
+}
+
+func (f *fixedSender) EstimatedSendTime(bytes protocol.ByteCount) time.Duration {
+	return SendTime(bytes, f.BandwidthEstimate())
+}
+
+// State returns the phase the sender is currently in.
+// The sender is either application-limited, or in congestion avoidance.
+func (f *fixedSender) State(bytesInFlight protocol.ByteCount) State {
+	if bytesInFlight+maxBurstPackets*f.maxDatagramSize < f.GetCongestionWindow() {
+		return StateApplicationLimited
+	}
+	return StateCongestionAvoidance
+}
+
+func (f *fixedSender) OnConnectionMigration() {
+	f.setMaxDatagramSize(f.initialMaxDatagramSize)
+}
+
+func (f *fixedSender) SetMaxDatagramSize(s protocol.ByteCount) {
+	if s < f.maxDatagramSize {
+		panic(fmt.Sprintf("congestion BUG: decreased max datagram size from %d to %d", f.maxDatagramSize, s))
+	}
+	f.setMaxDatagramSize(min(s, f.maxDatagramSizeCeiling))
+}
+
+func (f *fixedSender) setMaxDatagramSize(s protocol.ByteCount) {
+	oldCongestionWindow := f.GetCongestionWindow()
+	f.maxDatagramSize = s
+	if f.pacer != nil {
+		f.pacer.SetMaxDatagramSize(s)
+	}
+	if f.onCongestionWindowChange != nil && oldCongestionWindow != f.GetCongestionWindow() {
+		f.onCongestionWindowChange(oldCongestionWindow, f.GetCongestionWindow())
+	}
+}
@@ -0,0 +1,94 @@
+package congestion
+
+import (
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go/internal/monotime"
+	"github.com/quic-go/quic-go/internal/protocol"
+	"github.com/quic-go/quic-go/internal/utils"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixedSenderIgnoresCongestionSignals(t *testing.T) {
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(50*time.Millisecond, 0)
+	sender := NewFixedSender(rttStats, maxDatagramSize, &Config{FixedWindowPackets: 100})
+	const cwnd = 100 * maxDatagramSize
+	require.Equal(t, cwnd, sender.GetCongestionWindow())
+	require.True(t, sender.CanSend(cwnd-1))
+	require.False(t, sender.CanSend(cwnd))
+
+	// packets are not paced
+	now := monotime.Now()
+	for i := range 100 {
+		sender.OnPacketSent(now, protocol.ByteCount(i)*maxDatagramSize, protocol.PacketNumber(i), maxDatagramSize, true)
+	}
+	require.Zero(t, sender.TimeUntilSend(0))
+	require.True(t, sender.HasPacingBudget(now))
+	require.Equal(t, protocol.MaxByteCount, sender.PacingBudget(now))
+
+	sender.OnCongestionEvent(1, maxDatagramSize, cwnd, TrafficClassDefault, false)
+	require.Equal(t, cwnd, sender.GetCongestionWindow())
+	sender.OnRetransmissionTimeout(true)
+	require.Equal(t, cwnd, sender.GetCongestionWindow())
+	rttStats.UpdateRTT(time.Second, 0)
+	sender.OnPacketAcked(2, maxDatagramSize, cwnd, now)
+	require.Equal(t, cwnd, sender.GetCongestionWindow())
+	require.False(t, sender.InSlowStart())
+	require.False(t, sender.InRecovery())
+	require.Equal(t, protocol.MaxByteCount, sender.SlowStartThreshold())
+}
+
+func TestFixedSenderPacing(t *testing.T) {
+	sender := NewFixedSender(utils.NewRTTStats(), maxDatagramSize, &Config{
+		FixedWindowPackets: 100,
+		MaxPacingRate:      10 * 1000 * 1000 * BitsPerSecond,
+	})
+	require.Equal(t, 10*1000*1000*BitsPerSecond, sender.BandwidthEstimate())
+
+	// sending a packet of this size takes 1ms at the configured rate
+	const size = 10 * 1000 * 1000 / 8 / 1000
+	now := monotime.Now()
+	for i := range 10 {
+		sender.OnPacketSent(now, 0, protocol.PacketNumber(i), size, true)
+	}
+	require.Equal(t, now.Add(time.Millisecond), sender.TimeUntilSend(0))
+	require.False(t, sender.HasPacingBudget(now))
+	require.Less(t, sender.PacingBudget(now), protocol.ByteCount(maxDatagramSize))
+}
+
+func TestFixedSenderWindow(t *testing.T) {
+	for _, tc := range []struct {
+		packets, expected int
+	}{
+		{packets: 0, expected: minCongestionWindowPackets},
+		{packets: 42, expected: 42},
+		{packets: 1e6, expected: protocol.MaxCongestionWindowPackets},
+	} {
+		sender := NewFixedSender(utils.NewRTTStats(), maxDatagramSize, &Config{FixedWindowPackets: tc.packets})
+		require.Equal(t, protocol.ByteCount(tc.expected)*maxDatagramSize, sender.GetCongestionWindow())
+	}
+}
+
+func TestFixedSenderMaxDatagramSize(t *testing.T) {
+	var changes [][2]protocol.ByteCount
+	sender := NewFixedSender(utils.NewRTTStats(), 1200, &Config{
+		FixedWindowPackets:       10,
+		OnCongestionWindowChange: func(old, new protocol.ByteCount) { changes = append(changes, [2]protocol.ByteCount{old, new}) },
+	})
+	sender.SetMaxDatagramSize(1400)
+	require.Equal(t, protocol.ByteCount(14000), sender.GetCongestionWindow())
+	require.Panics(t, func() { sender.SetMaxDatagramSize(1300) })
+
+	sender.OnConnectionMigration()
+	require.Equal(t, protocol.ByteCount(12000), sender.GetCongestionWindow())
+	require.Equal(t, [][2]protocol.ByteCount{{12000, 14000}, {14000, 12000}}, changes)
+}
+
+func TestFixedSenderState(t *testing.T) {
+	sender := NewFixedSender(utils.NewRTTStats(), maxDatagramSize, &Config{FixedWindowPackets: 10})
+	require.Equal(t, StateApplicationLimited, sender.State(0))
+	require.Equal(t, StateCongestionAvoidance, sender.State(10*maxDatagramSize))
+}
@@ -0,0 +1,174 @@
+package congestion
+
+import (
+	"time"
+
+	"github.com/quic-go/quic-go/internal/monotime"
+	"github.com/quic-go/quic-go/internal/protocol"
+	"github.com/quic-go/quic-go/internal/utils"
+	"github.com/quic-go/quic-go/qlogwriter"
+)
+
+// hybridSender starts out as a Hysteria sender, using its rate-based pacing to quickly ramp up.
+// On the first congestion event that Hysteria considers to be caused by congestion,
+// or once the ramp reaches the target rate, it hands off to a CUBIC sender, which then
+// uses the usual window-based AIMD loss response for the rest of the connection.
+type hybridSender struct {
+	hysteria *hysteriaSender
+	// cubic is nil until the handoff
+	cubic *cubicSender
+
+	clock     Clock
+	rttStats  *utils.RTTStats
+	connStats *utils.ConnectionStats
+	conf      *Config
+	qlogger   qlogwriter.Recorder
+
+	largestSentPacketNumber  protocol.PacketNumber
+	largestAckedPacketNumber protocol.PacketNumber
+	initialMaxDatagramSize   protocol.ByteCount
+	maxDatagramSize          protocol.ByteCount
+}
+
+var (
+	_ SendAlgorithm               = &hybridSender{}
+	_ SendAlgorithmWithDebugInfos = &hybridSender{}
+)
+
+// NewHybridSender creates a new sender that ramps up like Hysteria, targeting maxBandwidth,
+// and then hands off to CUBIC.
+func NewHybridSender(clock Clock, rttStats *utils.RTTStats, connStats *utils.ConnectionStats, initialMaxDatagramSize protocol.ByteCount, maxBandwidth Bandwidth, conf *Config, qlogger qlogwriter.Recorder) *hybridSender {
+	if conf == nil {
+		conf = &Config{}
+	}
+	// The brutal mode never reacts to loss, and would prevent the handoff.
+	hysteriaConf := *conf
+	hysteriaConf.HysteriaBrutal = false
+	return &hybridSender{
+		hysteria:                 NewHysteriaSender(clock, rttStats, initialMaxDatagramSize, maxBandwidth, &hysteriaConf, qlogger).(*hysteriaSender),
+		clock:                    clock,
+		rttStats:                 rttStats,
+		connStats:                connStats,
+		conf:                     conf,
+		qlogger:                  qlogger,
+		largestSentPacketNumber:  protocol.InvalidPacketNumber,
+		largestAckedPacketNumber: protocol.InvalidPacketNumber,
+		initialMaxDatagramSize:   initialMaxDatagramSize,
+		maxDatagramSize:          initialMaxDatagramSize,
+	}
+}
+
+func (h *hybridSender) active() SendAlgorithmWithDebugInfos {
+	if h.cubic != nil {
+		return h.cubic
+	}
+	return h.hysteria
+}
+
+// handOff switches to the CUBIC sender.
+// CUBIC starts in congestion avoidance, with the congestion window that Hysteria was using.
+func (h *hybridSender) handOff() {
+	maxCongestionWindow := protocol.MaxCongestionWindowPackets * h.maxDatagramSize
+	cwnd := min(h.hysteria.GetCongestionWindow(), maxCongestionWindow)
+	h.cubic = newCubicSender(h.clock, h.rttStats, h.connStats, false, h.maxDatagramSize, cwnd, maxCongestionWindow, h.conf, h.qlogger)
+	h.cubic.largestSentPacketNumber = h.largestSentPacketNumber
+	h.cubic.largestAckedPacketNumber = h.largestAckedPacketNumber
+	h.cubic.slowStartThreshold = cwnd
+}
+
+func (h *hybridSender) TimeUntilSend(bytesInFlight protocol.ByteCount) monotime.Time {
+	return h.active().TimeUntilSend(bytesInFlight)
+}
+
+func (h *hybridSender) PacingBudget(now monotime.Time) protocol.ByteCount {
+	return h.active().PacingBudget(now)
+}
+
+func (h *hybridSender) HasPacingBudget(now monotime.Time) bool {
+	return h.active().HasPacingBudget(now)
+}
+
+func (h *hybridSender) OnPacketSent(sentTime monotime.Time, bytesInFlight protocol.ByteCount, packetNumber protocol.PacketNumber, bytes protocol.ByteCount, isRetransmittable bool) {
+	if isRetransmittable {
+		h.largestSentPacketNumber = packetNumber
+	}
+	h.active().OnPacketSent(sentTime, bytesInFlight, packetNumber, bytes, isRetransmittable)
+}
+
+func (h *hybridSender) CanSend(bytesInFlight protocol.ByteCount) bool {
+	return h.active().CanSend(bytesInFlight)
+}
+
+func (h *hybridSender) MaybeExitSlowStart() { h.active().MaybeExitSlowStart() }
+
+func (h *hybridSender) OnPacketAcked(number protocol.PacketNumber, ackedBytes protocol.ByteCount, priorInFlight protocol.ByteCount, eventTime monotime.Time) {
+	h.largestAckedPacketNumber = max(number, h.largestAckedPacketNumber)
+	h.active().OnPacketAcked(number, ackedBytes, priorInFlight, eventTime)
+	// The ramp reached the target rate without encountering congestion.
+	// Let CUBIC probe for more bandwidth from here.
+	if h.cubic == nil && h.hysteria.currentBps >= h.hysteria.targetBps {
+		h.handOff()
+	}
+}
+
+func (h *hybridSender) OnCongestionEvent(number protocol.PacketNumber, lostBytes protocol.ByteCount, priorInFlight protocol.ByteCount, class TrafficClass, reorderingLikely bool) {
+	if h.cubic == nil {
+		if reorderingLikely || !h.hysteria.isCongestionLoss(lostBytes, priorInFlight) {
+			h.hysteria.OnCongestionEvent(number, lostBytes, priorInFlight, class, reorderingLikely)
+			return
+		}
+		h.handOff()
+		h.cubic.onHandOffLoss(number)
+		return
+	}
+	h.cubic.OnCongestionEvent(number, lostBytes, priorInFlight, class, reorderingLikely)
+}
+
+func (h *hybridSender) OnRetransmissionTimeout(packetsRetransmitted bool) {
+	h.active().OnRetransmissionTimeout(packetsRetransmitted)
+}
+
+func (h *hybridSender) SetMaxDatagramSize(s protocol.ByteCount) {
+	h.maxDatagramSize = s
+	h.active().SetMaxDatagramSize(s)
+}
+
+// OnConnectionMigration resets the sender to the Hysteria ramp, since the new path might have a different capacity.
+func (h *hybridSender) OnConnectionMigration() {
+	h.cubic = nil
+	h.hysteria.OnConnectionMigration()
+	h.largestSentPacketNumber = protocol.InvalidPacketNumber
+	h.largestAckedPacketNumber = protocol.InvalidPacketNumber
+	h.maxDatagramSize = h.initialMaxDatagramSize
+}
+
+func (h *hybridSender) ProbeBandwidth(until monotime.Time) { h.active().ProbeBandwidth(until) }
+func (h *hybridSender) OnSpuriousLoss(number protocol.PacketNumber) {
+	h.active().OnSpuriousLoss(number)
+}
+func (h *hybridSender) OnPersistentCongestion() { h.active().OnPersistentCongestion() }
+func (h *hybridSender) OnFlowControlLimited(limited bool) {
+	h.active().OnFlowControlLimited(limited)
+}
+
+func (h *hybridSender) InSlowStart() bool { return h.active().InSlowStart() }
+func (h *hybridSender) InRecovery() bool  { return h.active().InRecovery() }
+func (h *hybridSender) GetCongestionWindow() protocol.ByteCount {
+	return h.active().GetCongestionWindow()
+}
+func (h *hybridSender) SlowStartThreshold() protocol.ByteCount {
+	return h.active().SlowStartThreshold()
+}
+func (h *hybridSender) BandwidthEstimate() Bandwidth { return h.active().BandwidthEstimate() }
+
+func (h *hybridSender) EstimatedSendTime(bytes protocol.ByteCount) time.Duration {
+	return h.active().EstimatedSendTime(bytes)
+}
+
+func (h *hybridSender) State(bytesInFlight protocol.ByteCount) State {
+	if h.cubic == nil {
+		// Hysteria has no slow start phase, but the ramp serves the same purpose.
+		return StateSlowStart
+	}
+	return h.cubic.State(bytesInFlight)
+}
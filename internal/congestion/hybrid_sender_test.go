@@ -0,0 +1,98 @@
+package congestion
+
+import (
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go/internal/monotime"
+	"github.com/quic-go/quic-go/internal/protocol"
+	"github.com/quic-go/quic-go/internal/utils"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHybridSender(mbps int) *hybridSender {
+	var clock mockClock
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(50*time.Millisecond, 0)
+	return NewHybridSender(&clock, rttStats, &utils.ConnectionStats{}, maxDatagramSize, BandwidthFromMbps(mbps), nil, nil)
+}
+
+func TestHybridSenderHandOffOnCongestion(t *testing.T) {
+	sender := newTestHybridSender(100)
+	require.Equal(t, StateSlowStart, sender.State(0))
+
+	for i := range 10 {
+		sender.OnPacketSent(monotime.Now(), 0, protocol.PacketNumber(i), maxDatagramSize, true)
+	}
+	sender.OnPacketAcked(0, maxDatagramSize, sender.GetCongestionWindow(), monotime.Now())
+
+	// a single lost packet is not considered congestion by Hysteria
+	sender.OnCongestionEvent(1, maxDatagramSize, sender.GetCongestionWindow(), TrafficClassDefault, false)
+	require.Nil(t, sender.cubic)
+
+	// heavy loss is
+	cwnd := sender.GetCongestionWindow()
+	sender.OnCongestionEvent(2, cwnd/2, cwnd, TrafficClassDefault, false)
+	require.NotNil(t, sender.cubic)
+	require.False(t, sender.InSlowStart())
+	require.True(t, sender.InRecovery())
+	require.Less(t, sender.GetCongestionWindow(), cwnd)
+	require.Equal(t, StateRecovery, sender.State(sender.GetCongestionWindow()))
+}
+
+func TestHybridSenderHandOffCutsWindow(t *testing.T) {
+	for name, policy := range map[string]LossTolerancePolicy{
+		"loss rate": LossTolerancePolicyLossRate,
+		"episode":   LossTolerancePolicyEpisode,
+	} {
+		t.Run(name, func(t *testing.T) {
+			var clock mockClock
+			rttStats := utils.NewRTTStats()
+			rttStats.UpdateRTT(50*time.Millisecond, 0)
+			// the sent packet handler counts all data sent during the ramp
+			var connStats utils.ConnectionStats
+			connStats.BytesSent.Store(10 << 20)
+			sender := NewHybridSender(&clock, rttStats, &connStats, maxDatagramSize, BandwidthFromMbps(100), &Config{LossTolerancePolicy: policy}, nil)
+			for i := range 10 {
+				sender.OnPacketSent(clock.Now(), 0, protocol.PacketNumber(i), maxDatagramSize, true)
+			}
+			sender.OnPacketAcked(0, maxDatagramSize, sender.GetCongestionWindow(), clock.Now())
+
+			// compared to all data sent, the loss is below the loss tolerance of the CUBIC sender
+			cwnd := sender.GetCongestionWindow()
+			sender.OnCongestionEvent(2, cwnd/2, cwnd, TrafficClassDefault, false)
+			require.NotNil(t, sender.cubic)
+			require.True(t, sender.InRecovery())
+			require.Less(t, sender.GetCongestionWindow(), cwnd)
+		})
+	}
+}
+
+func TestHybridSenderHandOffAtTargetRate(t *testing.T) {
+	sender := newTestHybridSender(10)
+	for i := range 100 {
+		if sender.cubic != nil {
+			break
+		}
+		sender.OnPacketAcked(protocol.PacketNumber(i), maxDatagramSize, sender.GetCongestionWindow(), monotime.Now())
+	}
+	require.NotNil(t, sender.cubic)
+	// CUBIC starts in congestion avoidance
+	require.False(t, sender.InSlowStart())
+	require.Equal(t, StateCongestionAvoidance, sender.State(sender.GetCongestionWindow()))
+}
+
+func TestHybridSenderConnectionMigration(t *testing.T) {
+	sender := newTestHybridSender(100)
+	cwnd := sender.GetCongestionWindow()
+	sender.OnPacketSent(monotime.Now(), 0, 1, maxDatagramSize, true)
+	sender.OnPacketAcked(1, maxDatagramSize, cwnd, monotime.Now())
+	sender.OnCongestionEvent(2, cwnd/2, cwnd, TrafficClassDefault, false)
+	require.NotNil(t, sender.cubic)
+
+	// the new path is probed using the Hysteria ramp again
+	sender.OnConnectionMigration()
+	require.Nil(t, sender.cubic)
+	require.Equal(t, StateSlowStart, sender.State(0))
+}
@@ -17,8 +17,8 @@ const hybridStartMinSamples = uint32(8)
 const hybridStartDelayFactorExp = 3 // 2^3 = 8
 // The original paper specifies 2 and 8ms, but those have changed over time.
 const (
-	hybridStartDelayMinThresholdUs = int64(4000)
-	hybridStartDelayMaxThresholdUs = int64(16000)
+	hybridStartDelayMinThreshold = 4 * time.Millisecond
+	hybridStartDelayMaxThreshold = 16 * time.Millisecond
 )
 
 // HybridSlowStart implements the TCP hybrid slow start algorithm
@@ -29,6 +29,34 @@ type HybridSlowStart struct {
 	currentMinRTT        time.Duration
 	rttSampleCount       uint32
 	hystartFound         bool
+
+	// tuning parameters, the zero value selects the default
+	minSamples        uint32
+	delayMinThreshold time.Duration
+	delayMaxThreshold time.Duration
+}
+
+// SetParameters sets the number of RTT samples taken at the beginning of each round,
+// and the bounds of the RTT increase (1/8th of the min RTT) that makes the sender exit slow start.
+// A value of 0 selects the default.
+func (s *HybridSlowStart) SetParameters(minSamples uint32, delayMinThreshold, delayMaxThreshold time.Duration) {
+	s.minSamples = minSamples
+	s.delayMinThreshold = delayMinThreshold
+	s.delayMaxThreshold = delayMaxThreshold
+}
+
+func (s *HybridSlowStart) parameters() (minSamples uint32, delayMinThreshold, delayMaxThreshold time.Duration) {
+	minSamples, delayMinThreshold, delayMaxThreshold = hybridStartMinSamples, hybridStartDelayMinThreshold, hybridStartDelayMaxThreshold
+	if s.minSamples > 0 {
+		minSamples = s.minSamples
+	}
+	if s.delayMinThreshold > 0 {
+		delayMinThreshold = s.delayMinThreshold
+	}
+	if s.delayMaxThreshold > 0 {
+		delayMaxThreshold = s.delayMaxThreshold
+	}
+	return minSamples, delayMinThreshold, max(delayMinThreshold, delayMaxThreshold)
 }
 
 // StartReceiveRound is called for the start of each receive round (burst) in the slow start phase.
@@ -63,19 +91,19 @@ func (s *HybridSlowStart) ShouldExitSlowStart(latestRTT time.Duration, minRTT ti
 	// Note: we only look at the first few(8) packets in each burst, since we
 	// only want to compare the lowest RTT of the burst relative to previous
 	// bursts.
+	minSamples, delayMinThreshold, delayMaxThreshold := s.parameters()
 	s.rttSampleCount++
-	if s.rttSampleCount <= hybridStartMinSamples {
+	if s.rttSampleCount <= minSamples {
 		if s.currentMinRTT == 0 || s.currentMinRTT > latestRTT {
 			s.currentMinRTT = latestRTT
 		}
 	}
 	// We only need to check this once per round.
-	if s.rttSampleCount == hybridStartMinSamples {
+	if s.rttSampleCount == minSamples {
 		// Divide minRTT by 8 to get a rtt increase threshold for exiting.
-		minRTTincreaseThresholdUs := int64(minRTT / time.Microsecond >> hybridStartDelayFactorExp)
-		// Ensure the rtt threshold is never less than 2ms or more than 16ms.
-		minRTTincreaseThresholdUs = min(minRTTincreaseThresholdUs, hybridStartDelayMaxThresholdUs)
-		minRTTincreaseThreshold := time.Duration(max(minRTTincreaseThresholdUs, hybridStartDelayMinThresholdUs)) * time.Microsecond
+		minRTTincreaseThreshold := time.Duration(int64(minRTT/time.Microsecond>>hybridStartDelayFactorExp)) * time.Microsecond
+		// Ensure the rtt threshold is never less than 4ms or more than 16ms (by default).
+		minRTTincreaseThreshold = max(min(minRTTincreaseThreshold, delayMaxThreshold), delayMinThreshold)
 
 		if s.currentMinRTT > (minRTT + minRTTincreaseThreshold) {
 			s.hystartFound = true
@@ -110,3 +138,13 @@ func (s *HybridSlowStart) Restart() {
 	s.started = false
 	s.hystartFound = false
 }
+
+// Reset resets the state to that of a new HybridSlowStart, keeping the parameters.
+// Unlike Restart, it also forgets the packet numbers and RTT samples, e.g. after a connection migration.
+func (s *HybridSlowStart) Reset() {
+	*s = HybridSlowStart{
+		minSamples:        s.minSamples,
+		delayMinThreshold: s.delayMinThreshold,
+		delayMaxThreshold: s.delayMaxThreshold,
+	}
+}
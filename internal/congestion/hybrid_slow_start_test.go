@@ -66,3 +66,16 @@ func TestHybridSlowStartWithDelay(t *testing.T) {
 	// RTT provided.
 	require.True(t, slowStart.ShouldExitSlowStart(rtt+10*time.Millisecond, rtt, 100))
 }
+
+func TestHybridSlowStartMinSamples(t *testing.T) {
+	slowStart := HybridSlowStart{}
+	slowStart.SetParameters(4, 0, 0)
+	const rtt = 60 * time.Millisecond
+
+	slowStart.StartReceiveRound(1)
+	for range 3 {
+		require.False(t, slowStart.ShouldExitSlowStart(rtt+10*time.Millisecond, rtt, 100))
+	}
+	// the increase is detected after 4 samples
+	require.True(t, slowStart.ShouldExitSlowStart(rtt+10*time.Millisecond, rtt, 100))
+}
@@ -1,46 +1,190 @@
 package congestion
 
 import (
+	"log/slog"
+	"math"
 	"time"
 
 	"github.com/quic-go/quic-go/internal/monotime"
 	"github.com/quic-go/quic-go/internal/protocol"
 	"github.com/quic-go/quic-go/internal/utils"
+	"github.com/quic-go/quic-go/qlog"
+	"github.com/quic-go/quic-go/qlogwriter"
 )
 
 const (
-	rttWindowSize = 10
-	minStartBps   = 1024 * 1024 / 8 // 1Mbps 保护线
+	minStartBps = 1024 * 1024 / 8 // 1Mbps 保护线
+
+	// 窗口 Multiplier 的取值范围及其随 RTT 线性过渡的区间
+	maxCwndMultiplier     = 1.5
+	minCwndMultiplier     = 1.1
+	cwndMultiplierLowRTT  = 100 * time.Millisecond
+	cwndMultiplierHighRTT = 180 * time.Millisecond
+
+	// 自动带宽模式下，目标速率相对于测得交付速率的探测余量
+	autoBandwidthProbeGain = 1.25
+
+	// 应用受限衰减：持续应用受限超过 appLimitedDecayRTTs 个 RTT 后，
+	// 每个 RTT 将当前速率与测得交付速率之间的差距缩小 appLimitedDecayFactor
+	appLimitedDecayRTTs   = 4
+	appLimitedDecayFactor = 0.25
+
+	// 默认的 RTO 退避：RTO 后降到稳定速率的这一比例
+	defaultRTOBackoff = 0.5
+
+	// 默认需要持续多少个 RTT 没有拥塞丢包，当前速率才会成为新的稳定速率
+	defaultStableRTTs = 3
+
+	// 默认的 pacing 速率平滑系数：每经过一个 RTT，pacing 速率向当前速率靠近的比例
+	defaultPacingAlpha = 0.5
+
+	// 每个探测周期的速率增长倍数：RTT 较大时加快增长，以便快速填满长肥管道。
+	// 超高 RTT 一档默认与高 RTT 一档相同
+	defaultGrowthFactor        = 1.1
+	defaultHighRTTGrowthFactor = 1.25
+	growthHighRTT              = 150 * time.Millisecond
+	growthVeryHighRTT          = 300 * time.Millisecond
+
+	// RTO 和抖动压制后的速率下限：不低于最近测得交付速率的这一比例，
+	// 使恢复从网络最近实际承受过的速率附近开始，而不是从 1Mbps 开始
+	deliveryRateFloorGain = 0.5
+
+	// 拥塞丢包后的降速比例：丢包率刚超过容忍度时降速 hysteriaBaseRateCut，与丢包率超出容忍度的倍数成正比，
+	// 最多降速 defaultMaxRateCut
+	hysteriaBaseRateCut = 0.25
+	defaultMaxRateCut   = 0.5
+
+	// 排空阶段：检测到队列堆积后，在一个 RTT 内以交付速率的 defaultDrainGain 倍发送，排空瓶颈处的队列
+	defaultDrainGain = 0.75
+
+	// RTT 梯度检测：每个 RTT 聚合为 rttGradientSamplesPerRTT 个样本，
+	// 拟合出的 RTT 增量超过平滑 RTT 的 rttInflationThreshold 倍时，视为队列正在堆积
+	rttGradientSamplesPerRTT = 4
+	rttInflationThreshold    = 0.25
 )
 
+// MinHysteriaInitialBandwidth is the lowest rate the Hysteria sender starts sending at.
+const MinHysteriaInitialBandwidth = Bandwidth(minStartBps) * BytesPerSecond
+
+// defaultLossThresholds is the default RTT 梯度丢包容忍度
+var defaultLossThresholds = []LossThreshold{
+	{RTTBelow: 50 * time.Millisecond, Threshold: 0.10},
+	{RTTBelow: 100 * time.Millisecond, Threshold: 0.15},
+	{RTTBelow: 180 * time.Millisecond, Threshold: 0.20},
+	{RTTBelow: math.MaxInt64, Threshold: 0.30},
+}
+
 type hysteriaSender struct {
+	clock    Clock
 	rttStats *utils.RTTStats
 
 	targetBps  protocol.ByteCount
 	currentBps protocol.ByteCount
 	stableBps  protocol.ByteCount
 
-	maxDatagram  protocol.ByteCount
-	nextSendTime monotime.Time
+	// pacing 速率：currentBps 是目标，pacedBps 按指数加权移动平均平滑地跟随它，
+	// 避免 currentBps 的阶跃式调整直接传递给 pacer，造成锯齿状的吞吐量
+	pacedBps        protocol.ByteCount
+	pacingAlpha     float64
+	lastPacedUpdate monotime.Time
+
+	// 速率增长倍数：普通 RTT、高 RTT（>150ms）、超高 RTT（>300ms）
+	growthFactor            float64
+	highRTTGrowthFactor     float64
+	veryHighRTTGrowthFactor float64
+
+	// 排空阶段：drainUntil 之前以 drainBps 发送；不处于排空阶段时为 0
+	drainGain  float64
+	drainUntil monotime.Time
+	drainBps   protocol.ByteCount
+
+	// 拥塞丢包后的最大降速比例
+	maxRateCut float64
 
-	// 历史 RTT 监控 (固定数组减少 GC)
-	rttHistory [rttWindowSize]time.Duration
-	rttIdx     int
-	maxRTT     time.Duration
+	// 可容忍的排队时延：设置后拥塞窗口为 currentBps * (minRTT + maxQueuingDelay)，为 0 时使用 cwndMultiplier
+	maxQueuingDelay time.Duration
+
+	initialMaxDatagram protocol.ByteCount
+	maxDatagram        protocol.ByteCount
+	maxDatagramCeiling protocol.ByteCount
+	// 与基于窗口的算法共用令牌桶 pacer，按当前速率精确发送，空闲期间积累的额度不超过最大突发量
+	pacer *pacer
 
 	rttCount int
+	// 每个拥塞周期最多降速一次：降速前发出的数据包再丢失时，属于同一个拥塞周期，不再重复降速
+	largestSentPacketNumber  protocol.PacketNumber
+	largestSentAtLastCutback protocol.PacketNumber
+	// 惩罚期的开始时间：拥塞丢包后 rttCount 为负，期间不提速；不处于惩罚期时为 0
+	penaltyStart monotime.Time
+	// 基于时间的惩罚期：设置 lossCooldown 时，降速后到 cooldownUntil 之前不提速，取代基于计数的惩罚期
+	lossCooldown  time.Duration
+	cooldownUntil monotime.Time
+	onPenalty     func(HysteriaPenaltyEvent)
+	logger        *slog.Logger
+
+	// 当前阶段（探测、稳定、惩罚期、排空），只用于 qlog
+	lastState qlog.CongestionState
+	qlogger   qlogwriter.Recorder
+
+	// brutal 模式：始终以目标速率发送，不对丢包和 RTT 波动做出反应
+	brutal bool
+	// 仅基于 RTT 的模式：忽略丢包，只在 RTT 膨胀（以及 RTO）时降速，适用于随机丢包多但时延稳定的链路
+	rttOnly bool
+
+	lossThresholds []LossThreshold
+
+	// 自动带宽模式：目标速率不再固定，而是根据测得的交付速率动态调整
+	autoBandwidth bool
+	sampler       BandwidthSampler
+
+	// 发送速率上限 (Bytes/s)，与拥塞窗口无关；0 表示不限制
+	maxPacingBps protocol.ByteCount
+
+	// 带宽探测截止时间：在此之前以 autoBandwidthProbeGain 倍的速率发送
+	probeUntil monotime.Time
+
+	// 抖动检测的最小值滤波窗口：最近若干个 RTT 样本，为空时直接使用 LatestRTT
+	jitterSamples []time.Duration
+	jitterIdx     int
+	// RTT 变化趋势，用于区分持续上升（队列堆积）和单次抖动
+	rttGradient RTTGradient
+
+	// RTO 退避比例，以及连续 RTO 的次数（收到 ACK 后清零）
+	rtoBackoff      float64
+	consecutiveRTOs int
+
+	// 稳定速率的判定：当前速率需要持续 stableRTTs 个 RTT 没有拥塞丢包。
+	// sustainStart 为当前这个 RTT 的开始时间，sustainRTTs 为已经持续的 RTT 数，
+	// sustainBps 为这期间的最低速率，也就是真正经受住考验的速率
+	stableRTTs   int
+	sustainStart monotime.Time
+	sustainRTTs  int
+	sustainBps   protocol.ByteCount
+
+	// 本轮应用受限的开始时间，以及上一次衰减的时间；不处于应用受限时为 0
+	appLimitedSince monotime.Time
+	lastDecay       monotime.Time
+	// 是否受对端流量控制窗口限制
+	flowControlLimited bool
 }
 
-func NewHysteriaSender(rttStats *utils.RTTStats, initialMaxDatagramSize protocol.ByteCount, mbps int) SendAlgorithmWithDebugInfos {
-	if mbps <= 0 {
-		mbps = 10
+// NewHysteriaSender creates a sender that targets the given bandwidth. If it is 0, the target is 10 Mbps.
+func NewHysteriaSender(clock Clock, rttStats *utils.RTTStats, initialMaxDatagramSize protocol.ByteCount, maxBandwidth Bandwidth, conf *Config, qlogger qlogwriter.Recorder) SendAlgorithmWithDebugInfos {
+	if conf == nil {
+		conf = &Config{}
+	}
+	if maxBandwidth == 0 {
+		maxBandwidth = BandwidthFromMbps(10)
 	}
-	targetBps := protocol.ByteCount(mbps) * 1024 * 1024 / 8
+	targetBps := protocol.ByteCount(maxBandwidth / BytesPerSecond)
+	initialMaxDatagramSize = min(initialMaxDatagramSize, conf.maxDatagramSizeCeiling())
 
 	// 起始速率策略：
 	var initialBps protocol.ByteCount
-	if mbps > 100 {
-		initialBps = 100 * 1024 * 1024 / 8
+	if conf.HysteriaInitialBandwidth > 0 {
+		initialBps = min(protocol.ByteCount(conf.HysteriaInitialBandwidth/BytesPerSecond), targetBps)
+	} else if maxBandwidth > BandwidthFromMbps(100) {
+		initialBps = protocol.ByteCount(BandwidthFromMbps(100) / BytesPerSecond)
 	} else {
 		initialBps = protocol.ByteCount(float64(targetBps) * 0.6)
 	}
@@ -49,30 +193,70 @@ func NewHysteriaSender(rttStats *utils.RTTStats, initialMaxDatagramSize protocol
 	if initialBps < minStartBps {
 		initialBps = minStartBps
 	}
+	if conf.HysteriaBrutal {
+		initialBps = targetBps
+	}
+
+	lossThresholds := conf.HysteriaLossThresholds
+	if len(lossThresholds) == 0 {
+		lossThresholds = defaultLossThresholds
+	}
 
-	return &hysteriaSender{
-		rttStats:     rttStats,
-		targetBps:    targetBps,
-		currentBps:   initialBps,
-		stableBps:    initialBps,
-		maxDatagram:  initialMaxDatagramSize,
-		nextSendTime: monotime.Now().Add(-100 * time.Millisecond),
+	h := &hysteriaSender{
+		clock:                    clock,
+		rttStats:                 rttStats,
+		targetBps:                targetBps,
+		currentBps:               initialBps,
+		stableBps:                initialBps,
+		pacedBps:                 initialBps,
+		largestSentPacketNumber:  protocol.InvalidPacketNumber,
+		largestSentAtLastCutback: protocol.InvalidPacketNumber,
+		pacingAlpha:              conf.hysteriaPacingAlpha(),
+		onPenalty:                conf.OnHysteriaPenalty,
+		logger:                   conf.Logger,
+		qlogger:                  qlogger,
+		initialMaxDatagram:       initialMaxDatagramSize,
+		maxDatagram:              initialMaxDatagramSize,
+		maxDatagramCeiling:       conf.maxDatagramSizeCeiling(),
+		brutal:                   conf.HysteriaBrutal,
+		rttOnly:                  conf.HysteriaRTTOnly,
+		lossThresholds:           lossThresholds,
+		autoBandwidth:            conf.HysteriaAutoBandwidth,
+		maxPacingBps:             protocol.ByteCount(conf.MaxPacingRate / BytesPerSecond),
+		jitterSamples:            make([]time.Duration, max(conf.HysteriaJitterFilterWindow, 0)),
+		rtoBackoff:               conf.hysteriaRTOBackoff(),
+		stableRTTs:               conf.hysteriaStableRTTs(),
 	}
+	h.growthFactor, h.highRTTGrowthFactor, h.veryHighRTTGrowthFactor = conf.hysteriaGrowthFactors()
+	h.drainGain = conf.hysteriaDrainGain()
+	h.maxRateCut = conf.hysteriaMaxRateCut()
+	h.maxQueuingDelay = max(conf.HysteriaMaxQueuingDelay, 0)
+	h.lossCooldown = max(conf.HysteriaLossCooldown, 0)
+	h.pacer = newRatePacer(func() Bandwidth { return Bandwidth(h.pacingBps()) * BytesPerSecond })
+	h.pacer.SetMaxBurst(conf.MaxPacingBurst)
+	h.pacer.SetDisabled(conf.DisablePacing)
+	h.pacer.SetMaxDatagramSize(initialMaxDatagramSize)
+	h.lastState = h.phase(clock.Now())
+	if h.qlogger != nil {
+		h.qlogger.RecordEvent(qlog.CongestionStateUpdated{State: h.lastState})
+	}
+	return h
 }
 
 func (h *hysteriaSender) TimeUntilSend(bytesInFlight protocol.ByteCount) monotime.Time {
-	now := monotime.Now()
+	now := h.clock.Now()
 	if bytesInFlight >= h.GetCongestionWindow() {
 		return now.Add(time.Hour)
 	}
-	if h.nextSendTime.After(now.Add(time.Millisecond)) {
-		return h.nextSendTime
-	}
-	return 0
+	return h.pacer.TimeUntilSend()
 }
 
 func (h *hysteriaSender) HasPacingBudget(now monotime.Time) bool {
-	return !h.nextSendTime.After(now.Add(time.Millisecond))
+	return h.pacer.Budget(now) >= h.maxDatagram
+}
+
+func (h *hysteriaSender) PacingBudget(now monotime.Time) protocol.ByteCount {
+	return h.pacer.Budget(now)
 }
 
 func (h *hysteriaSender) CanSend(bytesInFlight protocol.ByteCount) bool {
@@ -82,56 +266,117 @@ func (h *hysteriaSender) CanSend(bytesInFlight protocol.ByteCount) bool {
 func (h *hysteriaSender) GetCongestionWindow() protocol.ByteCount {
 	rtt := h.rttStats.SmoothedRTT()
 	if rtt == 0 {
-		return 1 * 1024 * 1024
+		// 尚无 RTT 样本时，按默认初始 RTT 计算，使窗口与当前速率保持一致
+		rtt = h.rttStats.InitialRTT()
 	}
 
-	// 动态 Multiplier：随 RTT 增加收紧窗口，强制更均匀的发包节奏
-	multiplier := 1.5
-	if rtt >= 180*time.Millisecond {
-		multiplier = 1.1
-	} else if rtt >= 100*time.Millisecond {
-		multiplier = 1.3
+	var cwnd protocol.ByteCount
+	if h.maxQueuingDelay > 0 {
+		// 窗口 = 传播时延对应的 BDP + 可容忍的排队量
+		minRTT := h.rttStats.MinRTT()
+		if minRTT == 0 {
+			minRTT = rtt
+		}
+		cwnd = protocol.ByteCount(float64(h.rateBps()) * (minRTT + h.maxQueuingDelay).Seconds())
+	} else {
+		cwnd = protocol.ByteCount(float64(h.rateBps()) * rtt.Seconds() * cwndMultiplier(rtt))
 	}
-
-	cwnd := protocol.ByteCount(float64(h.currentBps) * rtt.Seconds() * multiplier)
 	if minCwnd := 32 * h.maxDatagram; cwnd < minCwnd {
 		return minCwnd
 	}
 	return cwnd
 }
 
-func (h *hysteriaSender) OnPacketSent(sentTime monotime.Time, bytesInFlight protocol.ByteCount, packetNumber protocol.PacketNumber, bytes protocol.ByteCount, isRetransmittable bool) {
-	interval := time.Duration(int64(bytes) * int64(time.Second) / int64(h.currentBps))
-	now := monotime.Now()
-	if h.nextSendTime.Before(now) {
-		h.nextSendTime = now.Add(interval)
-	} else {
-		h.nextSendTime = h.nextSendTime.Add(interval)
+// cwndMultiplier 返回拥塞窗口相对于 BDP 的倍数。
+// 动态 Multiplier：随 RTT 增加收紧窗口，强制更均匀的发包节奏。
+// RTT 低于 100ms 时为 1.5，高于 180ms 时为 1.1，中间线性过渡，
+// 避免 RTT 跨越阈值时窗口发生跳变，导致吞吐量振荡。
+func cwndMultiplier(rtt time.Duration) float64 {
+	if rtt <= cwndMultiplierLowRTT {
+		return maxCwndMultiplier
 	}
-
-	// 动态 Burst Limit
-	rtt := h.rttStats.LatestRTT()
-	limitTime := 20 * time.Millisecond
-	if halfRTT := rtt / 2; halfRTT > limitTime {
-		limitTime = halfRTT
+	if rtt >= cwndMultiplierHighRTT {
+		return minCwndMultiplier
 	}
+	fraction := float64(rtt-cwndMultiplierLowRTT) / float64(cwndMultiplierHighRTT-cwndMultiplierLowRTT)
+	return maxCwndMultiplier - fraction*(maxCwndMultiplier-minCwndMultiplier)
+}
+
+func (h *hysteriaSender) OnPacketSent(sentTime monotime.Time, bytesInFlight protocol.ByteCount, packetNumber protocol.PacketNumber, bytes protocol.ByteCount, isRetransmittable bool) {
+	// 排空阶段按时间结束，在发包时检查
+	h.maybeQlogStateChange(sentTime)
+	h.updatePacedBps(sentTime)
+	h.pacer.SentPacket(sentTime, bytes)
+	h.largestSentPacketNumber = packetNumber
+}
 
-	if limit := now.Add(limitTime); h.nextSendTime.After(limit) {
-		h.nextSendTime = limit
+// updatePacedBps 让 pacing 速率向当前速率靠近。平滑系数按经过的时间折算：
+// 每经过一个平滑 RTT，两者之间的差距缩小 pacingAlpha，与发包频率无关
+func (h *hysteriaSender) updatePacedBps(now monotime.Time) {
+	if h.pacedBps == h.currentBps {
+		h.lastPacedUpdate = now
+		return
+	}
+	rtt := h.rttStats.SmoothedRTT()
+	if h.pacingAlpha >= 1 || rtt == 0 || h.lastPacedUpdate.IsZero() {
+		h.pacedBps = h.currentBps
+		h.lastPacedUpdate = now
+		return
+	}
+	elapsed := now.Sub(h.lastPacedUpdate)
+	if elapsed <= 0 {
+		return
+	}
+	h.lastPacedUpdate = now
+	weight := 1 - math.Pow(1-h.pacingAlpha, float64(elapsed)/float64(rtt))
+	h.pacedBps += protocol.ByteCount(weight * float64(h.currentBps-h.pacedBps))
+	// 差距小于一个数据包每秒时直接对齐，避免因取整永远无法到达
+	if diff := h.currentBps - h.pacedBps; diff < h.maxDatagram && diff > -h.maxDatagram {
+		h.pacedBps = h.currentBps
 	}
 }
 
 func (h *hysteriaSender) OnPacketAcked(pn protocol.PacketNumber, ackedBytes protocol.ByteCount, priorInFlight protocol.ByteCount, eventTime monotime.Time) {
-	h.updateRTTAndCheckJitter()
-
-	rtt := h.rttStats.SmoothedRTT()
-	// RTT 过大时（>150ms），加快速率增加步长，以快速填满长肥管道
-	growFactor := 1.1
-	if rtt > 150*time.Millisecond {
-		growFactor = 1.25 // 加速探测
+	if h.brutal {
+		return
+	}
+	defer h.maybeQlogStateChange(eventTime)
+	h.consecutiveRTOs = 0
+	h.updateRTTAndCheckJitter(eventTime)
+	h.sampler.OnPacketAcked(ackedBytes, eventTime, h.rttStats.SmoothedRTT())
+
+	// 应用受限时（应用没有足够的数据可发）不进行探测，避免空闲期间速率持续攀升
+	if h.isApplicationLimited(priorInFlight) {
+		h.maybeDecayApplicationLimited(eventTime)
+		// 应用受限期间当前速率没有被真正使用，不能证明链路可以承受
+		h.resetSustain()
+		return
+	}
+	h.appLimitedSince = 0
+	// 受流量控制限制时同样不进行探测：测得的交付速率反映的是对端的接收窗口，而不是链路容量。
+	// 排空阶段也不提速
+	if h.flowControlLimited || h.isDraining(eventTime) {
+		h.resetSustain()
+		return
+	}
+	if h.autoBandwidth {
+		h.updateTargetBps()
 	}
 
+	growFactor := h.growthFactorForRTT(h.rttStats.SmoothedRTT())
+
+	if !h.cooldownUntil.IsZero() {
+		if eventTime.Before(h.cooldownUntil) {
+			h.updateStableBps(eventTime)
+			return
+		}
+		h.cooldownUntil = 0
+		h.leavePenalty(eventTime)
+	}
 	h.rttCount++
+	if h.rttCount == 0 {
+		h.leavePenalty(eventTime)
+	}
 	// 每 4 个 RTT 探测周期
 	if h.rttCount >= 4 {
 		h.rttCount = 0
@@ -142,61 +387,409 @@ func (h *hysteriaSender) OnPacketAcked(pn protocol.PacketNumber, ackedBytes prot
 			}
 		}
 	}
+	h.updateStableBps(eventTime)
+}
+
+// growthFactorForRTT RTT 过大时，加快速率增加步长，以快速填满长肥管道
+func (h *hysteriaSender) growthFactorForRTT(rtt time.Duration) float64 {
+	switch {
+	case rtt > growthVeryHighRTT:
+		return h.veryHighRTTGrowthFactor
+	case rtt > growthHighRTT:
+		return h.highRTTGrowthFactor
+	default:
+		return h.growthFactor
+	}
+}
+
+// updateStableBps 当前速率持续 stableRTTs 个 RTT 没有拥塞丢包后，将这期间的最低速率作为新的稳定速率。
+// 刚刚探测上去、还没有经受考验的速率不会被立即采纳，否则之后的拥塞会从虚高的速率开始降速。
+func (h *hysteriaSender) updateStableBps(now monotime.Time) {
+	rtt := h.rttStats.SmoothedRTT()
+	if rtt == 0 {
+		return
+	}
+	if h.sustainStart.IsZero() {
+		h.sustainStart = now
+		h.sustainBps = h.currentBps
+		return
+	}
+	h.sustainBps = min(h.sustainBps, h.currentBps)
+	if now.Sub(h.sustainStart) < rtt {
+		return
+	}
+	h.sustainStart = now
+	h.sustainRTTs++
+	if h.sustainRTTs < h.stableRTTs {
+		return
+	}
+	h.stableBps = h.sustainBps
+	h.sustainRTTs = 0
+	h.sustainBps = h.currentBps
+}
+
+// resetSustain 重新开始稳定速率的判定
+func (h *hysteriaSender) resetSustain() {
+	h.sustainStart = 0
+	h.sustainRTTs = 0
 }
 
-func (h *hysteriaSender) OnCongestionEvent(pn protocol.PacketNumber, lostBytes protocol.ByteCount, priorInFlight protocol.ByteCount) {
+// maybeDecayApplicationLimited 应用长时间发送不足时，当前速率会一直停留在之前探测到的高位，
+// 流量恢复时会立即以过时的速率突发。持续应用受限超过若干个 RTT 后，
+// 每个 RTT 将当前速率逐步降向测得的交付速率，使恢复后的第一轮突发大小合适。
+func (h *hysteriaSender) maybeDecayApplicationLimited(now monotime.Time) {
+	if h.appLimitedSince.IsZero() {
+		h.appLimitedSince = now
+		h.lastDecay = now
+		return
+	}
 	rtt := h.rttStats.SmoothedRTT()
+	if rtt == 0 || now.Sub(h.appLimitedSince) < appLimitedDecayRTTs*rtt || now.Sub(h.lastDecay) < rtt {
+		return
+	}
+	h.lastDecay = now
+	bw := h.sampler.BandwidthEstimate()
+	if bw == 0 {
+		return
+	}
+	deliveryBps := max(protocol.ByteCount(bw/BytesPerSecond), minStartBps)
+	if h.currentBps <= deliveryBps {
+		return
+	}
+	h.currentBps -= protocol.ByteCount(float64(h.currentBps-deliveryBps) * appLimitedDecayFactor)
+	// 稳定速率也不应高于衰减后的速率，否则丢包或路径迁移后会恢复到过时的速率
+	h.stableBps = min(h.stableBps, h.currentBps)
+}
 
-	// RTT 梯度丢包容忍度
-	var threshold float64
+// updateTargetBps 自动带宽模式：以测得的交付速率加上探测余量作为目标速率。
+// 链路仍有余量时，交付速率随发送速率上升，目标速率随之上移；
+// 达到链路容量后，丢包和 RTT 膨胀会使发送速率回落。
+func (h *hysteriaSender) updateTargetBps() {
+	bw := h.sampler.BandwidthEstimate()
+	if bw == 0 {
+		return
+	}
+	h.targetBps = max(protocol.ByteCount(float64(bw/BytesPerSecond)*autoBandwidthProbeGain), minStartBps)
+}
+
+// isApplicationLimited returns true if the amount of data in flight is too small
+// to tell whether the network could sustain the current sending rate.
+// When sending at currentBps, roughly one BDP is in flight, which is more than
+// half of the congestion window for all multipliers used by GetCongestionWindow.
+func (h *hysteriaSender) isApplicationLimited(bytesInFlight protocol.ByteCount) bool {
+	return bytesInFlight < h.GetCongestionWindow()/2
+}
+
+func (h *hysteriaSender) OnCongestionEvent(pn protocol.PacketNumber, lostBytes protocol.ByteCount, priorInFlight protocol.ByteCount, _ TrafficClass, _ bool) {
+	if h.brutal {
+		return
+	}
+	// 仅基于 RTT 的模式下，拥塞只通过 RTT 膨胀判断（见 updateRTTAndCheckJitter）
+	if h.rttOnly {
+		logToleratedLoss(h.logger, pn, lostBytes)
+		return
+	}
+	// 该数据包在上一次降速之前发出，其丢失已经被上一次降速处理过
+	if pn <= h.largestSentAtLastCutback {
+		return
+	}
+	// 判定：丢包超标则降速
+	// 丢包率在容忍度以内时视为链路本身的随机丢包，不影响稳定速率的判定
+	if h.isCongestionLoss(lostBytes, priorInFlight) {
+		before := h.currentBps
+		h.largestSentAtLastCutback = h.largestSentPacketNumber
+		h.currentBps = protocol.ByteCount(float64(h.stableBps) * (1 - h.rateCut(lostBytes, priorInFlight)))
+		if h.lossCooldown > 0 {
+			h.rttCount = 0
+			h.cooldownUntil = h.clock.Now().Add(h.lossCooldown)
+		} else {
+			h.rttCount = -2 // 惩罚期
+		}
+		h.resetSustain()
+		h.enterPenalty(before)
+		h.maybeQlogStateChange(h.clock.Now())
+		logRateReduction(h.logger, pn, before, h.currentBps)
+		return
+	}
+	logToleratedLoss(h.logger, pn, lostBytes)
+}
+
+// enterPenalty 记录惩罚期的开始，并通知监控回调。惩罚期内再次降速时同样通知，但惩罚期的开始时间不变
+func (h *hysteriaSender) enterPenalty(before protocol.ByteCount) {
+	if h.penaltyStart.IsZero() {
+		h.penaltyStart = h.clock.Now()
+	}
+	if h.onPenalty != nil {
+		h.onPenalty(HysteriaPenaltyEvent{
+			Entered:    true,
+			RateBefore: Bandwidth(before) * BytesPerSecond,
+			RateAfter:  Bandwidth(h.currentBps) * BytesPerSecond,
+		})
+	}
+}
+
+// leavePenalty 结束惩罚期，并通知监控回调惩罚期的持续时间
+func (h *hysteriaSender) leavePenalty(now monotime.Time) {
+	if h.penaltyStart.IsZero() {
+		return
+	}
+	duration := now.Sub(h.penaltyStart)
+	h.penaltyStart = 0
+	if h.onPenalty != nil {
+		rate := Bandwidth(h.currentBps) * BytesPerSecond
+		h.onPenalty(HysteriaPenaltyEvent{RateBefore: rate, RateAfter: rate, Duration: duration})
+	}
+}
+
+func lossRate(lostBytes, priorInFlight protocol.ByteCount) float64 {
+	return float64(lostBytes) / float64(priorInFlight+1)
+}
+
+// phase 返回当前所处的阶段。同时满足多个条件时，排空优先于惩罚期，惩罚期优先于稳定
+func (h *hysteriaSender) phase(now monotime.Time) qlog.CongestionState {
 	switch {
-	case rtt < 50*time.Millisecond:
-		threshold = 0.10
-	case rtt < 100*time.Millisecond:
-		threshold = 0.15
-	case rtt < 180*time.Millisecond:
-		threshold = 0.20
+	case h.isDraining(now):
+		return qlog.CongestionStateDrain
+	case !h.penaltyStart.IsZero():
+		return qlog.CongestionStatePenalty
+	case h.brutal || h.currentBps >= h.targetBps:
+		return qlog.CongestionStateSteady
 	default:
-		threshold = 0.30
+		return qlog.CongestionStateProbing
+	}
+}
+
+// maybeQlogStateChange 阶段变化时记录到 qlog 和 slog logger
+func (h *hysteriaSender) maybeQlogStateChange(now monotime.Time) {
+	if h.qlogger == nil && h.logger == nil {
+		return
 	}
+	state := h.phase(now)
+	if state == h.lastState {
+		return
+	}
+	if h.qlogger != nil {
+		h.qlogger.RecordEvent(qlog.CongestionStateUpdated{State: state})
+	}
+	logStateChange(h.logger, state)
+	h.lastState = state
+}
 
-	lossRate := float64(lostBytes) / float64(priorInFlight+1)
+// isCongestionLoss 判断丢包是否由拥塞引起：丢包率超过随 RTT 变化的容忍度时视为拥塞
+func (h *hysteriaSender) isCongestionLoss(lostBytes, priorInFlight protocol.ByteCount) bool {
+	return lossRate(lostBytes, priorInFlight) > h.lossThreshold(h.rttStats.SmoothedRTT())
+}
 
-	// 判定：丢包超标则降速
-	if lossRate > threshold {
-		h.currentBps = protocol.ByteCount(float64(h.stableBps) * 0.75) // 降速 25%
-		h.rttCount = -2                                                // 惩罚期
-	} else {
-		h.stableBps = h.currentBps
+// rateCut 根据丢包的严重程度计算降速比例：丢包率刚超过容忍度时降速 25%，
+// 丢包率是容忍度的几倍，降速比例就是 25% 的几倍，但不超过 maxRateCut
+func (h *hysteriaSender) rateCut(lostBytes, priorInFlight protocol.ByteCount) float64 {
+	threshold := h.lossThreshold(h.rttStats.SmoothedRTT())
+	if threshold <= 0 {
+		return h.maxRateCut
+	}
+	return min(hysteriaBaseRateCut*lossRate(lostBytes, priorInFlight)/threshold, h.maxRateCut)
+}
+
+// lossThreshold 根据 RTT 查找丢包容忍度，超出所有分界点时使用最后一项
+func (h *hysteriaSender) lossThreshold(rtt time.Duration) float64 {
+	for _, t := range h.lossThresholds {
+		if rtt < t.RTTBelow {
+			return t.Threshold
+		}
 	}
+	return h.lossThresholds[len(h.lossThresholds)-1].Threshold
 }
 
-func (h *hysteriaSender) updateRTTAndCheckJitter() {
+func (h *hysteriaSender) updateRTTAndCheckJitter(now monotime.Time) {
 	rtt := h.rttStats.LatestRTT()
 	if rtt <= 0 {
 		return
 	}
 
-	// 记录 RTT 历史
-	h.rttHistory[h.rttIdx] = rtt
-	h.rttIdx = (h.rttIdx + 1) % rttWindowSize
-	if rtt > h.maxRTT {
-		h.maxRTT = rtt
+	// 队列已经排空：RTT 回落到接近最小 RTT
+	if h.isDraining(now) && rtt <= time.Duration(float64(h.rttStats.MinRTT())*(1+rttInflationThreshold)) {
+		h.drainUntil = 0
 	}
 
-	// 网络抖动快速下降：如果 LatestRTT 突增超过平滑 RTT 的 2 倍
+	// 队列堆积时快速下降：单个 RTT 尖峰不影响梯度，只有 RTT 持续上升才会降速
 	smoothed := h.rttStats.SmoothedRTT()
-	if smoothed > 20*time.Millisecond && rtt > smoothed*2 {
-		// 快速压制速率，减少网络抖动对缓冲区的冲击
-		h.currentBps = protocol.ByteCount(float64(h.currentBps) * 0.85)
-		if h.currentBps < minStartBps {
-			h.currentBps = minStartBps
+	h.rttGradient.AddSample(now, h.filterJitterRTT(rtt), smoothed/rttGradientSamplesPerRTT)
+	if smoothed <= 20*time.Millisecond {
+		return
+	}
+	if increase, ok := h.rttGradient.Increase(); ok && increase > time.Duration(float64(smoothed)*rttInflationThreshold) {
+		// 快速压制速率，减少排队对缓冲区的冲击
+		h.currentBps = max(protocol.ByteCount(float64(h.currentBps)*0.85), h.floorBps())
+		// 降速后重新观察 RTT 趋势，避免同一次上升反复触发降速
+		h.rttGradient.Reset()
+		h.startDrain(now, smoothed)
+	}
+}
+
+// startDrain 降速只是不再继续堆积队列，已经堆积的队列还需要排空：
+// 在一个 RTT 内以低于交付速率的速率发送，然后恢复到降速后的速率
+func (h *hysteriaSender) startDrain(now monotime.Time, rtt time.Duration) {
+	if h.drainGain >= 1 {
+		return
+	}
+	deliveryBps := h.currentBps
+	if bw := h.sampler.BandwidthEstimate(); bw > 0 {
+		deliveryBps = protocol.ByteCount(bw / BytesPerSecond)
+	}
+	h.drainBps = max(protocol.ByteCount(float64(deliveryBps)*h.drainGain), minStartBps)
+	h.drainUntil = now.Add(rtt)
+}
+
+func (h *hysteriaSender) isDraining(now monotime.Time) bool {
+	return !h.drainUntil.IsZero() && now.Before(h.drainUntil)
+}
+
+// filterJitterRTT 返回用于抖动检测的 RTT：启用滤波时为窗口内的最小样本，
+// 这样被延迟 ACK 或 ACK 聚合放大的样本在进入 RTT 梯度之前就被滤除
+func (h *hysteriaSender) filterJitterRTT(rtt time.Duration) time.Duration {
+	if len(h.jitterSamples) == 0 {
+		return rtt
+	}
+	h.jitterSamples[h.jitterIdx] = rtt
+	h.jitterIdx = (h.jitterIdx + 1) % len(h.jitterSamples)
+	filtered := rtt
+	for _, s := range h.jitterSamples {
+		if s > 0 && s < filtered {
+			filtered = s
 		}
 	}
+	return filtered
+}
+
+// OnRetransmissionTimeout 单次 RTO 不代表链路容量骤降，只将速率降到稳定速率的 rtoBackoff 倍；
+// 连续 RTO 时逐次按同一比例继续退避，直到 1Mbps 保护线。收到 ACK 后重新计数。
+func (h *hysteriaSender) OnRetransmissionTimeout(bool) {
+	if h.brutal {
+		return
+	}
+	h.consecutiveRTOs++
+	h.resetSustain()
+	backoff := math.Pow(h.rtoBackoff, float64(h.consecutiveRTOs))
+	h.currentBps = max(protocol.ByteCount(float64(h.stableBps)*backoff), h.floorBps())
+	h.maybeQlogStateChange(h.clock.Now())
+}
+
+// floorBps 返回降速的下限：minStartBps 与最近交付速率的 deliveryRateFloorGain 倍中的较大值。
+// 没有交付速率样本时使用 minStartBps
+func (h *hysteriaSender) floorBps() protocol.ByteCount {
+	deliveryBps := protocol.ByteCount(h.sampler.BandwidthEstimate() / BytesPerSecond)
+	return max(protocol.ByteCount(float64(deliveryBps)*deliveryRateFloorGain), minStartBps)
+}
+
+// OnConnectionMigration 路径迁移后，旧路径上的 RTT 梯度和速率估计不再适用，需要重置。
+// 最近的 RTT 样本由 RTTStats 记录，随 RTTStats 一起重置。
+// 新路径的速率从上一个稳定速率（不超过目标速率）重新开始。
+func (h *hysteriaSender) OnConnectionMigration() {
+	h.probeUntil = 0
+	h.consecutiveRTOs = 0
+	h.resetSustain()
+	h.appLimitedSince = 0
+	h.lastDecay = 0
+	h.drainUntil = 0
+	clear(h.jitterSamples)
+	h.jitterIdx = 0
+	h.rttGradient.Reset()
+	h.rttCount = 0
+	h.cooldownUntil = 0
+	h.largestSentAtLastCutback = protocol.InvalidPacketNumber
+	h.sampler.Reset()
+	h.maxDatagram = h.initialMaxDatagram
+	h.pacer.SetMaxDatagramSize(h.initialMaxDatagram)
+	if h.brutal {
+		h.currentBps = h.targetBps
+	} else {
+		h.currentBps = max(min(h.stableBps, h.targetBps), minStartBps)
+	}
+	h.stableBps = h.currentBps
+	h.pacedBps = h.currentBps
+	h.lastPacedUpdate = 0
+	h.pacer.Reset()
+	h.leavePenalty(h.clock.Now())
+	h.maybeQlogStateChange(h.clock.Now())
+}
+
+func (h *hysteriaSender) MaybeExitSlowStart() {}
+func (h *hysteriaSender) InSlowStart() bool   { return false }
+func (h *hysteriaSender) InRecovery() bool    { return false }
+
+func (h *hysteriaSender) SetMaxDatagramSize(s protocol.ByteCount) {
+	s = min(s, h.maxDatagramCeiling)
+	h.maxDatagram = s
+	h.pacer.SetMaxDatagramSize(s)
+}
+
+// State returns StateCongestionAvoidance: Hysteria is rate-based, and has neither a slow start nor a recovery phase.
+func (h *hysteriaSender) State(protocol.ByteCount) State         { return StateCongestionAvoidance }
+func (h *hysteriaSender) SlowStartThreshold() protocol.ByteCount { return protocol.MaxByteCount }
+
+// BandwidthEstimate returns the current sending rate.
+func (h *hysteriaSender) BandwidthEstimate() Bandwidth {
+	return Bandwidth(h.pacingBps()) * BytesPerSecond
+}
+
+// EstimatedSendTime 按当前速率（含带宽探测增益）估算发送给定字节数所需的时间
+func (h *hysteriaSender) EstimatedSendTime(bytes protocol.ByteCount) time.Duration {
+	return SendTime(bytes, Bandwidth(h.rateBps())*BytesPerSecond)
+}
+
+// rateBps 返回当前速率，带宽探测期间提高 autoBandwidthProbeGain 倍
+func (h *hysteriaSender) rateBps() protocol.ByteCount {
+	return h.withProbeGain(h.currentBps)
+}
+
+func (h *hysteriaSender) withProbeGain(bps protocol.ByteCount) protocol.ByteCount {
+	if !h.probeUntil.IsZero() && h.clock.Now().Before(h.probeUntil) {
+		return protocol.ByteCount(float64(bps) * autoBandwidthProbeGain)
+	}
+	return bps
 }
 
-func (h *hysteriaSender) OnRetransmissionTimeout(bool)            { h.currentBps = minStartBps }
-func (h *hysteriaSender) MaybeExitSlowStart()                     {}
-func (h *hysteriaSender) SetMaxDatagramSize(s protocol.ByteCount) { h.maxDatagram = s }
-func (h *hysteriaSender) InSlowStart() bool                       { return false }
-func (h *hysteriaSender) InRecovery() bool                        { return false }
+// pacingBps 返回实际的发送速率：平滑后的 pacing 速率，不超过配置的速率上限，但不低于每个 RTT 一个数据包
+func (h *hysteriaSender) pacingBps() protocol.ByteCount {
+	bps := h.withProbeGain(h.pacedBps)
+	if h.isDraining(h.clock.Now()) {
+		bps = min(bps, h.drainBps)
+	}
+	if h.maxPacingBps > 0 {
+		bps = min(bps, h.maxPacingBps)
+	}
+	return max(bps, h.minPacingBps())
+}
+
+// minPacingBps 返回每个 RTT 发送一个完整数据包所需的速率。
+// 无论 currentBps 多低，pacer 每个 RTT 至少允许发送一个数据包，
+// 避免在极低速率、高 RTT 时连接长时间无法发送，导致空闲超时。
+// 下限优先于目标速率和显式配置的 maxPacingBps：即使它们低于每个 RTT 一个数据包，连接也不会停止发送
+func (h *hysteriaSender) minPacingBps() protocol.ByteCount {
+	rtt := h.rttStats.SmoothedRTT()
+	if rtt == 0 {
+		rtt = h.rttStats.InitialRTT()
+	}
+	return protocol.ByteCount(math.Ceil(float64(h.maxDatagram) / rtt.Seconds()))
+}
+
+// OnSpuriousLoss 无需处理：Hysteria 按丢包率而不是单个丢包调整速率，少量误判不会触发降速。
+func (h *hysteriaSender) OnSpuriousLoss(protocol.PacketNumber) {}
+
+// OnPersistentCongestion 无需处理：Hysteria 没有拥塞窗口可以收缩，持续丢包时已经按丢包率降速。
+func (h *hysteriaSender) OnPersistentCongestion() {}
+
+// OnFlowControlLimited 受对端流量控制窗口限制时，发送速率由接收方决定，无法证明链路可以承受更高的速率，
+// 因此暂停速率探测，直到窗口重新打开。
+func (h *hysteriaSender) OnFlowControlLimited(limited bool) {
+	h.flowControlLimited = limited
+}
+
+// ProbeBandwidth 在给定时间之前临时提高发送速率，以探测链路的剩余带宽。
+// brutal 模式下始终以目标速率发送，不做探测。
+func (h *hysteriaSender) ProbeBandwidth(until monotime.Time) {
+	if h.brutal {
+		return
+	}
+	h.probeUntil = until
+}
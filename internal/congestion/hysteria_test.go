@@ -0,0 +1,1100 @@
+package congestion
+
+import (
+	"cmp"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go/internal/monotime"
+	"github.com/quic-go/quic-go/internal/protocol"
+	"github.com/quic-go/quic-go/internal/utils"
+	"github.com/quic-go/quic-go/qlog"
+	"github.com/quic-go/quic-go/qlogwriter"
+	"github.com/quic-go/quic-go/testutils/events"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHysteriaSender(mbps int) (*hysteriaSender, *utils.RTTStats) {
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(50*time.Millisecond, 0)
+	return NewHysteriaSender(DefaultClock{}, rttStats, maxDatagramSize, BandwidthFromMbps(mbps), nil, nil).(*hysteriaSender), rttStats
+}
+
+func TestHysteriaSenderMaxBandwidth(t *testing.T) {
+	rttStats := utils.NewRTTStats()
+	sender := NewHysteriaSender(DefaultClock{}, rttStats, maxDatagramSize, 1_500_000*BitsPerSecond, nil, nil).(*hysteriaSender)
+	require.Equal(t, protocol.ByteCount(187_500), sender.targetBps)
+	// the target rate defaults to 10 Mbps
+	sender = NewHysteriaSender(DefaultClock{}, rttStats, maxDatagramSize, 0, nil, nil).(*hysteriaSender)
+	require.Equal(t, protocol.ByteCount(BandwidthFromMbps(10)/BytesPerSecond), sender.targetBps)
+	// high target rates start at 100 Mbps
+	sender = NewHysteriaSender(DefaultClock{}, rttStats, maxDatagramSize, 2_500_000_000*BitsPerSecond, nil, nil).(*hysteriaSender)
+	require.Equal(t, protocol.ByteCount(312_500_000), sender.targetBps)
+	require.Equal(t, protocol.ByteCount(BandwidthFromMbps(100)/BytesPerSecond), sender.currentBps)
+}
+
+func TestHysteriaSenderInitialBandwidth(t *testing.T) {
+	rttStats := utils.NewRTTStats()
+	newSender := func(initial Bandwidth) *hysteriaSender {
+		return NewHysteriaSender(DefaultClock{}, rttStats, maxDatagramSize, BandwidthFromMbps(200), &Config{
+			HysteriaInitialBandwidth: initial,
+		}, nil).(*hysteriaSender)
+	}
+	require.Equal(t, protocol.ByteCount(BandwidthFromMbps(20)/BytesPerSecond), newSender(BandwidthFromMbps(20)).currentBps)
+	require.Equal(t, protocol.ByteCount(BandwidthFromMbps(20)/BytesPerSecond), newSender(BandwidthFromMbps(20)).stableBps)
+	// the initial rate is clamped to the target rate and the minimum start rate
+	require.Equal(t, protocol.ByteCount(BandwidthFromMbps(200)/BytesPerSecond), newSender(BandwidthFromMbps(300)).currentBps)
+	require.Equal(t, protocol.ByteCount(minStartBps), newSender(BitsPerSecond).currentBps)
+
+	// brutal mode always starts at the target rate
+	sender := NewHysteriaSender(DefaultClock{}, rttStats, maxDatagramSize, BandwidthFromMbps(200), &Config{
+		HysteriaInitialBandwidth: BandwidthFromMbps(20),
+		HysteriaBrutal:           true,
+	}, nil).(*hysteriaSender)
+	require.Equal(t, sender.targetBps, sender.currentBps)
+}
+
+func TestHysteriaSenderApplicationLimited(t *testing.T) {
+	sender, _ := newTestHysteriaSender(100)
+	initialBps := sender.currentBps
+
+	// only a single packet in flight: the application is not using the available rate
+	for i := range 100 {
+		sender.OnPacketAcked(protocol.PacketNumber(i), maxDatagramSize, maxDatagramSize, monotime.Now())
+	}
+	require.Equal(t, initialBps, sender.currentBps)
+
+	// the congestion window is fully utilized: the rate is probed
+	for i := range 4 {
+		sender.OnPacketAcked(protocol.PacketNumber(100+i), maxDatagramSize, sender.GetCongestionWindow(), monotime.Now())
+	}
+	require.Greater(t, sender.currentBps, initialBps)
+}
+
+func TestHysteriaSenderFlowControlLimited(t *testing.T) {
+	sender, _ := newTestHysteriaSender(100)
+	initialBps := sender.currentBps
+
+	// the congestion window is fully utilized, but sending is blocked by flow control
+	sender.OnFlowControlLimited(true)
+	for i := range 100 {
+		sender.OnPacketAcked(protocol.PacketNumber(i), maxDatagramSize, sender.GetCongestionWindow(), monotime.Now())
+	}
+	require.Equal(t, initialBps, sender.currentBps)
+
+	// once the flow control window opens, the rate is probed again
+	sender.OnFlowControlLimited(false)
+	for i := range 4 {
+		sender.OnPacketAcked(protocol.PacketNumber(100+i), maxDatagramSize, sender.GetCongestionWindow(), monotime.Now())
+	}
+	require.Greater(t, sender.currentBps, initialBps)
+}
+
+func TestHysteriaSenderGrowthFactors(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		conf     *Config
+		rtt      time.Duration
+		expected float64
+	}{
+		{name: "default", conf: nil, rtt: 50 * time.Millisecond, expected: 1.1},
+		{name: "default, high RTT", conf: nil, rtt: 200 * time.Millisecond, expected: 1.25},
+		{name: "default, very high RTT", conf: nil, rtt: 600 * time.Millisecond, expected: 1.25},
+		{name: "configured", conf: &Config{HysteriaGrowthFactor: 1.05}, rtt: 50 * time.Millisecond, expected: 1.05},
+		{name: "configured, high RTT", conf: &Config{HysteriaHighRTTGrowthFactor: 1.5}, rtt: 200 * time.Millisecond, expected: 1.5},
+		{name: "configured, very high RTT", conf: &Config{HysteriaVeryHighRTTGrowthFactor: 2}, rtt: 600 * time.Millisecond, expected: 2},
+		{
+			name:     "very high RTT defaults to the high-RTT factor",
+			conf:     &Config{HysteriaHighRTTGrowthFactor: 1.5},
+			rtt:      600 * time.Millisecond,
+			expected: 1.5,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rttStats := utils.NewRTTStats()
+			rttStats.UpdateRTT(tc.rtt, 0)
+			sender := NewHysteriaSender(DefaultClock{}, rttStats, maxDatagramSize, BandwidthFromMbps(1000), tc.conf, nil).(*hysteriaSender)
+			initialBps := sender.currentBps
+			// one probing cycle takes 4 acknowledgments
+			for i := range 4 {
+				sender.OnPacketAcked(protocol.PacketNumber(i), maxDatagramSize, sender.GetCongestionWindow(), monotime.Now())
+			}
+			require.InEpsilon(t, float64(initialBps)*tc.expected, float64(sender.currentBps), 0.001)
+		})
+	}
+}
+
+func TestHysteriaSenderApplicationLimitedDecay(t *testing.T) {
+	sender, _ := newTestHysteriaSender(100)
+	initialBps := sender.currentBps
+
+	// the application sends one packet every millisecond
+	const deliveryBps = maxDatagramSize * 1000
+	now := monotime.Now()
+	var pn protocol.PacketNumber
+	ack := func(d time.Duration, priorInFlight protocol.ByteCount) {
+		for end := now.Add(d); now.Before(end); now = now.Add(time.Millisecond) {
+			sender.OnPacketAcked(pn, maxDatagramSize, priorInFlight, now)
+			pn++
+		}
+	}
+	// short application-limited periods don't reduce the rate
+	ack(200*time.Millisecond, maxDatagramSize)
+	require.Equal(t, initialBps, sender.currentBps)
+
+	// the rate is gradually reduced to the delivery rate
+	ack(100*time.Millisecond, maxDatagramSize)
+	require.Less(t, sender.currentBps, initialBps)
+	require.Greater(t, sender.currentBps, protocol.ByteCount(deliveryBps))
+	ack(3*time.Second, maxDatagramSize)
+	require.InEpsilon(t, float64(deliveryBps), float64(sender.currentBps), 0.1)
+	require.LessOrEqual(t, sender.stableBps, sender.currentBps)
+
+	// the rate is not reduced while the congestion window is utilized
+	decayedBps := sender.currentBps
+	ack(time.Second, sender.GetCongestionWindow())
+	require.Greater(t, sender.currentBps, decayedBps)
+}
+
+func TestHysteriaSenderBrutal(t *testing.T) {
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(50*time.Millisecond, 0)
+	sender := NewHysteriaSender(DefaultClock{}, rttStats, maxDatagramSize, BandwidthFromMbps(100), &Config{HysteriaBrutal: true}, nil).(*hysteriaSender)
+	require.Equal(t, sender.targetBps, sender.currentBps)
+
+	// heavy loss doesn't reduce the rate
+	sender.OnCongestionEvent(1, 1000*maxDatagramSize, 1000*maxDatagramSize, TrafficClassDefault, false)
+	require.Equal(t, sender.targetBps, sender.currentBps)
+	// neither do RTT spikes
+	rttStats.UpdateRTT(500*time.Millisecond, 0)
+	sender.OnPacketAcked(2, maxDatagramSize, sender.GetCongestionWindow(), monotime.Now())
+	require.Equal(t, sender.targetBps, sender.currentBps)
+	// nor retransmission timeouts
+	sender.OnRetransmissionTimeout(true)
+	require.Equal(t, sender.targetBps, sender.currentBps)
+}
+
+func TestHysteriaSenderRTTOnly(t *testing.T) {
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(50*time.Millisecond, 0)
+	sender := NewHysteriaSender(DefaultClock{}, rttStats, maxDatagramSize, BandwidthFromMbps(100), &Config{HysteriaRTTOnly: true}, nil).(*hysteriaSender)
+	initialBps := sender.currentBps
+
+	// heavy loss doesn't reduce the rate
+	sender.OnPacketSent(monotime.Now(), 0, 1, maxDatagramSize, true)
+	sender.OnCongestionEvent(1, 1000*maxDatagramSize, 1000*maxDatagramSize, TrafficClassDefault, false)
+	require.Equal(t, initialBps, sender.currentBps)
+	require.Zero(t, sender.penaltyStart)
+	// but RTT inflation does
+	inflateRTT(t, sender, rttStats, monotime.Now())
+	require.Less(t, sender.currentBps, initialBps)
+}
+
+func TestHysteriaSenderRetransmissionTimeout(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		backoff float64
+	}{
+		{name: "default", backoff: 0},
+		{name: "custom", backoff: 0.8},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			expectedBackoff := cmp.Or(tc.backoff, defaultRTOBackoff)
+			rttStats := utils.NewRTTStats()
+			rttStats.UpdateRTT(50*time.Millisecond, 0)
+			sender := NewHysteriaSender(DefaultClock{}, rttStats, maxDatagramSize, BandwidthFromMbps(100), &Config{HysteriaRTOBackoff: tc.backoff}, nil).(*hysteriaSender)
+			stableBps := sender.stableBps
+
+			sender.OnRetransmissionTimeout(true)
+			require.Equal(t, protocol.ByteCount(float64(stableBps)*expectedBackoff), sender.currentBps)
+			// consecutive RTOs escalate the backoff, down to the minimum rate
+			sender.OnRetransmissionTimeout(true)
+			require.Equal(t, max(protocol.ByteCount(float64(stableBps)*expectedBackoff*expectedBackoff), minStartBps), sender.currentBps)
+			for range 20 {
+				sender.OnRetransmissionTimeout(true)
+			}
+			require.Equal(t, protocol.ByteCount(minStartBps), sender.currentBps)
+
+			// an ACK resets the backoff
+			sender.OnPacketAcked(1, maxDatagramSize, maxDatagramSize, monotime.Now())
+			sender.OnRetransmissionTimeout(true)
+			require.Equal(t, protocol.ByteCount(float64(stableBps)*expectedBackoff), sender.currentBps)
+		})
+	}
+}
+
+func TestHysteriaSenderDeliveryRateFloor(t *testing.T) {
+	sender, rttStats := newTestHysteriaSender(100)
+	// the network recently delivered 1000 packets per second
+	now := monotime.Now()
+	for range 100 {
+		sender.sampler.OnPacketAcked(maxDatagramSize, now, rttStats.SmoothedRTT())
+		now = now.Add(time.Millisecond)
+	}
+	deliveryBps := protocol.ByteCount(sender.sampler.BandwidthEstimate() / BytesPerSecond)
+	require.InEpsilon(t, float64(1000*maxDatagramSize), float64(deliveryBps), 0.05)
+	floor := protocol.ByteCount(float64(deliveryBps) * deliveryRateFloorGain)
+	require.Greater(t, floor, protocol.ByteCount(minStartBps))
+
+	// consecutive RTOs reduce the rate down to a fraction of the delivery rate
+	for range 20 {
+		sender.OnRetransmissionTimeout(true)
+	}
+	require.Equal(t, floor, sender.currentBps)
+
+	// so does the reaction to RTT inflation
+	sender.currentBps = floor + 1
+	inflateRTT(t, sender, rttStats, now)
+	require.Equal(t, floor, sender.currentBps)
+}
+
+func TestHysteriaSenderCongestionWindowMultiplier(t *testing.T) {
+	require.Equal(t, 1.5, cwndMultiplier(10*time.Millisecond))
+	require.Equal(t, 1.5, cwndMultiplier(100*time.Millisecond))
+	require.InDelta(t, 1.3, cwndMultiplier(140*time.Millisecond), 1e-9)
+	require.Equal(t, 1.1, cwndMultiplier(180*time.Millisecond))
+	require.Equal(t, 1.1, cwndMultiplier(time.Second))
+
+	sender, rttStats := newTestHysteriaSender(100)
+	var lastMultiplier float64
+	var lastCwnd protocol.ByteCount
+	for rtt := 90 * time.Millisecond; rtt <= 200*time.Millisecond; rtt += time.Millisecond {
+		*rttStats = utils.RTTStats{}
+		rttStats.UpdateRTT(rtt, 0)
+		multiplier := cwndMultiplier(rtt)
+		cwnd := sender.GetCongestionWindow()
+		if lastCwnd > 0 {
+			// the multiplier decreases monotonically, but the window still grows with the RTT
+			require.LessOrEqual(t, multiplier, lastMultiplier)
+			require.GreaterOrEqual(t, cwnd, lastCwnd, "window decreased at %s", rtt)
+			// no jumps: a 1ms RTT increase changes the window by less than 2%
+			require.Less(t, float64(cwnd-lastCwnd), 0.02*float64(lastCwnd), "window jumped at %s", rtt)
+		}
+		lastMultiplier = multiplier
+		lastCwnd = cwnd
+	}
+}
+
+func TestHysteriaSenderState(t *testing.T) {
+	sender, _ := newTestHysteriaSender(100)
+	require.Equal(t, StateCongestionAvoidance, sender.State(0))
+	sender.OnCongestionEvent(1, maxDatagramSize, sender.GetCongestionWindow(), TrafficClassDefault, false)
+	require.Equal(t, StateCongestionAvoidance, sender.State(sender.GetCongestionWindow()))
+}
+
+func TestHysteriaSenderLossThresholds(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		sender, _ := newTestHysteriaSender(100)
+		require.Equal(t, 0.10, sender.lossThreshold(10*time.Millisecond))
+		require.Equal(t, 0.15, sender.lossThreshold(50*time.Millisecond))
+		require.Equal(t, 0.20, sender.lossThreshold(150*time.Millisecond))
+		require.Equal(t, 0.30, sender.lossThreshold(time.Second))
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		rttStats := utils.NewRTTStats()
+		rttStats.UpdateRTT(50*time.Millisecond, 0)
+		sender := NewHysteriaSender(DefaultClock{}, rttStats, maxDatagramSize, BandwidthFromMbps(100), &Config{
+			HysteriaLossThresholds: []LossThreshold{
+				{RTTBelow: 20 * time.Millisecond, Threshold: 0.01},
+				{RTTBelow: 200 * time.Millisecond, Threshold: 0.5},
+			},
+		}, nil).(*hysteriaSender)
+		require.Equal(t, 0.01, sender.lossThreshold(10*time.Millisecond))
+		require.Equal(t, 0.5, sender.lossThreshold(100*time.Millisecond))
+		// RTTs exceeding all breakpoints use the last threshold
+		require.Equal(t, 0.5, sender.lossThreshold(time.Second))
+
+		// 40% loss at 50ms is below the threshold
+		initialBps := sender.currentBps
+		sender.OnCongestionEvent(1, 4*maxDatagramSize, 10*maxDatagramSize, TrafficClassDefault, false)
+		require.Equal(t, initialBps, sender.currentBps)
+		sender.OnCongestionEvent(2, 6*maxDatagramSize, 10*maxDatagramSize, TrafficClassDefault, false)
+		require.Less(t, sender.currentBps, initialBps)
+	})
+}
+
+func TestHysteriaSenderProportionalRateCut(t *testing.T) {
+	newSender := func(conf *Config) *hysteriaSender {
+		rttStats := utils.NewRTTStats()
+		// the loss threshold is 30%
+		rttStats.UpdateRTT(time.Second, 0)
+		return NewHysteriaSender(DefaultClock{}, rttStats, maxDatagramSize, BandwidthFromMbps(100), conf, nil).(*hysteriaSender)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		conf     *Config
+		lossRate float64
+		cut      float64
+	}{
+		{name: "just above the threshold", lossRate: 0.31, cut: 0.25 * 0.31 / 0.3},
+		{name: "at 1.5 times the threshold", lossRate: 0.45, cut: 0.375},
+		{name: "at 3 times the threshold", lossRate: 0.9, cut: defaultMaxRateCut},
+		{name: "custom maximum", conf: &Config{HysteriaMaxRateCut: 0.8}, lossRate: 0.9, cut: 0.75},
+		{name: "custom maximum below the base cut", conf: &Config{HysteriaMaxRateCut: 0.1}, lossRate: 0.31, cut: 0.1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sender := newSender(tc.conf)
+			stableBps := sender.stableBps
+			const priorInFlight = 1000 * maxDatagramSize
+			sender.OnCongestionEvent(1, protocol.ByteCount(tc.lossRate*float64(priorInFlight+1)), priorInFlight, TrafficClassDefault, false)
+			require.InEpsilon(t, float64(stableBps)*(1-tc.cut), float64(sender.currentBps), 0.001)
+		})
+	}
+
+	// higher loss rates result in larger cuts
+	var rates []protocol.ByteCount
+	for _, lossRate := range []float64{0.31, 0.4, 0.5, 0.6} {
+		sender := newSender(nil)
+		const priorInFlight = 1000 * maxDatagramSize
+		sender.OnCongestionEvent(1, protocol.ByteCount(lossRate*float64(priorInFlight+1)), priorInFlight, TrafficClassDefault, false)
+		rates = append(rates, sender.currentBps)
+	}
+	require.IsDecreasing(t, rates)
+}
+
+func TestHysteriaSenderPenalty(t *testing.T) {
+	var clock mockClock
+	clock.Advance(time.Second)
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(50*time.Millisecond, 0)
+	var events []HysteriaPenaltyEvent
+	sender := NewHysteriaSender(&clock, rttStats, maxDatagramSize, BandwidthFromMbps(100), &Config{
+		OnHysteriaPenalty: func(e HysteriaPenaltyEvent) { events = append(events, e) },
+	}, nil).(*hysteriaSender)
+	initialBps := sender.currentBps
+
+	sender.OnCongestionEvent(1, 5*maxDatagramSize, 10*maxDatagramSize, TrafficClassDefault, false)
+	require.Equal(t, []HysteriaPenaltyEvent{{
+		Entered:    true,
+		RateBefore: Bandwidth(initialBps) * BytesPerSecond,
+		RateAfter:  Bandwidth(sender.currentBps) * BytesPerSecond,
+	}}, events)
+	require.Less(t, sender.currentBps, initialBps)
+
+	// another congestion event during the penalty period reduces the rate again
+	clock.Advance(10 * time.Millisecond)
+	before := sender.currentBps
+	sender.OnCongestionEvent(2, 5*maxDatagramSize, 10*maxDatagramSize, TrafficClassDefault, false)
+	require.Len(t, events, 2)
+	require.True(t, events[1].Entered)
+	require.Equal(t, Bandwidth(before)*BytesPerSecond, events[1].RateBefore)
+
+	// the penalty period ends after 2 ACKs
+	clock.Advance(10 * time.Millisecond)
+	sender.OnPacketAcked(3, maxDatagramSize, sender.GetCongestionWindow(), clock.Now())
+	require.Len(t, events, 2)
+	clock.Advance(10 * time.Millisecond)
+	sender.OnPacketAcked(4, maxDatagramSize, sender.GetCongestionWindow(), clock.Now())
+	require.Len(t, events, 3)
+	rate := Bandwidth(sender.currentBps) * BytesPerSecond
+	require.Equal(t, HysteriaPenaltyEvent{RateBefore: rate, RateAfter: rate, Duration: 30 * time.Millisecond}, events[2])
+
+	// the penalty period also ends when the connection migrates
+	sender.OnCongestionEvent(5, 5*maxDatagramSize, 10*maxDatagramSize, TrafficClassDefault, false)
+	require.Len(t, events, 4)
+	sender.OnConnectionMigration()
+	require.Len(t, events, 5)
+	require.False(t, events[4].Entered)
+}
+
+func TestHysteriaSenderLossCooldown(t *testing.T) {
+	var clock mockClock
+	clock.Advance(time.Second)
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(50*time.Millisecond, 0)
+	var events []HysteriaPenaltyEvent
+	sender := NewHysteriaSender(&clock, rttStats, maxDatagramSize, BandwidthFromMbps(100), &Config{
+		HysteriaLossCooldown: 500 * time.Millisecond,
+		OnHysteriaPenalty:    func(e HysteriaPenaltyEvent) { events = append(events, e) },
+	}, nil).(*hysteriaSender)
+
+	sender.OnCongestionEvent(1, 5*maxDatagramSize, 10*maxDatagramSize, TrafficClassDefault, false)
+	require.Len(t, events, 1)
+	bps := sender.currentBps
+
+	// no matter how many ACKs arrive, the rate isn't increased during the cooldown
+	pn := protocol.PacketNumber(2)
+	for range 49 {
+		clock.Advance(10 * time.Millisecond)
+		sender.OnPacketAcked(pn, maxDatagramSize, sender.GetCongestionWindow(), clock.Now())
+		pn++
+	}
+	require.Equal(t, bps, sender.currentBps)
+	require.Len(t, events, 1)
+	require.Equal(t, qlog.CongestionStatePenalty, sender.phase(clock.Now()))
+
+	// the first ACK after the cooldown ends the penalty period, and the sender resumes probing
+	clock.Advance(10 * time.Millisecond)
+	sender.OnPacketAcked(pn, maxDatagramSize, sender.GetCongestionWindow(), clock.Now())
+	pn++
+	require.Len(t, events, 2)
+	require.Equal(t, 500*time.Millisecond, events[1].Duration)
+	for range 3 {
+		clock.Advance(10 * time.Millisecond)
+		sender.OnPacketAcked(pn, maxDatagramSize, sender.GetCongestionWindow(), clock.Now())
+		pn++
+	}
+	require.Greater(t, sender.currentBps, bps)
+
+	// the cooldown restarts with every rate reduction
+	sender.OnPacketSent(clock.Now(), 0, pn, maxDatagramSize, true)
+	sender.OnCongestionEvent(pn, 5*maxDatagramSize, 10*maxDatagramSize, TrafficClassDefault, false)
+	require.Equal(t, clock.Now().Add(500*time.Millisecond), sender.cooldownUntil)
+	sender.OnConnectionMigration()
+	require.Zero(t, sender.cooldownUntil)
+}
+
+func TestHysteriaSenderOneCutPerCongestionEpisode(t *testing.T) {
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(50*time.Millisecond, 0)
+	var events []HysteriaPenaltyEvent
+	sender := NewHysteriaSender(DefaultClock{}, rttStats, maxDatagramSize, BandwidthFromMbps(100), &Config{
+		OnHysteriaPenalty: func(e HysteriaPenaltyEvent) { events = append(events, e) },
+	}, nil).(*hysteriaSender)
+	initialBps := sender.currentBps
+	now := monotime.Now()
+	for pn := range protocol.PacketNumber(10) {
+		sender.OnPacketSent(now, 0, pn, maxDatagramSize, true)
+	}
+
+	// multiple losses within the same RTT only cause a single rate cut
+	for pn := range protocol.PacketNumber(5) {
+		sender.OnCongestionEvent(pn, 5*maxDatagramSize, 10*maxDatagramSize, TrafficClassDefault, false)
+	}
+	require.Len(t, events, 1)
+	// 50% loss is more than 3 times the threshold, resulting in the maximum cut
+	require.Equal(t, protocol.ByteCount(float64(initialBps)*(1-defaultMaxRateCut)), sender.currentBps)
+	require.Equal(t, -2, sender.rttCount)
+	// the penalty period isn't extended either
+	sender.OnPacketAcked(5, maxDatagramSize, sender.GetCongestionWindow(), now)
+	sender.OnCongestionEvent(6, 5*maxDatagramSize, 10*maxDatagramSize, TrafficClassDefault, false)
+	require.Len(t, events, 1)
+	require.Equal(t, -1, sender.rttCount)
+
+	// the loss of a packet sent after the cut starts a new congestion episode
+	sender.OnPacketSent(now, 0, 10, maxDatagramSize, true)
+	sender.OnCongestionEvent(10, 5*maxDatagramSize, 10*maxDatagramSize, TrafficClassDefault, false)
+	require.Len(t, events, 2)
+	require.Equal(t, -2, sender.rttCount)
+}
+
+func TestHysteriaSenderStableRate(t *testing.T) {
+	sender, _ := newTestHysteriaSender(100)
+	initialBps := sender.stableBps
+	now := monotime.Now()
+
+	// a rate that was only just reached doesn't become the stable rate, not even if a loss below the threshold occurs
+	for i := range 4 {
+		sender.OnPacketAcked(protocol.PacketNumber(i), maxDatagramSize, sender.GetCongestionWindow(), now)
+	}
+	require.Greater(t, sender.currentBps, initialBps)
+	sender.OnCongestionEvent(4, 1, sender.GetCongestionWindow(), TrafficClassDefault, false)
+	require.Equal(t, initialBps, sender.stableBps)
+	// the rate is reduced relative to the stable rate
+	sender.OnCongestionEvent(5, sender.GetCongestionWindow(), sender.GetCongestionWindow(), TrafficClassDefault, false)
+	cutBps := sender.currentBps
+	require.Equal(t, protocol.ByteCount(float64(initialBps)*(1-defaultMaxRateCut)), cutBps)
+
+	// once the rate was sustained for 3 RTTs, the lowest rate of this period becomes the stable rate
+	var pn protocol.PacketNumber = 10
+	start := now
+	for sender.stableBps == initialBps {
+		now = now.Add(10 * time.Millisecond)
+		sender.OnPacketAcked(pn, maxDatagramSize, sender.GetCongestionWindow(), now)
+		pn++
+		require.Less(t, now.Sub(start), time.Second)
+	}
+	require.GreaterOrEqual(t, now.Sub(start), 3*50*time.Millisecond)
+	require.Equal(t, cutBps, sender.stableBps)
+	require.Greater(t, sender.currentBps, cutBps)
+
+	t.Run("custom number of RTTs", func(t *testing.T) {
+		rttStats := utils.NewRTTStats()
+		rttStats.UpdateRTT(50*time.Millisecond, 0)
+		sender := NewHysteriaSender(DefaultClock{}, rttStats, maxDatagramSize, BandwidthFromMbps(100), &Config{HysteriaStableRTTs: 1}, nil).(*hysteriaSender)
+		now := monotime.Now()
+		for i := range 4 {
+			sender.OnPacketAcked(protocol.PacketNumber(i), maxDatagramSize, sender.GetCongestionWindow(), now)
+		}
+		grownBps := sender.currentBps
+		require.Greater(t, grownBps, sender.stableBps)
+		// the RTT during which the rate grew still counts with the previous rate
+		sender.OnPacketAcked(4, maxDatagramSize, sender.GetCongestionWindow(), now.Add(50*time.Millisecond))
+		require.Less(t, sender.stableBps, grownBps)
+		sender.OnPacketAcked(5, maxDatagramSize, sender.GetCongestionWindow(), now.Add(100*time.Millisecond))
+		require.Equal(t, grownBps, sender.stableBps)
+	})
+}
+
+// inflateRTT acknowledges packets every 5ms while the RTT grows by 1ms on every acknowledgement,
+// until the sender reduces its rate. It returns the time of the last acknowledgement.
+func inflateRTT(t *testing.T, sender *hysteriaSender, rttStats *utils.RTTStats, now monotime.Time) monotime.Time {
+	t.Helper()
+	bps := sender.currentBps
+	rtt := rttStats.LatestRTT()
+	for range 100 {
+		now = now.Add(5 * time.Millisecond)
+		rtt += time.Millisecond
+		rttStats.UpdateRTT(rtt, 0)
+		sender.updateRTTAndCheckJitter(now)
+		if sender.currentBps != bps {
+			return now
+		}
+	}
+	t.Fatal("rate was not reduced")
+	return now
+}
+
+func TestHysteriaSenderRTTInflation(t *testing.T) {
+	sender, rttStats := newTestHysteriaSender(100)
+	initialBps := sender.currentBps
+
+	// RTT samples fluctuating around a stable value don't reduce the rate
+	now := monotime.Now()
+	for i := range 200 {
+		now = now.Add(5 * time.Millisecond)
+		rttStats.UpdateRTT(50*time.Millisecond+time.Duration(i%5)*time.Millisecond, 0)
+		sender.updateRTTAndCheckJitter(now)
+	}
+	require.Equal(t, initialBps, sender.currentBps)
+
+	// neither does a single RTT spike
+	now = now.Add(5 * time.Millisecond)
+	rttStats.UpdateRTT(500*time.Millisecond, 0)
+	sender.updateRTTAndCheckJitter(now)
+	for range 50 {
+		now = now.Add(5 * time.Millisecond)
+		rttStats.UpdateRTT(50*time.Millisecond, 0)
+		sender.updateRTTAndCheckJitter(now)
+	}
+	require.Equal(t, initialBps, sender.currentBps)
+
+	// a sustained increase of the RTT reduces the rate
+	inflateRTT(t, sender, rttStats, now)
+	require.Equal(t, protocol.ByteCount(float64(initialBps)*0.85), sender.currentBps)
+}
+
+func TestHysteriaSenderDrain(t *testing.T) {
+	t.Run("drain until the queue is empty", func(t *testing.T) {
+		clock := new(mockClock)
+		clock.Advance(time.Second)
+		rttStats := utils.NewRTTStats()
+		rttStats.UpdateRTT(50*time.Millisecond, 0)
+		sender := NewHysteriaSender(clock, rttStats, maxDatagramSize, BandwidthFromMbps(100), nil, nil).(*hysteriaSender)
+		sender.pacingAlpha = 1 // don't smooth the pacing rate
+		*clock = mockClock(inflateRTT(t, sender, rttStats, clock.Now()))
+		sender.updatePacedBps(clock.Now())
+		reducedBps := sender.currentBps
+		// without a delivery rate sample, the reduced rate is used as the delivery rate
+		require.True(t, sender.isDraining(clock.Now()))
+		require.Equal(t, protocol.ByteCount(float64(reducedBps)*defaultDrainGain), sender.pacingBps())
+
+		// the rate isn't increased while draining
+		for i := range 8 {
+			sender.OnPacketAcked(protocol.PacketNumber(i), maxDatagramSize, sender.GetCongestionWindow(), clock.Now())
+		}
+		require.Equal(t, reducedBps, sender.currentBps)
+
+		// the RTT drops back to the minimum RTT: the queue was drained
+		clock.Advance(5 * time.Millisecond)
+		rttStats.UpdateRTT(50*time.Millisecond, 0)
+		sender.updateRTTAndCheckJitter(clock.Now())
+		require.False(t, sender.isDraining(clock.Now()))
+		require.Equal(t, reducedBps, sender.pacingBps())
+	})
+
+	t.Run("drain for one RTT", func(t *testing.T) {
+		clock := new(mockClock)
+		clock.Advance(time.Second)
+		rttStats := utils.NewRTTStats()
+		rttStats.UpdateRTT(50*time.Millisecond, 0)
+		sender := NewHysteriaSender(clock, rttStats, maxDatagramSize, BandwidthFromMbps(100), &Config{HysteriaDrainGain: 0.5}, nil).(*hysteriaSender)
+		sender.pacingAlpha = 1
+		*clock = mockClock(inflateRTT(t, sender, rttStats, clock.Now()))
+		sender.updatePacedBps(clock.Now())
+		require.Equal(t, sender.currentBps/2, sender.pacingBps())
+		clock.Advance(rttStats.SmoothedRTT())
+		require.Equal(t, sender.currentBps, sender.pacingBps())
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		rttStats := utils.NewRTTStats()
+		rttStats.UpdateRTT(50*time.Millisecond, 0)
+		sender := NewHysteriaSender(DefaultClock{}, rttStats, maxDatagramSize, BandwidthFromMbps(100), &Config{HysteriaDrainGain: 1}, nil).(*hysteriaSender)
+		now := inflateRTT(t, sender, rttStats, monotime.Now())
+		require.False(t, sender.isDraining(now))
+	})
+}
+
+func TestHysteriaSenderQlogPhases(t *testing.T) {
+	t.Run("brutal", func(t *testing.T) {
+		var eventRecorder events.Recorder
+		NewHysteriaSender(DefaultClock{}, utils.NewRTTStats(), maxDatagramSize, BandwidthFromMbps(100), &Config{HysteriaBrutal: true}, &eventRecorder)
+		require.Equal(t,
+			[]qlogwriter.Event{qlog.CongestionStateUpdated{State: qlog.CongestionStateSteady}},
+			eventRecorder.Events(),
+		)
+	})
+
+	t.Run("probing, penalty and steady", func(t *testing.T) {
+		var clock mockClock
+		clock.Advance(time.Second)
+		rttStats := utils.NewRTTStats()
+		rttStats.UpdateRTT(50*time.Millisecond, 0)
+		var eventRecorder events.Recorder
+		sender := NewHysteriaSender(&clock, rttStats, maxDatagramSize, BandwidthFromMbps(100), nil, &eventRecorder).(*hysteriaSender)
+		require.Equal(t,
+			[]qlogwriter.Event{qlog.CongestionStateUpdated{State: qlog.CongestionStateProbing}},
+			eventRecorder.Events(),
+		)
+		eventRecorder.Clear()
+
+		sender.OnPacketSent(clock.Now(), 0, 1, maxDatagramSize, true)
+		sender.OnCongestionEvent(1, 5*maxDatagramSize, 10*maxDatagramSize, TrafficClassDefault, false)
+		require.Equal(t,
+			[]qlogwriter.Event{qlog.CongestionStateUpdated{State: qlog.CongestionStatePenalty}},
+			eventRecorder.Events(),
+		)
+		eventRecorder.Clear()
+
+		// the penalty period ends after 2 ACKs, and the sender then ramps up to the target rate
+		var pn protocol.PacketNumber = 2
+		for sender.currentBps < sender.targetBps {
+			clock.Advance(time.Millisecond)
+			sender.OnPacketAcked(pn, maxDatagramSize, sender.GetCongestionWindow(), clock.Now())
+			pn++
+		}
+		require.Equal(t,
+			[]qlogwriter.Event{
+				qlog.CongestionStateUpdated{State: qlog.CongestionStateProbing},
+				qlog.CongestionStateUpdated{State: qlog.CongestionStateSteady},
+			},
+			eventRecorder.Events(),
+		)
+	})
+
+	t.Run("drain", func(t *testing.T) {
+		clock := new(mockClock)
+		clock.Advance(time.Second)
+		rttStats := utils.NewRTTStats()
+		rttStats.UpdateRTT(50*time.Millisecond, 0)
+		var eventRecorder events.Recorder
+		sender := NewHysteriaSender(clock, rttStats, maxDatagramSize, BandwidthFromMbps(100), nil, &eventRecorder).(*hysteriaSender)
+		eventRecorder.Clear()
+
+		*clock = mockClock(inflateRTT(t, sender, rttStats, clock.Now()))
+		sender.OnPacketSent(clock.Now(), 0, 1, maxDatagramSize, true)
+		require.Equal(t,
+			[]qlogwriter.Event{qlog.CongestionStateUpdated{State: qlog.CongestionStateDrain}},
+			eventRecorder.Events(),
+		)
+		eventRecorder.Clear()
+
+		// the drain phase ends after one RTT
+		clock.Advance(rttStats.SmoothedRTT())
+		sender.OnPacketSent(clock.Now(), 0, 2, maxDatagramSize, true)
+		require.Equal(t,
+			[]qlogwriter.Event{qlog.CongestionStateUpdated{State: qlog.CongestionStateProbing}},
+			eventRecorder.Events(),
+		)
+	})
+}
+
+func TestHysteriaSenderJitterFilter(t *testing.T) {
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(50*time.Millisecond, 0)
+	sender := NewHysteriaSender(DefaultClock{}, rttStats, maxDatagramSize, BandwidthFromMbps(100), &Config{HysteriaJitterFilterWindow: 3}, nil).(*hysteriaSender)
+
+	require.Equal(t, 50*time.Millisecond, sender.filterJitterRTT(50*time.Millisecond))
+	require.Equal(t, 50*time.Millisecond, sender.filterJitterRTT(500*time.Millisecond))
+	require.Equal(t, 50*time.Millisecond, sender.filterJitterRTT(60*time.Millisecond))
+	// the 50ms sample dropped out of the window
+	require.Equal(t, 60*time.Millisecond, sender.filterJitterRTT(70*time.Millisecond))
+	require.Equal(t, 60*time.Millisecond, sender.filterJitterRTT(80*time.Millisecond))
+	require.Equal(t, 70*time.Millisecond, sender.filterJitterRTT(90*time.Millisecond))
+
+	// without a filter, every sample is used
+	sender, _ = newTestHysteriaSender(100)
+	require.Equal(t, 500*time.Millisecond, sender.filterJitterRTT(500*time.Millisecond))
+}
+
+func TestHysteriaSenderAutoBandwidth(t *testing.T) {
+	for _, auto := range []bool{false, true} {
+		rttStats := utils.NewRTTStats()
+		rttStats.UpdateRTT(50*time.Millisecond, 0)
+		sender := NewHysteriaSender(DefaultClock{}, rttStats, maxDatagramSize, BandwidthFromMbps(10), &Config{HysteriaAutoBandwidth: auto}, nil).(*hysteriaSender)
+		initialTarget := sender.targetBps
+
+		// acknowledge one packet every 200µs for one second
+		const deliveryRate = maxDatagramSize * 5000 // bytes per second
+		now := monotime.Now()
+		for i := range 5000 {
+			now = now.Add(200 * time.Microsecond)
+			sender.OnPacketAcked(protocol.PacketNumber(i), maxDatagramSize, sender.GetCongestionWindow(), now)
+		}
+		if auto {
+			require.InEpsilon(t, float64(deliveryRate)*autoBandwidthProbeGain, float64(sender.targetBps), 0.05)
+		} else {
+			require.Equal(t, initialTarget, sender.targetBps)
+		}
+	}
+}
+
+func TestHysteriaSenderMaxQueuingDelay(t *testing.T) {
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(50*time.Millisecond, 0)
+	rttStats.UpdateRTT(80*time.Millisecond, 0)
+	sender := NewHysteriaSender(DefaultClock{}, rttStats, maxDatagramSize, BandwidthFromMbps(1000), &Config{
+		HysteriaMaxQueuingDelay: 20 * time.Millisecond,
+	}, nil).(*hysteriaSender)
+	// the window is based on the min RTT, not on the smoothed RTT
+	require.Equal(t, protocol.ByteCount(float64(sender.currentBps)*0.07), sender.GetCongestionWindow())
+
+	// without RTT samples, the initial RTT is used
+	rttStats = utils.NewRTTStats()
+	sender = NewHysteriaSender(DefaultClock{}, rttStats, maxDatagramSize, BandwidthFromMbps(1000), &Config{
+		HysteriaMaxQueuingDelay: 20 * time.Millisecond,
+	}, nil).(*hysteriaSender)
+	require.Equal(t,
+		protocol.ByteCount(float64(sender.currentBps)*(rttStats.InitialRTT()+20*time.Millisecond).Seconds()),
+		sender.GetCongestionWindow(),
+	)
+}
+
+func TestHysteriaSenderCongestionWindowWithoutRTT(t *testing.T) {
+	for _, mbps := range []int{10, 100} {
+		sender := NewHysteriaSender(DefaultClock{}, &utils.RTTStats{}, maxDatagramSize, BandwidthFromMbps(mbps), nil, nil).(*hysteriaSender)
+		expected := protocol.ByteCount(float64(sender.currentBps) * utils.DefaultInitialRTT.Seconds() * maxCwndMultiplier)
+		require.Equal(t, max(expected, 32*maxDatagramSize), sender.GetCongestionWindow())
+	}
+}
+
+func TestHysteriaSenderConnectionMigration(t *testing.T) {
+	sender, rttStats := newTestHysteriaSender(100)
+	sender.SetMaxDatagramSize(1500)
+	// sustaining the rate for a few RTTs marks it as stable
+	now := monotime.Now()
+	for i := range 20 {
+		now = now.Add(20 * time.Millisecond)
+		sender.OnPacketAcked(protocol.PacketNumber(i), maxDatagramSize, sender.GetCongestionWindow(), now)
+	}
+	stableBps := sender.stableBps
+	require.Less(t, stableBps, sender.currentBps)
+	// RTT inflation reduces the rate
+	bps := sender.currentBps
+	now = inflateRTT(t, sender, rttStats, now)
+	require.Less(t, sender.currentBps, bps)
+	// start collecting RTT samples for the next gradient
+	sender.updateRTTAndCheckJitter(now.Add(50 * time.Millisecond))
+	// use up the pacing budget
+	sender.OnPacketSent(now, 0, 20, sender.PacingBudget(now), true)
+	require.Zero(t, sender.PacingBudget(now))
+
+	sender.OnConnectionMigration()
+	require.Equal(t, stableBps, sender.currentBps)
+	require.Equal(t, sender.pacer.maxBurstSize(), sender.PacingBudget(now))
+	require.Zero(t, sender.rttGradient.count)
+	require.Zero(t, sender.rttGradient.intervalStart)
+	require.Zero(t, sender.rttCount)
+	require.Zero(t, sender.sustainRTTs)
+	require.Zero(t, sender.sustainStart)
+	require.Equal(t, maxDatagramSize, sender.maxDatagram)
+}
+
+func TestHysteriaSenderPacing(t *testing.T) {
+	var clock mockClock
+	clock.Advance(time.Second)
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(50*time.Millisecond, 0)
+	sender := NewHysteriaSender(&clock, rttStats, maxDatagramSize, BandwidthFromMbps(100), &Config{HysteriaBrutal: true}, nil).(*hysteriaSender)
+
+	// sending a packet of this size takes 1ms at the configured rate
+	const size = 100 * 1024 * 1024 / 8 / 1000
+	now := clock.Now()
+	require.Zero(t, sender.TimeUntilSend(0))
+	require.True(t, sender.HasPacingBudget(now))
+	// the default burst size is the amount of data sent within 2ms
+	require.Equal(t, protocol.ByteCount(2*size), sender.PacingBudget(now))
+
+	sender.OnPacketSent(now, 0, 1, size, true)
+	require.Zero(t, sender.TimeUntilSend(size))
+	sender.OnPacketSent(now, size, 2, size, true)
+	require.Equal(t, now.Add(time.Millisecond), sender.TimeUntilSend(2*size))
+	require.False(t, sender.HasPacingBudget(now))
+	require.Zero(t, sender.PacingBudget(now))
+	require.True(t, sender.HasPacingBudget(now.Add(time.Millisecond)))
+
+	// a bytes-in-flight-limited sender doesn't send at all
+	require.Equal(t, now.Add(time.Hour), sender.TimeUntilSend(sender.GetCongestionWindow()))
+
+	// packets sent without a budget don't delay the following packets any further
+	for i := range 100 {
+		sender.OnPacketSent(now, 0, protocol.PacketNumber(3+i), size, true)
+	}
+	require.Equal(t, now.Add(time.Millisecond), sender.TimeUntilSend(0))
+}
+
+func TestHysteriaSenderPacingExtremeRates(t *testing.T) {
+	const size = 1500
+
+	t.Run("1 Gbps", func(t *testing.T) {
+		var clock mockClock
+		clock.Advance(time.Second)
+		rttStats := utils.NewRTTStats()
+		rttStats.UpdateRTT(50*time.Millisecond, 0)
+		sender := NewHysteriaSender(&clock, rttStats, size, 1_000_000_000*BitsPerSecond, &Config{HysteriaBrutal: true}, nil).(*hysteriaSender)
+
+		// the burst size is the amount of data sent within 2ms
+		now := clock.Now()
+		burst := sender.PacingBudget(now)
+		require.Equal(t, protocol.ByteCount(250_000), burst)
+		var pn protocol.PacketNumber
+		for sent := protocol.ByteCount(0); sent < burst; sent += size {
+			pn++
+			sender.OnPacketSent(now, 0, pn, size, true)
+		}
+		// sending a single packet takes 12µs, which is below the timer granularity
+		require.Equal(t, now.Add(protocol.MinPacingDelay), sender.TimeUntilSend(0))
+		require.Equal(t, protocol.ByteCount(125_000), sender.PacingBudget(now.Add(time.Millisecond)))
+
+		// the budget doesn't overflow when the sender is idle for a long time
+		require.Equal(t, burst, sender.PacingBudget(now.Add(200*time.Second)))
+		require.Equal(t, burst, sender.PacingBudget(now.Add(100*24*time.Hour)))
+	})
+
+	t.Run("64 kbps", func(t *testing.T) {
+		var clock mockClock
+		clock.Advance(time.Second)
+		rttStats := utils.NewRTTStats()
+		// with a shorter RTT, the minimum pacing rate of one packet per RTT would exceed 64 kbps
+		rttStats.UpdateRTT(time.Second, 0)
+		sender := NewHysteriaSender(&clock, rttStats, size, 64_000*BitsPerSecond, &Config{HysteriaBrutal: true}, nil).(*hysteriaSender)
+
+		// the burst size is 10 packets
+		now := clock.Now()
+		require.Equal(t, protocol.ByteCount(10*size), sender.PacingBudget(now))
+		for pn := range protocol.PacketNumber(10) {
+			sender.OnPacketSent(now, 0, pn, size, true)
+		}
+		// sending a single packet takes 187.5ms
+		require.Equal(t, now.Add(187500*time.Microsecond), sender.TimeUntilSend(0))
+		require.Equal(t, protocol.ByteCount(size-1), sender.PacingBudget(now.Add(187499*time.Microsecond)))
+		require.Equal(t, protocol.ByteCount(size), sender.PacingBudget(now.Add(187500*time.Microsecond)))
+		require.Equal(t, protocol.ByteCount(10*size), sender.PacingBudget(now.Add(100*24*time.Hour)))
+	})
+}
+
+func TestHysteriaSenderMinPacingRate(t *testing.T) {
+	var clock mockClock
+	clock.Advance(time.Second)
+	const rtt = 10 * time.Second
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(rtt, 0)
+	// the target rate is below one packet per RTT: sending a single packet would take more than 20 minutes
+	sender := NewHysteriaSender(&clock, rttStats, maxDatagramSize, BytesPerSecond, &Config{
+		HysteriaBrutal: true,
+		MaxPacingRate:  BytesPerSecond,
+	}, nil).(*hysteriaSender)
+	// the minimum pacing rate takes precedence over both the target rate and the configured maximum pacing rate
+	require.Equal(t, maxDatagramSize/10, sender.pacingBps())
+
+	// use up the burst
+	var pn protocol.PacketNumber
+	for sender.HasPacingBudget(clock.Now()) {
+		pn++
+		sender.OnPacketSent(clock.Now(), 0, pn, maxDatagramSize, true)
+	}
+	// at least one full-size packet can be sent per RTT
+	start := clock.Now()
+	for range 6 {
+		next := sender.TimeUntilSend(0)
+		require.LessOrEqual(t, next.Sub(clock.Now()), rtt)
+		clock.Advance(next.Sub(clock.Now()))
+		require.True(t, sender.HasPacingBudget(clock.Now()))
+		pn++
+		sender.OnPacketSent(clock.Now(), 0, pn, maxDatagramSize, true)
+	}
+	require.LessOrEqual(t, clock.Now().Sub(start), 6*rtt)
+}
+
+func TestHysteriaSenderMaxPacingRate(t *testing.T) {
+	var clock mockClock
+	clock.Advance(time.Second)
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(50*time.Millisecond, 0)
+	// sending a full-size packet takes 1ms at the capped rate
+	const size = maxDatagramSize
+	const rate = Bandwidth(size) * 1000 * BytesPerSecond
+	sender := NewHysteriaSender(&clock, rttStats, maxDatagramSize, BandwidthFromMbps(100), &Config{
+		HysteriaBrutal: true,
+		MaxPacingRate:  rate,
+		MaxPacingBurst: 2 * size,
+	}, nil).(*hysteriaSender)
+	// the congestion window is still derived from the target rate
+	uncapped := NewHysteriaSender(&clock, rttStats, maxDatagramSize, BandwidthFromMbps(100), &Config{HysteriaBrutal: true}, nil)
+	require.Equal(t, uncapped.GetCongestionWindow(), sender.GetCongestionWindow())
+	require.Equal(t, rate, sender.BandwidthEstimate())
+
+	now := clock.Now()
+	sender.OnPacketSent(now, 0, 1, size, true)
+	sender.OnPacketSent(now, size, 2, size, true)
+	require.Equal(t, now.Add(time.Millisecond), sender.TimeUntilSend(2*size))
+	sender.OnPacketSent(now.Add(time.Millisecond), size, 3, size, true)
+	require.Equal(t, now.Add(2*time.Millisecond), sender.TimeUntilSend(3*size))
+}
+
+func TestHysteriaSenderPacingSmoothing(t *testing.T) {
+	const rtt = 100 * time.Millisecond
+
+	newSender := func(conf *Config) (*hysteriaSender, *mockClock) {
+		clock := new(mockClock)
+		clock.Advance(time.Second)
+		rttStats := utils.NewRTTStats()
+		rttStats.UpdateRTT(rtt, 0)
+		sender := NewHysteriaSender(clock, rttStats, maxDatagramSize, BandwidthFromMbps(100), conf, nil).(*hysteriaSender)
+		sender.OnPacketSent(clock.Now(), 0, 1, maxDatagramSize, true)
+		return sender, clock
+	}
+
+	t.Run("rate decrease", func(t *testing.T) {
+		sender, clock := newSender(&Config{HysteriaPacingAlpha: 0.5})
+		start := sender.currentBps
+		sender.OnCongestionEvent(1, maxDatagramSize*10, maxDatagramSize*20, TrafficClassDefault, false)
+		target := sender.currentBps
+		require.Less(t, target, start)
+		// the pacing rate only changes once the next packet is sent
+		require.Equal(t, Bandwidth(start)*BytesPerSecond, sender.BandwidthEstimate())
+
+		// after one RTT, the gap is halved
+		clock.Advance(rtt)
+		sender.OnPacketSent(clock.Now(), 0, 2, maxDatagramSize, true)
+		require.InEpsilon(t, float64(start+target)/2, float64(sender.pacedBps), 0.01)
+		// the smoothing doesn't depend on how many packets are sent
+		for i := range 10 {
+			clock.Advance(rtt / 10)
+			sender.OnPacketSent(clock.Now(), 0, protocol.PacketNumber(3+i), maxDatagramSize, true)
+		}
+		require.InEpsilon(t, float64(target)+float64(start-target)/4, float64(sender.pacedBps), 0.01)
+		// eventually, the pacing rate reaches the current rate
+		clock.Advance(100 * rtt)
+		sender.OnPacketSent(clock.Now(), 0, 20, maxDatagramSize, true)
+		require.Equal(t, target, sender.pacedBps)
+		require.Equal(t, Bandwidth(target)*BytesPerSecond, sender.BandwidthEstimate())
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		sender, clock := newSender(&Config{HysteriaPacingAlpha: 1})
+		sender.OnCongestionEvent(1, maxDatagramSize*10, maxDatagramSize*20, TrafficClassDefault, false)
+		clock.Advance(time.Millisecond)
+		sender.OnPacketSent(clock.Now(), 0, 2, maxDatagramSize, true)
+		require.Equal(t, sender.currentBps, sender.pacedBps)
+	})
+
+	t.Run("connection migration", func(t *testing.T) {
+		sender, _ := newSender(nil)
+		sender.OnCongestionEvent(1, maxDatagramSize*10, maxDatagramSize*20, TrafficClassDefault, false)
+		require.NotEqual(t, sender.currentBps, sender.pacedBps)
+		sender.OnConnectionMigration()
+		require.Equal(t, sender.currentBps, sender.pacedBps)
+	})
+}
+
+func TestHysteriaSenderProbeBandwidth(t *testing.T) {
+	var clock mockClock
+	clock.Advance(time.Second)
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(50*time.Millisecond, 0)
+	sender := NewHysteriaSender(&clock, rttStats, maxDatagramSize, BandwidthFromMbps(100), nil, nil).(*hysteriaSender)
+	cwnd := sender.GetCongestionWindow()
+	bandwidth := sender.BandwidthEstimate()
+
+	sender.ProbeBandwidth(clock.Now().Add(100 * time.Millisecond))
+	require.Greater(t, sender.GetCongestionWindow(), cwnd)
+	require.InEpsilon(t, float64(bandwidth)*autoBandwidthProbeGain, float64(sender.BandwidthEstimate()), 0.01)
+
+	// the probe ends after the deadline
+	clock.Advance(100 * time.Millisecond)
+	require.Equal(t, cwnd, sender.GetCongestionWindow())
+	require.Equal(t, bandwidth, sender.BandwidthEstimate())
+
+	// brutal mode always sends at the configured rate
+	brutal := NewHysteriaSender(&clock, rttStats, maxDatagramSize, BandwidthFromMbps(100), &Config{HysteriaBrutal: true}, nil)
+	cwnd = brutal.GetCongestionWindow()
+	brutal.ProbeBandwidth(clock.Now().Add(100 * time.Millisecond))
+	require.Equal(t, cwnd, brutal.GetCongestionWindow())
+}
+
+func TestHysteriaSenderMaxPacingBurst(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		conf     *Config
+		expected protocol.ByteCount
+	}{
+		{name: "default", conf: &Config{}, expected: maxBurstSizePackets * maxDatagramSize},
+		{name: "custom", conf: &Config{MaxPacingBurst: 3 * maxDatagramSize}, expected: 3 * maxDatagramSize},
+		{name: "smaller than a packet", conf: &Config{MaxPacingBurst: 100}, expected: maxDatagramSize},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var clock mockClock
+			clock.Advance(time.Second)
+			rttStats := utils.NewRTTStats()
+			rttStats.UpdateRTT(200*time.Millisecond, 0)
+			tc.conf.HysteriaBrutal = true
+			sender := NewHysteriaSender(&clock, rttStats, maxDatagramSize, BandwidthFromMbps(10), tc.conf, nil).(*hysteriaSender)
+			now := clock.Now()
+			sender.OnPacketSent(now, 0, 0, maxDatagramSize, true)
+
+			// credit accumulated while idle is capped
+			clock.Advance(time.Minute)
+			now = clock.Now()
+			require.Equal(t, tc.expected, sender.PacingBudget(now))
+			for i := range tc.expected / maxDatagramSize {
+				require.True(t, sender.HasPacingBudget(now))
+				require.False(t, sender.TimeUntilSend(0).After(now))
+				sender.OnPacketSent(now, 0, protocol.PacketNumber(i+1), maxDatagramSize, true)
+			}
+			require.False(t, sender.HasPacingBudget(now))
+			require.True(t, sender.TimeUntilSend(0).After(now))
+		})
+	}
+}
+
+func TestHysteriaSenderEstimatedSendTime(t *testing.T) {
+	var clock mockClock
+	clock.Advance(time.Second)
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(50*time.Millisecond, 0)
+	sender := NewHysteriaSender(&clock, rttStats, maxDatagramSize, BandwidthFromMbps(10), &Config{HysteriaBrutal: true}, nil).(*hysteriaSender)
+
+	require.Equal(t, time.Second, sender.EstimatedSendTime(sender.currentBps))
+	require.Equal(t, 2*time.Second, sender.EstimatedSendTime(2*sender.currentBps))
+	require.Zero(t, sender.EstimatedSendTime(0))
+}
+
+func TestHysteriaSenderDisablePacing(t *testing.T) {
+	var clock mockClock
+	clock.Advance(time.Second)
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(200*time.Millisecond, 0)
+	sender := NewHysteriaSender(&clock, rttStats, maxDatagramSize, BandwidthFromMbps(10), &Config{
+		HysteriaBrutal: true,
+		DisablePacing:  true,
+		MaxPacingBurst: maxDatagramSize,
+	}, nil).(*hysteriaSender)
+	now := clock.Now()
+
+	// only the congestion window limits sending
+	var bytesInFlight protocol.ByteCount
+	for i := 0; sender.CanSend(bytesInFlight); i++ {
+		require.True(t, sender.HasPacingBudget(now))
+		require.Zero(t, sender.TimeUntilSend(0))
+		sender.OnPacketSent(now, bytesInFlight, protocol.PacketNumber(i+1), maxDatagramSize, true)
+		bytesInFlight += maxDatagramSize
+	}
+	require.Greater(t, bytesInFlight, maxBurstSizePackets*maxDatagramSize)
+	require.True(t, sender.HasPacingBudget(now))
+	require.Zero(t, sender.TimeUntilSend(0))
+}
@@ -1,6 +1,8 @@
 package congestion
 
 import (
+	"time"
+
 	"github.com/quic-go/quic-go/internal/monotime"
 	"github.com/quic-go/quic-go/internal/protocol"
 )
@@ -13,7 +15,13 @@ type SendAlgorithm interface {
 	CanSend(bytesInFlight protocol.ByteCount) bool
 	MaybeExitSlowStart()
 	OnPacketAcked(number protocol.PacketNumber, ackedBytes protocol.ByteCount, priorInFlight protocol.ByteCount, eventTime monotime.Time)
-	OnCongestionEvent(number protocol.PacketNumber, lostBytes protocol.ByteCount, priorInFlight protocol.ByteCount)
+	// OnCongestionEvent is called when a packet is declared lost, or when an ECN congestion mark is received.
+	// The class is the traffic class of the data carried in the lost packet. It can be used to react differently
+	// depending on the kind of data that was affected. None of the senders differentiate yet.
+	// reorderingLikely is set if the packet was declared lost by the packet reordering threshold, but the distance
+	// to the largest acknowledged packet is within the reordering that was observed on the path before.
+	// Such a packet is likely to still arrive, and window-based senders don't reduce their window for it.
+	OnCongestionEvent(number protocol.PacketNumber, lostBytes protocol.ByteCount, priorInFlight protocol.ByteCount, class TrafficClass, reorderingLikely bool)
 	OnRetransmissionTimeout(packetsRetransmitted bool)
 	SetMaxDatagramSize(protocol.ByteCount)
 }
@@ -24,4 +32,47 @@ type SendAlgorithmWithDebugInfos interface {
 	InSlowStart() bool
 	InRecovery() bool
 	GetCongestionWindow() protocol.ByteCount
+	// SlowStartThreshold returns the slow start threshold.
+	// Senders that don't use a slow start threshold return protocol.MaxByteCount.
+	SlowStartThreshold() protocol.ByteCount
+	// BandwidthEstimate returns the rate that the sender is currently pacing at.
+	BandwidthEstimate() Bandwidth
+	// EstimatedSendTime returns how long it takes to send the given number of bytes at the current sending rate.
+	// It doesn't account for bytes in flight, nor for changes of the sending rate while the data is sent.
+	EstimatedSendTime(bytes protocol.ByteCount) time.Duration
+	// PacingBudget returns the number of bytes that the pacer allows to be sent at the given time without delay.
+	// Senders that don't pace packets return protocol.MaxByteCount.
+	PacingBudget(now monotime.Time) protocol.ByteCount
+	// State returns the phase the sender is currently in.
+	State(bytesInFlight protocol.ByteCount) State
+	// OnConnectionMigration resets the sender, such that it can be reused on a different path,
+	// since the state it gathered on the old path doesn't apply to the new path.
+	// This includes the max datagram size, which is reset to the initial value.
+	OnConnectionMigration()
+	// ProbeBandwidth makes the sender probe for additional bandwidth until the given time.
+	// Rate-based senders temporarily increase their sending rate.
+	// Window-based senders grow their congestion window even if the application doesn't fully utilize it.
+	ProbeBandwidth(until monotime.Time)
+	// OnSpuriousLoss is called when a packet that was declared lost is acknowledged.
+	// Senders that reduced their congestion window due to the loss can undo the reduction.
+	OnSpuriousLoss(number protocol.PacketNumber)
+	// OnPersistentCongestion is called when persistent congestion is detected (RFC 9002, section 7.6).
+	// It is called after OnCongestionEvent was called for the lost packets.
+	// Window-based senders collapse their congestion window to the minimum congestion window.
+	OnPersistentCongestion()
+	// OnFlowControlLimited is called when sending becomes blocked by the peer's connection-level flow control window,
+	// and when it is unblocked again. While blocked, the sending rate is determined by the receiver, not by the network.
+	// Window-based senders don't need to act on it, since they only grow their window when it is fully utilized.
+	OnFlowControlLimited(limited bool)
+}
+
+// A ScalableSender is a congestion controller that reacts to ECN-CE marks in proportion to their extent,
+// as required for L4S (RFC 9330), instead of treating every CE mark like a packet loss.
+// Its packets are sent with the ECT(1) codepoint, and OnCongestionEvent is only called for lost packets.
+type ScalableSender interface {
+	SendAlgorithmWithDebugInfos
+	// OnECNFeedback is called for every ACK frame that increases the ECN counts, once the path was validated
+	// to be ECN-capable. ecnMarked is the number of ECN-marked packets newly reported as received by the peer,
+	// and ceMarked is how many of them were CE-marked.
+	OnECNFeedback(ecnMarked, ceMarked int64)
 }
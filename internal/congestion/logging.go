@@ -0,0 +1,56 @@
+package congestion
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/quic-go/quic-go/internal/protocol"
+	"github.com/quic-go/quic-go/qlog"
+)
+
+// The senders emit congestion events as structured log records at debug level, see Config.Logger.
+// All functions are no-ops if the logger is nil.
+
+func logStateChange(logger *slog.Logger, state qlog.CongestionState) {
+	if logger == nil {
+		return
+	}
+	logger.LogAttrs(context.Background(), slog.LevelDebug, "congestion state updated",
+		slog.String("state", string(state)),
+	)
+}
+
+// logWindowReduction logs a reduction of the congestion window in response to the loss (or CE mark) of a packet.
+func logWindowReduction(logger *slog.Logger, pn protocol.PacketNumber, old, new protocol.ByteCount) {
+	if logger == nil {
+		return
+	}
+	logger.LogAttrs(context.Background(), slog.LevelDebug, "congestion window reduced",
+		slog.Int64("packet_number", int64(pn)),
+		slog.Int64("old_window", int64(old)),
+		slog.Int64("new_window", int64(new)),
+	)
+}
+
+// logRateReduction logs a reduction of the sending rate of a rate-based sender, in bytes per second.
+func logRateReduction(logger *slog.Logger, pn protocol.PacketNumber, old, new protocol.ByteCount) {
+	if logger == nil {
+		return
+	}
+	logger.LogAttrs(context.Background(), slog.LevelDebug, "sending rate reduced",
+		slog.Int64("packet_number", int64(pn)),
+		slog.Uint64("old_rate_bps", uint64(old)*8),
+		slog.Uint64("new_rate_bps", uint64(new)*8),
+	)
+}
+
+// logToleratedLoss logs a loss that didn't cause a reduction, because it was within the loss tolerance.
+func logToleratedLoss(logger *slog.Logger, pn protocol.PacketNumber, lostBytes protocol.ByteCount) {
+	if logger == nil {
+		return
+	}
+	logger.LogAttrs(context.Background(), slog.LevelDebug, "loss tolerated",
+		slog.Int64("packet_number", int64(pn)),
+		slog.Int64("lost_bytes", int64(lostBytes)),
+	)
+}
@@ -0,0 +1,118 @@
+package congestion
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go/internal/monotime"
+	"github.com/quic-go/quic-go/internal/protocol"
+	"github.com/quic-go/quic-go/internal/utils"
+
+	"github.com/stretchr/testify/require"
+)
+
+type recordingHandler struct {
+	records []slog.Record
+}
+
+var _ slog.Handler = &recordingHandler{}
+
+func (h *recordingHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *recordingHandler) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h *recordingHandler) WithGroup(string) slog.Handler            { return h }
+
+func (h *recordingHandler) Handle(_ context.Context, r slog.Record) error {
+	h.records = append(h.records, r)
+	return nil
+}
+
+func (h *recordingHandler) Messages() []string {
+	msgs := make([]string, 0, len(h.records))
+	for _, r := range h.records {
+		msgs = append(msgs, r.Message)
+	}
+	return msgs
+}
+
+func (h *recordingHandler) Attrs(i int) map[string]slog.Value {
+	attrs := make(map[string]slog.Value)
+	h.records[i].Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value
+		return true
+	})
+	return attrs
+}
+
+func TestCubicSenderLogger(t *testing.T) {
+	var h recordingHandler
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(100*time.Millisecond, 0)
+	sender := NewCubicSender(DefaultClock{}, rttStats, &utils.ConnectionStats{}, maxDatagramSize, true, &Config{
+		Logger:         slog.New(&h),
+		MinRatePolicy:  MinRatePolicyPackets,
+		MinRatePackets: minCongestionWindowPackets,
+	}, nil)
+	// the initial state is not logged
+	require.Empty(t, h.records)
+
+	cwnd := sender.GetCongestionWindow()
+	sender.OnPacketSent(monotime.Now(), 0, 1, maxDatagramSize, true)
+	sender.OnCongestionEvent(1, maxDatagramSize, cwnd, TrafficClassDefault, false)
+	require.Equal(t, []string{"congestion state updated", "congestion window reduced"}, h.Messages())
+	require.Equal(t, slog.LevelDebug, h.records[0].Level)
+	require.Equal(t, "recovery", h.Attrs(0)["state"].String())
+	attrs := h.Attrs(1)
+	require.Equal(t, int64(1), attrs["packet_number"].Int64())
+	require.Equal(t, int64(cwnd), attrs["old_window"].Int64())
+	require.Equal(t, int64(sender.GetCongestionWindow()), attrs["new_window"].Int64())
+}
+
+func TestCubicSenderLoggerToleratedLoss(t *testing.T) {
+	var h recordingHandler
+	sender := NewCubicSender(DefaultClock{}, utils.NewRTTStats(), &utils.ConnectionStats{}, maxDatagramSize, true, &Config{
+		Logger:              slog.New(&h),
+		LossTolerancePolicy: LossTolerancePolicyEpisode,
+	}, nil)
+	cwnd := sender.GetCongestionWindow()
+	sender.OnPacketSent(monotime.Now(), 0, 1, maxDatagramSize, true)
+	sender.OnCongestionEvent(1, maxDatagramSize, cwnd, TrafficClassDefault, false)
+	require.Equal(t, cwnd, sender.GetCongestionWindow())
+	require.Equal(t, []string{"loss tolerated"}, h.Messages())
+	require.Equal(t, int64(maxDatagramSize), h.Attrs(0)["lost_bytes"].Int64())
+}
+
+func TestHysteriaSenderLogger(t *testing.T) {
+	var h recordingHandler
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(50*time.Millisecond, 0)
+	sender := NewHysteriaSender(DefaultClock{}, rttStats, maxDatagramSize, BandwidthFromMbps(100), &Config{Logger: slog.New(&h)}, nil)
+	sender.OnPacketSent(monotime.Now(), 0, 1, maxDatagramSize, true)
+	sender.OnPacketSent(monotime.Now(), 0, 2, maxDatagramSize, true)
+
+	// a small loss is within the tolerance
+	sender.OnCongestionEvent(1, 1, 100*maxDatagramSize, TrafficClassDefault, false)
+	require.Equal(t, []string{"loss tolerated"}, h.Messages())
+
+	rate := sender.BandwidthEstimate()
+	sender.OnCongestionEvent(2, maxDatagramSize, maxDatagramSize, TrafficClassDefault, false)
+	require.Equal(t, []string{"loss tolerated", "congestion state updated", "sending rate reduced"}, h.Messages())
+	require.Equal(t, "penalty", h.Attrs(1)["state"].String())
+	attrs := h.Attrs(2)
+	require.Equal(t, int64(2), attrs["packet_number"].Int64())
+	require.Equal(t, uint64(rate/BitsPerSecond), attrs["old_rate_bps"].Uint64())
+	require.Less(t, attrs["new_rate_bps"].Uint64(), attrs["old_rate_bps"].Uint64())
+}
+
+func TestPragueSenderLogger(t *testing.T) {
+	var h recordingHandler
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(100*time.Millisecond, 0)
+	sender := NewPragueSender(rttStats, maxDatagramSize, &Config{Logger: slog.New(&h)}, nil)
+	cwnd := sender.GetCongestionWindow()
+	sender.OnPacketSent(monotime.Now(), 0, 1, maxDatagramSize, true)
+	sender.OnCongestionEvent(1, maxDatagramSize, cwnd, TrafficClassDefault, false)
+	require.Equal(t, []string{"congestion state updated", "congestion window reduced"}, h.Messages())
+	require.Equal(t, int64(protocol.ByteCount(float64(cwnd)*renoBeta)), h.Attrs(1)["new_window"].Int64())
+}
@@ -2,6 +2,7 @@ package congestion
 
 import (
 	"math"
+	"math/bits"
 	"time"
 
 	"github.com/quic-go/quic-go/internal/monotime"
@@ -11,31 +12,59 @@ import (
 const maxBurstSizePackets = 10
 
 // The pacer implements a token bucket pacing algorithm.
+// It is used by both the window-based and the rate-based senders.
+// While the sender is idle, the bucket fills up, but never beyond the maximum burst size,
+// such that a sender resuming after an idle period doesn't send a huge burst.
 type pacer struct {
 	budgetAtLastSent  protocol.ByteCount
 	maxDatagramSize   protocol.ByteCount
 	lastSentTime      monotime.Time
 	adjustedBandwidth func() uint64 // in bytes/s
+	// maxBandwidth caps the pacing rate. 0 means no cap.
+	maxBandwidth Bandwidth
+	// maxBurst caps the budget. 0 means that the default burst size is used.
+	maxBurst protocol.ByteCount
+	// disabled turns the pacer into a passthrough: it always has budget, and never delays sending.
+	disabled bool
 }
 
+// newPacer creates a pacer for a window-based sender.
+// It paces slightly faster than the bandwidth estimate of the sender.
 func newPacer(getBandwidth func() Bandwidth) *pacer {
-	p := &pacer{
-		maxDatagramSize: initialMaxDatagramSize,
-		adjustedBandwidth: func() uint64 {
-			// Bandwidth is in bits/s. We need the value in bytes/s.
-			bw := uint64(getBandwidth() / BytesPerSecond)
-			// Use a slightly higher value than the actual measured bandwidth.
-			// RTT variations then won't result in under-utilization of the congestion window.
-			// Ultimately, this will result in sending packets as acknowledgments are received rather than when timers fire,
-			// provided the congestion window is fully utilized and acknowledgments arrive at regular intervals.
-			return bw * 5 / 4
-		},
+	return newPacerWithGain(getBandwidth, true)
+}
+
+// newRatePacer creates a pacer for a rate-based sender.
+// It paces at exactly the sending rate of the sender.
+func newRatePacer(getRate func() Bandwidth) *pacer {
+	return newPacerWithGain(getRate, false)
+}
+
+func newPacerWithGain(getBandwidth func() Bandwidth, withGain bool) *pacer {
+	p := &pacer{maxDatagramSize: initialMaxDatagramSize}
+	p.adjustedBandwidth = func() uint64 {
+		// Bandwidth is in bits/s. We need the value in bytes/s.
+		bw := uint64(getBandwidth() / BytesPerSecond)
+		// Use a slightly higher value than the actual measured bandwidth.
+		// RTT variations then won't result in under-utilization of the congestion window.
+		// Ultimately, this will result in sending packets as acknowledgments are received rather than when timers fire,
+		// provided the congestion window is fully utilized and acknowledgments arrive at regular intervals.
+		if withGain {
+			bw = bw * 5 / 4
+		}
+		if p.maxBandwidth > 0 {
+			bw = min(bw, max(uint64(p.maxBandwidth/BytesPerSecond), 1))
+		}
+		return bw
 	}
 	p.budgetAtLastSent = p.maxBurstSize()
 	return p
 }
 
 func (p *pacer) SentPacket(sendTime monotime.Time, size protocol.ByteCount) {
+	if p.disabled {
+		return
+	}
 	budget := p.Budget(sendTime)
 	if size >= budget {
 		p.budgetAtLastSent = 0
@@ -46,6 +75,9 @@ func (p *pacer) SentPacket(sendTime monotime.Time, size protocol.ByteCount) {
 }
 
 func (p *pacer) Budget(now monotime.Time) protocol.ByteCount {
+	if p.disabled {
+		return protocol.MaxByteCount
+	}
 	if p.lastSentTime.IsZero() {
 		return p.maxBurstSize()
 	}
@@ -62,34 +94,43 @@ func (p *pacer) Budget(now monotime.Time) protocol.ByteCount {
 }
 
 func (p *pacer) maxBurstSize() protocol.ByteCount {
-	return max(
-		p.timeScaledBandwidth(uint64((protocol.MinPacingDelay + protocol.TimerGranularity).Nanoseconds())),
-		maxBurstSizePackets*p.maxDatagramSize,
-	)
+	if p.maxBurst > 0 {
+		// the budget needs to allow sending at least a single packet
+		return max(p.maxBurst, p.maxDatagramSize)
+	}
+	maxBurst := maxBurstSizePackets * p.maxDatagramSize
+	const burstInterval = uint64(protocol.MinPacingDelay + protocol.TimerGranularity)
+	// At absurdly high rates, the burst size is limited to the default number of packets.
+	if bw := p.adjustedBandwidth(); bw > 0 && burstInterval > math.MaxUint64/bw {
+		return maxBurst
+	}
+	return max(p.timeScaledBandwidth(burstInterval), maxBurst)
 }
 
 // timeScaledBandwidth calculates the number of bytes that may be sent within
 // a given time interval (ns nanoseconds), based on the current bandwidth estimate.
-// It caps the scaled value to the maximum allowed burst and handles overflows.
+// The intermediate product is calculated with 128 bits, such that long intervals at high rates don't overflow.
+// If the result doesn't fit into a ByteCount, it saturates.
 func (p *pacer) timeScaledBandwidth(ns uint64) protocol.ByteCount {
 	bw := p.adjustedBandwidth()
 	if bw == 0 {
 		return 0
 	}
 	const nsPerSecond = 1e9
-	maxBurst := maxBurstSizePackets * p.maxDatagramSize
-	var scaled protocol.ByteCount
-	if ns > math.MaxUint64/bw {
-		scaled = maxBurst
-	} else {
-		scaled = protocol.ByteCount(bw * ns / nsPerSecond)
+	hi, lo := bits.Mul64(bw, ns)
+	if hi >= nsPerSecond {
+		return protocol.MaxByteCount
 	}
-	return scaled
+	scaled, _ := bits.Div64(hi, lo, nsPerSecond)
+	return protocol.ByteCount(min(scaled, uint64(protocol.MaxByteCount)))
 }
 
 // TimeUntilSend returns when the next packet should be sent.
 // It returns zero if a packet can be sent immediately.
 func (p *pacer) TimeUntilSend() monotime.Time {
+	if p.disabled {
+		return 0
+	}
 	if p.budgetAtLastSent >= p.maxDatagramSize {
 		return 0
 	}
@@ -105,6 +146,32 @@ func (p *pacer) TimeUntilSend() monotime.Time {
 	return p.lastSentTime.Add(max(protocol.MinPacingDelay, time.Duration(d)*time.Nanosecond))
 }
 
+// SetMaxBandwidth caps the pacing rate, independent of the bandwidth estimate of the sender.
+// A value of 0 removes the cap.
+func (p *pacer) SetMaxBandwidth(b Bandwidth) {
+	p.maxBandwidth = b
+}
+
+// SetMaxBurst caps the number of bytes that can be sent in a burst, for example after an idle period.
+// A value of 0 restores the default, which is the amount of data sent within 2ms, but at least 10 packets.
+func (p *pacer) SetMaxBurst(b protocol.ByteCount) {
+	p.maxBurst = b
+}
+
+// SetDisabled turns pacing off (or back on).
+// While disabled, the maximum bandwidth and the maximum burst size have no effect:
+// packets are sent as fast as the congestion window allows.
+func (p *pacer) SetDisabled(disabled bool) {
+	p.disabled = disabled
+}
+
 func (p *pacer) SetMaxDatagramSize(s protocol.ByteCount) {
 	p.maxDatagramSize = s
 }
+
+// Reset restores the budget of a newly created pacer, i.e. a full burst.
+// The configuration (maximum bandwidth, maximum burst size, and whether pacing is disabled) is kept.
+func (p *pacer) Reset() {
+	p.lastSentTime = 0
+	p.budgetAtLastSent = p.maxBurstSize()
+}
@@ -86,6 +86,81 @@ func TestPacerUpdatePacketSize(t *testing.T) {
 	require.Equal(t, maxBurstSizePackets*newDatagramSize, p.Budget(now.Add(time.Hour)))
 }
 
+func TestPacerMaxBandwidth(t *testing.T) {
+	bandwidth := 50 * initialMaxDatagramSize // 50 full-size packets per second
+	p := newPacer(func() Bandwidth { return Bandwidth(bandwidth) * BytesPerSecond * 4 / 5 })
+	p.SetMaxBandwidth(Bandwidth(10*initialMaxDatagramSize) * BytesPerSecond) // 10 full-size packets per second
+
+	// consume the initial budget by sending packets
+	now := monotime.Now()
+	for p.Budget(now) > 0 {
+		p.SentPacket(now, initialMaxDatagramSize)
+	}
+	require.Equal(t, time.Second/10, p.TimeUntilSend().Sub(now))
+
+	// the cap doesn't apply if the bandwidth estimate is lower
+	bandwidth = 5 * initialMaxDatagramSize
+	require.Equal(t, time.Second/5, p.TimeUntilSend().Sub(now))
+
+	// remove the cap
+	bandwidth = 50 * initialMaxDatagramSize
+	p.SetMaxBandwidth(0)
+	require.Equal(t, time.Second/50, p.TimeUntilSend().Sub(now))
+}
+
+func TestPacerRatePacing(t *testing.T) {
+	// the rate pacer doesn't pace faster than the rate
+	p := newRatePacer(func() Bandwidth { return Bandwidth(10*initialMaxDatagramSize) * BytesPerSecond })
+	now := monotime.Now()
+	for p.Budget(now) > 0 {
+		p.SentPacket(now, initialMaxDatagramSize)
+	}
+	require.Equal(t, time.Second/10, p.TimeUntilSend().Sub(now))
+}
+
+func TestPacerMaxBurst(t *testing.T) {
+	p := newPacer(func() Bandwidth { return Bandwidth(1e6*initialMaxDatagramSize) * BytesPerSecond * 4 / 5 })
+	now := monotime.Now()
+	p.SentPacket(now, initialMaxDatagramSize)
+	// the budget accumulated while idle is capped to the default burst size
+	now = now.Add(time.Second)
+	defaultBurst := p.Budget(now)
+	require.Equal(t, p.maxBurstSize(), defaultBurst)
+
+	p.SetMaxBurst(3 * initialMaxDatagramSize)
+	require.Equal(t, 3*initialMaxDatagramSize, p.Budget(now))
+	for range 3 {
+		p.SentPacket(now, initialMaxDatagramSize)
+	}
+	require.Zero(t, p.Budget(now))
+	require.True(t, p.TimeUntilSend().After(now))
+
+	// the budget always allows sending a single packet
+	p.SetMaxBurst(1)
+	require.Equal(t, initialMaxDatagramSize, p.Budget(now.Add(time.Second)))
+
+	// restore the default
+	p.SetMaxBurst(0)
+	require.Equal(t, defaultBurst, p.Budget(now.Add(time.Second)))
+}
+
+func TestPacerDisabled(t *testing.T) {
+	p := newPacer(func() Bandwidth { return Bandwidth(50*initialMaxDatagramSize) * BytesPerSecond })
+	p.SetMaxBandwidth(Bandwidth(initialMaxDatagramSize) * BytesPerSecond)
+	p.SetMaxBurst(2 * initialMaxDatagramSize)
+	p.SetDisabled(true)
+	now := monotime.Now()
+	for range 100 {
+		require.Equal(t, protocol.MaxByteCount, p.Budget(now))
+		require.Zero(t, p.TimeUntilSend())
+		p.SentPacket(now, initialMaxDatagramSize)
+	}
+
+	// turning pacing back on applies the burst limit again
+	p.SetDisabled(false)
+	require.Equal(t, 2*initialMaxDatagramSize, p.Budget(now))
+}
+
 func TestPacerFastPacing(t *testing.T) {
 	const bandwidth = 10000 * initialMaxDatagramSize // 10,000 full-size packets per second
 	p := newPacer(func() Bandwidth { return Bandwidth(bandwidth) * BytesPerSecond * 4 / 5 })
@@ -0,0 +1,319 @@
+package congestion
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/quic-go/quic-go/internal/monotime"
+	"github.com/quic-go/quic-go/internal/protocol"
+	"github.com/quic-go/quic-go/internal/utils"
+	"github.com/quic-go/quic-go/qlog"
+	"github.com/quic-go/quic-go/qlogwriter"
+)
+
+// The gain of the EWMA of the fraction of CE-marked packets, as used by DCTCP (RFC 8257).
+const pragueAlphaGain = 1.0 / 16
+
+// pragueSender implements a scalable congestion controller in the style of TCP Prague, for use with L4S (RFC 9330).
+// Like DCTCP, it maintains an EWMA (alpha) of the fraction of packets that were CE-marked per round trip,
+// and once per round trip that saw CE marks, it reduces the congestion window by alpha/2.
+// A small number of CE marks therefore only causes a small reduction, which allows an L4S bottleneck
+// to signal congestion early and keep queuing delay very low, while still utilizing the link.
+// Packet loss is treated like by Reno. Without ECN feedback, the sender therefore behaves like Reno.
+type pragueSender struct {
+	rttStats *utils.RTTStats
+	pacer    *pacer
+
+	largestSentPacketNumber  protocol.PacketNumber
+	largestAckedPacketNumber protocol.PacketNumber
+	largestSentAtLastCutback protocol.PacketNumber
+
+	// the round ends once a packet sent after the start of the round is acknowledged
+	endOfRound protocol.PacketNumber
+	// the number of ECN-marked and CE-marked packets reported during the current round
+	roundECNMarked, roundCEMarked int64
+	// the EWMA of the fraction of CE-marked packets
+	alpha float64
+
+	congestionWindow   protocol.ByteCount
+	slowStartThreshold protocol.ByteCount
+
+	initialCongestionWindow protocol.ByteCount
+	initialMaxDatagramSize  protocol.ByteCount
+	maxDatagramSize         protocol.ByteCount
+	maxDatagramSizeCeiling  protocol.ByteCount
+
+	onCongestionWindowChange func(old, new protocol.ByteCount)
+
+	// while probing for bandwidth, the congestion window grows even if the sender is not cwnd-limited
+	probeUntil monotime.Time
+
+	lastState qlog.CongestionState
+	qlogger   qlogwriter.Recorder
+	logger    *slog.Logger
+}
+
+var (
+	_ SendAlgorithm               = &pragueSender{}
+	_ SendAlgorithmWithDebugInfos = &pragueSender{}
+	_ ScalableSender              = &pragueSender{}
+)
+
+// NewPragueSender creates a new Prague sender.
+func NewPragueSender(rttStats *utils.RTTStats, initialMaxDatagramSize protocol.ByteCount, conf *Config, qlogger qlogwriter.Recorder) *pragueSender {
+	if conf == nil {
+		conf = &Config{}
+	}
+	initialMaxDatagramSize = min(initialMaxDatagramSize, conf.maxDatagramSizeCeiling())
+	p := &pragueSender{
+		rttStats:                 rttStats,
+		largestSentPacketNumber:  protocol.InvalidPacketNumber,
+		largestAckedPacketNumber: protocol.InvalidPacketNumber,
+		largestSentAtLastCutback: protocol.InvalidPacketNumber,
+		endOfRound:               protocol.InvalidPacketNumber,
+		alpha:                    1,
+		initialCongestionWindow:  conf.initialCongestionWindow(initialMaxDatagramSize),
+		congestionWindow:         conf.initialCongestionWindow(initialMaxDatagramSize),
+		slowStartThreshold:       protocol.MaxByteCount,
+		initialMaxDatagramSize:   initialMaxDatagramSize,
+		maxDatagramSize:          initialMaxDatagramSize,
+		maxDatagramSizeCeiling:   conf.maxDatagramSizeCeiling(),
+		onCongestionWindowChange: conf.OnCongestionWindowChange,
+		qlogger:                  qlogger,
+		logger:                   conf.Logger,
+	}
+	p.pacer = newPacer(p.BandwidthEstimate)
+	p.pacer.SetMaxBandwidth(conf.MaxPacingRate)
+	p.pacer.SetMaxBurst(conf.MaxPacingBurst)
+	p.pacer.SetDisabled(conf.DisablePacing)
+	p.pacer.SetMaxDatagramSize(initialMaxDatagramSize)
+	p.lastState = qlog.CongestionStateSlowStart
+	if p.qlogger != nil {
+		p.qlogger.RecordEvent(qlog.CongestionStateUpdated{State: qlog.CongestionStateSlowStart})
+	}
+	return p
+}
+
+func (p *pragueSender) TimeUntilSend(_ protocol.ByteCount) monotime.Time {
+	return p.pacer.TimeUntilSend()
+}
+
+func (p *pragueSender) PacingBudget(now monotime.Time) protocol.ByteCount {
+	return p.pacer.Budget(now)
+}
+
+func (p *pragueSender) HasPacingBudget(now monotime.Time) bool {
+	return p.pacer.Budget(now) >= p.maxDatagramSize
+}
+
+func (p *pragueSender) maxCongestionWindow() protocol.ByteCount {
+	return p.maxDatagramSize * protocol.MaxCongestionWindowPackets
+}
+
+func (p *pragueSender) minCongestionWindow() protocol.ByteCount {
+	return p.maxDatagramSize * minCongestionWindowPackets
+}
+
+func (p *pragueSender) OnPacketSent(sentTime monotime.Time, _ protocol.ByteCount, packetNumber protocol.PacketNumber, bytes protocol.ByteCount, isRetransmittable bool) {
+	p.pacer.SentPacket(sentTime, bytes)
+	if !isRetransmittable {
+		return
+	}
+	p.largestSentPacketNumber = packetNumber
+}
+
+func (p *pragueSender) CanSend(bytesInFlight protocol.ByteCount) bool {
+	return bytesInFlight < p.GetCongestionWindow()
+}
+
+func (p *pragueSender) InRecovery() bool {
+	return p.largestAckedPacketNumber != protocol.InvalidPacketNumber && p.largestAckedPacketNumber <= p.largestSentAtLastCutback
+}
+
+func (p *pragueSender) InSlowStart() bool                       { return p.GetCongestionWindow() < p.slowStartThreshold }
+func (p *pragueSender) GetCongestionWindow() protocol.ByteCount { return p.congestionWindow }
+func (p *pragueSender) SlowStartThreshold() protocol.ByteCount  { return p.slowStartThreshold }
+
+// MaybeExitSlowStart is a no-op: Prague leaves slow start on the first round trip that saw CE marks.
+func (p *pragueSender) MaybeExitSlowStart() {}
+
+// OnECNFeedback accounts the ECN counts reported by the peer to the current round.
+func (p *pragueSender) OnECNFeedback(ecnMarked, ceMarked int64) {
+	p.roundECNMarked += ecnMarked
+	p.roundCEMarked += min(ceMarked, ecnMarked)
+}
+
+func (p *pragueSender) OnPacketAcked(ackedPacketNumber protocol.PacketNumber, ackedBytes protocol.ByteCount, priorInFlight protocol.ByteCount, eventTime monotime.Time) {
+	p.largestAckedPacketNumber = max(ackedPacketNumber, p.largestAckedPacketNumber)
+	if ackedPacketNumber > p.endOfRound {
+		p.onRoundEnd()
+	}
+	if p.InRecovery() {
+		return
+	}
+	if !p.isCwndLimited(priorInFlight) && !eventTime.Before(p.probeUntil) {
+		p.maybeQlogStateChange(qlog.CongestionStateApplicationLimited)
+		return
+	}
+
+	oldCongestionWindow := p.congestionWindow
+	defer p.maybeNotifyCongestionWindowChange(oldCongestionWindow)
+
+	if p.InSlowStart() {
+		p.maybeQlogStateChange(qlog.CongestionStateSlowStart)
+		p.congestionWindow = min(p.congestionWindow+ackedBytes, p.maxCongestionWindow())
+		return
+	}
+	// Additive increase: grow the window by one packet per round trip.
+	p.maybeQlogStateChange(qlog.CongestionStateCongestionAvoidance)
+	p.congestionWindow = min(p.congestionWindow+max(p.maxDatagramSize*ackedBytes/p.congestionWindow, 1), p.maxCongestionWindow())
+}
+
+// onRoundEnd is called once per round trip. It updates alpha with the fraction of CE-marked packets reported
+// during the round, and reduces the congestion window if any packets were CE-marked.
+func (p *pragueSender) onRoundEnd() {
+	p.endOfRound = p.largestSentPacketNumber
+	ecnMarked, ceMarked := p.roundECNMarked, p.roundCEMarked
+	p.roundECNMarked, p.roundCEMarked = 0, 0
+	if ecnMarked == 0 {
+		return
+	}
+	p.alpha += pragueAlphaGain * (float64(ceMarked)/float64(ecnMarked) - p.alpha)
+	// a loss already reduced the congestion window in this round trip
+	if ceMarked == 0 || p.InRecovery() {
+		return
+	}
+	oldCongestionWindow := p.congestionWindow
+	p.congestionWindow = max(protocol.ByteCount(float64(p.congestionWindow)*(1-p.alpha/2)), p.minCongestionWindow())
+	p.slowStartThreshold = p.congestionWindow
+	p.maybeQlogStateChange(qlog.CongestionStateCongestionAvoidance)
+	logWindowReduction(p.logger, p.largestAckedPacketNumber, oldCongestionWindow, p.congestionWindow)
+	p.maybeNotifyCongestionWindowChange(oldCongestionWindow)
+}
+
+func (p *pragueSender) OnCongestionEvent(packetNumber protocol.PacketNumber, lostBytes, _ protocol.ByteCount, _ TrafficClass, _ bool) {
+	// only react once per round trip
+	if packetNumber <= p.largestSentAtLastCutback {
+		return
+	}
+	p.maybeQlogStateChange(qlog.CongestionStateRecovery)
+
+	oldCongestionWindow := p.congestionWindow
+	p.congestionWindow = max(protocol.ByteCount(float64(p.congestionWindow)*renoBeta), p.minCongestionWindow())
+	p.slowStartThreshold = p.congestionWindow
+	p.largestSentAtLastCutback = p.largestSentPacketNumber
+	logWindowReduction(p.logger, packetNumber, oldCongestionWindow, p.congestionWindow)
+	p.maybeNotifyCongestionWindowChange(oldCongestionWindow)
+}
+
+func (p *pragueSender) OnRetransmissionTimeout(packetsRetransmitted bool) {
+	p.largestSentAtLastCutback = protocol.InvalidPacketNumber
+	if !packetsRetransmitted {
+		return
+	}
+	oldCongestionWindow := p.congestionWindow
+	p.slowStartThreshold = max(p.congestionWindow/2, p.minCongestionWindow())
+	p.congestionWindow = p.minCongestionWindow()
+	p.maybeNotifyCongestionWindowChange(oldCongestionWindow)
+}
+
+// OnPersistentCongestion collapses the congestion window to the minimum congestion window.
+func (p *pragueSender) OnPersistentCongestion() {
+	oldCongestionWindow := p.congestionWindow
+	p.slowStartThreshold = max(p.congestionWindow/2, p.minCongestionWindow())
+	p.congestionWindow = p.minCongestionWindow()
+	p.largestSentAtLastCutback = p.largestSentPacketNumber
+	p.maybeNotifyCongestionWindowChange(oldCongestionWindow)
+}
+
+// OnSpuriousLoss is a no-op: on an L4S bottleneck, congestion is signaled by CE marks, not by losses.
+func (p *pragueSender) OnSpuriousLoss(protocol.PacketNumber) {}
+
+func (p *pragueSender) OnFlowControlLimited(bool) {}
+
+func (p *pragueSender) ProbeBandwidth(until monotime.Time) {
+	p.probeUntil = until
+}
+
+func (p *pragueSender) OnConnectionMigration() {
+	p.probeUntil = 0
+	p.largestSentPacketNumber = protocol.InvalidPacketNumber
+	p.largestAckedPacketNumber = protocol.InvalidPacketNumber
+	p.largestSentAtLastCutback = protocol.InvalidPacketNumber
+	p.endOfRound = protocol.InvalidPacketNumber
+	p.roundECNMarked, p.roundCEMarked = 0, 0
+	p.alpha = 1
+	p.congestionWindow = p.initialCongestionWindow
+	p.slowStartThreshold = protocol.MaxByteCount
+	p.maxDatagramSize = p.initialMaxDatagramSize
+	p.pacer.SetMaxDatagramSize(p.initialMaxDatagramSize)
+}
+
+// State returns the phase the sender is currently in.
+func (p *pragueSender) State(bytesInFlight protocol.ByteCount) State {
+	switch {
+	case p.InRecovery():
+		return StateRecovery
+	case !p.isCwndLimited(bytesInFlight):
+		return StateApplicationLimited
+	case p.InSlowStart():
+		return StateSlowStart
+	default:
+		return StateCongestionAvoidance
+	}
+}
+
+func (p *pragueSender) isCwndLimited(bytesInFlight protocol.ByteCount) bool {
+	congestionWindow := p.GetCongestionWindow()
+	if bytesInFlight >= congestionWindow {
+		return true
+	}
+	availableBytes := congestionWindow - bytesInFlight
+	slowStartLimited := p.InSlowStart() && bytesInFlight > congestionWindow/2
+	return slowStartLimited || availableBytes <= maxBurstPackets*p.maxDatagramSize
+}
+
+// BandwidthEstimate returns the rate used for pacing, derived from the congestion window.
+func (p *pragueSender) BandwidthEstimate() Bandwidth {
+	srtt := p.rttStats.SmoothedRTT()
+	if srtt == 0 {
+		srtt = p.rttStats.InitialRTT()
+	}
+	return BandwidthFromDelta(p.GetCongestionWindow(), srtt)
+}
+
+func (p *pragueSender) EstimatedSendTime(bytes protocol.ByteCount) time.Duration {
+	return SendTime(bytes, p.BandwidthEstimate())
+}
+
+func (p *pragueSender) maybeNotifyCongestionWindowChange(old protocol.ByteCount) {
+	if p.onCongestionWindowChange == nil || old == p.congestionWindow {
+		return
+	}
+	p.onCongestionWindowChange(old, p.congestionWindow)
+}
+
+func (p *pragueSender) maybeQlogStateChange(new qlog.CongestionState) {
+	if new == p.lastState {
+		return
+	}
+	if p.qlogger != nil {
+		p.qlogger.RecordEvent(qlog.CongestionStateUpdated{State: new})
+	}
+	logStateChange(p.logger, new)
+	p.lastState = new
+}
+
+func (p *pragueSender) SetMaxDatagramSize(s protocol.ByteCount) {
+	if s < p.maxDatagramSize {
+		panic(fmt.Sprintf("congestion BUG: decreased max datagram size from %d to %d", p.maxDatagramSize, s))
+	}
+	s = min(s, p.maxDatagramSizeCeiling)
+	cwndIsMinCwnd := p.congestionWindow == p.minCongestionWindow()
+	p.maxDatagramSize = s
+	if cwndIsMinCwnd {
+		p.congestionWindow = p.minCongestionWindow()
+	}
+	p.pacer.SetMaxDatagramSize(s)
+}
@@ -0,0 +1,115 @@
+package congestion
+
+import (
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go/internal/monotime"
+	"github.com/quic-go/quic-go/internal/protocol"
+	"github.com/quic-go/quic-go/internal/utils"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testPragueSender struct {
+	sender *pragueSender
+	pn     protocol.PacketNumber
+}
+
+func newTestPragueSender() *testPragueSender {
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(100*time.Millisecond, 0)
+	return &testPragueSender{sender: NewPragueSender(rttStats, maxDatagramSize, nil, nil)}
+}
+
+// SendAndAckRound sends a full congestion window and acknowledges it.
+// The ECN feedback is reported before the packets are acknowledged, as the sent packet handler does.
+func (s *testPragueSender) SendAndAckRound(ceMarked int) {
+	now := monotime.Now()
+	cwnd := s.sender.GetCongestionWindow()
+	first := s.pn
+	for bytesInFlight := protocol.ByteCount(0); bytesInFlight < cwnd; bytesInFlight += maxDatagramSize {
+		s.sender.OnPacketSent(now, bytesInFlight, s.pn, maxDatagramSize, true)
+		s.pn++
+	}
+	s.sender.OnECNFeedback(int64(s.pn-first), int64(ceMarked))
+	for pn := first; pn < s.pn; pn++ {
+		s.sender.OnPacketAcked(pn, maxDatagramSize, cwnd, now)
+	}
+}
+
+func TestPragueSenderSlowStart(t *testing.T) {
+	s := newTestPragueSender()
+	require.True(t, s.sender.InSlowStart())
+	cwnd := s.sender.GetCongestionWindow()
+
+	// no CE marks: the window doubles every round, and alpha decays
+	s.SendAndAckRound(0)
+	require.Equal(t, 2*cwnd, s.sender.GetCongestionWindow())
+	require.True(t, s.sender.InSlowStart())
+	require.InDelta(t, 1-pragueAlphaGain, s.sender.alpha, 1e-9)
+
+	// The first CE mark ends slow start.
+	// Since alpha is still large, the window is almost halved.
+	// It then grows by one packet per round trip.
+	s.SendAndAckRound(1)
+	require.False(t, s.sender.InSlowStart())
+	alpha := s.sender.alpha
+	require.Less(t, alpha, 1-pragueAlphaGain)
+	require.InDelta(t, float64(2*cwnd)*(1-alpha/2)+float64(maxDatagramSize), float64(s.sender.GetCongestionWindow()), float64(maxDatagramSize))
+}
+
+func TestPragueSenderProportionalReduction(t *testing.T) {
+	s := newTestPragueSender()
+	// leave slow start
+	s.SendAndAckRound(1)
+	require.False(t, s.sender.InSlowStart())
+	// no CE marks for a while: alpha decays, and the window grows by one packet per round
+	for range 50 {
+		cwnd := s.sender.GetCongestionWindow()
+		s.SendAndAckRound(0)
+		require.InDelta(t, float64(cwnd+maxDatagramSize), float64(s.sender.GetCongestionWindow()), 100)
+	}
+	require.Less(t, s.sender.alpha, 0.05)
+
+	// a single CE mark only causes a small reduction
+	cwnd := s.sender.GetCongestionWindow()
+	s.SendAndAckRound(1)
+	require.Less(t, s.sender.GetCongestionWindow(), cwnd)
+	require.Greater(t, s.sender.GetCongestionWindow(), cwnd*95/100)
+
+	// the more packets are CE-marked, the larger the reduction
+	for range 7 {
+		s.SendAndAckRound(int(s.sender.GetCongestionWindow() / maxDatagramSize))
+	}
+	require.Greater(t, s.sender.alpha, 0.3)
+	cwnd = s.sender.GetCongestionWindow()
+	s.SendAndAckRound(int(cwnd / maxDatagramSize))
+	require.Less(t, s.sender.GetCongestionWindow(), cwnd*85/100)
+}
+
+func TestPragueSenderLoss(t *testing.T) {
+	s := newTestPragueSender()
+	s.SendAndAckRound(0)
+	cwnd := s.sender.GetCongestionWindow()
+
+	// a loss reduces the window like Reno
+	s.sender.OnPacketSent(monotime.Now(), 0, s.pn, maxDatagramSize, true)
+	s.sender.OnCongestionEvent(s.pn, maxDatagramSize, cwnd, TrafficClassDefault, false)
+	s.pn++
+	require.Equal(t, protocol.ByteCount(float64(cwnd)*renoBeta), s.sender.GetCongestionWindow())
+	require.False(t, s.sender.InSlowStart())
+}
+
+func TestPragueSenderConnectionMigration(t *testing.T) {
+	s := newTestPragueSender()
+	cwnd := s.sender.GetCongestionWindow()
+	s.SendAndAckRound(0)
+	s.SendAndAckRound(1)
+	require.Less(t, s.sender.alpha, 1.0)
+
+	s.sender.OnConnectionMigration()
+	require.Equal(t, cwnd, s.sender.GetCongestionWindow())
+	require.True(t, s.sender.InSlowStart())
+	require.Equal(t, 1.0, s.sender.alpha)
+}
@@ -0,0 +1,112 @@
+package congestion
+
+import (
+	"slices"
+	"time"
+
+	"github.com/quic-go/quic-go/internal/monotime"
+)
+
+// The number of RTT samples the RTTGradient fits the slope over.
+const rttGradientWindow = 8
+
+type rttGradientSample struct {
+	time monotime.Time
+	rtt  time.Duration
+}
+
+// An RTTGradient estimates whether the RTT is trending upwards, which indicates that a queue is building up,
+// as opposed to fluctuating around a stable value.
+//
+// RTT samples are aggregated into intervals, using the minimum RTT sample of each interval.
+// This prevents a burst of samples taken from a single ACK frame from dominating the estimate.
+// The slope is then estimated using the Theil-Sen estimator (the median of the slopes between all pairs of samples),
+// which is robust to outliers: a single RTT spike doesn't affect the estimate.
+type RTTGradient struct {
+	samples [rttGradientWindow]rttGradientSample
+	idx     int
+	count   int
+
+	intervalStart monotime.Time
+	intervalMin   time.Duration
+
+	// the slope only changes when a sample is added, but it is queried on every ACK
+	slope      float64
+	slopeOK    bool
+	slopeValid bool
+	slopes     []float64 // reused to calculate the median
+}
+
+// AddSample adds an RTT sample taken at the given time.
+// All samples taken within the interval after the first sample of an interval are aggregated.
+func (g *RTTGradient) AddSample(now monotime.Time, rtt time.Duration, interval time.Duration) {
+	if g.intervalStart.IsZero() {
+		g.intervalStart = now
+		g.intervalMin = rtt
+		return
+	}
+	if now.Sub(g.intervalStart) < interval {
+		g.intervalMin = min(g.intervalMin, rtt)
+		return
+	}
+	g.samples[g.idx] = rttGradientSample{time: g.intervalStart, rtt: g.intervalMin}
+	g.idx = (g.idx + 1) % rttGradientWindow
+	g.count = min(g.count+1, rttGradientWindow)
+	g.slopeValid = false
+	g.intervalStart = now
+	g.intervalMin = rtt
+}
+
+// Slope returns the rate at which the RTT changes: the increase of the RTT per elapsed time.
+// It returns false until enough samples were collected.
+func (g *RTTGradient) Slope() (float64, bool) {
+	if g.count < rttGradientWindow {
+		return 0, false
+	}
+	if !g.slopeValid {
+		g.slope, g.slopeOK = g.medianSlope()
+		g.slopeValid = true
+	}
+	return g.slope, g.slopeOK
+}
+
+// medianSlope calculates the median of the slopes between all pairs of samples.
+func (g *RTTGradient) medianSlope() (float64, bool) {
+	g.slopes = g.slopes[:0]
+	for i := range g.samples {
+		for j := i + 1; j < len(g.samples); j++ {
+			dt := g.samples[j].time.Sub(g.samples[i].time)
+			if dt == 0 {
+				continue
+			}
+			g.slopes = append(g.slopes, float64(g.samples[j].rtt-g.samples[i].rtt)/float64(dt))
+		}
+	}
+	if len(g.slopes) == 0 {
+		return 0, false
+	}
+	slices.Sort(g.slopes)
+	mid := len(g.slopes) / 2
+	if len(g.slopes)%2 == 0 {
+		return (g.slopes[mid-1] + g.slopes[mid]) / 2, true
+	}
+	return g.slopes[mid], true
+}
+
+// Increase returns by how much the RTT increased over the time covered by the samples, according to the slope.
+// It is negative if the RTT decreased. It returns false until enough samples were collected.
+func (g *RTTGradient) Increase() (time.Duration, bool) {
+	slope, ok := g.Slope()
+	if !ok {
+		return 0, false
+	}
+	oldest := g.samples[g.idx]
+	newest := g.samples[(g.idx+rttGradientWindow-1)%rttGradientWindow]
+	return time.Duration(slope * float64(newest.time.Sub(oldest.time))), true
+}
+
+// Reset discards all samples.
+func (g *RTTGradient) Reset() {
+	slopes := g.slopes
+	*g = RTTGradient{slopes: slopes[:0]}
+}
@@ -0,0 +1,100 @@
+package congestion
+
+import (
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go/internal/monotime"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRTTGradient(t *testing.T) {
+	const interval = 10 * time.Millisecond
+	// addSamples adds one sample per interval, and returns the time after the last sample
+	addSamples := func(g *RTTGradient, now monotime.Time, rtts ...time.Duration) monotime.Time {
+		for _, rtt := range rtts {
+			g.AddSample(now, rtt, interval)
+			now = now.Add(interval)
+		}
+		return now
+	}
+	repeat := func(rtt time.Duration, n int) []time.Duration {
+		rtts := make([]time.Duration, n)
+		for i := range rtts {
+			rtts[i] = rtt
+		}
+		return rtts
+	}
+
+	t.Run("not enough samples", func(t *testing.T) {
+		var g RTTGradient
+		addSamples(&g, monotime.Now(), repeat(50*time.Millisecond, rttGradientWindow)...)
+		_, ok := g.Slope()
+		require.False(t, ok)
+		_, ok = g.Increase()
+		require.False(t, ok)
+	})
+
+	t.Run("constant RTT", func(t *testing.T) {
+		var g RTTGradient
+		now := addSamples(&g, monotime.Now(), repeat(50*time.Millisecond, rttGradientWindow+1)...)
+		slope, ok := g.Slope()
+		require.True(t, ok)
+		require.Zero(t, slope)
+
+		// the slope is updated once new samples are added
+		var rtts []time.Duration
+		for i := range rttGradientWindow {
+			rtts = append(rtts, 50*time.Millisecond+time.Duration(i+1)*time.Millisecond)
+		}
+		addSamples(&g, now, rtts...)
+		slope, ok = g.Slope()
+		require.True(t, ok)
+		require.Positive(t, slope)
+	})
+
+	t.Run("increasing RTT", func(t *testing.T) {
+		var g RTTGradient
+		// the RTT increases by 1ms every 10ms
+		var rtts []time.Duration
+		for i := range rttGradientWindow + 1 {
+			rtts = append(rtts, 50*time.Millisecond+time.Duration(i)*time.Millisecond)
+		}
+		addSamples(&g, monotime.Now(), rtts...)
+		slope, ok := g.Slope()
+		require.True(t, ok)
+		require.InDelta(t, 0.1, slope, 1e-9)
+		increase, ok := g.Increase()
+		require.True(t, ok)
+		require.Equal(t, (rttGradientWindow-1)*time.Millisecond, increase)
+
+		g.Reset()
+		_, ok = g.Slope()
+		require.False(t, ok)
+	})
+
+	t.Run("single spike", func(t *testing.T) {
+		var g RTTGradient
+		rtts := repeat(50*time.Millisecond, rttGradientWindow+1)
+		rtts[rttGradientWindow-1] = 500 * time.Millisecond
+		addSamples(&g, monotime.Now(), rtts...)
+		slope, ok := g.Slope()
+		require.True(t, ok)
+		require.Zero(t, slope)
+	})
+
+	t.Run("aggregation", func(t *testing.T) {
+		var g RTTGradient
+		now := monotime.Now()
+		for i := range rttGradientWindow + 1 {
+			// multiple samples taken within the same interval are aggregated to the minimum
+			g.AddSample(now, 100*time.Millisecond, interval)
+			g.AddSample(now.Add(interval/2), 50*time.Millisecond+time.Duration(i)*time.Millisecond, interval)
+			now = now.Add(interval)
+		}
+		slope, ok := g.Slope()
+		require.True(t, ok)
+		require.InDelta(t, 0.1, slope, 1e-9)
+	})
+}
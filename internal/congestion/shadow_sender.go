@@ -0,0 +1,199 @@
+package congestion
+
+import (
+	"time"
+
+	"github.com/quic-go/quic-go/internal/monotime"
+	"github.com/quic-go/quic-go/internal/protocol"
+	"github.com/quic-go/quic-go/internal/utils"
+)
+
+// A ShadowDecision is the state of the shadow congestion controller, see NewShadowSender.
+// It describes what the shadow would do if it was in charge of the connection.
+type ShadowDecision struct {
+	// CongestionWindow is the congestion window of the shadow, in bytes.
+	CongestionWindow protocol.ByteCount
+	// BandwidthEstimate is the rate the shadow would pace at.
+	BandwidthEstimate Bandwidth
+	// InSlowStart and InRecovery are the phase the shadow is in.
+	InSlowStart, InRecovery bool
+}
+
+// shadowSender lets a primary sender make all sending decisions, while a second (shadow) sender
+// is fed the same events. The shadow never gates sending, but its window and rate are reported,
+// which allows comparing two congestion controllers on the same connection.
+// Since the shadow sees the bytes in flight of the primary, its window only grows as far as the primary's traffic allows.
+type shadowSender struct {
+	primary SendAlgorithmWithDebugInfos
+	shadow  SendAlgorithmWithDebugInfos
+	// shadowStats are the ConnectionStats used by the shadow
+	shadowStats *utils.ConnectionStats
+
+	onDecision   func(ShadowDecision)
+	lastDecision ShadowDecision
+}
+
+var (
+	_ SendAlgorithm               = &shadowSender{}
+	_ SendAlgorithmWithDebugInfos = &shadowSender{}
+	_ ScalableSender              = &scalableShadowSender{}
+)
+
+// scalableShadowSender is used if the primary sender is a ScalableSender, such that the ECN feedback still reaches it.
+type scalableShadowSender struct {
+	*shadowSender
+}
+
+// OnECNFeedback passes the ECN feedback to the primary, and to the shadow if it is a ScalableSender.
+func (s *scalableShadowSender) OnECNFeedback(ecnMarked, ceMarked int64) {
+	s.primary.(ScalableSender).OnECNFeedback(ecnMarked, ceMarked)
+	if shadow, ok := s.shadow.(ScalableSender); ok {
+		shadow.OnECNFeedback(ecnMarked, ceMarked)
+		s.report()
+	}
+}
+
+// NewShadowSender creates a sender that uses primary for all sending decisions, and runs shadow alongside it.
+// onDecision is called with the state of the shadow whenever its congestion window or bandwidth estimate changes.
+// The shadow must use its own ConnectionStats (shadowStats), such that it doesn't overwrite the values published by the primary.
+// The bytes sent and lost are recorded in shadowStats, such that the shadow can calculate the loss rate.
+// The shadow shouldn't report to the callbacks of the connection (e.g. Config.OnCongestionWindowChange).
+func NewShadowSender(primary, shadow SendAlgorithmWithDebugInfos, shadowStats *utils.ConnectionStats, onDecision func(ShadowDecision)) SendAlgorithmWithDebugInfos {
+	s := &shadowSender{
+		primary:     primary,
+		shadow:      shadow,
+		shadowStats: shadowStats,
+		onDecision:  onDecision,
+	}
+	s.lastDecision = s.decision()
+	if _, ok := primary.(ScalableSender); ok {
+		return &scalableShadowSender{shadowSender: s}
+	}
+	return s
+}
+
+func (s *shadowSender) decision() ShadowDecision {
+	return ShadowDecision{
+		CongestionWindow:  s.shadow.GetCongestionWindow(),
+		BandwidthEstimate: s.shadow.BandwidthEstimate(),
+		InSlowStart:       s.shadow.InSlowStart(),
+		InRecovery:        s.shadow.InRecovery(),
+	}
+}
+
+// report calls the callback if the state of the shadow changed since it was last reported.
+func (s *shadowSender) report() {
+	d := s.decision()
+	if d == s.lastDecision {
+		return
+	}
+	s.lastDecision = d
+	if s.onDecision != nil {
+		s.onDecision(d)
+	}
+}
+
+func (s *shadowSender) TimeUntilSend(bytesInFlight protocol.ByteCount) monotime.Time {
+	return s.primary.TimeUntilSend(bytesInFlight)
+}
+
+func (s *shadowSender) PacingBudget(now monotime.Time) protocol.ByteCount {
+	return s.primary.PacingBudget(now)
+}
+
+func (s *shadowSender) HasPacingBudget(now monotime.Time) bool {
+	return s.primary.HasPacingBudget(now)
+}
+
+func (s *shadowSender) CanSend(bytesInFlight protocol.ByteCount) bool {
+	return s.primary.CanSend(bytesInFlight)
+}
+
+func (s *shadowSender) OnPacketSent(sentTime monotime.Time, bytesInFlight protocol.ByteCount, packetNumber protocol.PacketNumber, bytes protocol.ByteCount, isRetransmittable bool) {
+	if s.shadowStats != nil {
+		s.shadowStats.BytesSent.Add(uint64(bytes))
+		s.shadowStats.PacketsSent.Add(1)
+	}
+	s.primary.OnPacketSent(sentTime, bytesInFlight, packetNumber, bytes, isRetransmittable)
+	s.shadow.OnPacketSent(sentTime, bytesInFlight, packetNumber, bytes, isRetransmittable)
+}
+
+func (s *shadowSender) MaybeExitSlowStart() {
+	s.primary.MaybeExitSlowStart()
+	s.shadow.MaybeExitSlowStart()
+	s.report()
+}
+
+func (s *shadowSender) OnPacketAcked(number protocol.PacketNumber, ackedBytes protocol.ByteCount, priorInFlight protocol.ByteCount, eventTime monotime.Time) {
+	s.primary.OnPacketAcked(number, ackedBytes, priorInFlight, eventTime)
+	s.shadow.OnPacketAcked(number, ackedBytes, priorInFlight, eventTime)
+	s.report()
+}
+
+func (s *shadowSender) OnCongestionEvent(number protocol.PacketNumber, lostBytes protocol.ByteCount, priorInFlight protocol.ByteCount, class TrafficClass, reorderingLikely bool) {
+	s.primary.OnCongestionEvent(number, lostBytes, priorInFlight, class, reorderingLikely)
+	if s.shadowStats != nil {
+		s.shadowStats.PacketsLost.Add(1)
+		s.shadowStats.BytesLost.Add(uint64(lostBytes))
+	}
+	s.shadow.OnCongestionEvent(number, lostBytes, priorInFlight, class, reorderingLikely)
+	s.report()
+}
+
+func (s *shadowSender) OnRetransmissionTimeout(packetsRetransmitted bool) {
+	s.primary.OnRetransmissionTimeout(packetsRetransmitted)
+	s.shadow.OnRetransmissionTimeout(packetsRetransmitted)
+	s.report()
+}
+
+func (s *shadowSender) SetMaxDatagramSize(size protocol.ByteCount) {
+	s.primary.SetMaxDatagramSize(size)
+	s.shadow.SetMaxDatagramSize(size)
+	s.report()
+}
+
+func (s *shadowSender) OnConnectionMigration() {
+	s.primary.OnConnectionMigration()
+	s.shadow.OnConnectionMigration()
+	s.report()
+}
+
+func (s *shadowSender) ProbeBandwidth(until monotime.Time) {
+	s.primary.ProbeBandwidth(until)
+	s.shadow.ProbeBandwidth(until)
+}
+
+func (s *shadowSender) OnSpuriousLoss(number protocol.PacketNumber) {
+	s.primary.OnSpuriousLoss(number)
+	s.shadow.OnSpuriousLoss(number)
+	s.report()
+}
+
+func (s *shadowSender) OnPersistentCongestion() {
+	s.primary.OnPersistentCongestion()
+	s.shadow.OnPersistentCongestion()
+	s.report()
+}
+
+func (s *shadowSender) OnFlowControlLimited(limited bool) {
+	s.primary.OnFlowControlLimited(limited)
+	s.shadow.OnFlowControlLimited(limited)
+}
+
+func (s *shadowSender) InSlowStart() bool { return s.primary.InSlowStart() }
+func (s *shadowSender) InRecovery() bool  { return s.primary.InRecovery() }
+func (s *shadowSender) GetCongestionWindow() protocol.ByteCount {
+	return s.primary.GetCongestionWindow()
+}
+func (s *shadowSender) SlowStartThreshold() protocol.ByteCount {
+	return s.primary.SlowStartThreshold()
+}
+func (s *shadowSender) BandwidthEstimate() Bandwidth { return s.primary.BandwidthEstimate() }
+
+func (s *shadowSender) EstimatedSendTime(bytes protocol.ByteCount) time.Duration {
+	return s.primary.EstimatedSendTime(bytes)
+}
+
+func (s *shadowSender) State(bytesInFlight protocol.ByteCount) State {
+	return s.primary.State(bytesInFlight)
+}
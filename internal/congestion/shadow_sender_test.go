@@ -0,0 +1,82 @@
+package congestion
+
+import (
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go/internal/monotime"
+	"github.com/quic-go/quic-go/internal/protocol"
+	"github.com/quic-go/quic-go/internal/utils"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShadowSender(t *testing.T) {
+	var clock mockClock
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(50*time.Millisecond, 0)
+	var shadowStats utils.ConnectionStats
+	primary := NewFixedSender(rttStats, maxDatagramSize, &Config{FixedWindowPackets: 1000})
+	shadow := NewCubicSender(&clock, rttStats, &shadowStats, maxDatagramSize, true, &Config{MinRatePolicy: MinRatePolicyPackets, MinRatePackets: 2}, nil)
+	var decisions []ShadowDecision
+	sender := NewShadowSender(primary, shadow, &shadowStats, func(d ShadowDecision) { decisions = append(decisions, d) })
+	_, ok := sender.(ScalableSender)
+	require.False(t, ok)
+
+	const cwnd = 1000 * maxDatagramSize
+	initialShadowWindow := shadow.GetCongestionWindow()
+	require.Less(t, initialShadowWindow, protocol.ByteCount(cwnd))
+	// the shadow doesn't gate sending
+	require.True(t, sender.CanSend(2*initialShadowWindow))
+	require.Equal(t, protocol.ByteCount(cwnd), sender.GetCongestionWindow())
+
+	for i := range 20 {
+		sender.OnPacketSent(clock.Now(), protocol.ByteCount(i)*maxDatagramSize, protocol.PacketNumber(i), maxDatagramSize, true)
+	}
+	require.Equal(t, uint64(20*maxDatagramSize), shadowStats.BytesSent.Load())
+	require.Empty(t, decisions)
+
+	// the shadow grows its window in slow start
+	sender.OnPacketAcked(0, maxDatagramSize, 20*maxDatagramSize, clock.Now())
+	require.Len(t, decisions, 1)
+	require.Equal(t, initialShadowWindow+maxDatagramSize, decisions[0].CongestionWindow)
+	require.True(t, decisions[0].InSlowStart)
+	require.Equal(t, shadow.BandwidthEstimate(), decisions[0].BandwidthEstimate)
+	require.Equal(t, protocol.ByteCount(cwnd), sender.GetCongestionWindow())
+
+	// The shadow calculates the loss rate from its own statistics.
+	// Losing 5% of the bytes sent is tolerated.
+	sender.OnCongestionEvent(1, maxDatagramSize, 19*maxDatagramSize, TrafficClassDefault, false)
+	require.Len(t, decisions, 1)
+	// only the shadow reduces its window
+	sender.OnCongestionEvent(2, 2*maxDatagramSize, 18*maxDatagramSize, TrafficClassDefault, false)
+	require.Len(t, decisions, 2)
+	require.True(t, decisions[1].InRecovery)
+	require.Less(t, decisions[1].CongestionWindow, decisions[0].CongestionWindow)
+	require.Equal(t, protocol.ByteCount(cwnd), sender.GetCongestionWindow())
+	require.False(t, sender.InRecovery())
+	require.Equal(t, uint64(2), shadowStats.PacketsLost.Load())
+	require.Equal(t, uint64(3*maxDatagramSize), shadowStats.BytesLost.Load())
+
+	// events that don't change the state of the shadow are not reported
+	sender.OnFlowControlLimited(true)
+	sender.MaybeExitSlowStart()
+	require.Len(t, decisions, 2)
+}
+
+func TestShadowSenderScalablePrimary(t *testing.T) {
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(50*time.Millisecond, 0)
+	var shadowStats utils.ConnectionStats
+	primary := NewPragueSender(rttStats, maxDatagramSize, nil, nil)
+	shadow := NewFixedSender(rttStats, maxDatagramSize, &Config{FixedWindowPackets: 10})
+	sender := NewShadowSender(primary, shadow, &shadowStats, nil)
+	scalable, ok := sender.(ScalableSender)
+	require.True(t, ok)
+	// ECN feedback reaches the primary
+	scalable.OnECNFeedback(10, 5)
+	require.Equal(t, int64(10), primary.roundECNMarked)
+	require.Equal(t, int64(5), primary.roundCEMarked)
+	sender.OnPacketAcked(1, maxDatagramSize, maxDatagramSize, monotime.Now())
+	require.Equal(t, 10*maxDatagramSize, shadow.GetCongestionWindow())
+}
@@ -0,0 +1,30 @@
+package congestion
+
+// State is the phase a congestion controller is in.
+type State uint8
+
+const (
+	// StateSlowStart is the slow start phase
+	StateSlowStart State = iota
+	// StateCongestionAvoidance is the congestion avoidance phase
+	StateCongestionAvoidance
+	// StateRecovery is the recovery phase, entered after a congestion event
+	StateRecovery
+	// StateApplicationLimited means that the sender is not limited by the congestion window
+	StateApplicationLimited
+)
+
+func (s State) String() string {
+	switch s {
+	case StateSlowStart:
+		return "slow start"
+	case StateCongestionAvoidance:
+		return "congestion avoidance"
+	case StateRecovery:
+		return "recovery"
+	case StateApplicationLimited:
+		return "application limited"
+	default:
+		return "unknown state"
+	}
+}
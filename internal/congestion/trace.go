@@ -0,0 +1,152 @@
+package congestion
+
+import (
+	"sync"
+
+	"github.com/quic-go/quic-go/internal/monotime"
+	"github.com/quic-go/quic-go/internal/protocol"
+)
+
+// A TraceEvent is the kind of event recorded in a Trace.
+type TraceEvent uint8
+
+const (
+	// TraceEventAck is a call to OnPacketAcked.
+	TraceEventAck TraceEvent = iota
+	// TraceEventCongestion is a call to OnCongestionEvent.
+	TraceEventCongestion
+)
+
+// A TraceEntry records the inputs of a single call to OnPacketAcked or OnCongestionEvent,
+// together with the resulting state of the congestion controller.
+type TraceEntry struct {
+	Time         monotime.Time
+	Event        TraceEvent
+	PacketNumber protocol.PacketNumber
+	// Bytes is the number of bytes acknowledged or lost
+	Bytes         protocol.ByteCount
+	PriorInFlight protocol.ByteCount
+	// ReorderingLikely is the hint passed to OnCongestionEvent
+	ReorderingLikely bool
+
+	// the congestion window before and after the event
+	PriorCongestionWindow protocol.ByteCount
+	CongestionWindow      protocol.ByteCount
+	SlowStartThreshold    protocol.ByteCount
+	InSlowStart           bool
+	InRecovery            bool
+}
+
+// Trace keeps track of the most recent decisions of a congestion controller.
+// It is safe for concurrent use.
+type Trace struct {
+	mx      sync.Mutex
+	entries []TraceEntry
+	next    int
+	full    bool
+}
+
+// NewTrace creates a trace that keeps track of the last size events.
+func NewTrace(size int) *Trace {
+	return &Trace{entries: make([]TraceEntry, size)}
+}
+
+// Add records an event, overwriting the oldest entry if the trace is full.
+func (t *Trace) Add(e TraceEntry) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	t.entries[t.next] = e
+	t.next++
+	if t.next == len(t.entries) {
+		t.next = 0
+		t.full = true
+	}
+}
+
+// Snapshot returns a copy of the recorded events, oldest first.
+func (t *Trace) Snapshot() []TraceEntry {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	if !t.full {
+		return append([]TraceEntry(nil), t.entries[:t.next]...)
+	}
+	s := make([]TraceEntry, 0, len(t.entries))
+	s = append(s, t.entries[t.next:]...)
+	return append(s, t.entries[:t.next]...)
+}
+
+// tracingSender records every acknowledgment and congestion event passed to a sender in a Trace.
+// Unlike the qlog events, which are only emitted when the state changes, this allows reconstructing
+// exactly how the congestion window evolved.
+type tracingSender struct {
+	SendAlgorithmWithDebugInfos
+
+	clock Clock
+	trace *Trace
+}
+
+var (
+	_ SendAlgorithm               = &tracingSender{}
+	_ SendAlgorithmWithDebugInfos = &tracingSender{}
+	_ ScalableSender              = &scalableTracingSender{}
+)
+
+// scalableTracingSender is used if the traced sender is a ScalableSender, such that the ECN feedback still reaches it.
+type scalableTracingSender struct {
+	*tracingSender
+}
+
+func (s *scalableTracingSender) OnECNFeedback(ecnMarked, ceMarked int64) {
+	s.SendAlgorithmWithDebugInfos.(ScalableSender).OnECNFeedback(ecnMarked, ceMarked)
+}
+
+// NewTracingSender creates a sender that records the calls to OnPacketAcked and OnCongestionEvent of sender in trace.
+func NewTracingSender(sender SendAlgorithmWithDebugInfos, clock Clock, trace *Trace) SendAlgorithmWithDebugInfos {
+	s := &tracingSender{
+		SendAlgorithmWithDebugInfos: sender,
+		clock:                       clock,
+		trace:                       trace,
+	}
+	if _, ok := sender.(ScalableSender); ok {
+		return &scalableTracingSender{tracingSender: s}
+	}
+	return s
+}
+
+func (s *tracingSender) OnPacketAcked(number protocol.PacketNumber, ackedBytes protocol.ByteCount, priorInFlight protocol.ByteCount, eventTime monotime.Time) {
+	priorCongestionWindow := s.GetCongestionWindow()
+	s.SendAlgorithmWithDebugInfos.OnPacketAcked(number, ackedBytes, priorInFlight, eventTime)
+	s.record(TraceEntry{
+		Time:                  eventTime,
+		Event:                 TraceEventAck,
+		PacketNumber:          number,
+		Bytes:                 ackedBytes,
+		PriorInFlight:         priorInFlight,
+		PriorCongestionWindow: priorCongestionWindow,
+	})
+}
+
+func (s *tracingSender) OnCongestionEvent(number protocol.PacketNumber, lostBytes protocol.ByteCount, priorInFlight protocol.ByteCount, class TrafficClass, reorderingLikely bool) {
+	priorCongestionWindow := s.GetCongestionWindow()
+	s.SendAlgorithmWithDebugInfos.OnCongestionEvent(number, lostBytes, priorInFlight, class, reorderingLikely)
+	s.record(TraceEntry{
+		Time:                  s.clock.Now(),
+		Event:                 TraceEventCongestion,
+		PacketNumber:          number,
+		Bytes:                 lostBytes,
+		PriorInFlight:         priorInFlight,
+		ReorderingLikely:      reorderingLikely,
+		PriorCongestionWindow: priorCongestionWindow,
+	})
+}
+
+// record completes the entry with the state of the sender after the event.
+func (s *tracingSender) record(e TraceEntry) {
+	e.CongestionWindow = s.GetCongestionWindow()
+	e.SlowStartThreshold = s.SlowStartThreshold()
+	e.InSlowStart = s.InSlowStart()
+	e.InRecovery = s.InRecovery()
+	s.trace.Add(e)
+}
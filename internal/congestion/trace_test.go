@@ -0,0 +1,96 @@
+package congestion
+
+import (
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go/internal/protocol"
+	"github.com/quic-go/quic-go/internal/utils"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrace(t *testing.T) {
+	trace := NewTrace(3)
+	require.Empty(t, trace.Snapshot())
+
+	trace.Add(TraceEntry{PacketNumber: 1})
+	trace.Add(TraceEntry{PacketNumber: 2, Event: TraceEventCongestion})
+	require.Equal(t, []TraceEntry{
+		{PacketNumber: 1},
+		{PacketNumber: 2, Event: TraceEventCongestion},
+	}, trace.Snapshot())
+
+	// the oldest entries are overwritten
+	for pn := protocol.PacketNumber(10); pn < 14; pn++ {
+		trace.Add(TraceEntry{PacketNumber: pn})
+	}
+	snapshot := trace.Snapshot()
+	require.Len(t, snapshot, 3)
+	require.Equal(t, protocol.PacketNumber(11), snapshot[0].PacketNumber)
+	require.Equal(t, protocol.PacketNumber(12), snapshot[1].PacketNumber)
+	require.Equal(t, protocol.PacketNumber(13), snapshot[2].PacketNumber)
+}
+
+func TestTracingSender(t *testing.T) {
+	var clock mockClock
+	clock.Advance(time.Second)
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(50*time.Millisecond, 0)
+	cubic := NewCubicSender(&clock, rttStats, nil, maxDatagramSize, true, &Config{MinRatePolicy: MinRatePolicyPackets, MinRatePackets: 2}, nil)
+	trace := NewTrace(10)
+	sender := NewTracingSender(cubic, &clock, trace)
+	_, ok := sender.(ScalableSender)
+	require.False(t, ok)
+
+	initialWindow := sender.GetCongestionWindow()
+	for i := range 10 {
+		sender.OnPacketSent(clock.Now(), protocol.ByteCount(i)*maxDatagramSize, protocol.PacketNumber(i), maxDatagramSize, true)
+	}
+	require.Empty(t, trace.Snapshot())
+
+	ackTime := clock.Now().Add(time.Millisecond)
+	sender.OnPacketAcked(0, maxDatagramSize, initialWindow, ackTime)
+	clock.Advance(2 * time.Millisecond)
+	sender.OnCongestionEvent(1, maxDatagramSize, initialWindow-maxDatagramSize, TrafficClassDefault, true)
+	sender.OnCongestionEvent(2, maxDatagramSize, initialWindow-2*maxDatagramSize, TrafficClassDefault, false)
+
+	snapshot := trace.Snapshot()
+	require.Len(t, snapshot, 3)
+	require.Equal(t, TraceEntry{
+		Time:                  ackTime,
+		Event:                 TraceEventAck,
+		PacketNumber:          0,
+		Bytes:                 maxDatagramSize,
+		PriorInFlight:         initialWindow,
+		PriorCongestionWindow: initialWindow,
+		CongestionWindow:      initialWindow + maxDatagramSize,
+		SlowStartThreshold:    protocol.MaxByteCount,
+		InSlowStart:           true,
+	}, snapshot[0])
+	// the loss likely caused by reordering doesn't reduce the window
+	require.Equal(t, TraceEventCongestion, snapshot[1].Event)
+	require.Equal(t, clock.Now(), snapshot[1].Time)
+	require.True(t, snapshot[1].ReorderingLikely)
+	require.Equal(t, snapshot[1].PriorCongestionWindow, snapshot[1].CongestionWindow)
+	// the other loss does
+	require.Equal(t, protocol.PacketNumber(2), snapshot[2].PacketNumber)
+	require.Equal(t, initialWindow-2*maxDatagramSize, snapshot[2].PriorInFlight)
+	require.Equal(t, initialWindow+maxDatagramSize, snapshot[2].PriorCongestionWindow)
+	require.Less(t, snapshot[2].CongestionWindow, snapshot[2].PriorCongestionWindow)
+	require.Equal(t, snapshot[2].CongestionWindow, snapshot[2].SlowStartThreshold)
+	require.True(t, snapshot[2].InRecovery)
+	require.False(t, snapshot[2].InSlowStart)
+}
+
+func TestTracingSenderScalable(t *testing.T) {
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(50*time.Millisecond, 0)
+	prague := NewPragueSender(rttStats, maxDatagramSize, nil, nil)
+	sender := NewTracingSender(prague, DefaultClock{}, NewTrace(10))
+	scalable, ok := sender.(ScalableSender)
+	require.True(t, ok)
+	scalable.OnECNFeedback(10, 5)
+	require.Equal(t, int64(10), prague.roundECNMarked)
+	require.Equal(t, int64(5), prague.roundCEMarked)
+}
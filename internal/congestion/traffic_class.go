@@ -0,0 +1,27 @@
+package congestion
+
+// A TrafficClass classifies the data carried in a packet.
+// It allows the congestion controller to take into account which kind of data was affected by a congestion event.
+type TrafficClass uint8
+
+const (
+	// TrafficClassDefault is used for data that wasn't classified
+	TrafficClassDefault TrafficClass = iota
+	// TrafficClassControl is latency-sensitive control data
+	TrafficClassControl
+	// TrafficClassBulk is bulk data, for which throughput matters more than latency
+	TrafficClassBulk
+)
+
+func (c TrafficClass) String() string {
+	switch c {
+	case TrafficClassDefault:
+		return "default"
+	case TrafficClassControl:
+		return "control"
+	case TrafficClassBulk:
+		return "bulk"
+	default:
+		return "unknown traffic class"
+	}
+}
@@ -0,0 +1,328 @@
+package congestion
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/quic-go/quic-go/internal/monotime"
+	"github.com/quic-go/quic-go/internal/protocol"
+	"github.com/quic-go/quic-go/internal/utils"
+	"github.com/quic-go/quic-go/qlog"
+	"github.com/quic-go/quic-go/qlogwriter"
+)
+
+const (
+	// Once per round trip, Vegas estimates the number of packets queued in the network.
+	// It grows the window if fewer than vegasAlpha packets are queued,
+	// and shrinks it if more than vegasBeta packets are queued.
+	vegasAlpha = 2
+	vegasBeta  = 4
+	// Slow start is left as soon as more than vegasGamma packets are queued.
+	vegasGamma = 1
+)
+
+// vegasSender implements TCP Vegas.
+// It is a delay-based congestion controller: it compares the expected throughput (based on the minimum RTT)
+// to the actual throughput, and keeps the number of packets queued in the network between vegasAlpha and vegasBeta.
+// This keeps queues short, at the cost of losing out against loss-based congestion controllers on shared bottlenecks.
+type vegasSender struct {
+	rttStats *utils.RTTStats
+	pacer    *pacer
+
+	largestSentPacketNumber  protocol.PacketNumber
+	largestAckedPacketNumber protocol.PacketNumber
+	largestSentAtLastCutback protocol.PacketNumber
+
+	// the round ends once a packet sent after the start of the round is acknowledged
+	endOfRound protocol.PacketNumber
+	// the minimum RTT sample taken during the current round
+	roundMinRTT time.Duration
+
+	congestionWindow   protocol.ByteCount
+	slowStartThreshold protocol.ByteCount
+
+	initialCongestionWindow protocol.ByteCount
+	initialMaxDatagramSize  protocol.ByteCount
+	maxDatagramSize         protocol.ByteCount
+	maxDatagramSizeCeiling  protocol.ByteCount
+
+	onCongestionWindowChange func(old, new protocol.ByteCount)
+
+	// while probing for bandwidth, the congestion window grows even if the sender is not cwnd-limited
+	probeUntil monotime.Time
+
+	lastState qlog.CongestionState
+	qlogger   qlogwriter.Recorder
+	logger    *slog.Logger
+}
+
+var (
+	_ SendAlgorithm               = &vegasSender{}
+	_ SendAlgorithmWithDebugInfos = &vegasSender{}
+)
+
+// NewVegasSender creates a new Vegas sender.
+func NewVegasSender(rttStats *utils.RTTStats, initialMaxDatagramSize protocol.ByteCount, conf *Config, qlogger qlogwriter.Recorder) *vegasSender {
+	if conf == nil {
+		conf = &Config{}
+	}
+	initialMaxDatagramSize = min(initialMaxDatagramSize, conf.maxDatagramSizeCeiling())
+	v := &vegasSender{
+		rttStats:                 rttStats,
+		largestSentPacketNumber:  protocol.InvalidPacketNumber,
+		largestAckedPacketNumber: protocol.InvalidPacketNumber,
+		largestSentAtLastCutback: protocol.InvalidPacketNumber,
+		endOfRound:               protocol.InvalidPacketNumber,
+		initialCongestionWindow:  conf.initialCongestionWindow(initialMaxDatagramSize),
+		congestionWindow:         conf.initialCongestionWindow(initialMaxDatagramSize),
+		slowStartThreshold:       protocol.MaxByteCount,
+		initialMaxDatagramSize:   initialMaxDatagramSize,
+		maxDatagramSize:          initialMaxDatagramSize,
+		maxDatagramSizeCeiling:   conf.maxDatagramSizeCeiling(),
+		onCongestionWindowChange: conf.OnCongestionWindowChange,
+		qlogger:                  qlogger,
+		logger:                   conf.Logger,
+	}
+	v.pacer = newPacer(v.BandwidthEstimate)
+	v.pacer.SetMaxBandwidth(conf.MaxPacingRate)
+	v.pacer.SetMaxBurst(conf.MaxPacingBurst)
+	v.pacer.SetDisabled(conf.DisablePacing)
+	v.pacer.SetMaxDatagramSize(initialMaxDatagramSize)
+	v.lastState = qlog.CongestionStateSlowStart
+	if v.qlogger != nil {
+		v.qlogger.RecordEvent(qlog.CongestionStateUpdated{State: qlog.CongestionStateSlowStart})
+	}
+	return v
+}
+
+func (v *vegasSender) TimeUntilSend(_ protocol.ByteCount) monotime.Time {
+	return v.pacer.TimeUntilSend()
+}
+
+func (v *vegasSender) PacingBudget(now monotime.Time) protocol.ByteCount {
+	return v.pacer.Budget(now)
+}
+func (v *vegasSender) HasPacingBudget(now monotime.Time) bool {
+	return v.pacer.Budget(now) >= v.maxDatagramSize
+}
+
+func (v *vegasSender) maxCongestionWindow() protocol.ByteCount {
+	return v.maxDatagramSize * protocol.MaxCongestionWindowPackets
+}
+
+func (v *vegasSender) minCongestionWindow() protocol.ByteCount {
+	return v.maxDatagramSize * minCongestionWindowPackets
+}
+
+func (v *vegasSender) OnPacketSent(sentTime monotime.Time, _ protocol.ByteCount, packetNumber protocol.PacketNumber, bytes protocol.ByteCount, isRetransmittable bool) {
+	v.pacer.SentPacket(sentTime, bytes)
+	if !isRetransmittable {
+		return
+	}
+	v.largestSentPacketNumber = packetNumber
+}
+
+func (v *vegasSender) CanSend(bytesInFlight protocol.ByteCount) bool {
+	return bytesInFlight < v.GetCongestionWindow()
+}
+
+func (v *vegasSender) InRecovery() bool {
+	return v.largestAckedPacketNumber != protocol.InvalidPacketNumber && v.largestAckedPacketNumber <= v.largestSentAtLastCutback
+}
+
+func (v *vegasSender) InSlowStart() bool                       { return v.GetCongestionWindow() < v.slowStartThreshold }
+func (v *vegasSender) GetCongestionWindow() protocol.ByteCount { return v.congestionWindow }
+func (v *vegasSender) SlowStartThreshold() protocol.ByteCount  { return v.slowStartThreshold }
+
+// MaybeExitSlowStart is a no-op: Vegas leaves slow start based on the queueing delay, see OnPacketAcked.
+func (v *vegasSender) MaybeExitSlowStart() {}
+
+func (v *vegasSender) OnPacketAcked(ackedPacketNumber protocol.PacketNumber, ackedBytes protocol.ByteCount, priorInFlight protocol.ByteCount, eventTime monotime.Time) {
+	v.largestAckedPacketNumber = max(ackedPacketNumber, v.largestAckedPacketNumber)
+	if latestRTT := v.rttStats.LatestRTT(); latestRTT > 0 && (v.roundMinRTT == 0 || latestRTT < v.roundMinRTT) {
+		v.roundMinRTT = latestRTT
+	}
+	if v.InRecovery() {
+		return
+	}
+	if !v.isCwndLimited(priorInFlight) && !eventTime.Before(v.probeUntil) {
+		v.maybeQlogStateChange(qlog.CongestionStateApplicationLimited)
+		return
+	}
+
+	oldCongestionWindow := v.congestionWindow
+	defer v.maybeNotifyCongestionWindowChange(oldCongestionWindow)
+
+	if v.InSlowStart() {
+		v.maybeQlogStateChange(qlog.CongestionStateSlowStart)
+		v.congestionWindow = min(v.congestionWindow+ackedBytes, v.maxCongestionWindow())
+	}
+	if ackedPacketNumber <= v.endOfRound {
+		return
+	}
+	// A round trip has passed. Adjust the window based on the RTT samples taken during that round.
+	v.endOfRound = v.largestSentPacketNumber
+	diff, ok := v.queuedPackets()
+	v.roundMinRTT = 0
+	if !ok {
+		return
+	}
+	if v.InSlowStart() {
+		if diff > vegasGamma {
+			v.slowStartThreshold = v.congestionWindow
+		}
+		return
+	}
+	v.maybeQlogStateChange(qlog.CongestionStateCongestionAvoidance)
+	switch {
+	case diff < vegasAlpha:
+		v.congestionWindow = min(v.congestionWindow+v.maxDatagramSize, v.maxCongestionWindow())
+	case diff > vegasBeta:
+		v.congestionWindow = max(v.congestionWindow-v.maxDatagramSize, v.minCongestionWindow())
+	}
+}
+
+// queuedPackets estimates the number of packets queued in the network,
+// as the difference between the expected and the actual throughput, multiplied by the base RTT:
+//
+//	diff = (cwnd/baseRTT - cwnd/rtt) * baseRTT
+//
+// It returns false if no RTT samples are available.
+func (v *vegasSender) queuedPackets() (float64, bool) {
+	baseRTT := v.rttStats.MinRTT()
+	rtt := v.roundMinRTT
+	if baseRTT == 0 || rtt == 0 {
+		return 0, false
+	}
+	cwnd := float64(v.congestionWindow) / float64(v.maxDatagramSize)
+	expected := cwnd / baseRTT.Seconds()
+	actual := cwnd / rtt.Seconds()
+	return (expected - actual) * baseRTT.Seconds(), true
+}
+
+func (v *vegasSender) OnCongestionEvent(packetNumber protocol.PacketNumber, lostBytes, _ protocol.ByteCount, _ TrafficClass, _ bool) {
+	// only react once per round trip
+	if packetNumber <= v.largestSentAtLastCutback {
+		return
+	}
+	v.maybeQlogStateChange(qlog.CongestionStateRecovery)
+
+	oldCongestionWindow := v.congestionWindow
+	v.congestionWindow = max(protocol.ByteCount(float64(v.congestionWindow)*renoBeta), v.minCongestionWindow())
+	v.slowStartThreshold = v.congestionWindow
+	v.largestSentAtLastCutback = v.largestSentPacketNumber
+	logWindowReduction(v.logger, packetNumber, oldCongestionWindow, v.congestionWindow)
+	v.maybeNotifyCongestionWindowChange(oldCongestionWindow)
+}
+
+func (v *vegasSender) OnRetransmissionTimeout(packetsRetransmitted bool) {
+	v.largestSentAtLastCutback = protocol.InvalidPacketNumber
+	if !packetsRetransmitted {
+		return
+	}
+	oldCongestionWindow := v.congestionWindow
+	v.slowStartThreshold = max(v.congestionWindow/2, v.minCongestionWindow())
+	v.congestionWindow = v.minCongestionWindow()
+	v.maybeNotifyCongestionWindowChange(oldCongestionWindow)
+}
+
+// OnPersistentCongestion collapses the congestion window to the minimum congestion window.
+func (v *vegasSender) OnPersistentCongestion() {
+	oldCongestionWindow := v.congestionWindow
+	v.slowStartThreshold = max(v.congestionWindow/2, v.minCongestionWindow())
+	v.congestionWindow = v.minCongestionWindow()
+	v.largestSentAtLastCutback = v.largestSentPacketNumber
+	v.maybeNotifyCongestionWindowChange(oldCongestionWindow)
+}
+
+// OnSpuriousLoss is a no-op: Vegas primarily reacts to queuing delay,
+// and quickly regrows the window after a loss if the RTT doesn't increase.
+func (v *vegasSender) OnSpuriousLoss(protocol.PacketNumber) {}
+
+func (v *vegasSender) OnFlowControlLimited(bool) {}
+
+func (v *vegasSender) ProbeBandwidth(until monotime.Time) {
+	v.probeUntil = until
+}
+
+func (v *vegasSender) OnConnectionMigration() {
+	v.probeUntil = 0
+	v.largestSentPacketNumber = protocol.InvalidPacketNumber
+	v.largestAckedPacketNumber = protocol.InvalidPacketNumber
+	v.largestSentAtLastCutback = protocol.InvalidPacketNumber
+	v.endOfRound = protocol.InvalidPacketNumber
+	v.roundMinRTT = 0
+	v.congestionWindow = v.initialCongestionWindow
+	v.slowStartThreshold = protocol.MaxByteCount
+	v.maxDatagramSize = v.initialMaxDatagramSize
+	v.pacer.SetMaxDatagramSize(v.initialMaxDatagramSize)
+}
+
+// State returns the phase the sender is currently in.
+func (v *vegasSender) State(bytesInFlight protocol.ByteCount) State {
+	switch {
+	case v.InRecovery():
+		return StateRecovery
+	case !v.isCwndLimited(bytesInFlight):
+		return StateApplicationLimited
+	case v.InSlowStart():
+		return StateSlowStart
+	default:
+		return StateCongestionAvoidance
+	}
+}
+
+func (v *vegasSender) isCwndLimited(bytesInFlight protocol.ByteCount) bool {
+	congestionWindow := v.GetCongestionWindow()
+	if bytesInFlight >= congestionWindow {
+		return true
+	}
+	availableBytes := congestionWindow - bytesInFlight
+	slowStartLimited := v.InSlowStart() && bytesInFlight > congestionWindow/2
+	return slowStartLimited || availableBytes <= maxBurstPackets*v.maxDatagramSize
+}
+
+// BandwidthEstimate returns the rate used for pacing, derived from the congestion window.
+func (v *vegasSender) BandwidthEstimate() Bandwidth {
+	srtt := v.rttStats.SmoothedRTT()
+	if srtt == 0 {
+		srtt = v.rttStats.InitialRTT()
+	}
+	return BandwidthFromDelta(v.GetCongestionWindow(), srtt)
+}
+
+func (v *vegasSender) EstimatedSendTime(bytes protocol.ByteCount) time.Duration {
+	return SendTime(bytes, v.BandwidthEstimate())
+}
+
+func (v *vegasSender) maybeNotifyCongestionWindowChange(old protocol.ByteCount) {
+	if v.onCongestionWindowChange == nil || old == v.congestionWindow {
+		return
+	}
+	v.onCongestionWindowChange(old, v.congestionWindow)
+}
+
+func (v *vegasSender) maybeQlogStateChange(new qlog.CongestionState) {
+	if new == v.lastState {
+		return
+	}
+	if v.qlogger != nil {
+		v.qlogger.RecordEvent(qlog.CongestionStateUpdated{State: new})
+	}
+	logStateChange(v.logger, new)
+	v.lastState = new
+}
+
+func (v *vegasSender) SetMaxDatagramSize(s protocol.ByteCount) {
+	if s < v.maxDatagramSize {
+		panic(fmt.Sprintf("congestion BUG: decreased max datagram size from %d to %d", v.maxDatagramSize, s))
+	}
+	s = min(s, v.maxDatagramSizeCeiling)
+	cwndIsMinCwnd := v.congestionWindow == v.minCongestionWindow()
+	v.maxDatagramSize = s
+	if cwndIsMinCwnd {
+		v.congestionWindow = v.minCongestionWindow()
+	}
+	v.pacer.SetMaxDatagramSize(s)
+}
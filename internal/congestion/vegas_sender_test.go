@@ -0,0 +1,103 @@
+package congestion
+
+import (
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go/internal/monotime"
+	"github.com/quic-go/quic-go/internal/protocol"
+	"github.com/quic-go/quic-go/internal/utils"
+
+	"github.com/stretchr/testify/require"
+)
+
+const vegasBaseRTT = 100 * time.Millisecond
+
+type testVegasSender struct {
+	sender   *vegasSender
+	rttStats *utils.RTTStats
+	pn       protocol.PacketNumber
+}
+
+func newTestVegasSender() *testVegasSender {
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(vegasBaseRTT, 0)
+	return &testVegasSender{
+		sender:   NewVegasSender(rttStats, maxDatagramSize, nil, nil),
+		rttStats: rttStats,
+	}
+}
+
+// SendAndAckRound sends a full congestion window and acknowledges it, with every packet experiencing the given RTT.
+func (s *testVegasSender) SendAndAckRound(rtt time.Duration) {
+	now := monotime.Now()
+	cwnd := s.sender.GetCongestionWindow()
+	first := s.pn
+	for bytesInFlight := protocol.ByteCount(0); bytesInFlight < cwnd; bytesInFlight += maxDatagramSize {
+		s.sender.OnPacketSent(now, bytesInFlight, s.pn, maxDatagramSize, true)
+		s.pn++
+	}
+	for pn := first; pn < s.pn; pn++ {
+		s.rttStats.UpdateRTT(rtt, 0)
+		s.sender.OnPacketAcked(pn, maxDatagramSize, cwnd, now)
+	}
+}
+
+func TestVegasSenderSlowStart(t *testing.T) {
+	s := newTestVegasSender()
+	require.True(t, s.sender.InSlowStart())
+	cwnd := s.sender.GetCongestionWindow()
+
+	// no queueing delay: the window doubles every round
+	s.SendAndAckRound(vegasBaseRTT)
+	require.Equal(t, 2*cwnd, s.sender.GetCongestionWindow())
+	require.True(t, s.sender.InSlowStart())
+
+	// Packets are queued: slow start is left.
+	// The window is adjusted once per round trip, so it takes another round for the RTT increase to be detected.
+	s.SendAndAckRound(3 * vegasBaseRTT / 2)
+	s.SendAndAckRound(3 * vegasBaseRTT / 2)
+	require.False(t, s.sender.InSlowStart())
+	require.Equal(t, s.sender.GetCongestionWindow(), s.sender.SlowStartThreshold())
+}
+
+func TestVegasSenderCongestionAvoidance(t *testing.T) {
+	s := newTestVegasSender()
+	s.SendAndAckRound(2 * vegasBaseRTT)
+	require.False(t, s.sender.InSlowStart())
+
+	// no queueing delay: the window grows by one packet per round
+	s.SendAndAckRound(vegasBaseRTT)
+	cwnd := s.sender.GetCongestionWindow()
+	s.SendAndAckRound(vegasBaseRTT)
+	s.SendAndAckRound(vegasBaseRTT)
+	require.Equal(t, cwnd+2*maxDatagramSize, s.sender.GetCongestionWindow())
+
+	// 3 packets queued: the window stays the same
+	cwndPackets := float64(s.sender.GetCongestionWindow()/maxDatagramSize) + 1
+	rtt := time.Duration(float64(vegasBaseRTT) * cwndPackets / (cwndPackets - 3))
+	s.SendAndAckRound(rtt)
+	cwnd = s.sender.GetCongestionWindow()
+	s.SendAndAckRound(rtt)
+	s.SendAndAckRound(rtt)
+	require.Equal(t, cwnd, s.sender.GetCongestionWindow())
+
+	// lots of packets queued: the window shrinks by one packet per round
+	s.SendAndAckRound(2 * vegasBaseRTT)
+	cwnd = s.sender.GetCongestionWindow()
+	s.SendAndAckRound(2 * vegasBaseRTT)
+	s.SendAndAckRound(2 * vegasBaseRTT)
+	require.Equal(t, cwnd-2*maxDatagramSize, s.sender.GetCongestionWindow())
+}
+
+func TestVegasSenderLoss(t *testing.T) {
+	s := newTestVegasSender()
+	s.SendAndAckRound(vegasBaseRTT)
+	cwnd := s.sender.GetCongestionWindow()
+	s.sender.OnCongestionEvent(s.pn-1, maxDatagramSize, cwnd, TrafficClassDefault, false)
+	require.Equal(t, protocol.ByteCount(float64(cwnd)*renoBeta), s.sender.GetCongestionWindow())
+	require.True(t, s.sender.InRecovery())
+	// only one reduction per round trip
+	s.sender.OnCongestionEvent(s.pn-2, maxDatagramSize, cwnd, TrafficClassDefault, false)
+	require.Equal(t, protocol.ByteCount(float64(cwnd)*renoBeta), s.sender.GetCongestionWindow())
+}
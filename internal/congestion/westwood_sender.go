@@ -0,0 +1,303 @@
+package congestion
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/quic-go/quic-go/internal/monotime"
+	"github.com/quic-go/quic-go/internal/protocol"
+	"github.com/quic-go/quic-go/internal/utils"
+	"github.com/quic-go/quic-go/qlog"
+	"github.com/quic-go/quic-go/qlogwriter"
+)
+
+// westwoodSender implements TCP Westwood+.
+// It grows the congestion window like Reno, but on a congestion event, it sets the slow start threshold
+// to the bandwidth-delay product derived from the measured delivery rate, instead of halving the window.
+// This makes it well suited for links where loss is often not caused by congestion (e.g. wireless links).
+type westwoodSender struct {
+	rttStats *utils.RTTStats
+	sampler  BandwidthSampler
+	pacer    *pacer
+
+	largestSentPacketNumber  protocol.PacketNumber
+	largestAckedPacketNumber protocol.PacketNumber
+	largestSentAtLastCutback protocol.PacketNumber
+
+	congestionWindow   protocol.ByteCount
+	slowStartThreshold protocol.ByteCount
+	// the number of bytes acknowledged since the last window increase in congestion avoidance
+	numAckedBytes protocol.ByteCount
+
+	initialCongestionWindow protocol.ByteCount
+	initialMaxDatagramSize  protocol.ByteCount
+	maxDatagramSize         protocol.ByteCount
+	maxDatagramSizeCeiling  protocol.ByteCount
+
+	onCongestionWindowChange func(old, new protocol.ByteCount)
+
+	// while probing for bandwidth, the congestion window grows even if the sender is not cwnd-limited
+	probeUntil monotime.Time
+
+	lastState qlog.CongestionState
+	qlogger   qlogwriter.Recorder
+	logger    *slog.Logger
+}
+
+var (
+	_ SendAlgorithm               = &westwoodSender{}
+	_ SendAlgorithmWithDebugInfos = &westwoodSender{}
+)
+
+// NewWestwoodSender creates a new Westwood+ sender.
+func NewWestwoodSender(rttStats *utils.RTTStats, initialMaxDatagramSize protocol.ByteCount, conf *Config, qlogger qlogwriter.Recorder) *westwoodSender {
+	if conf == nil {
+		conf = &Config{}
+	}
+	initialMaxDatagramSize = min(initialMaxDatagramSize, conf.maxDatagramSizeCeiling())
+	w := &westwoodSender{
+		rttStats:                 rttStats,
+		largestSentPacketNumber:  protocol.InvalidPacketNumber,
+		largestAckedPacketNumber: protocol.InvalidPacketNumber,
+		largestSentAtLastCutback: protocol.InvalidPacketNumber,
+		initialCongestionWindow:  conf.initialCongestionWindow(initialMaxDatagramSize),
+		congestionWindow:         conf.initialCongestionWindow(initialMaxDatagramSize),
+		slowStartThreshold:       protocol.MaxByteCount,
+		initialMaxDatagramSize:   initialMaxDatagramSize,
+		maxDatagramSize:          initialMaxDatagramSize,
+		maxDatagramSizeCeiling:   conf.maxDatagramSizeCeiling(),
+		onCongestionWindowChange: conf.OnCongestionWindowChange,
+		qlogger:                  qlogger,
+		logger:                   conf.Logger,
+	}
+	w.pacer = newPacer(w.BandwidthEstimate)
+	w.pacer.SetMaxBandwidth(conf.MaxPacingRate)
+	w.pacer.SetMaxBurst(conf.MaxPacingBurst)
+	w.pacer.SetDisabled(conf.DisablePacing)
+	w.pacer.SetMaxDatagramSize(initialMaxDatagramSize)
+	w.lastState = qlog.CongestionStateSlowStart
+	if w.qlogger != nil {
+		w.qlogger.RecordEvent(qlog.CongestionStateUpdated{State: qlog.CongestionStateSlowStart})
+	}
+	return w
+}
+
+func (w *westwoodSender) TimeUntilSend(_ protocol.ByteCount) monotime.Time {
+	return w.pacer.TimeUntilSend()
+}
+
+func (w *westwoodSender) PacingBudget(now monotime.Time) protocol.ByteCount {
+	return w.pacer.Budget(now)
+}
+func (w *westwoodSender) HasPacingBudget(now monotime.Time) bool {
+	return w.pacer.Budget(now) >= w.maxDatagramSize
+}
+
+func (w *westwoodSender) maxCongestionWindow() protocol.ByteCount {
+	return w.maxDatagramSize * protocol.MaxCongestionWindowPackets
+}
+
+func (w *westwoodSender) minCongestionWindow() protocol.ByteCount {
+	return w.maxDatagramSize * minCongestionWindowPackets
+}
+
+func (w *westwoodSender) OnPacketSent(sentTime monotime.Time, _ protocol.ByteCount, packetNumber protocol.PacketNumber, bytes protocol.ByteCount, isRetransmittable bool) {
+	w.pacer.SentPacket(sentTime, bytes)
+	if !isRetransmittable {
+		return
+	}
+	w.largestSentPacketNumber = packetNumber
+}
+
+func (w *westwoodSender) CanSend(bytesInFlight protocol.ByteCount) bool {
+	return bytesInFlight < w.GetCongestionWindow()
+}
+
+func (w *westwoodSender) InRecovery() bool {
+	return w.largestAckedPacketNumber != protocol.InvalidPacketNumber && w.largestAckedPacketNumber <= w.largestSentAtLastCutback
+}
+
+func (w *westwoodSender) InSlowStart() bool                       { return w.GetCongestionWindow() < w.slowStartThreshold }
+func (w *westwoodSender) GetCongestionWindow() protocol.ByteCount { return w.congestionWindow }
+func (w *westwoodSender) SlowStartThreshold() protocol.ByteCount  { return w.slowStartThreshold }
+
+// MaybeExitSlowStart is a no-op: Westwood+ only leaves slow start on a congestion event.
+func (w *westwoodSender) MaybeExitSlowStart() {}
+
+func (w *westwoodSender) OnPacketAcked(ackedPacketNumber protocol.PacketNumber, ackedBytes protocol.ByteCount, priorInFlight protocol.ByteCount, eventTime monotime.Time) {
+	w.largestAckedPacketNumber = max(ackedPacketNumber, w.largestAckedPacketNumber)
+	w.sampler.OnPacketAcked(ackedBytes, eventTime, w.rttStats.SmoothedRTT())
+	if w.InRecovery() {
+		return
+	}
+	if !w.isCwndLimited(priorInFlight) && !eventTime.Before(w.probeUntil) {
+		w.maybeQlogStateChange(qlog.CongestionStateApplicationLimited)
+		return
+	}
+	if w.congestionWindow >= w.maxCongestionWindow() {
+		return
+	}
+	oldCongestionWindow := w.congestionWindow
+	if w.InSlowStart() {
+		w.congestionWindow += w.maxDatagramSize
+		w.maybeQlogStateChange(qlog.CongestionStateSlowStart)
+	} else {
+		w.maybeQlogStateChange(qlog.CongestionStateCongestionAvoidance)
+		// increase the window by one packet per congestion window acknowledged
+		w.numAckedBytes += ackedBytes
+		if w.numAckedBytes >= w.congestionWindow {
+			w.numAckedBytes -= w.congestionWindow
+			w.congestionWindow += w.maxDatagramSize
+		}
+	}
+	w.maybeNotifyCongestionWindowChange(oldCongestionWindow)
+}
+
+func (w *westwoodSender) OnCongestionEvent(packetNumber protocol.PacketNumber, lostBytes, _ protocol.ByteCount, _ TrafficClass, _ bool) {
+	// only react once per round trip
+	if packetNumber <= w.largestSentAtLastCutback {
+		return
+	}
+	w.maybeQlogStateChange(qlog.CongestionStateRecovery)
+
+	oldCongestionWindow := w.congestionWindow
+	w.slowStartThreshold = w.estimatedBDP()
+	if w.slowStartThreshold == 0 {
+		// no bandwidth estimate available yet, fall back to Reno
+		w.slowStartThreshold = max(protocol.ByteCount(float64(w.congestionWindow)*renoBeta), w.minCongestionWindow())
+	}
+	w.congestionWindow = min(w.congestionWindow, w.slowStartThreshold)
+	w.largestSentAtLastCutback = w.largestSentPacketNumber
+	w.numAckedBytes = 0
+	logWindowReduction(w.logger, packetNumber, oldCongestionWindow, w.congestionWindow)
+	w.maybeNotifyCongestionWindowChange(oldCongestionWindow)
+}
+
+// estimatedBDP returns the bandwidth-delay product, calculated from the bandwidth estimate and the minimum RTT.
+// It returns 0 if no bandwidth estimate is available yet.
+func (w *westwoodSender) estimatedBDP() protocol.ByteCount {
+	bw := w.sampler.BandwidthEstimate()
+	if bw == 0 {
+		return 0
+	}
+	bdp := protocol.ByteCount(float64(bw/BytesPerSecond) * w.rttStats.MinRTT().Seconds())
+	return max(bdp, w.minCongestionWindow())
+}
+
+func (w *westwoodSender) OnRetransmissionTimeout(packetsRetransmitted bool) {
+	w.largestSentAtLastCutback = protocol.InvalidPacketNumber
+	if !packetsRetransmitted {
+		return
+	}
+	oldCongestionWindow := w.congestionWindow
+	w.slowStartThreshold = w.estimatedBDP()
+	if w.slowStartThreshold == 0 {
+		w.slowStartThreshold = max(w.congestionWindow/2, w.minCongestionWindow())
+	}
+	w.congestionWindow = w.minCongestionWindow()
+	w.numAckedBytes = 0
+	w.maybeNotifyCongestionWindowChange(oldCongestionWindow)
+}
+
+// OnPersistentCongestion collapses the congestion window to the minimum congestion window.
+func (w *westwoodSender) OnPersistentCongestion() {
+	oldCongestionWindow := w.congestionWindow
+	w.slowStartThreshold = max(w.estimatedBDP(), w.minCongestionWindow())
+	w.congestionWindow = w.minCongestionWindow()
+	w.largestSentAtLastCutback = w.largestSentPacketNumber
+	w.numAckedBytes = 0
+	w.maybeNotifyCongestionWindowChange(oldCongestionWindow)
+}
+
+// OnSpuriousLoss is a no-op: after a loss, the window is set to the estimated bandwidth-delay product,
+// which is a good estimate regardless of whether the loss was spurious.
+func (w *westwoodSender) OnSpuriousLoss(protocol.PacketNumber) {}
+
+func (w *westwoodSender) OnFlowControlLimited(bool) {}
+
+func (w *westwoodSender) ProbeBandwidth(until monotime.Time) {
+	w.probeUntil = until
+}
+
+func (w *westwoodSender) OnConnectionMigration() {
+	w.probeUntil = 0
+	w.largestSentPacketNumber = protocol.InvalidPacketNumber
+	w.largestAckedPacketNumber = protocol.InvalidPacketNumber
+	w.largestSentAtLastCutback = protocol.InvalidPacketNumber
+	w.sampler.Reset()
+	w.numAckedBytes = 0
+	w.congestionWindow = w.initialCongestionWindow
+	w.slowStartThreshold = protocol.MaxByteCount
+	w.maxDatagramSize = w.initialMaxDatagramSize
+	w.pacer.SetMaxDatagramSize(w.initialMaxDatagramSize)
+}
+
+// State returns the phase the sender is currently in.
+func (w *westwoodSender) State(bytesInFlight protocol.ByteCount) State {
+	switch {
+	case w.InRecovery():
+		return StateRecovery
+	case !w.isCwndLimited(bytesInFlight):
+		return StateApplicationLimited
+	case w.InSlowStart():
+		return StateSlowStart
+	default:
+		return StateCongestionAvoidance
+	}
+}
+
+func (w *westwoodSender) isCwndLimited(bytesInFlight protocol.ByteCount) bool {
+	congestionWindow := w.GetCongestionWindow()
+	if bytesInFlight >= congestionWindow {
+		return true
+	}
+	availableBytes := congestionWindow - bytesInFlight
+	slowStartLimited := w.InSlowStart() && bytesInFlight > congestionWindow/2
+	return slowStartLimited || availableBytes <= maxBurstPackets*w.maxDatagramSize
+}
+
+// BandwidthEstimate returns the rate used for pacing.
+// Like for the cubicSender, it is derived from the congestion window, not from the measured delivery rate.
+func (w *westwoodSender) BandwidthEstimate() Bandwidth {
+	srtt := w.rttStats.SmoothedRTT()
+	if srtt == 0 {
+		srtt = w.rttStats.InitialRTT()
+	}
+	return BandwidthFromDelta(w.GetCongestionWindow(), srtt)
+}
+
+func (w *westwoodSender) EstimatedSendTime(bytes protocol.ByteCount) time.Duration {
+	return SendTime(bytes, w.BandwidthEstimate())
+}
+
+func (w *westwoodSender) maybeNotifyCongestionWindowChange(old protocol.ByteCount) {
+	if w.onCongestionWindowChange == nil || old == w.congestionWindow {
+		return
+	}
+	w.onCongestionWindowChange(old, w.congestionWindow)
+}
+
+func (w *westwoodSender) maybeQlogStateChange(new qlog.CongestionState) {
+	if new == w.lastState {
+		return
+	}
+	if w.qlogger != nil {
+		w.qlogger.RecordEvent(qlog.CongestionStateUpdated{State: new})
+	}
+	logStateChange(w.logger, new)
+	w.lastState = new
+}
+
+func (w *westwoodSender) SetMaxDatagramSize(s protocol.ByteCount) {
+	if s < w.maxDatagramSize {
+		panic(fmt.Sprintf("congestion BUG: decreased max datagram size from %d to %d", w.maxDatagramSize, s))
+	}
+	s = min(s, w.maxDatagramSizeCeiling)
+	cwndIsMinCwnd := w.congestionWindow == w.minCongestionWindow()
+	w.maxDatagramSize = s
+	if cwndIsMinCwnd {
+		w.congestionWindow = w.minCongestionWindow()
+	}
+	w.pacer.SetMaxDatagramSize(s)
+}
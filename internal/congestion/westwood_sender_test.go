@@ -0,0 +1,72 @@
+package congestion
+
+import (
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go/internal/monotime"
+	"github.com/quic-go/quic-go/internal/protocol"
+	"github.com/quic-go/quic-go/internal/utils"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWestwoodSenderSlowStart(t *testing.T) {
+	rttStats := utils.NewRTTStats()
+	sender := NewWestwoodSender(rttStats, maxDatagramSize, nil, nil)
+	cwnd := sender.GetCongestionWindow()
+	require.Equal(t, initialCongestionWindow*maxDatagramSize, cwnd)
+	require.True(t, sender.InSlowStart())
+
+	for i := range 10 {
+		sender.OnPacketSent(monotime.Now(), 0, protocol.PacketNumber(i), maxDatagramSize, true)
+	}
+	for i := range 10 {
+		sender.OnPacketAcked(protocol.PacketNumber(i), maxDatagramSize, sender.GetCongestionWindow(), monotime.Now())
+	}
+	require.Equal(t, cwnd+10*maxDatagramSize, sender.GetCongestionWindow())
+}
+
+func TestWestwoodSenderLossWithoutBandwidthEstimate(t *testing.T) {
+	sender := NewWestwoodSender(utils.NewRTTStats(), maxDatagramSize, nil, nil)
+	cwnd := sender.GetCongestionWindow()
+	sender.OnPacketSent(monotime.Now(), 0, 1, maxDatagramSize, true)
+	sender.OnCongestionEvent(1, maxDatagramSize, cwnd, TrafficClassDefault, false)
+	require.Equal(t, protocol.ByteCount(float64(cwnd)*renoBeta), sender.GetCongestionWindow())
+	require.Equal(t, sender.GetCongestionWindow(), sender.slowStartThreshold)
+}
+
+func TestWestwoodSenderLossUsesBandwidthEstimate(t *testing.T) {
+	const rtt = 100 * time.Millisecond
+	rttStats := utils.NewRTTStats()
+	rttStats.UpdateRTT(rtt, 0)
+	sender := NewWestwoodSender(rttStats, maxDatagramSize, nil, nil)
+
+	// deliver 100 packets per RTT for a few RTTs
+	now := monotime.Now()
+	var pn protocol.PacketNumber
+	for range 500 {
+		sender.OnPacketSent(now, 0, pn, maxDatagramSize, true)
+		sender.OnPacketAcked(pn, maxDatagramSize, sender.GetCongestionWindow(), now)
+		now = now.Add(rtt / 100)
+		pn++
+	}
+	bdp := 100 * maxDatagramSize
+	require.Greater(t, sender.GetCongestionWindow(), bdp)
+
+	sender.OnPacketSent(now, 0, pn, maxDatagramSize, true)
+	sender.OnCongestionEvent(pn, maxDatagramSize, sender.GetCongestionWindow(), TrafficClassDefault, false)
+	require.InDelta(t, float64(bdp), float64(sender.GetCongestionWindow()), float64(bdp)/50)
+	require.Equal(t, sender.GetCongestionWindow(), sender.slowStartThreshold)
+	require.True(t, sender.InRecovery())
+
+	// losses of packets sent before the cutback are ignored
+	cwnd := sender.GetCongestionWindow()
+	sender.OnCongestionEvent(pn-1, maxDatagramSize, cwnd, TrafficClassDefault, false)
+	require.Equal(t, cwnd, sender.GetCongestionWindow())
+
+	// RTO collapses the window, but keeps the threshold at the BDP
+	sender.OnRetransmissionTimeout(true)
+	require.Equal(t, sender.minCongestionWindow(), sender.GetCongestionWindow())
+	require.InDelta(t, float64(bdp), float64(sender.slowStartThreshold), float64(bdp)/50)
+}
@@ -11,8 +11,10 @@ package mockackhandler
 
 import (
 	reflect "reflect"
+	time "time"
 
 	ackhandler "github.com/quic-go/quic-go/internal/ackhandler"
+	congestion "github.com/quic-go/quic-go/internal/congestion"
 	monotime "github.com/quic-go/quic-go/internal/monotime"
 	protocol "github.com/quic-go/quic-go/internal/protocol"
 	wire "github.com/quic-go/quic-go/internal/wire"
@@ -155,16 +157,52 @@ func (c *MockSentPacketHandlerGetLossDetectionTimeoutCall) DoAndReturn(f func()
 	return c
 }
 
+// InjectChaos mocks base method.
+func (m *MockSentPacketHandler) InjectChaos(now monotime.Time, lossRate float64, extraRTT, duration time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "InjectChaos", now, lossRate, extraRTT, duration)
+}
+
+// InjectChaos indicates an expected call of InjectChaos.
+func (mr *MockSentPacketHandlerMockRecorder) InjectChaos(now, lossRate, extraRTT, duration any) *MockSentPacketHandlerInjectChaosCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InjectChaos", reflect.TypeOf((*MockSentPacketHandler)(nil).InjectChaos), now, lossRate, extraRTT, duration)
+	return &MockSentPacketHandlerInjectChaosCall{Call: call}
+}
+
+// MockSentPacketHandlerInjectChaosCall wrap *gomock.Call
+type MockSentPacketHandlerInjectChaosCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockSentPacketHandlerInjectChaosCall) Return() *MockSentPacketHandlerInjectChaosCall {
+	c.Call = c.Call.Return()
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockSentPacketHandlerInjectChaosCall) Do(f func(monotime.Time, float64, time.Duration, time.Duration)) *MockSentPacketHandlerInjectChaosCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockSentPacketHandlerInjectChaosCall) DoAndReturn(f func(monotime.Time, float64, time.Duration, time.Duration)) *MockSentPacketHandlerInjectChaosCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // MigratedPath mocks base method.
-func (m *MockSentPacketHandler) MigratedPath(now monotime.Time, initialMaxPacketSize protocol.ByteCount) {
+func (m *MockSentPacketHandler) MigratedPath(now monotime.Time, initialMaxPacketSize protocol.ByteCount, from, to ackhandler.PathKey) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "MigratedPath", now, initialMaxPacketSize)
+	m.ctrl.Call(m, "MigratedPath", now, initialMaxPacketSize, from, to)
 }
 
 // MigratedPath indicates an expected call of MigratedPath.
-func (mr *MockSentPacketHandlerMockRecorder) MigratedPath(now, initialMaxPacketSize any) *MockSentPacketHandlerMigratedPathCall {
+func (mr *MockSentPacketHandlerMockRecorder) MigratedPath(now, initialMaxPacketSize, from, to any) *MockSentPacketHandlerMigratedPathCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MigratedPath", reflect.TypeOf((*MockSentPacketHandler)(nil).MigratedPath), now, initialMaxPacketSize)
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MigratedPath", reflect.TypeOf((*MockSentPacketHandler)(nil).MigratedPath), now, initialMaxPacketSize, from, to)
 	return &MockSentPacketHandlerMigratedPathCall{Call: call}
 }
 
@@ -180,13 +218,13 @@ func (c *MockSentPacketHandlerMigratedPathCall) Return() *MockSentPacketHandlerM
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockSentPacketHandlerMigratedPathCall) Do(f func(monotime.Time, protocol.ByteCount)) *MockSentPacketHandlerMigratedPathCall {
+func (c *MockSentPacketHandlerMigratedPathCall) Do(f func(monotime.Time, protocol.ByteCount, ackhandler.PathKey, ackhandler.PathKey)) *MockSentPacketHandlerMigratedPathCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockSentPacketHandlerMigratedPathCall) DoAndReturn(f func(monotime.Time, protocol.ByteCount)) *MockSentPacketHandlerMigratedPathCall {
+func (c *MockSentPacketHandlerMigratedPathCall) DoAndReturn(f func(monotime.Time, protocol.ByteCount, ackhandler.PathKey, ackhandler.PathKey)) *MockSentPacketHandlerMigratedPathCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
@@ -306,6 +344,42 @@ func (c *MockSentPacketHandlerPopPacketNumberCall) DoAndReturn(f func(protocol.E
 	return c
 }
 
+// ProbeBandwidth mocks base method.
+func (m *MockSentPacketHandler) ProbeBandwidth(now monotime.Time, onResult func(congestion.Bandwidth)) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ProbeBandwidth", now, onResult)
+}
+
+// ProbeBandwidth indicates an expected call of ProbeBandwidth.
+func (mr *MockSentPacketHandlerMockRecorder) ProbeBandwidth(now, onResult any) *MockSentPacketHandlerProbeBandwidthCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProbeBandwidth", reflect.TypeOf((*MockSentPacketHandler)(nil).ProbeBandwidth), now, onResult)
+	return &MockSentPacketHandlerProbeBandwidthCall{Call: call}
+}
+
+// MockSentPacketHandlerProbeBandwidthCall wrap *gomock.Call
+type MockSentPacketHandlerProbeBandwidthCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockSentPacketHandlerProbeBandwidthCall) Return() *MockSentPacketHandlerProbeBandwidthCall {
+	c.Call = c.Call.Return()
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockSentPacketHandlerProbeBandwidthCall) Do(f func(monotime.Time, func(congestion.Bandwidth))) *MockSentPacketHandlerProbeBandwidthCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockSentPacketHandlerProbeBandwidthCall) DoAndReturn(f func(monotime.Time, func(congestion.Bandwidth))) *MockSentPacketHandlerProbeBandwidthCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // QueueProbePacket mocks base method.
 func (m *MockSentPacketHandler) QueueProbePacket(arg0 protocol.EncryptionLevel) bool {
 	m.ctrl.T.Helper()
@@ -565,6 +639,42 @@ func (c *MockSentPacketHandlerSentPacketCall) DoAndReturn(f func(monotime.Time,
 	return c
 }
 
+// SetFlowControlLimited mocks base method.
+func (m *MockSentPacketHandler) SetFlowControlLimited(limited bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetFlowControlLimited", limited)
+}
+
+// SetFlowControlLimited indicates an expected call of SetFlowControlLimited.
+func (mr *MockSentPacketHandlerMockRecorder) SetFlowControlLimited(limited any) *MockSentPacketHandlerSetFlowControlLimitedCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFlowControlLimited", reflect.TypeOf((*MockSentPacketHandler)(nil).SetFlowControlLimited), limited)
+	return &MockSentPacketHandlerSetFlowControlLimitedCall{Call: call}
+}
+
+// MockSentPacketHandlerSetFlowControlLimitedCall wrap *gomock.Call
+type MockSentPacketHandlerSetFlowControlLimitedCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockSentPacketHandlerSetFlowControlLimitedCall) Return() *MockSentPacketHandlerSetFlowControlLimitedCall {
+	c.Call = c.Call.Return()
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockSentPacketHandlerSetFlowControlLimitedCall) Do(f func(bool)) *MockSentPacketHandlerSetFlowControlLimitedCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockSentPacketHandlerSetFlowControlLimitedCall) DoAndReturn(f func(bool)) *MockSentPacketHandlerSetFlowControlLimitedCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // SetMaxDatagramSize mocks base method.
 func (m *MockSentPacketHandler) SetMaxDatagramSize(count protocol.ByteCount) {
 	m.ctrl.T.Helper()
@@ -11,7 +11,9 @@ package mocks
 
 import (
 	reflect "reflect"
+	time "time"
 
+	congestion "github.com/quic-go/quic-go/internal/congestion"
 	monotime "github.com/quic-go/quic-go/internal/monotime"
 	protocol "github.com/quic-go/quic-go/internal/protocol"
 	gomock "go.uber.org/mock/gomock"
@@ -41,6 +43,44 @@ func (m *MockSendAlgorithmWithDebugInfos) EXPECT() *MockSendAlgorithmWithDebugIn
 	return m.recorder
 }
 
+// BandwidthEstimate mocks base method.
+func (m *MockSendAlgorithmWithDebugInfos) BandwidthEstimate() congestion.Bandwidth {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BandwidthEstimate")
+	ret0, _ := ret[0].(congestion.Bandwidth)
+	return ret0
+}
+
+// BandwidthEstimate indicates an expected call of BandwidthEstimate.
+func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) BandwidthEstimate() *MockSendAlgorithmWithDebugInfosBandwidthEstimateCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BandwidthEstimate", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).BandwidthEstimate))
+	return &MockSendAlgorithmWithDebugInfosBandwidthEstimateCall{Call: call}
+}
+
+// MockSendAlgorithmWithDebugInfosBandwidthEstimateCall wrap *gomock.Call
+type MockSendAlgorithmWithDebugInfosBandwidthEstimateCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockSendAlgorithmWithDebugInfosBandwidthEstimateCall) Return(arg0 congestion.Bandwidth) *MockSendAlgorithmWithDebugInfosBandwidthEstimateCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockSendAlgorithmWithDebugInfosBandwidthEstimateCall) Do(f func() congestion.Bandwidth) *MockSendAlgorithmWithDebugInfosBandwidthEstimateCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockSendAlgorithmWithDebugInfosBandwidthEstimateCall) DoAndReturn(f func() congestion.Bandwidth) *MockSendAlgorithmWithDebugInfosBandwidthEstimateCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // CanSend mocks base method.
 func (m *MockSendAlgorithmWithDebugInfos) CanSend(bytesInFlight protocol.ByteCount) bool {
 	m.ctrl.T.Helper()
@@ -79,6 +119,44 @@ func (c *MockSendAlgorithmWithDebugInfosCanSendCall) DoAndReturn(f func(protocol
 	return c
 }
 
+// EstimatedSendTime mocks base method.
+func (m *MockSendAlgorithmWithDebugInfos) EstimatedSendTime(bytes protocol.ByteCount) time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EstimatedSendTime", bytes)
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// EstimatedSendTime indicates an expected call of EstimatedSendTime.
+func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) EstimatedSendTime(bytes any) *MockSendAlgorithmWithDebugInfosEstimatedSendTimeCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EstimatedSendTime", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).EstimatedSendTime), bytes)
+	return &MockSendAlgorithmWithDebugInfosEstimatedSendTimeCall{Call: call}
+}
+
+// MockSendAlgorithmWithDebugInfosEstimatedSendTimeCall wrap *gomock.Call
+type MockSendAlgorithmWithDebugInfosEstimatedSendTimeCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockSendAlgorithmWithDebugInfosEstimatedSendTimeCall) Return(arg0 time.Duration) *MockSendAlgorithmWithDebugInfosEstimatedSendTimeCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockSendAlgorithmWithDebugInfosEstimatedSendTimeCall) Do(f func(protocol.ByteCount) time.Duration) *MockSendAlgorithmWithDebugInfosEstimatedSendTimeCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockSendAlgorithmWithDebugInfosEstimatedSendTimeCall) DoAndReturn(f func(protocol.ByteCount) time.Duration) *MockSendAlgorithmWithDebugInfosEstimatedSendTimeCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // GetCongestionWindow mocks base method.
 func (m *MockSendAlgorithmWithDebugInfos) GetCongestionWindow() protocol.ByteCount {
 	m.ctrl.T.Helper()
@@ -268,15 +346,15 @@ func (c *MockSendAlgorithmWithDebugInfosMaybeExitSlowStartCall) DoAndReturn(f fu
 }
 
 // OnCongestionEvent mocks base method.
-func (m *MockSendAlgorithmWithDebugInfos) OnCongestionEvent(number protocol.PacketNumber, lostBytes, priorInFlight protocol.ByteCount) {
+func (m *MockSendAlgorithmWithDebugInfos) OnCongestionEvent(number protocol.PacketNumber, lostBytes, priorInFlight protocol.ByteCount, class congestion.TrafficClass, reorderingLikely bool) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "OnCongestionEvent", number, lostBytes, priorInFlight)
+	m.ctrl.Call(m, "OnCongestionEvent", number, lostBytes, priorInFlight, class, reorderingLikely)
 }
 
 // OnCongestionEvent indicates an expected call of OnCongestionEvent.
-func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) OnCongestionEvent(number, lostBytes, priorInFlight any) *MockSendAlgorithmWithDebugInfosOnCongestionEventCall {
+func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) OnCongestionEvent(number, lostBytes, priorInFlight, class, reorderingLikely any) *MockSendAlgorithmWithDebugInfosOnCongestionEventCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnCongestionEvent", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).OnCongestionEvent), number, lostBytes, priorInFlight)
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnCongestionEvent", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).OnCongestionEvent), number, lostBytes, priorInFlight, class, reorderingLikely)
 	return &MockSendAlgorithmWithDebugInfosOnCongestionEventCall{Call: call}
 }
 
@@ -292,13 +370,85 @@ func (c *MockSendAlgorithmWithDebugInfosOnCongestionEventCall) Return() *MockSen
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockSendAlgorithmWithDebugInfosOnCongestionEventCall) Do(f func(protocol.PacketNumber, protocol.ByteCount, protocol.ByteCount)) *MockSendAlgorithmWithDebugInfosOnCongestionEventCall {
+func (c *MockSendAlgorithmWithDebugInfosOnCongestionEventCall) Do(f func(protocol.PacketNumber, protocol.ByteCount, protocol.ByteCount, congestion.TrafficClass, bool)) *MockSendAlgorithmWithDebugInfosOnCongestionEventCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockSendAlgorithmWithDebugInfosOnCongestionEventCall) DoAndReturn(f func(protocol.PacketNumber, protocol.ByteCount, protocol.ByteCount, congestion.TrafficClass, bool)) *MockSendAlgorithmWithDebugInfosOnCongestionEventCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// OnConnectionMigration mocks base method.
+func (m *MockSendAlgorithmWithDebugInfos) OnConnectionMigration() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "OnConnectionMigration")
+}
+
+// OnConnectionMigration indicates an expected call of OnConnectionMigration.
+func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) OnConnectionMigration() *MockSendAlgorithmWithDebugInfosOnConnectionMigrationCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnConnectionMigration", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).OnConnectionMigration))
+	return &MockSendAlgorithmWithDebugInfosOnConnectionMigrationCall{Call: call}
+}
+
+// MockSendAlgorithmWithDebugInfosOnConnectionMigrationCall wrap *gomock.Call
+type MockSendAlgorithmWithDebugInfosOnConnectionMigrationCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockSendAlgorithmWithDebugInfosOnConnectionMigrationCall) Return() *MockSendAlgorithmWithDebugInfosOnConnectionMigrationCall {
+	c.Call = c.Call.Return()
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockSendAlgorithmWithDebugInfosOnConnectionMigrationCall) Do(f func()) *MockSendAlgorithmWithDebugInfosOnConnectionMigrationCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockSendAlgorithmWithDebugInfosOnConnectionMigrationCall) DoAndReturn(f func()) *MockSendAlgorithmWithDebugInfosOnConnectionMigrationCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// OnFlowControlLimited mocks base method.
+func (m *MockSendAlgorithmWithDebugInfos) OnFlowControlLimited(limited bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "OnFlowControlLimited", limited)
+}
+
+// OnFlowControlLimited indicates an expected call of OnFlowControlLimited.
+func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) OnFlowControlLimited(limited any) *MockSendAlgorithmWithDebugInfosOnFlowControlLimitedCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnFlowControlLimited", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).OnFlowControlLimited), limited)
+	return &MockSendAlgorithmWithDebugInfosOnFlowControlLimitedCall{Call: call}
+}
+
+// MockSendAlgorithmWithDebugInfosOnFlowControlLimitedCall wrap *gomock.Call
+type MockSendAlgorithmWithDebugInfosOnFlowControlLimitedCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockSendAlgorithmWithDebugInfosOnFlowControlLimitedCall) Return() *MockSendAlgorithmWithDebugInfosOnFlowControlLimitedCall {
+	c.Call = c.Call.Return()
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockSendAlgorithmWithDebugInfosOnFlowControlLimitedCall) Do(f func(bool)) *MockSendAlgorithmWithDebugInfosOnFlowControlLimitedCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockSendAlgorithmWithDebugInfosOnCongestionEventCall) DoAndReturn(f func(protocol.PacketNumber, protocol.ByteCount, protocol.ByteCount)) *MockSendAlgorithmWithDebugInfosOnCongestionEventCall {
+func (c *MockSendAlgorithmWithDebugInfosOnFlowControlLimitedCall) DoAndReturn(f func(bool)) *MockSendAlgorithmWithDebugInfosOnFlowControlLimitedCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
@@ -375,6 +525,42 @@ func (c *MockSendAlgorithmWithDebugInfosOnPacketSentCall) DoAndReturn(f func(mon
 	return c
 }
 
+// OnPersistentCongestion mocks base method.
+func (m *MockSendAlgorithmWithDebugInfos) OnPersistentCongestion() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "OnPersistentCongestion")
+}
+
+// OnPersistentCongestion indicates an expected call of OnPersistentCongestion.
+func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) OnPersistentCongestion() *MockSendAlgorithmWithDebugInfosOnPersistentCongestionCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnPersistentCongestion", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).OnPersistentCongestion))
+	return &MockSendAlgorithmWithDebugInfosOnPersistentCongestionCall{Call: call}
+}
+
+// MockSendAlgorithmWithDebugInfosOnPersistentCongestionCall wrap *gomock.Call
+type MockSendAlgorithmWithDebugInfosOnPersistentCongestionCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockSendAlgorithmWithDebugInfosOnPersistentCongestionCall) Return() *MockSendAlgorithmWithDebugInfosOnPersistentCongestionCall {
+	c.Call = c.Call.Return()
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockSendAlgorithmWithDebugInfosOnPersistentCongestionCall) Do(f func()) *MockSendAlgorithmWithDebugInfosOnPersistentCongestionCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockSendAlgorithmWithDebugInfosOnPersistentCongestionCall) DoAndReturn(f func()) *MockSendAlgorithmWithDebugInfosOnPersistentCongestionCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // OnRetransmissionTimeout mocks base method.
 func (m *MockSendAlgorithmWithDebugInfos) OnRetransmissionTimeout(packetsRetransmitted bool) {
 	m.ctrl.T.Helper()
@@ -411,6 +597,116 @@ func (c *MockSendAlgorithmWithDebugInfosOnRetransmissionTimeoutCall) DoAndReturn
 	return c
 }
 
+// OnSpuriousLoss mocks base method.
+func (m *MockSendAlgorithmWithDebugInfos) OnSpuriousLoss(number protocol.PacketNumber) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "OnSpuriousLoss", number)
+}
+
+// OnSpuriousLoss indicates an expected call of OnSpuriousLoss.
+func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) OnSpuriousLoss(number any) *MockSendAlgorithmWithDebugInfosOnSpuriousLossCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnSpuriousLoss", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).OnSpuriousLoss), number)
+	return &MockSendAlgorithmWithDebugInfosOnSpuriousLossCall{Call: call}
+}
+
+// MockSendAlgorithmWithDebugInfosOnSpuriousLossCall wrap *gomock.Call
+type MockSendAlgorithmWithDebugInfosOnSpuriousLossCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockSendAlgorithmWithDebugInfosOnSpuriousLossCall) Return() *MockSendAlgorithmWithDebugInfosOnSpuriousLossCall {
+	c.Call = c.Call.Return()
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockSendAlgorithmWithDebugInfosOnSpuriousLossCall) Do(f func(protocol.PacketNumber)) *MockSendAlgorithmWithDebugInfosOnSpuriousLossCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockSendAlgorithmWithDebugInfosOnSpuriousLossCall) DoAndReturn(f func(protocol.PacketNumber)) *MockSendAlgorithmWithDebugInfosOnSpuriousLossCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// PacingBudget mocks base method.
+func (m *MockSendAlgorithmWithDebugInfos) PacingBudget(now monotime.Time) protocol.ByteCount {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PacingBudget", now)
+	ret0, _ := ret[0].(protocol.ByteCount)
+	return ret0
+}
+
+// PacingBudget indicates an expected call of PacingBudget.
+func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) PacingBudget(now any) *MockSendAlgorithmWithDebugInfosPacingBudgetCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PacingBudget", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).PacingBudget), now)
+	return &MockSendAlgorithmWithDebugInfosPacingBudgetCall{Call: call}
+}
+
+// MockSendAlgorithmWithDebugInfosPacingBudgetCall wrap *gomock.Call
+type MockSendAlgorithmWithDebugInfosPacingBudgetCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockSendAlgorithmWithDebugInfosPacingBudgetCall) Return(arg0 protocol.ByteCount) *MockSendAlgorithmWithDebugInfosPacingBudgetCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockSendAlgorithmWithDebugInfosPacingBudgetCall) Do(f func(monotime.Time) protocol.ByteCount) *MockSendAlgorithmWithDebugInfosPacingBudgetCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockSendAlgorithmWithDebugInfosPacingBudgetCall) DoAndReturn(f func(monotime.Time) protocol.ByteCount) *MockSendAlgorithmWithDebugInfosPacingBudgetCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// ProbeBandwidth mocks base method.
+func (m *MockSendAlgorithmWithDebugInfos) ProbeBandwidth(until monotime.Time) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ProbeBandwidth", until)
+}
+
+// ProbeBandwidth indicates an expected call of ProbeBandwidth.
+func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) ProbeBandwidth(until any) *MockSendAlgorithmWithDebugInfosProbeBandwidthCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProbeBandwidth", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).ProbeBandwidth), until)
+	return &MockSendAlgorithmWithDebugInfosProbeBandwidthCall{Call: call}
+}
+
+// MockSendAlgorithmWithDebugInfosProbeBandwidthCall wrap *gomock.Call
+type MockSendAlgorithmWithDebugInfosProbeBandwidthCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockSendAlgorithmWithDebugInfosProbeBandwidthCall) Return() *MockSendAlgorithmWithDebugInfosProbeBandwidthCall {
+	c.Call = c.Call.Return()
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockSendAlgorithmWithDebugInfosProbeBandwidthCall) Do(f func(monotime.Time)) *MockSendAlgorithmWithDebugInfosProbeBandwidthCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockSendAlgorithmWithDebugInfosProbeBandwidthCall) DoAndReturn(f func(monotime.Time)) *MockSendAlgorithmWithDebugInfosProbeBandwidthCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // SetMaxDatagramSize mocks base method.
 func (m *MockSendAlgorithmWithDebugInfos) SetMaxDatagramSize(arg0 protocol.ByteCount) {
 	m.ctrl.T.Helper()
@@ -447,6 +743,82 @@ func (c *MockSendAlgorithmWithDebugInfosSetMaxDatagramSizeCall) DoAndReturn(f fu
 	return c
 }
 
+// SlowStartThreshold mocks base method.
+func (m *MockSendAlgorithmWithDebugInfos) SlowStartThreshold() protocol.ByteCount {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SlowStartThreshold")
+	ret0, _ := ret[0].(protocol.ByteCount)
+	return ret0
+}
+
+// SlowStartThreshold indicates an expected call of SlowStartThreshold.
+func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) SlowStartThreshold() *MockSendAlgorithmWithDebugInfosSlowStartThresholdCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SlowStartThreshold", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).SlowStartThreshold))
+	return &MockSendAlgorithmWithDebugInfosSlowStartThresholdCall{Call: call}
+}
+
+// MockSendAlgorithmWithDebugInfosSlowStartThresholdCall wrap *gomock.Call
+type MockSendAlgorithmWithDebugInfosSlowStartThresholdCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockSendAlgorithmWithDebugInfosSlowStartThresholdCall) Return(arg0 protocol.ByteCount) *MockSendAlgorithmWithDebugInfosSlowStartThresholdCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockSendAlgorithmWithDebugInfosSlowStartThresholdCall) Do(f func() protocol.ByteCount) *MockSendAlgorithmWithDebugInfosSlowStartThresholdCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockSendAlgorithmWithDebugInfosSlowStartThresholdCall) DoAndReturn(f func() protocol.ByteCount) *MockSendAlgorithmWithDebugInfosSlowStartThresholdCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// State mocks base method.
+func (m *MockSendAlgorithmWithDebugInfos) State(bytesInFlight protocol.ByteCount) congestion.State {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "State", bytesInFlight)
+	ret0, _ := ret[0].(congestion.State)
+	return ret0
+}
+
+// State indicates an expected call of State.
+func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) State(bytesInFlight any) *MockSendAlgorithmWithDebugInfosStateCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "State", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).State), bytesInFlight)
+	return &MockSendAlgorithmWithDebugInfosStateCall{Call: call}
+}
+
+// MockSendAlgorithmWithDebugInfosStateCall wrap *gomock.Call
+type MockSendAlgorithmWithDebugInfosStateCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockSendAlgorithmWithDebugInfosStateCall) Return(arg0 congestion.State) *MockSendAlgorithmWithDebugInfosStateCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockSendAlgorithmWithDebugInfosStateCall) Do(f func(protocol.ByteCount) congestion.State) *MockSendAlgorithmWithDebugInfosStateCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockSendAlgorithmWithDebugInfosStateCall) DoAndReturn(f func(protocol.ByteCount) congestion.State) *MockSendAlgorithmWithDebugInfosStateCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // TimeUntilSend mocks base method.
 func (m *MockSendAlgorithmWithDebugInfos) TimeUntilSend(bytesInFlight protocol.ByteCount) monotime.Time {
 	m.ctrl.T.Helper()
@@ -11,4 +11,74 @@ type ConnectionStats struct {
 	PacketsReceived atomic.Uint64
 	BytesLost       atomic.Uint64
 	PacketsLost     atomic.Uint64
+	// BytesAcked is the number of bytes acknowledged by the peer
+	BytesAcked atomic.Uint64
+	// BytesRetransmitted is the number of bytes of packets whose frames were queued for retransmission.
+	// The frames are sent again in new packets, so this approximates the retransmissions included in BytesSent.
+	BytesRetransmitted atomic.Uint64
+	// CongestionState is the congestion.State of the congestion controller
+	CongestionState atomic.Uint32
+	// CongestionStateSince is the monotime.Time when the congestion controller entered the current state
+	CongestionStateSince atomic.Int64
+	// TimeInCongestionState is the time spent in each of the previous congestion states,
+	// indexed by congestion.State, in nanoseconds
+	TimeInCongestionState [4]atomic.Int64
+	// CongestionWindow is the current congestion window, in bytes
+	CongestionWindow atomic.Int64
+	// SlowStartThreshold is the current slow start threshold, in bytes
+	SlowStartThreshold atomic.Int64
+	// BytesInFlight is the number of bytes sent, but neither acknowledged nor declared lost
+	BytesInFlight atomic.Int64
+	// NextSendTime is the monotime.Time when the pacer allows sending the next packet,
+	// 0 if a packet can be sent immediately
+	NextSendTime atomic.Int64
+	// PacingBudget is the number of bytes that the pacer allowed to be sent without delay, in bytes
+	PacingBudget atomic.Int64
+	// PacingUpdateTime is the monotime.Time when NextSendTime and PacingBudget were last updated
+	PacingUpdateTime atomic.Int64
+	// SendTimePerMegabyte is the time it takes to send 2^20 bytes at the current sending rate, in nanoseconds
+	SendTimePerMegabyte atomic.Int64
+	// PacingRate is the rate the congestion controller currently sends at, in bits per second
+	PacingRate atomic.Uint64
+	// MinRateProtectionActive is set while the congestion window is held at the floor of the minimum rate protection
+	MinRateProtectionActive atomic.Bool
+	// DeliveryRate is the smoothed rate at which data is acknowledged by the peer, in bits per second
+	DeliveryRate atomic.Uint64
+	// SendOpportunities is the number of times the congestion controller was asked whether a packet can be sent
+	SendOpportunities atomic.Uint64
+	// CongestionWindowLimited is the number of send opportunities where the congestion window was full
+	CongestionWindowLimited atomic.Uint64
+	// PacingLimited is the number of send opportunities where the pacer didn't allow sending a packet
+	PacingLimited atomic.Uint64
+	// LostPackets logs the most recently lost packets.
+	// It is nil unless enabled via the config.
+	LostPackets *LostPacketLog
+}
+
+// Goodput is the number of unique bytes delivered to the peer.
+// Unlike BytesSent, it doesn't count retransmissions: a packet declared lost is never counted as acknowledged,
+// even if it is acknowledged later, so retransmitted data is only counted once, when its retransmission is acknowledged.
+func (s *ConnectionStats) Goodput() uint64 {
+	return s.BytesAcked.Load()
+}
+
+// UniqueBytesSent is the number of bytes sent, excluding retransmissions.
+func (s *ConnectionStats) UniqueBytesSent() uint64 {
+	sent := s.BytesSent.Load()
+	retransmitted := s.BytesRetransmitted.Load()
+	if retransmitted >= sent {
+		return 0
+	}
+	return sent - retransmitted
+}
+
+// Reset zeroes the counters the loss tolerance is based on: BytesSent, BytesRetransmitted, BytesLost and PacketsLost.
+// Each counter is reset atomically, but not all of them at once.
+// BytesSent is reset first, such that a concurrent reader never sees the old losses relative to the new bytes sent,
+// which would understate the loss rate.
+func (s *ConnectionStats) Reset() {
+	s.BytesSent.Store(0)
+	s.BytesRetransmitted.Store(0)
+	s.BytesLost.Store(0)
+	s.PacketsLost.Store(0)
 }
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectionStatsReset(t *testing.T) {
+	var s ConnectionStats
+	s.BytesSent.Store(1000)
+	s.BytesRetransmitted.Store(100)
+	s.BytesLost.Store(100)
+	s.PacketsLost.Store(2)
+	s.PacketsSent.Store(10)
+	s.Reset()
+	require.Zero(t, s.BytesSent.Load())
+	require.Zero(t, s.BytesRetransmitted.Load())
+	require.Zero(t, s.BytesLost.Load())
+	require.Zero(t, s.PacketsLost.Load())
+	// other counters are not affected
+	require.Equal(t, uint64(10), s.PacketsSent.Load())
+}
+
+func TestConnectionStatsGoodput(t *testing.T) {
+	var s ConnectionStats
+	s.BytesSent.Store(10000)
+	s.BytesRetransmitted.Store(2000)
+	s.BytesAcked.Store(7000)
+	require.Equal(t, uint64(8000), s.UniqueBytesSent())
+	require.Equal(t, uint64(7000), s.Goodput())
+
+	// after a reset, the counters might be inconsistent for a short moment
+	s.BytesSent.Store(0)
+	require.Zero(t, s.UniqueBytesSent())
+}
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"sync"
+
+	"github.com/quic-go/quic-go/internal/monotime"
+	"github.com/quic-go/quic-go/internal/protocol"
+)
+
+// A LostPacket is a packet that was declared lost.
+type LostPacket struct {
+	PacketNumber protocol.PacketNumber
+	SendTime     monotime.Time
+	LossTime     monotime.Time
+}
+
+// LostPacketLog keeps track of the most recently lost packets.
+// It is safe for concurrent use.
+type LostPacketLog struct {
+	mx      sync.Mutex
+	entries []LostPacket
+	next    int
+	full    bool
+}
+
+// NewLostPacketLog creates a log that keeps track of the last size lost packets.
+func NewLostPacketLog(size int) *LostPacketLog {
+	return &LostPacketLog{entries: make([]LostPacket, size)}
+}
+
+// Add records a lost packet, overwriting the oldest entry if the log is full.
+func (l *LostPacketLog) Add(pn protocol.PacketNumber, sendTime, lossTime monotime.Time) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	l.entries[l.next] = LostPacket{PacketNumber: pn, SendTime: sendTime, LossTime: lossTime}
+	l.next++
+	if l.next == len(l.entries) {
+		l.next = 0
+		l.full = true
+	}
+}
+
+// Snapshot returns a copy of the logged packets, oldest first.
+func (l *LostPacketLog) Snapshot() []LostPacket {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	if !l.full {
+		return append([]LostPacket(nil), l.entries[:l.next]...)
+	}
+	s := make([]LostPacket, 0, len(l.entries))
+	s = append(s, l.entries[l.next:]...)
+	return append(s, l.entries[:l.next]...)
+}
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go/internal/monotime"
+	"github.com/quic-go/quic-go/internal/protocol"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLostPacketLog(t *testing.T) {
+	l := NewLostPacketLog(3)
+	require.Empty(t, l.Snapshot())
+
+	now := monotime.Now()
+	l.Add(1, now, now.Add(time.Second))
+	l.Add(5, now.Add(time.Millisecond), now.Add(2*time.Second))
+	require.Equal(t, []LostPacket{
+		{PacketNumber: 1, SendTime: now, LossTime: now.Add(time.Second)},
+		{PacketNumber: 5, SendTime: now.Add(time.Millisecond), LossTime: now.Add(2 * time.Second)},
+	}, l.Snapshot())
+
+	// the oldest entries are overwritten
+	for pn := protocol.PacketNumber(10); pn < 14; pn++ {
+		l.Add(pn, now, now)
+	}
+	snapshot := l.Snapshot()
+	require.Len(t, snapshot, 3)
+	require.Equal(t, protocol.PacketNumber(11), snapshot[0].PacketNumber)
+	require.Equal(t, protocol.PacketNumber(12), snapshot[1].PacketNumber)
+	require.Equal(t, protocol.PacketNumber(13), snapshot[2].PacketNumber)
+}
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"math"
+	"slices"
 	"sync/atomic"
 	"time"
 
@@ -17,6 +19,9 @@ const (
 // The default RTT used before an RTT sample is taken
 const DefaultInitialRTT = 100 * time.Millisecond
 
+// DefaultRecentRTTWindow is the default number of recent RTT samples kept by the RTTStats.
+const DefaultRecentRTTWindow = 10
+
 // RTTStats provides round-trip statistics
 type RTTStats struct {
 	hasMeasurement bool
@@ -27,6 +32,15 @@ type RTTStats struct {
 	meanDeviation atomic.Int64 // nanoseconds
 
 	maxAckDelay atomic.Int64 // nanoseconds
+	initialRTT  atomic.Int64 // nanoseconds, 0 if DefaultInitialRTT is used
+
+	// called with every RTT sample, may be nil
+	onSample func(sendDelta, ackDelay time.Duration)
+
+	// ring buffer of the most recent RTT samples, allocated with the first sample
+	recentRTTWindow int
+	recentRTTs      []time.Duration
+	recentRTTIdx    int
 }
 
 func NewRTTStats() *RTTStats {
@@ -65,10 +79,30 @@ func (r *RTTStats) MaxAckDelay() time.Duration {
 	return time.Duration(r.maxAckDelay.Load())
 }
 
+// InitialRTT returns the RTT used before an RTT sample is taken.
+func (r *RTTStats) InitialRTT() time.Duration {
+	if rtt := time.Duration(r.initialRTT.Load()); rtt > 0 {
+		return rtt
+	}
+	return DefaultInitialRTT
+}
+
+// ConfigureInitialRTT sets the RTT used before an RTT sample is taken, and after a path migration.
+// If t is 0, DefaultInitialRTT is used.
+func (r *RTTStats) ConfigureInitialRTT(t time.Duration) {
+	r.initialRTT.Store(int64(t))
+	if r.hasMeasurement {
+		return
+	}
+	r.minRTT.Store(r.InitialRTT().Nanoseconds())
+	r.latestRTT.Store(r.InitialRTT().Nanoseconds())
+	r.smoothedRTT.Store(r.InitialRTT().Nanoseconds())
+}
+
 // PTO gets the probe timeout duration.
 func (r *RTTStats) PTO(includeMaxAckDelay bool) time.Duration {
 	if !r.hasMeasurement {
-		return 2 * DefaultInitialRTT
+		return 2 * r.InitialRTT()
 	}
 	pto := r.SmoothedRTT() + max(4*r.MeanDeviation(), protocol.TimerGranularity)
 	if includeMaxAckDelay {
@@ -77,11 +111,20 @@ func (r *RTTStats) PTO(includeMaxAckDelay bool) time.Duration {
 	return pto
 }
 
+// SetSampleObserver sets a callback that is called with every RTT sample,
+// before the sample is corrected for the ack delay.
+func (r *RTTStats) SetSampleObserver(f func(sendDelta, ackDelay time.Duration)) {
+	r.onSample = f
+}
+
 // UpdateRTT updates the RTT based on a new sample.
 func (r *RTTStats) UpdateRTT(sendDelta, ackDelay time.Duration) {
 	if sendDelta <= 0 {
 		return
 	}
+	if r.onSample != nil {
+		r.onSample(sendDelta, ackDelay)
+	}
 
 	// Update r.minRTT first. r.minRTT does not use an rttSample corrected for
 	// ackDelay but the raw observed sendDelta, since poor clock granularity at
@@ -101,6 +144,7 @@ func (r *RTTStats) UpdateRTT(sendDelta, ackDelay time.Duration) {
 		sample -= ackDelay
 	}
 	r.latestRTT.Store(sample.Nanoseconds())
+	r.addRecentRTT(sample)
 	// First time call.
 	if !r.hasMeasurement {
 		r.hasMeasurement = true
@@ -115,6 +159,59 @@ func (r *RTTStats) UpdateRTT(sendDelta, ackDelay time.Duration) {
 	}
 }
 
+func (r *RTTStats) addRecentRTT(sample time.Duration) {
+	window := r.recentRTTWindow
+	if window <= 0 {
+		window = DefaultRecentRTTWindow
+	}
+	if len(r.recentRTTs) < window {
+		r.recentRTTs = append(r.recentRTTs, sample)
+		return
+	}
+	r.recentRTTs[r.recentRTTIdx] = sample
+	r.recentRTTIdx = (r.recentRTTIdx + 1) % window
+}
+
+// SetRecentRTTWindow sets the number of recent RTT samples used by MaxRecentRTT, MinRecentRTT and PercentileRTT.
+// It discards the samples collected so far. If n is 0, DefaultRecentRTTWindow is used.
+func (r *RTTStats) SetRecentRTTWindow(n int) {
+	r.recentRTTWindow = n
+	r.recentRTTs = nil
+	r.recentRTTIdx = 0
+}
+
+// MaxRecentRTT returns the largest of the recent RTT samples.
+// It returns 0 if no RTT sample was taken yet.
+func (r *RTTStats) MaxRecentRTT() time.Duration {
+	if len(r.recentRTTs) == 0 {
+		return 0
+	}
+	return slices.Max(r.recentRTTs)
+}
+
+// MinRecentRTT returns the smallest of the recent RTT samples.
+// Unlike MinRTT, it follows increases of the path's RTT.
+// It returns 0 if no RTT sample was taken yet.
+func (r *RTTStats) MinRecentRTT() time.Duration {
+	if len(r.recentRTTs) == 0 {
+		return 0
+	}
+	return slices.Min(r.recentRTTs)
+}
+
+// PercentileRTT returns the p-th percentile of the recent RTT samples (nearest rank), for p in the range [0, 1].
+// Values of p outside of that range are clamped.
+// It returns 0 if no RTT sample was taken yet.
+func (r *RTTStats) PercentileRTT(p float64) time.Duration {
+	if len(r.recentRTTs) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(r.recentRTTs)
+	slices.Sort(sorted)
+	rank := int(math.Ceil(min(max(p, 0), 1) * float64(len(sorted))))
+	return sorted[max(rank-1, 0)]
+}
+
 func (r *RTTStats) HasMeasurement() bool {
 	return r.hasMeasurement
 }
@@ -140,10 +237,12 @@ func (r *RTTStats) SetInitialRTT(t time.Duration) {
 
 func (r *RTTStats) ResetForPathMigration() {
 	r.hasMeasurement = false
-	r.minRTT.Store(DefaultInitialRTT.Nanoseconds())
-	r.latestRTT.Store(DefaultInitialRTT.Nanoseconds())
-	r.smoothedRTT.Store(DefaultInitialRTT.Nanoseconds())
+	r.minRTT.Store(r.InitialRTT().Nanoseconds())
+	r.latestRTT.Store(r.InitialRTT().Nanoseconds())
+	r.smoothedRTT.Store(r.InitialRTT().Nanoseconds())
 	r.meanDeviation.Store(0)
+	r.recentRTTs = r.recentRTTs[:0]
+	r.recentRTTIdx = 0
 	// max_ack_delay remains valid
 }
 
@@ -155,5 +254,9 @@ func (r *RTTStats) Clone() *RTTStats {
 	out.smoothedRTT.Store(r.smoothedRTT.Load())
 	out.meanDeviation.Store(r.meanDeviation.Load())
 	out.maxAckDelay.Store(r.maxAckDelay.Load())
+	out.initialRTT.Store(r.initialRTT.Load())
+	out.recentRTTWindow = r.recentRTTWindow
+	out.recentRTTs = slices.Clone(r.recentRTTs)
+	out.recentRTTIdx = r.recentRTTIdx
 	return out
 }
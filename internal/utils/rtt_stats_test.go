@@ -96,6 +96,25 @@ func TestRTTStatsUpdateWithBadSendDeltas(t *testing.T) {
 	}
 }
 
+func TestRTTStatsSampleObserver(t *testing.T) {
+	type sample struct{ sendDelta, ackDelay time.Duration }
+	var samples []sample
+	rttStats := NewRTTStats()
+	rttStats.SetSampleObserver(func(sendDelta, ackDelay time.Duration) {
+		samples = append(samples, sample{sendDelta, ackDelay})
+	})
+	rttStats.UpdateRTT(10*time.Millisecond, 0)
+	// the sample is reported before it is corrected for the ack delay
+	rttStats.UpdateRTT(50*time.Millisecond, 20*time.Millisecond)
+	require.Equal(t, 30*time.Millisecond, rttStats.LatestRTT())
+	// invalid samples are not reported
+	rttStats.UpdateRTT(0, 0)
+	require.Equal(t, []sample{
+		{10 * time.Millisecond, 0},
+		{50 * time.Millisecond, 20 * time.Millisecond},
+	}, samples)
+}
+
 func TestRTTStatsRestore(t *testing.T) {
 	rttStats := NewRTTStats()
 	rttStats.SetInitialRTT(10 * time.Second)
@@ -120,6 +139,34 @@ func TestRTTMeasurementAfterRestore(t *testing.T) {
 	require.Equal(t, rtt, rttStats.SmoothedRTT())
 }
 
+func TestRTTStatsConfiguredInitialRTT(t *testing.T) {
+	rttStats := NewRTTStats()
+	require.Equal(t, DefaultInitialRTT, rttStats.InitialRTT())
+	rttStats.ConfigureInitialRTT(5 * time.Millisecond)
+	require.Equal(t, 5*time.Millisecond, rttStats.InitialRTT())
+	require.Equal(t, 5*time.Millisecond, rttStats.MinRTT())
+	require.Equal(t, 5*time.Millisecond, rttStats.LatestRTT())
+	require.Equal(t, 5*time.Millisecond, rttStats.SmoothedRTT())
+	require.Equal(t, 10*time.Millisecond, rttStats.PTO(false))
+	// an RTT restored from a token takes precedence
+	rttStats.SetInitialRTT(20 * time.Millisecond)
+	require.Equal(t, 20*time.Millisecond, rttStats.SmoothedRTT())
+
+	// the initial RTT is used again after a path migration
+	rttStats.UpdateRTT(time.Second, 0)
+	require.Equal(t, time.Second, rttStats.SmoothedRTT())
+	rttStats.ResetForPathMigration()
+	require.Equal(t, 5*time.Millisecond, rttStats.SmoothedRTT())
+	require.Equal(t, 10*time.Millisecond, rttStats.PTO(false))
+	require.Equal(t, 5*time.Millisecond, rttStats.Clone().InitialRTT())
+
+	// setting the initial RTT doesn't overwrite an RTT measurement
+	rttStats.UpdateRTT(time.Second, 0)
+	rttStats.ConfigureInitialRTT(0)
+	require.Equal(t, DefaultInitialRTT, rttStats.InitialRTT())
+	require.Equal(t, time.Second, rttStats.SmoothedRTT())
+}
+
 func TestRTTStatsResetForPathMigration(t *testing.T) {
 	rttStats := NewRTTStats()
 	rttStats.SetMaxAckDelay(42 * time.Millisecond)
@@ -129,6 +176,7 @@ func TestRTTStatsResetForPathMigration(t *testing.T) {
 	require.Equal(t, time.Second, rttStats.MinRTT())
 	require.Equal(t, 10*time.Second, rttStats.LatestRTT())
 	require.NotZero(t, rttStats.SmoothedRTT())
+	require.Equal(t, 10*time.Second, rttStats.MaxRecentRTT())
 
 	rttStats.ResetForPathMigration()
 	require.False(t, rttStats.HasMeasurement())
@@ -138,9 +186,62 @@ func TestRTTStatsResetForPathMigration(t *testing.T) {
 	require.Equal(t, 2*DefaultInitialRTT, rttStats.PTO(false))
 	// make sure that max_ack_delay was not reset
 	require.Equal(t, 42*time.Millisecond, rttStats.MaxAckDelay())
+	require.Zero(t, rttStats.MaxRecentRTT())
 
 	rttStats.UpdateRTT(10*time.Millisecond, 0)
 	require.True(t, rttStats.HasMeasurement())
 	require.Equal(t, 10*time.Millisecond, rttStats.SmoothedRTT())
 	require.Equal(t, 10*time.Millisecond, rttStats.LatestRTT())
 }
+
+func TestRTTStatsRecentRTTs(t *testing.T) {
+	rttStats := NewRTTStats()
+	require.Zero(t, rttStats.MaxRecentRTT())
+	require.Zero(t, rttStats.MinRecentRTT())
+	require.Zero(t, rttStats.PercentileRTT(0.5))
+
+	for i := 1; i <= DefaultRecentRTTWindow; i++ {
+		rttStats.UpdateRTT(time.Duration(i)*10*time.Millisecond, 0)
+	}
+	require.Equal(t, 10*time.Millisecond, rttStats.MinRecentRTT())
+	require.Equal(t, 100*time.Millisecond, rttStats.MaxRecentRTT())
+	require.Equal(t, 50*time.Millisecond, rttStats.PercentileRTT(0.5))
+	require.Equal(t, 90*time.Millisecond, rttStats.PercentileRTT(0.9))
+	require.Equal(t, 10*time.Millisecond, rttStats.PercentileRTT(0))
+	require.Equal(t, 100*time.Millisecond, rttStats.PercentileRTT(1))
+	require.Equal(t, 100*time.Millisecond, rttStats.PercentileRTT(1.5))
+
+	// the oldest samples are replaced by newer ones
+	rttStats.UpdateRTT(200*time.Millisecond, 0)
+	rttStats.UpdateRTT(200*time.Millisecond, 0)
+	require.Equal(t, 30*time.Millisecond, rttStats.MinRecentRTT())
+	require.Equal(t, 200*time.Millisecond, rttStats.MaxRecentRTT())
+	// unlike the min RTT, the min of the recent samples follows RTT increases
+	require.Equal(t, 10*time.Millisecond, rttStats.MinRTT())
+
+	// the samples are corrected for the ack delay
+	rttStats.UpdateRTT(300*time.Millisecond, 50*time.Millisecond)
+	require.Equal(t, 250*time.Millisecond, rttStats.MaxRecentRTT())
+}
+
+func TestRTTStatsRecentRTTWindow(t *testing.T) {
+	rttStats := NewRTTStats()
+	rttStats.UpdateRTT(time.Second, 0)
+	rttStats.SetRecentRTTWindow(3)
+	require.Zero(t, rttStats.MaxRecentRTT())
+
+	for _, rtt := range []time.Duration{40, 10, 20, 30} {
+		rttStats.UpdateRTT(rtt*time.Millisecond, 0)
+	}
+	require.Equal(t, 10*time.Millisecond, rttStats.MinRecentRTT())
+	require.Equal(t, 30*time.Millisecond, rttStats.MaxRecentRTT())
+	rttStats.UpdateRTT(25*time.Millisecond, 0)
+	require.Equal(t, 20*time.Millisecond, rttStats.MinRecentRTT())
+	require.Equal(t, 25*time.Millisecond, rttStats.PercentileRTT(0.5))
+
+	clone := rttStats.Clone()
+	require.Equal(t, 20*time.Millisecond, clone.MinRecentRTT())
+	clone.UpdateRTT(time.Millisecond, 0)
+	require.Equal(t, time.Millisecond, clone.MinRecentRTT())
+	require.Equal(t, 20*time.Millisecond, rttStats.MinRecentRTT())
+}
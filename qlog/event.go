@@ -441,8 +441,10 @@ type MetricsUpdated struct {
 	LatestRTT        time.Duration
 	RTTVariance      time.Duration
 	CongestionWindow int
+	SSThresh         int
 	BytesInFlight    int
 	PacketsInFlight  int
+	PacingRate       int // in bits per second
 }
 
 func (e MetricsUpdated) Name() string { return "recovery:metrics_updated" }
@@ -470,6 +472,10 @@ func (e MetricsUpdated) Encode(enc *jsontext.Encoder, _ time.Time) error {
 		h.WriteToken(jsontext.String("congestion_window"))
 		h.WriteToken(jsontext.Uint(uint64(e.CongestionWindow)))
 	}
+	if e.SSThresh != 0 {
+		h.WriteToken(jsontext.String("ssthresh"))
+		h.WriteToken(jsontext.Uint(uint64(e.SSThresh)))
+	}
 	if e.BytesInFlight != 0 {
 		h.WriteToken(jsontext.String("bytes_in_flight"))
 		h.WriteToken(jsontext.Uint(uint64(e.BytesInFlight)))
@@ -478,6 +484,10 @@ func (e MetricsUpdated) Encode(enc *jsontext.Encoder, _ time.Time) error {
 		h.WriteToken(jsontext.String("packets_in_flight"))
 		h.WriteToken(jsontext.Uint(uint64(e.PacketsInFlight)))
 	}
+	if e.PacingRate != 0 {
+		h.WriteToken(jsontext.String("pacing_rate"))
+		h.WriteToken(jsontext.Uint(uint64(e.PacingRate)))
+	}
 	h.WriteToken(jsontext.EndObject)
 	return h.err
 }
@@ -649,8 +649,10 @@ func TestMetricsUpdated(t *testing.T) {
 		LatestRTT:        rttStats.LatestRTT(),
 		RTTVariance:      rttStats.MeanDeviation(),
 		CongestionWindow: 4321,
+		SSThresh:         5678,
 		BytesInFlight:    1234,
 		PacketsInFlight:  42,
+		PacingRate:       10_000_000,
 	})
 
 	require.Equal(t, "recovery:metrics_updated", name)
@@ -663,6 +665,8 @@ func TestMetricsUpdated(t *testing.T) {
 	require.Equal(t, float64(4321), ev["congestion_window"])
 	require.Equal(t, float64(1234), ev["bytes_in_flight"])
 	require.Equal(t, float64(42), ev["packets_in_flight"])
+	require.Equal(t, float64(5678), ev["ssthresh"])
+	require.Equal(t, float64(10_000_000), ev["pacing_rate"])
 }
 
 func TestPacketLost(t *testing.T) {
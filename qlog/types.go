@@ -262,6 +262,18 @@ const (
 	CongestionStateRecovery CongestionState = "recovery"
 	// CongestionStateApplicationLimited means that the congestion controller is application limited
 	CongestionStateApplicationLimited CongestionState = "application_limited"
+
+	// CongestionStateProbing is the phase of a rate-based congestion controller (e.g. Hysteria)
+	// in which it increases its sending rate to probe for more bandwidth
+	CongestionStateProbing CongestionState = "probing"
+	// CongestionStateSteady means that a rate-based congestion controller reached its target rate
+	CongestionStateSteady CongestionState = "steady"
+	// CongestionStatePenalty is the phase after a rate-based congestion controller reduced its sending rate
+	// due to congestion loss, during which it doesn't increase the rate
+	CongestionStatePenalty CongestionState = "penalty"
+	// CongestionStateDrain is the phase in which a rate-based congestion controller sends below the delivery rate,
+	// to drain the queue it built up at the bottleneck
+	CongestionStateDrain CongestionState = "drain"
 )
 
 func (s CongestionState) String() string {
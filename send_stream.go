@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/quic-go/quic-go/internal/ackhandler"
+	"github.com/quic-go/quic-go/internal/congestion"
 	"github.com/quic-go/quic-go/internal/flowcontrol"
 	"github.com/quic-go/quic-go/internal/monotime"
 	"github.com/quic-go/quic-go/internal/protocol"
@@ -54,6 +55,8 @@ type SendStream struct {
 	writeOnce chan struct{}
 	deadline  monotime.Time
 
+	trafficClass congestion.TrafficClass
+
 	flowController flowcontrol.StreamFlowController
 }
 
@@ -454,6 +457,14 @@ func (s *SendStream) SetReliableBoundary() {
 	}
 }
 
+// SetTrafficClass sets the traffic class of the data sent on this stream.
+// It is reported to the congestion controller when a packet carrying data of this stream is lost.
+func (s *SendStream) SetTrafficClass(c TrafficClass) {
+	s.mutex.Lock()
+	s.trafficClass = c
+	s.mutex.Unlock()
+}
+
 // returnFramesToPool returns all queued frames to the sync.Pool
 func (s *SendStream) returnFramesToPool() {
 	for _, f := range s.retransmissionQueue {
@@ -658,7 +669,10 @@ func (s *SendStream) signalWrite() {
 
 type sendStreamAckHandler SendStream
 
-var _ ackhandler.FrameHandler = &sendStreamAckHandler{}
+var (
+	_ ackhandler.FrameHandler      = &sendStreamAckHandler{}
+	_ ackhandler.TrafficClassifier = &sendStreamAckHandler{}
+)
 
 func (s *sendStreamAckHandler) OnAcked(f wire.Frame) {
 	sf := f.(*wire.StreamFrame)
@@ -681,6 +695,12 @@ func (s *sendStreamAckHandler) OnAcked(f wire.Frame) {
 	}
 }
 
+func (s *sendStreamAckHandler) TrafficClass() congestion.TrafficClass {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.trafficClass
+}
+
 func (s *sendStreamAckHandler) OnLost(f wire.Frame) {
 	sf := f.(*wire.StreamFrame)
 	s.mutex.Lock()
@@ -143,6 +143,29 @@ func TestSendStreamWriteData(t *testing.T) {
 	)
 }
 
+func TestSendStreamTrafficClass(t *testing.T) {
+	const streamID protocol.StreamID = 42
+	mockCtrl := gomock.NewController(t)
+	mockFC := mocks.NewMockStreamFlowController(mockCtrl)
+	mockSender := NewMockStreamSender(mockCtrl)
+	str := newSendStream(context.Background(), streamID, mockSender, mockFC, false)
+
+	mockSender.EXPECT().onHasStreamData(streamID, str)
+	_, err := (&writerWithTimeout{Writer: str, Timeout: time.Second}).Write([]byte("foobar"))
+	require.NoError(t, err)
+	mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount)
+	mockFC.EXPECT().AddBytesSent(protocol.ByteCount(6))
+	frame, _, _ := str.popStreamFrame(protocol.MaxByteCount, protocol.Version1)
+	require.NotNil(t, frame.Frame)
+
+	classifier, ok := frame.Handler.(ackhandler.TrafficClassifier)
+	require.True(t, ok)
+	require.Equal(t, TrafficClassDefault, classifier.TrafficClass())
+	// the traffic class applies to data that was already sent
+	str.SetTrafficClass(TrafficClassBulk)
+	require.Equal(t, TrafficClassBulk, classifier.TrafficClass())
+}
+
 func TestSendStreamLargeWrites(t *testing.T) {
 	synctest.Test(t, func(t *testing.T) {
 		const streamID protocol.StreamID = 1337
@@ -141,6 +141,12 @@ func (s *Stream) SetReliableBoundary() {
 	s.sendStr.SetReliableBoundary()
 }
 
+// SetTrafficClass sets the traffic class of the data sent on this stream.
+// See SendStream.SetTrafficClass for more details.
+func (s *Stream) SetTrafficClass(c TrafficClass) {
+	s.sendStr.SetTrafficClass(c)
+}
+
 // CancelWrite aborts sending on this stream.
 // See [SendStream.CancelWrite] for more details.
 func (s *Stream) CancelWrite(errorCode StreamErrorCode) {